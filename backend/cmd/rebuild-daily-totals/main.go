@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	KPIRepository "app/internal/app/kpi/repository"
+	"app/internal/db"
+)
+
+// Rebuilds the daily totals read by the KPIs from the active and archived work sessions.
+// The totals are kept up to date by triggers, a rebuild is only needed after writing the sessions with the triggers disabled.
+//
+// Usage: rebuild-daily-totals [-start YYYY-MM-DD] [-end YYYY-MM-DD]
+func main() {
+	startDate := flag.String("start", "", "first day to rebuild (YYYY-MM-DD), every day before the end when empty")
+	endDate := flag.String("end", "", "last day to rebuild (YYYY-MM-DD), every day after the start when empty")
+	flag.Parse()
+
+	for _, date := range []string{*startDate, *endDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			log.Fatalf("❌ Invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	database := db.ConnectPostgres()
+	if database == nil {
+		log.Fatal("❌ No database connection")
+	}
+
+	written, err := KPIRepository.NewKPIRepository(database).RebuildDailyTotals(*startDate, *endDate)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	log.Printf("✅ Rebuilt %d daily totals", written)
+}
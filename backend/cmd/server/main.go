@@ -25,6 +25,7 @@ import (
 // @name Authorization
 func main() {
 	cfg := config.LoadConfig()
+	config.WarnDeprecatedVariables()
 
 	mailerProvider := &provider.BrevoMailer{
 		APIKey: cfg.Mail.APIKey,
@@ -51,7 +52,10 @@ func main() {
 		log.Println("✅ Root user created or already exists")
 	}
 
-	r := router.SetupRouter()
+	r, err := router.SetupRouter()
+	if err != nil {
+		log.Fatalf("❌ Failed to set up the router: %v", err)
+	}
 
 	allowedOrigins := []string{
 		"http://localhost:5173",
@@ -10,8 +10,8 @@ CREATE EXTENSION IF NOT EXISTS pgcrypto;
 -- 0) Weekly rates
 -- ------------------------------------------------------------
 INSERT INTO
-    weekly_rate (uuid, rate_name, amount)
-SELECT '11111111-1111-1111-1111-111111111111', 'Temps pleins', 35
+    weekly_rate (uuid, rate_name, amount, monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours)
+SELECT '11111111-1111-1111-1111-111111111111', 'Temps pleins', 35, 7, 7, 7, 7, 7
 WHERE
     NOT EXISTS (
         SELECT 1
@@ -21,8 +21,8 @@ WHERE
     );
 
 INSERT INTO
-    weekly_rate (uuid, rate_name, amount)
-SELECT '22222222-2222-2222-2222-222222222222', 'Temps pleins + RTT', 39
+    weekly_rate (uuid, rate_name, amount, monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours)
+SELECT '22222222-2222-2222-2222-222222222222', 'Temps pleins + RTT', 39, 8, 8, 8, 8, 7
 WHERE
     NOT EXISTS (
         SELECT 1
@@ -32,8 +32,8 @@ WHERE
     );
 
 INSERT INTO
-    weekly_rate (uuid, rate_name, amount)
-SELECT '33333333-3333-3333-3333-333333333333', 'Temps partiel', 20
+    weekly_rate (uuid, rate_name, amount, monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours)
+SELECT '33333333-3333-3333-3333-333333333333', 'Temps partiel', 20, 5, 5, 5, 5, 0
 WHERE
     NOT EXISTS (
         SELECT 1
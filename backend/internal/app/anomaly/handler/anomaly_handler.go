@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"app/internal/app/anomaly/model"
+	AnomalyService "app/internal/app/anomaly/service"
+	AuthService "app/internal/app/auth/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnomalyHandler struct {
+	service AnomalyService.AnomalyService
+}
+
+func NewAnomalyHandler(service AnomalyService.AnomalyService) *AnomalyHandler {
+	return &AnomalyHandler{service: service}
+}
+
+// Scan Anomalies
+//
+// @Summary      Scan the clocking data for anomalies
+// @Description  Run the detection rules on the sessions clocked in between the two dates, both included and formatted as YYYY-MM-DD, and store the anomalies not flagged yet. Without dates, the last days configured by **ANOMALY_SCAN_DAYS** are scanned. The rules flag long and short sessions, clock ins recorded twice at the same second, long streaks without any break and the sessions deviating from the usual duration or start time of their user. 🔒 Requires role: **admin**
+// @Tags         Anomalies
+// @Security     BearerAuth
+// @Produce      json
+// @Param        start_date  query     string  false  "Start Date (YYYY-MM-DD)"
+// @Param        end_date    query     string  false  "End Date (YYYY-MM-DD)"
+// @Success      200  {object}  model.AnomalyScanResult
+// @Router       /anomalies/scan [post]
+func (handler *AnomalyHandler) Scan(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	if (startDate == "") != (endDate == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date must be provided together"})
+		return
+	}
+
+	result, err := handler.service.Scan(startDate, endDate)
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetByTeam Anomalies
+//
+// @Summary      Get the anomalies of a team
+// @Description  Retrieve the anomalies flagged on the members of a team, most recent first. Managers only see the teams they manage. 🔒 Requires role: **manager**
+// @Tags         Anomalies
+// @Security     BearerAuth
+// @Produce      json
+// @Param        team_uuid  path      string  true   "Team UUID"
+// @Param        status     query     string  false  "Status filter: open, acknowledged or resolved"
+// @Success      200  {array}   model.AnomalyRead
+// @Router       /anomalies/team/{team_uuid} [get]
+func (handler *AnomalyHandler) GetByTeam(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	status := c.Query("status")
+	if status != "" && !slices.Contains([]string{"open", "acknowledged", "resolved"}, status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, acknowledged or resolved"})
+		return
+	}
+
+	anomalies, err := handler.service.GetByTeam(c.Param("team_uuid"), authClaims.UUID, slices.Contains(authClaims.Roles, "admin"), status)
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, anomalies)
+}
+
+// UpdateStatus Anomaly
+//
+// @Summary      Update the status of an anomaly
+// @Description  Acknowledge, resolve or reopen an anomaly. Managers can only update the anomalies of the members of the teams they manage. A later scan never reopens an anomaly. 🔒 Requires role: **manager**
+// @Tags         Anomalies
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        uuid    path      string                     true  "Anomaly UUID"
+// @Param        status  body      model.AnomalyStatusUpdate  true  "Anomaly Status"
+// @Success      200 		"Anomaly status updated successfully"
+// @Router       /anomalies/{uuid}/status [put]
+func (handler *AnomalyHandler) UpdateStatus(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var update model.AnomalyStatusUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := handler.service.UpdateStatus(c.Param("uuid"), authClaims.UUID, slices.Contains(authClaims.Roles, "admin"), update.Status)
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Anomaly status updated successfully"})
+}
+
+func anomalyErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["ANOMALY_NOT_FOUND"]),
+		strings.HasPrefix(message, Config.ErrorMessages()["TEAM_NOT_FOUND"]):
+		return http.StatusNotFound
+	case strings.HasPrefix(message, Config.ErrorMessages()["NOT_TEAM_MANAGER"]):
+		return http.StatusForbidden
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_REQUEST"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+const (
+	TypeLongSession      = "long_session"
+	TypeShortSession     = "short_session"
+	TypeDuplicateClockIn = "duplicate_clock_in"
+	TypeNoBreaks         = "no_breaks"
+	TypeDurationOutlier  = "duration_outlier"
+	TypeStartTimeOutlier = "start_time_outlier"
+)
+
+// AnomalyConfig holds the thresholds of the detection rules, loaded from the environment.
+type AnomalyConfig struct {
+	// sessions longer than this are flagged, still running sessions included
+	LongSessionMinutes int
+	// completed sessions shorter than this are flagged
+	ShortSessionMinutes int
+	// a user working this many consecutive days without any break is flagged
+	NoBreakDays int
+	// minimum number of sessions in the streak for the no break rule to apply
+	NoBreakMinSessions int
+	// number of days of history before the scanned range used to compute the usual duration and start time of a user
+	BaselineDays int
+	// minimum number of sessions in the history before outliers are computed
+	MinSamples int
+	// number of standard deviations from the user's mean above which a session is an outlier
+	ZScoreThreshold float64
+	// number of days looked back by a scan when no range is given
+	ScanDays int
+	// interval between two scheduled scans, 0 disables the scheduler
+	ScanIntervalMinutes int
+}
+
+// swagger:model AnomalyRead
+type AnomalyRead struct {
+	UUID            string  `json:"uuid"`
+	UserUUID        string  `json:"user_uuid"`
+	FirstName       string  `json:"first_name"`
+	LastName        string  `json:"last_name"`
+	WorkSessionUUID *string `json:"work_session_uuid"`
+	// anomaly type is one of "long_session", "short_session", "duplicate_clock_in", "no_breaks", "duration_outlier" or "start_time_outlier"
+	AnomalyType string `json:"anomaly_type"`
+	// day is formatted as YYYY-MM-DD
+	Day     string   `json:"day"`
+	Details string   `json:"details"`
+	Score   *float64 `json:"score"`
+	// status is either "open", "acknowledged" or "resolved"
+	Status          string     `json:"status"`
+	StatusUpdatedAt *time.Time `json:"status_updated_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// swagger:model AnomalyStatusUpdate
+type AnomalyStatusUpdate struct {
+	Status string `json:"status" binding:"required,oneof=open acknowledged resolved"`
+}
+
+// swagger:model AnomalyScanResult
+type AnomalyScanResult struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// number of anomalies found by the rules, including the ones already stored
+	Detected int `json:"detected"`
+	// number of anomalies stored by this scan
+	Created int            `json:"created"`
+	ByType  map[string]int `json:"by_type"`
+}
+
+// AnomalyCandidate is a row flagged by one of the detection rules.
+type AnomalyCandidate struct {
+	UserID          int
+	WorkSessionUUID *string
+	Day             string
+	// timestamp of the flagged clock in, formatted as YYYY-MM-DD HH24:MI:SS
+	ClockIn string
+	// measured value: minutes for durations and start times, days for streaks
+	Value       float64
+	Mean        float64
+	StdDev      float64
+	Occurrences int
+}
+
+type AnomalyEntry struct {
+	UUID            string
+	UserID          int
+	WorkSessionUUID *string
+	AnomalyType     string
+	Day             string
+	Details         string
+	Score           *float64
+	Fingerprint     string
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	AnomalyModel "app/internal/app/anomaly/model"
+)
+
+// scannedSessions gathers the sessions of both the active and the archived tables.
+const scannedSessions = `
+	SELECT uuid, user_id, clock_in, clock_out, duration_minutes, status, breaks_count, breaks_duration_minutes FROM work_session_active
+	UNION ALL
+	SELECT uuid, user_id, clock_in, clock_out, duration_minutes, status, breaks_count, breaks_duration_minutes FROM work_session_archived
+`
+
+type AnomalyRepository interface {
+	FindLongSessions(start time.Time, end time.Time, now time.Time, maxMinutes int) ([]AnomalyModel.AnomalyCandidate, error)
+	FindShortSessions(start time.Time, end time.Time, minMinutes int) ([]AnomalyModel.AnomalyCandidate, error)
+	FindDuplicateClockIns(start time.Time, end time.Time) ([]AnomalyModel.AnomalyCandidate, error)
+	FindNoBreakStreaks(start time.Time, end time.Time, minDays int, minSessions int) ([]AnomalyModel.AnomalyCandidate, error)
+	FindDurationOutliers(start time.Time, end time.Time, baselineStart time.Time, minSamples int, threshold float64) ([]AnomalyModel.AnomalyCandidate, error)
+	FindStartTimeOutliers(start time.Time, end time.Time, baselineStart time.Time, minSamples int, threshold float64) ([]AnomalyModel.AnomalyCandidate, error)
+	Create(anomalies []AnomalyModel.AnomalyEntry) (int, error)
+	FindByTeamID(teamID int, status string) ([]AnomalyModel.AnomalyRead, error)
+	FindIdByUuid(uuid string) (int, error)
+	IsManagedBy(anomalyID int, managerID int) (bool, error)
+	UpdateStatus(id int, status string, updatedBy int) error
+}
+
+type anomalyRepository struct {
+	db *gorm.DB
+}
+
+func NewAnomalyRepository(db *gorm.DB) AnomalyRepository {
+	return &anomalyRepository{db}
+}
+
+// FindLongSessions returns the sessions lasting more than maxMinutes, a session still running being measured up to now.
+func (repo *anomalyRepository) FindLongSessions(start time.Time, end time.Time, now time.Time, maxMinutes int) ([]AnomalyModel.AnomalyCandidate, error) {
+	var candidates []AnomalyModel.AnomalyCandidate
+	err := repo.db.Raw(`
+		SELECT
+			s.user_id,
+			s.uuid AS work_session_uuid,
+			TO_CHAR(s.clock_in, 'YYYY-MM-DD') AS day,
+			TO_CHAR(s.clock_in, 'YYYY-MM-DD HH24:MI:SS') AS clock_in,
+			s.minutes AS value
+		FROM (
+			SELECT
+				uuid,
+				user_id,
+				clock_in,
+				(CASE
+					WHEN clock_out IS NULL THEN EXTRACT(EPOCH FROM (?::timestamp - clock_in)) / 60
+					ELSE COALESCE(duration_minutes, 0)
+				END)::float8 AS minutes
+			FROM (`+scannedSessions+`) sessions
+			WHERE clock_in >= ? AND clock_in < ?
+		) s
+		WHERE s.minutes > ?
+		ORDER BY s.clock_in
+	`, now, start, end, maxMinutes).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find long sessions: %w", err)
+	}
+	return candidates, nil
+}
+
+func (repo *anomalyRepository) FindShortSessions(start time.Time, end time.Time, minMinutes int) ([]AnomalyModel.AnomalyCandidate, error) {
+	var candidates []AnomalyModel.AnomalyCandidate
+	err := repo.db.Raw(`
+		SELECT
+			user_id,
+			uuid AS work_session_uuid,
+			TO_CHAR(clock_in, 'YYYY-MM-DD') AS day,
+			TO_CHAR(clock_in, 'YYYY-MM-DD HH24:MI:SS') AS clock_in,
+			COALESCE(duration_minutes, 0)::float8 AS value
+		FROM (`+scannedSessions+`) sessions
+		WHERE clock_in >= ? AND clock_in < ?
+			AND clock_out IS NOT NULL
+			AND COALESCE(duration_minutes, 0) < ?
+		ORDER BY clock_in
+	`, start, end, minMinutes).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find short sessions: %w", err)
+	}
+	return candidates, nil
+}
+
+// FindDuplicateClockIns returns the clock ins recorded several times by a same user at the same second.
+func (repo *anomalyRepository) FindDuplicateClockIns(start time.Time, end time.Time) ([]AnomalyModel.AnomalyCandidate, error) {
+	var candidates []AnomalyModel.AnomalyCandidate
+	err := repo.db.Raw(`
+		SELECT
+			user_id,
+			MIN(uuid) AS work_session_uuid,
+			TO_CHAR(DATE_TRUNC('second', clock_in), 'YYYY-MM-DD') AS day,
+			TO_CHAR(DATE_TRUNC('second', clock_in), 'YYYY-MM-DD HH24:MI:SS') AS clock_in,
+			COUNT(*)::float8 AS value,
+			COUNT(*) AS occurrences
+		FROM (`+scannedSessions+`) sessions
+		WHERE clock_in >= ? AND clock_in < ?
+		GROUP BY user_id, DATE_TRUNC('second', clock_in)
+		HAVING COUNT(*) > 1
+		ORDER BY DATE_TRUNC('second', clock_in)
+	`, start, end).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate clock ins: %w", err)
+	}
+	return candidates, nil
+}
+
+// FindNoBreakStreaks returns, for each user, the completed sessions since their last break when they span at least minDays.
+// The streak is identified by the day of its first session, which does not move while the streak goes on.
+// Only the streaks still going on during the scanned range are returned.
+func (repo *anomalyRepository) FindNoBreakStreaks(start time.Time, end time.Time, minDays int, minSessions int) ([]AnomalyModel.AnomalyCandidate, error) {
+	var candidates []AnomalyModel.AnomalyCandidate
+	err := repo.db.Raw(`
+		WITH sessions AS (
+			SELECT user_id, clock_in, COALESCE(breaks_count, 0) AS breaks_count, COALESCE(breaks_duration_minutes, 0) AS breaks_minutes
+			FROM (`+scannedSessions+`) s
+			WHERE status = 'completed' AND clock_in < ?
+		),
+		last_break AS (
+			SELECT user_id, MAX(clock_in) AS last_break_at
+			FROM sessions
+			WHERE breaks_count > 0 OR breaks_minutes > 0
+			GROUP BY user_id
+		)
+		SELECT
+			s.user_id,
+			TO_CHAR(MIN(s.clock_in), 'YYYY-MM-DD') AS day,
+			TO_CHAR(MIN(s.clock_in), 'YYYY-MM-DD HH24:MI:SS') AS clock_in,
+			(MAX(s.clock_in)::date - MIN(s.clock_in)::date + 1)::float8 AS value,
+			COUNT(*) AS occurrences
+		FROM sessions s
+		LEFT JOIN last_break lb ON lb.user_id = s.user_id
+		WHERE lb.last_break_at IS NULL OR s.clock_in > lb.last_break_at
+		GROUP BY s.user_id
+		HAVING MAX(s.clock_in)::date - MIN(s.clock_in)::date + 1 >= ?
+			AND COUNT(*) >= ?
+			AND MAX(s.clock_in) >= ?
+	`, end, minDays, minSessions, start).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find no break streaks: %w", err)
+	}
+	return candidates, nil
+}
+
+// FindDurationOutliers returns the completed sessions whose duration deviates from the usual duration of the user.
+func (repo *anomalyRepository) FindDurationOutliers(start time.Time, end time.Time, baselineStart time.Time, minSamples int, threshold float64) ([]AnomalyModel.AnomalyCandidate, error) {
+	return repo.findOutliers("duration_minutes", "status = 'completed'", start, end, baselineStart, minSamples, threshold)
+}
+
+// FindStartTimeOutliers returns the sessions starting at an unusual time of the day for the user, in minutes since midnight.
+func (repo *anomalyRepository) FindStartTimeOutliers(start time.Time, end time.Time, baselineStart time.Time, minSamples int, threshold float64) ([]AnomalyModel.AnomalyCandidate, error) {
+	return repo.findOutliers("EXTRACT(HOUR FROM clock_in) * 60 + EXTRACT(MINUTE FROM clock_in)", "TRUE", start, end, baselineStart, minSamples, threshold)
+}
+
+// findOutliers compares the metric of each session of the range to the mean and standard deviation
+// of the metric over the sessions of the same user from baselineStart to the start of the range.
+// The scored sessions are left out of the baseline, otherwise they would mask themselves and each other.
+func (repo *anomalyRepository) findOutliers(metric string, filter string, start time.Time, end time.Time, baselineStart time.Time, minSamples int, threshold float64) ([]AnomalyModel.AnomalyCandidate, error) {
+	var candidates []AnomalyModel.AnomalyCandidate
+	query := fmt.Sprintf(`
+		WITH sessions AS (
+			SELECT uuid, user_id, clock_in, (%s)::float8 AS metric
+			FROM (`+scannedSessions+`) s
+			WHERE %s AND clock_in >= ? AND clock_in < ?
+		),
+		stats AS (
+			SELECT user_id, AVG(metric) AS mean, STDDEV_SAMP(metric) AS stddev, COUNT(*) AS samples
+			FROM sessions
+			WHERE metric IS NOT NULL AND clock_in < ?
+			GROUP BY user_id
+		)
+		SELECT
+			s.user_id,
+			s.uuid AS work_session_uuid,
+			TO_CHAR(s.clock_in, 'YYYY-MM-DD') AS day,
+			TO_CHAR(s.clock_in, 'YYYY-MM-DD HH24:MI:SS') AS clock_in,
+			s.metric AS value,
+			st.mean,
+			st.stddev AS std_dev
+		FROM sessions s
+		INNER JOIN stats st ON st.user_id = s.user_id
+		WHERE s.clock_in >= ?
+			AND st.samples >= ?
+			AND st.stddev > 0
+			AND ABS(s.metric - st.mean) / st.stddev > ?
+		ORDER BY s.clock_in
+	`, metric, filter)
+
+	err := repo.db.Raw(query, baselineStart, end, start, start, minSamples, threshold).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find outliers: %w", err)
+	}
+	return candidates, nil
+}
+
+// Create stores the anomalies not flagged yet and returns how many were stored.
+func (repo *anomalyRepository) Create(anomalies []AnomalyModel.AnomalyEntry) (int, error) {
+	created := 0
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		for _, anomaly := range anomalies {
+			result := tx.Exec(`
+				INSERT INTO anomalies (uuid, user_id, work_session_uuid, anomaly_type, day, details, score, fingerprint)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (fingerprint) DO NOTHING
+			`, anomaly.UUID, anomaly.UserID, anomaly.WorkSessionUUID, anomaly.AnomalyType, anomaly.Day, anomaly.Details, anomaly.Score, anomaly.Fingerprint)
+			if result.Error != nil {
+				return result.Error
+			}
+			created += int(result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store anomalies: %w", err)
+	}
+	return created, nil
+}
+
+// FindByTeamID returns the anomalies of the members of the team, most recent first. An empty status returns every status.
+func (repo *anomalyRepository) FindByTeamID(teamID int, status string) ([]AnomalyModel.AnomalyRead, error) {
+	var anomalies []AnomalyModel.AnomalyRead
+	err := repo.db.Raw(`
+		SELECT
+			a.uuid,
+			u.uuid AS user_uuid,
+			COALESCE(u.first_name, '') AS first_name,
+			COALESCE(u.last_name, '') AS last_name,
+			a.work_session_uuid,
+			a.anomaly_type,
+			TO_CHAR(a.day, 'YYYY-MM-DD') AS day,
+			a.details,
+			a.score,
+			a.status,
+			a.status_updated_at,
+			a.created_at
+		FROM anomalies a
+		INNER JOIN users u ON u.id = a.user_id
+		WHERE a.user_id IN (SELECT user_id FROM teams_members WHERE team_id = ?)
+			AND (? = '' OR a.status::text = ?)
+		ORDER BY a.day DESC, a.id DESC
+	`, teamID, status, status).Scan(&anomalies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch anomalies: %w", err)
+	}
+	return anomalies, nil
+}
+
+func (repo *anomalyRepository) FindIdByUuid(uuid string) (int, error) {
+	var anomalyID int
+	err := repo.db.Raw("SELECT id FROM anomalies WHERE uuid = ?", uuid).Scan(&anomalyID).Error
+	if err != nil {
+		return 0, err
+	}
+	if anomalyID == 0 {
+		return 0, fmt.Errorf("anomaly not found")
+	}
+	return anomalyID, nil
+}
+
+// IsManagedBy checks if the user of the anomaly belongs to a team managed by the given user.
+func (repo *anomalyRepository) IsManagedBy(anomalyID int, managerID int) (bool, error) {
+	var managed bool
+	err := repo.db.Raw(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM anomalies a
+			INNER JOIN teams_members member ON member.user_id = a.user_id
+			INNER JOIN teams_members manager ON manager.team_id = member.team_id
+			WHERE a.id = ?
+				AND manager.user_id = ?
+				AND manager.is_manager = TRUE
+		)
+	`, anomalyID, managerID).Scan(&managed).Error
+	return managed, err
+}
+
+func (repo *anomalyRepository) UpdateStatus(id int, status string, updatedBy int) error {
+	return repo.db.Exec(`
+		UPDATE anomalies
+		SET status = ?,
+			status_updated_by = ?,
+			status_updated_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, updatedBy, id).Error
+}
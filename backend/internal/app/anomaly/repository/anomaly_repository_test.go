@@ -0,0 +1,289 @@
+package repository_test
+
+import (
+	"app/internal/app/anomaly/model"
+	"app/internal/app/anomaly/repository"
+	"app/internal/test"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const insertAnomalyUserQuery = "INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)"
+const selectIdUserUuid = "SELECT id FROM users WHERE uuid = ?"
+const insertCompletedSessionQuery = `INSERT INTO work_session_archived (uuid, user_id, clock_in, clock_out, duration_minutes, status, breaks_duration_minutes, breaks_count)
+	VALUES (?, ?, ?::timestamp, ?::timestamp + make_interval(mins => ?), ?, 'completed', ?, ?)`
+
+func createAnomalyUser(t *testing.T, db *gorm.DB, uuid string, username string) int {
+	t.Helper()
+
+	err := db.Exec(insertAnomalyUserQuery, uuid, username, username+"@example.com", "hashed_password", "active").Error
+	assert.NoError(t, err)
+
+	var userID int
+	db.Raw(selectIdUserUuid, uuid).Scan(&userID)
+	return userID
+}
+
+func insertCompletedSession(t *testing.T, db *gorm.DB, uuid string, userID int, clockIn string, minutes int, breakMinutes int) {
+	t.Helper()
+
+	breaksCount := 0
+	if breakMinutes > 0 {
+		breaksCount = 1
+	}
+	err := db.Exec(insertCompletedSessionQuery, uuid, userID, clockIn, clockIn, minutes, minutes, breakMinutes, breaksCount).Error
+	assert.NoError(t, err)
+}
+
+func date(value string) time.Time {
+	parsed, _ := time.Parse("2006-01-02", value)
+	return parsed
+}
+
+//
+// RULES
+//
+
+func TestFindLongAndShortSessions(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	userID := createAnomalyUser(t, db, "anomaly-user-1", "anomalyone")
+	insertCompletedSession(t, db, "ws-long", userID, "2025-03-03 07:00:00", 16*60, 30)
+	insertCompletedSession(t, db, "ws-short", userID, "2025-03-04 09:00:00", 2, 0)
+	insertCompletedSession(t, db, "ws-normal", userID, "2025-03-05 09:00:00", 8*60, 30)
+	// still running since the day before, measured up to now
+	db.Exec("INSERT INTO work_session_active (uuid, user_id, clock_in, status) VALUES (?, ?, ?, 'active')", "ws-forgotten", userID, "2025-03-06 08:00:00")
+
+	now := time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)
+
+	long, err := repo.FindLongSessions(date("2025-03-01"), date("2025-03-08"), now, 720)
+	assert.NoError(t, err)
+	assert.Len(t, long, 2)
+	assert.Equal(t, "ws-long", *long[0].WorkSessionUUID)
+	assert.Equal(t, 960.0, long[0].Value)
+	assert.Equal(t, "2025-03-03", long[0].Day)
+	assert.Equal(t, "ws-forgotten", *long[1].WorkSessionUUID)
+	assert.Equal(t, 26*60.0, long[1].Value)
+
+	short, err := repo.FindShortSessions(date("2025-03-01"), date("2025-03-08"), 5)
+	assert.NoError(t, err)
+	assert.Len(t, short, 1)
+	assert.Equal(t, "ws-short", *short[0].WorkSessionUUID)
+	assert.Equal(t, "2025-03-04 09:00:00", short[0].ClockIn)
+
+	// out of range
+	long, err = repo.FindLongSessions(date("2025-04-01"), date("2025-04-08"), now, 720)
+	assert.NoError(t, err)
+	assert.Empty(t, long)
+}
+
+func TestFindDuplicateClockIns(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	userID := createAnomalyUser(t, db, "anomaly-user-2", "anomalytwo")
+	otherUserID := createAnomalyUser(t, db, "anomaly-user-3", "anomalythree")
+	insertCompletedSession(t, db, "ws-dup-1", userID, "2025-03-03 09:00:00.120", 60, 0)
+	insertCompletedSession(t, db, "ws-dup-2", userID, "2025-03-03 09:00:00.870", 60, 0)
+	insertCompletedSession(t, db, "ws-dup-3", userID, "2025-03-03 14:00:00", 60, 0)
+	// same second but another user
+	insertCompletedSession(t, db, "ws-dup-4", otherUserID, "2025-03-03 09:00:00", 60, 0)
+
+	duplicates, err := repo.FindDuplicateClockIns(date("2025-03-01"), date("2025-03-08"))
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 1)
+	assert.Equal(t, userID, duplicates[0].UserID)
+	assert.Equal(t, 2, duplicates[0].Occurrences)
+	assert.Equal(t, "2025-03-03 09:00:00", duplicates[0].ClockIn)
+}
+
+func TestFindNoBreakStreaks(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	userID := createAnomalyUser(t, db, "anomaly-user-4", "anomalyfour")
+	restedUserID := createAnomalyUser(t, db, "anomaly-user-5", "anomalyfive")
+
+	// last break on the 1st of march, then 31 days without any break
+	insertCompletedSession(t, db, "ws-break", userID, "2025-03-01 09:00:00", 480, 45)
+	for day := 2; day <= 31; day++ {
+		insertCompletedSession(t, db, fmt.Sprintf("ws-nobreak-%d", day), userID, fmt.Sprintf("2025-03-%02d 09:00:00", day), 480, 0)
+	}
+	insertCompletedSession(t, db, "ws-nobreak-32", userID, "2025-04-01 09:00:00", 480, 0)
+
+	for day := 2; day <= 31; day++ {
+		insertCompletedSession(t, db, fmt.Sprintf("ws-rested-%d", day), restedUserID, fmt.Sprintf("2025-03-%02d 09:00:00", day), 480, 30)
+	}
+
+	streaks, err := repo.FindNoBreakStreaks(date("2025-03-28"), date("2025-04-02"), 30, 10)
+	assert.NoError(t, err)
+	assert.Len(t, streaks, 1)
+	assert.Equal(t, userID, streaks[0].UserID)
+	assert.Equal(t, "2025-03-02", streaks[0].Day)
+	assert.Equal(t, 31.0, streaks[0].Value)
+	assert.Equal(t, 31, streaks[0].Occurrences)
+
+	// the streak is not long enough yet
+	streaks, err = repo.FindNoBreakStreaks(date("2025-03-10"), date("2025-03-15"), 30, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, streaks)
+}
+
+func TestFindOutliers(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	userID := createAnomalyUser(t, db, "anomaly-user-6", "anomalysix")
+	// usual sessions of 7h50 to 8h10 starting between 08:50 and 09:10
+	for day := 3; day <= 14; day++ {
+		offset := (day%3 - 1) * 10
+		clockIn := time.Date(2025, 2, day, 9, 0, 0, 0, time.UTC).Add(time.Duration(offset) * time.Minute)
+		insertCompletedSession(t, db, fmt.Sprintf("ws-usual-%d", day), userID, clockIn.Format("2006-01-02 15:04:05"), 480+offset, 30)
+	}
+	insertCompletedSession(t, db, "ws-unusual-duration", userID, "2025-02-17 09:00:00", 11*60, 30)
+	insertCompletedSession(t, db, "ws-unusual-start", userID, "2025-02-18 14:00:00", 480, 30)
+
+	durationOutliers, err := repo.FindDurationOutliers(date("2025-02-15"), date("2025-02-22"), date("2025-01-01"), 10, 2)
+	assert.NoError(t, err)
+	assert.Len(t, durationOutliers, 1)
+	assert.Equal(t, "ws-unusual-duration", *durationOutliers[0].WorkSessionUUID)
+	assert.Equal(t, 660.0, durationOutliers[0].Value)
+	assert.Greater(t, durationOutliers[0].StdDev, 0.0)
+
+	startTimeOutliers, err := repo.FindStartTimeOutliers(date("2025-02-15"), date("2025-02-22"), date("2025-01-01"), 10, 2)
+	assert.NoError(t, err)
+	assert.Len(t, startTimeOutliers, 1)
+	assert.Equal(t, "ws-unusual-start", *startTimeOutliers[0].WorkSessionUUID)
+	assert.Equal(t, 840.0, startTimeOutliers[0].Value)
+
+	// not enough history
+	durationOutliers, err = repo.FindDurationOutliers(date("2025-02-15"), date("2025-02-22"), date("2025-01-01"), 100, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, durationOutliers)
+}
+
+func TestFindOutliersExcludesScoredSessionsFromBaseline(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	userID := createAnomalyUser(t, db, "anomaly-user-8", "anomalyeight")
+	// exactly 10 usual sessions of 7h50 or 8h10 before the scanned range
+	for day := 3; day <= 12; day++ {
+		minutes := 470
+		if day%2 == 0 {
+			minutes = 490
+		}
+		insertCompletedSession(t, db, fmt.Sprintf("ws-baseline-%d", day), userID, fmt.Sprintf("2025-02-%02d 09:00:00", day), minutes, 30)
+	}
+	// counted in the baseline, its z-score would be about 2.9
+	insertCompletedSession(t, db, "ws-outlier", userID, "2025-02-17 09:00:00", 600, 30)
+
+	durationOutliers, err := repo.FindDurationOutliers(date("2025-02-15"), date("2025-02-22"), date("2025-01-01"), 10, 3)
+	assert.NoError(t, err)
+	assert.Len(t, durationOutliers, 1)
+	assert.Equal(t, "ws-outlier", *durationOutliers[0].WorkSessionUUID)
+	assert.Equal(t, 480.0, durationOutliers[0].Mean)
+
+	// the scored session does not count as a sample of the baseline
+	durationOutliers, err = repo.FindDurationOutliers(date("2025-02-15"), date("2025-02-22"), date("2025-01-01"), 11, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, durationOutliers)
+}
+
+//
+// STORE & STATUS
+//
+
+func TestCreateAnomaliesIsIdempotent(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	userID := createAnomalyUser(t, db, "anomaly-user-7", "anomalyseven")
+	sessionUUID := "ws-anomaly"
+	anomalies := []model.AnomalyEntry{
+		{UUID: "anomaly-1", UserID: userID, WorkSessionUUID: &sessionUUID, AnomalyType: model.TypeLongSession, Day: "2025-03-03", Details: "long", Fingerprint: "long_session:ws-anomaly"},
+		{UUID: "anomaly-2", UserID: userID, AnomalyType: model.TypeNoBreaks, Day: "2025-03-02", Details: "no breaks", Fingerprint: "no_breaks:1:2025-03-02 09:00:00"},
+	}
+
+	created, err := repo.Create(anomalies)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created)
+
+	// a second scan finds the same anomalies with new uuids
+	anomalies[0].UUID = "anomaly-3"
+	anomalies[1].UUID = "anomaly-4"
+	created, err = repo.Create(anomalies)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, created)
+
+	var count int
+	db.Raw("SELECT COUNT(*) FROM anomalies").Scan(&count)
+	assert.Equal(t, 2, count)
+}
+
+func TestFindByTeamIDAndUpdateStatus(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	managerID := createAnomalyUser(t, db, "anomaly-manager", "anomalymanager")
+	memberID := createAnomalyUser(t, db, "anomaly-member", "anomalymember")
+	outsiderID := createAnomalyUser(t, db, "anomaly-outsider", "anomalyoutsider")
+
+	db.Exec("INSERT INTO teams (uuid, name) VALUES (?, ?)", "anomaly-team", "Team A")
+	var teamID int
+	db.Raw("SELECT id FROM teams WHERE uuid = ?", "anomaly-team").Scan(&teamID)
+	db.Exec("INSERT INTO teams_members (uuid, team_id, user_id, is_manager) VALUES (?, ?, ?, ?)", "tm-1", teamID, managerID, true)
+	db.Exec("INSERT INTO teams_members (uuid, team_id, user_id, is_manager) VALUES (?, ?, ?, ?)", "tm-2", teamID, memberID, false)
+
+	_, err := repo.Create([]model.AnomalyEntry{
+		{UUID: "anomaly-member-1", UserID: memberID, AnomalyType: model.TypeShortSession, Day: "2025-03-03", Details: "short", Fingerprint: "short_session:1"},
+		{UUID: "anomaly-member-2", UserID: memberID, AnomalyType: model.TypeLongSession, Day: "2025-03-05", Details: "long", Fingerprint: "long_session:2"},
+		{UUID: "anomaly-outsider-1", UserID: outsiderID, AnomalyType: model.TypeLongSession, Day: "2025-03-05", Details: "long", Fingerprint: "long_session:3"},
+	})
+	assert.NoError(t, err)
+
+	anomalies, err := repo.FindByTeamID(teamID, "")
+	assert.NoError(t, err)
+	assert.Len(t, anomalies, 2)
+	assert.Equal(t, "anomaly-member-2", anomalies[0].UUID)
+	assert.Equal(t, "anomaly-member", anomalies[0].UserUUID)
+	assert.Equal(t, "open", anomalies[0].Status)
+
+	id, err := repo.FindIdByUuid("anomaly-member-1")
+	assert.NoError(t, err)
+
+	managed, err := repo.IsManagedBy(id, managerID)
+	assert.NoError(t, err)
+	assert.True(t, managed)
+
+	managed, err = repo.IsManagedBy(id, outsiderID)
+	assert.NoError(t, err)
+	assert.False(t, managed)
+
+	err = repo.UpdateStatus(id, "resolved", managerID)
+	assert.NoError(t, err)
+
+	resolved, err := repo.FindByTeamID(teamID, "resolved")
+	assert.NoError(t, err)
+	assert.Len(t, resolved, 1)
+	assert.Equal(t, "anomaly-member-1", resolved[0].UUID)
+	assert.NotNil(t, resolved[0].StatusUpdatedAt)
+
+	open, err := repo.FindByTeamID(teamID, "open")
+	assert.NoError(t, err)
+	assert.Len(t, open, 1)
+}
+
+func TestFindAnomalyIdByUuidNotFound(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAnomalyRepository(db)
+
+	id, err := repo.FindIdByUuid("not-existing-uuid")
+	assert.Error(t, err)
+	assert.Equal(t, 0, id)
+}
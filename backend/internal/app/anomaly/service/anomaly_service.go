@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	AnomalyModel "app/internal/app/anomaly/model"
+	AnomalyRepository "app/internal/app/anomaly/repository"
+	TeamService "app/internal/app/team/service"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+
+	"github.com/google/uuid"
+)
+
+const anomalyDateLayout = "2006-01-02"
+
+type AnomalyService interface {
+	Scan(startDate string, endDate string) (AnomalyModel.AnomalyScanResult, error)
+	StartScheduler(ctx context.Context)
+	GetByTeam(teamUUID string, requesterUUID string, isAdmin bool, status string) ([]AnomalyModel.AnomalyRead, error)
+	UpdateStatus(uuid string, requesterUUID string, isAdmin bool, status string) error
+}
+
+type anomalyService struct {
+	AnomalyRepo AnomalyRepository.AnomalyRepository
+	TeamService TeamService.TeamService
+	UserService UserService.UserService
+	Config      AnomalyModel.AnomalyConfig
+}
+
+func NewAnomalyService(repo AnomalyRepository.AnomalyRepository, teamService TeamService.TeamService, userService UserService.UserService, config AnomalyModel.AnomalyConfig) AnomalyService {
+	return &anomalyService{
+		AnomalyRepo: repo,
+		TeamService: teamService,
+		UserService: userService,
+		Config:      config,
+	}
+}
+
+// Scan runs every detection rule on the sessions clocked in between the two dates, both included,
+// and stores the anomalies not flagged yet. Without dates, the last ScanDays days are scanned.
+func (service *anomalyService) Scan(startDate string, endDate string) (AnomalyModel.AnomalyScanResult, error) {
+	now := time.Now()
+	start, end, err := service.scanRange(startDate, endDate, now)
+	if err != nil {
+		return AnomalyModel.AnomalyScanResult{}, err
+	}
+
+	anomalies, err := service.detect(start, end, now)
+	if err != nil {
+		return AnomalyModel.AnomalyScanResult{}, err
+	}
+
+	created, err := service.AnomalyRepo.Create(anomalies)
+	if err != nil {
+		return AnomalyModel.AnomalyScanResult{}, err
+	}
+
+	result := AnomalyModel.AnomalyScanResult{
+		StartDate: start.Format(anomalyDateLayout),
+		EndDate:   end.AddDate(0, 0, -1).Format(anomalyDateLayout),
+		Detected:  len(anomalies),
+		Created:   created,
+		ByType:    map[string]int{},
+	}
+	for _, anomaly := range anomalies {
+		result.ByType[anomaly.AnomalyType]++
+	}
+	return result, nil
+}
+
+// StartScheduler scans the last ScanDays days every ScanIntervalMinutes until the context is done.
+// Anomalies are identified by their fingerprint, so overlapping scans never store them twice.
+func (service *anomalyService) StartScheduler(ctx context.Context) {
+	if service.Config.ScanIntervalMinutes <= 0 {
+		log.Println("⚠️ Anomaly scan scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(service.Config.ScanIntervalMinutes) * time.Minute)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := service.Scan("", "")
+				if err != nil {
+					log.Printf("⚠️ Error scanning anomalies: %v", err)
+					continue
+				}
+				if result.Created > 0 {
+					log.Printf("✅ Anomaly scan stored %d new anomalies", result.Created)
+				}
+			}
+		}
+	}()
+}
+
+func (service *anomalyService) GetByTeam(teamUUID string, requesterUUID string, isAdmin bool, status string) ([]AnomalyModel.AnomalyRead, error) {
+	teamID, err := service.TeamService.GetIdByUuid(teamUUID)
+	if err != nil || teamID == 0 {
+		return nil, fmt.Errorf(Config.ErrorMessages()["TEAM_NOT_FOUND"]+": %w", err)
+	}
+
+	if !isAdmin {
+		requesterID, err := service.UserService.GetIdByUuid(requesterUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+
+		isManager, err := service.TeamService.IsTeamManager(teamID, requesterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check team manager: %w", err)
+		}
+		if !isManager {
+			return nil, fmt.Errorf("%s", Config.ErrorMessages()["NOT_TEAM_MANAGER"])
+		}
+	}
+
+	anomalies, err := service.AnomalyRepo.FindByTeamID(teamID, status)
+	if err != nil {
+		return nil, err
+	}
+	if anomalies == nil {
+		anomalies = []AnomalyModel.AnomalyRead{}
+	}
+	return anomalies, nil
+}
+
+func (service *anomalyService) UpdateStatus(uuid string, requesterUUID string, isAdmin bool, status string) error {
+	anomalyID, err := service.AnomalyRepo.FindIdByUuid(uuid)
+	if err != nil {
+		return fmt.Errorf(Config.ErrorMessages()["ANOMALY_NOT_FOUND"]+": %w", err)
+	}
+
+	requesterID, err := service.UserService.GetIdByUuid(requesterUUID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if !isAdmin {
+		managed, err := service.AnomalyRepo.IsManagedBy(anomalyID, requesterID)
+		if err != nil {
+			return fmt.Errorf("failed to check team manager: %w", err)
+		}
+		if !managed {
+			return fmt.Errorf("%s", Config.ErrorMessages()["NOT_TEAM_MANAGER"])
+		}
+	}
+
+	if err := service.AnomalyRepo.UpdateStatus(anomalyID, status, requesterID); err != nil {
+		return fmt.Errorf("failed to update anomaly status: %w", err)
+	}
+	return nil
+}
+
+// scanRange returns the scanned range as [start, end), end being the day after endDate.
+func (service *anomalyService) scanRange(startDate string, endDate string, now time.Time) (time.Time, time.Time, error) {
+	if startDate == "" && endDate == "" {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return today.AddDate(0, 0, -service.Config.ScanDays), today.AddDate(0, 0, 1), nil
+	}
+
+	start, err := time.ParseInLocation(anomalyDateLayout, startDate, now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s: start_date must be formatted as YYYY-MM-DD", Config.ErrorMessages()["INVALID_REQUEST"])
+	}
+	end, err := time.ParseInLocation(anomalyDateLayout, endDate, now.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s: end_date must be formatted as YYYY-MM-DD", Config.ErrorMessages()["INVALID_REQUEST"])
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s: end_date is before start_date", Config.ErrorMessages()["INVALID_REQUEST"])
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// detect runs the rules and turns their candidates into anomalies.
+func (service *anomalyService) detect(start time.Time, end time.Time, now time.Time) ([]AnomalyModel.AnomalyEntry, error) {
+	cfg := service.Config
+	baselineStart := start.AddDate(0, 0, -cfg.BaselineDays)
+	anomalies := []AnomalyModel.AnomalyEntry{}
+
+	longSessions, err := service.AnomalyRepo.FindLongSessions(start, end, now, cfg.LongSessionMinutes)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range longSessions {
+		details := fmt.Sprintf("session started at %s lasted %s, above the limit of %s", candidate.ClockIn, formatMinutes(candidate.Value), formatMinutes(float64(cfg.LongSessionMinutes)))
+		anomalies = append(anomalies, newAnomaly(AnomalyModel.TypeLongSession, candidate, details, nil))
+	}
+
+	shortSessions, err := service.AnomalyRepo.FindShortSessions(start, end, cfg.ShortSessionMinutes)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range shortSessions {
+		details := fmt.Sprintf("session started at %s lasted %s, below the minimum of %s", candidate.ClockIn, formatMinutes(candidate.Value), formatMinutes(float64(cfg.ShortSessionMinutes)))
+		anomalies = append(anomalies, newAnomaly(AnomalyModel.TypeShortSession, candidate, details, nil))
+	}
+
+	duplicates, err := service.AnomalyRepo.FindDuplicateClockIns(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range duplicates {
+		details := fmt.Sprintf("%d sessions clocked in at exactly %s", candidate.Occurrences, candidate.ClockIn)
+		anomalies = append(anomalies, newAnomaly(AnomalyModel.TypeDuplicateClockIn, candidate, details, nil))
+	}
+
+	streaks, err := service.AnomalyRepo.FindNoBreakStreaks(start, end, cfg.NoBreakDays, cfg.NoBreakMinSessions)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range streaks {
+		details := fmt.Sprintf("no break taken during %d sessions over %.0f days since %s", candidate.Occurrences, candidate.Value, candidate.Day)
+		anomalies = append(anomalies, newAnomaly(AnomalyModel.TypeNoBreaks, candidate, details, nil))
+	}
+
+	durationOutliers, err := service.AnomalyRepo.FindDurationOutliers(start, end, baselineStart, cfg.MinSamples, cfg.ZScoreThreshold)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range durationOutliers {
+		score := zScore(candidate)
+		details := fmt.Sprintf("session started at %s lasted %s while the usual duration is %s ± %s", candidate.ClockIn, formatMinutes(candidate.Value), formatMinutes(candidate.Mean), formatMinutes(candidate.StdDev))
+		anomalies = append(anomalies, newAnomaly(AnomalyModel.TypeDurationOutlier, candidate, details, &score))
+	}
+
+	startTimeOutliers, err := service.AnomalyRepo.FindStartTimeOutliers(start, end, baselineStart, cfg.MinSamples, cfg.ZScoreThreshold)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range startTimeOutliers {
+		score := zScore(candidate)
+		details := fmt.Sprintf("session started at %s while the usual start time is %s ± %s", candidate.ClockIn, formatClock(candidate.Mean), formatMinutes(candidate.StdDev))
+		anomalies = append(anomalies, newAnomaly(AnomalyModel.TypeStartTimeOutlier, candidate, details, &score))
+	}
+
+	return anomalies, nil
+}
+
+// newAnomaly fingerprints the candidate with its type and the session it was found on,
+// or the user and the clock in when the rule does not flag a single session.
+func newAnomaly(anomalyType string, candidate AnomalyModel.AnomalyCandidate, details string, score *float64) AnomalyModel.AnomalyEntry {
+	fingerprint := fmt.Sprintf("%s:%d:%s", anomalyType, candidate.UserID, candidate.ClockIn)
+	if candidate.WorkSessionUUID != nil && anomalyType != AnomalyModel.TypeDuplicateClockIn {
+		fingerprint = fmt.Sprintf("%s:%s", anomalyType, *candidate.WorkSessionUUID)
+	}
+
+	return AnomalyModel.AnomalyEntry{
+		UUID:            uuid.New().String(),
+		UserID:          candidate.UserID,
+		WorkSessionUUID: candidate.WorkSessionUUID,
+		AnomalyType:     anomalyType,
+		Day:             candidate.Day,
+		Details:         details,
+		Score:           score,
+		Fingerprint:     fingerprint,
+	}
+}
+
+func zScore(candidate AnomalyModel.AnomalyCandidate) float64 {
+	if candidate.StdDev == 0 {
+		return 0
+	}
+	return math.Round(math.Abs(candidate.Value-candidate.Mean)/candidate.StdDev*100) / 100
+}
+
+// formatMinutes formats a number of minutes as 8h05
+func formatMinutes(minutes float64) string {
+	total := int(math.Round(minutes))
+	return fmt.Sprintf("%dh%02d", total/60, total%60)
+}
+
+// formatClock formats a number of minutes since midnight as 08:05
+func formatClock(minutes float64) string {
+	total := int(math.Round(minutes))
+	return fmt.Sprintf("%02d:%02d", total/60, total%60)
+}
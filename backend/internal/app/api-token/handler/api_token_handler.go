@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"app/internal/app/api-token/model"
+	APITokenService "app/internal/app/api-token/service"
+	AuthService "app/internal/app/auth/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenHandler struct {
+	service APITokenService.APITokenService
+}
+
+func NewAPITokenHandler(service APITokenService.APITokenService) *APITokenHandler {
+	return &APITokenHandler{service: service}
+}
+
+// CreatePersonal API Token
+//
+// @Summary      Create a personal access token
+// @Description  Issue a token acting as the current user, to send in the **Authorization: Bearer** header. The token is restricted to its scopes (users:read, users:write, teams:read, teams:write, work-session:read, work-session:write, kpi:read, kpi:export, anomalies:read, anomalies:write) on top of the roles of the user, and is only returned in this response. The routes without a scope, such as the token routes, do not accept API tokens. 🔒 Requires role: **all**
+// @Tags         API Tokens
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        token  body      model.APITokenCreate  true  "Personal access token"
+// @Success      201  {object}  model.APITokenCreated
+// @Router       /tokens [post]
+func (handler *APITokenHandler) CreatePersonal(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var request model.APITokenCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	token, err := handler.service.CreatePersonal(request, authClaims.UUID)
+	if err != nil {
+		c.JSON(apiTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// GetMine API Tokens
+//
+// @Summary      Get the API tokens of the current user
+// @Description  Retrieve the personal access tokens of the current user and the API keys acting as them, revoked and expired ones included, with their last use. 🔒 Requires role: **all**
+// @Tags         API Tokens
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   model.APIToken
+// @Router       /tokens/me [get]
+func (handler *APITokenHandler) GetMine(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	tokens, err := handler.service.GetByUser(authClaims.UUID)
+	if err != nil {
+		c.JSON(apiTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Revoke API Token
+//
+// @Summary      Revoke an API token
+// @Description  Revoke a personal access token or an API key acting as the current user, admins can revoke any token. 🔒 Requires role: **all**
+// @Tags         API Tokens
+// @Security     BearerAuth
+// @Produce      json
+// @Param        uuid  path      string  true  "API Token UUID"
+// @Success      200 		"API token revoked successfully"
+// @Router       /tokens/{uuid} [delete]
+func (handler *APITokenHandler) Revoke(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	err := handler.service.Revoke(c.Param("uuid"), authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		c.JSON(apiTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
+
+// CreateAPIKey API Token
+//
+// @Summary      Create an API key
+// @Description  Issue a key acting as the given user, typically the account of an integration, restricted to its scopes on top of the roles of the user. The key is only returned in this response. 🔒 Requires role: **admin**
+// @Tags         API Tokens
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        key  body      model.APIKeyCreate  true  "API key"
+// @Success      201  {object}  model.APITokenCreated
+// @Router       /api-keys [post]
+func (handler *APITokenHandler) CreateAPIKey(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var request model.APIKeyCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	token, err := handler.service.CreateAPIKey(request, authClaims.UUID)
+	if err != nil {
+		c.JSON(apiTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// GetAPIKeys API Tokens
+//
+// @Summary      Get the API keys
+// @Description  Retrieve every API key issued by the admins, revoked and expired ones included, with their last use. 🔒 Requires role: **admin**
+// @Tags         API Tokens
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   model.APIToken
+// @Router       /api-keys [get]
+func (handler *APITokenHandler) GetAPIKeys(c *gin.Context) {
+	tokens, err := handler.service.GetAPIKeys()
+	if err != nil {
+		c.JSON(apiTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func apiTokenErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["API_TOKEN_NOT_FOUND"]):
+		return http.StatusNotFound
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_REQUEST"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
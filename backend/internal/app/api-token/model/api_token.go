@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"app/internal/app/common/model"
+)
+
+const (
+	// KindPersonal tokens are issued by the users for themselves
+	KindPersonal = "personal"
+	// KindAPIKey tokens are issued by the admins for an account, typically the account of an integration
+	KindAPIKey = "api_key"
+
+	// TokenPrefix tells the API tokens apart from the access tokens (JWT) in the Authorization header
+	TokenPrefix = "tm_"
+)
+
+// swagger:model APITokenCreate
+type APITokenCreate struct {
+	Name string `json:"name" binding:"required,max=100" example:"Payroll sync"`
+	// each route accepting API tokens requires one of these scopes, on top of the roles of the user the token acts as
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write teams:read teams:write work-session:read work-session:write kpi:read kpi:export anomalies:read anomalies:write" example:"kpi:read,users:read"`
+	// the token never expires when expires_at is not set
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+// swagger:model APIKeyCreate
+type APIKeyCreate struct {
+	APITokenCreate
+	// the key acts as this user, with their roles
+	UserUUID string `json:"user_uuid" binding:"required"`
+}
+
+// swagger:model APIToken
+type APIToken struct {
+	ID            int     `json:"-" gorm:"column:id"`
+	UUID          string  `json:"uuid" gorm:"column:uuid"`
+	UserID        int     `json:"-" gorm:"column:user_id"`
+	UserUUID      string  `json:"user_uuid" gorm:"column:user_uuid"`
+	CreatedBy     *int    `json:"-" gorm:"column:created_by"`
+	CreatedByUUID *string `json:"created_by_uuid" gorm:"column:created_by_uuid"`
+	Kind          string  `json:"kind" gorm:"column:kind"`
+	Name          string  `json:"name" gorm:"column:name"`
+	TokenHash     string  `json:"-" gorm:"column:token_hash"`
+	// first characters of the token, to recognize it
+	TokenPrefix string            `json:"token_prefix" gorm:"column:token_prefix"`
+	Scopes      model.StringArray `json:"scopes" gorm:"column:scopes;type:text[]"`
+	ExpiresAt   *time.Time        `json:"expires_at" gorm:"column:expires_at"`
+	LastUsedAt  *time.Time        `json:"last_used_at" gorm:"column:last_used_at"`
+	RevokedAt   *time.Time        `json:"revoked_at" gorm:"column:revoked_at"`
+	CreatedAt   time.Time         `json:"created_at" gorm:"column:created_at"`
+}
+
+// swagger:model APITokenCreated
+type APITokenCreated struct {
+	APIToken
+	// the token itself is only returned at its creation
+	Token string `json:"token" example:"tm_Jx0..."`
+}
+
+// APITokenUser is a valid token along with the user it acts as. Both are read on every request,
+// so that a change of the roles or of the status of the user applies at once.
+type APITokenUser struct {
+	TokenID     int               `gorm:"column:token_id"`
+	TokenUUID   string            `gorm:"column:token_uuid"`
+	Scopes      model.StringArray `gorm:"column:scopes;type:text[]"`
+	LastUsedAt  *time.Time        `gorm:"column:last_used_at"`
+	UUID        string            `gorm:"column:uuid"`
+	Roles       model.StringArray `gorm:"column:roles;type:text[]"`
+	FirstName   string            `gorm:"column:first_name"`
+	LastName    string            `gorm:"column:last_name"`
+	Email       string            `gorm:"column:email"`
+	Username    string            `gorm:"column:username"`
+	PhoneNumber *string           `gorm:"column:phone_number"`
+	Status      *string           `gorm:"column:status"`
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"app/internal/app/api-token/model"
+)
+
+// selectAPITokens reads the tokens with the uuid of their user and of their issuer.
+const selectAPITokens = `
+	SELECT
+		a.id, a.uuid, a.user_id, u.uuid AS user_uuid, a.created_by, c.uuid AS created_by_uuid, a.kind, a.name,
+		a.token_hash, a.token_prefix, a.scopes, a.expires_at, a.last_used_at, a.revoked_at, a.created_at
+	FROM api_tokens a
+	JOIN users u ON u.id = a.user_id
+	LEFT JOIN users c ON c.id = a.created_by
+`
+
+type APITokenRepository interface {
+	Create(token model.APIToken) error
+	FindByUUID(uuid string) (model.APIToken, error)
+	FindByUserID(userID int) ([]model.APIToken, error)
+	FindByKind(kind string) ([]model.APIToken, error)
+	FindUserByHash(tokenHash string, now time.Time) (model.APITokenUser, error)
+	UpdateLastUsed(id int, usedAt time.Time, before time.Time) error
+	Revoke(uuid string, revokedAt time.Time) error
+}
+
+type apiTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &apiTokenRepository{db}
+}
+
+func (repo *apiTokenRepository) Create(token model.APIToken) error {
+	err := repo.db.Exec(`
+		INSERT INTO api_tokens (uuid, user_id, created_by, kind, name, token_hash, token_prefix, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, token.UUID, token.UserID, token.CreatedBy, token.Kind, token.Name, token.TokenHash, token.TokenPrefix,
+		token.Scopes, token.ExpiresAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// FindByUUID returns the token, with an ID of 0 when it does not exist.
+func (repo *apiTokenRepository) FindByUUID(uuid string) (model.APIToken, error) {
+	var token model.APIToken
+
+	err := repo.db.Raw(selectAPITokens+" WHERE a.uuid = ?", uuid).Scan(&token).Error
+	if err != nil {
+		return model.APIToken{}, fmt.Errorf("failed to fetch API token: %w", err)
+	}
+
+	return token, nil
+}
+
+// FindByUserID returns every token acting as the user, revoked and expired ones included.
+func (repo *apiTokenRepository) FindByUserID(userID int) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+
+	err := repo.db.Raw(selectAPITokens+" WHERE a.user_id = ? ORDER BY a.created_at, a.id", userID).Scan(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (repo *apiTokenRepository) FindByKind(kind string) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+
+	err := repo.db.Raw(selectAPITokens+" WHERE a.kind = ? ORDER BY a.created_at, a.id", kind).Scan(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// FindUserByHash returns the token of the hash along with its user, provided it is neither revoked nor expired at now.
+// The token ID is 0 otherwise.
+func (repo *apiTokenRepository) FindUserByHash(tokenHash string, now time.Time) (model.APITokenUser, error) {
+	var user model.APITokenUser
+
+	err := repo.db.Raw(`
+		SELECT
+			a.id AS token_id, a.uuid AS token_uuid, a.scopes, a.last_used_at,
+			u.uuid, u.roles, u.first_name, u.last_name, u.email, u.username, u.phone_number, u.status
+		FROM api_tokens a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.token_hash = ? AND a.revoked_at IS NULL AND (a.expires_at IS NULL OR a.expires_at > ?)
+	`, tokenHash, now).Scan(&user).Error
+	if err != nil {
+		return model.APITokenUser{}, fmt.Errorf("failed to fetch API token: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateLastUsed records the use of the token, unless it was already recorded since before,
+// so that a busy integration does not write on every request.
+func (repo *apiTokenRepository) UpdateLastUsed(id int, usedAt time.Time, before time.Time) error {
+	return repo.db.Exec(`
+		UPDATE api_tokens
+		SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`, usedAt, id, before).Error
+}
+
+func (repo *apiTokenRepository) Revoke(uuid string, revokedAt time.Time) error {
+	return repo.db.Exec("UPDATE api_tokens SET revoked_at = ? WHERE uuid = ? AND revoked_at IS NULL", revokedAt, uuid).Error
+}
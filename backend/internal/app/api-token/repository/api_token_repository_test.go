@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"app/internal/app/api-token/model"
+	"app/internal/app/api-token/repository"
+	CommonModel "app/internal/app/common/model"
+	"app/internal/test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const (
+	tokenUserUUID  = "123e4567-e89b-12d3-a456-426614174009"
+	tokenAdminUUID = "123e4567-e89b-12d3-a456-426614174010"
+)
+
+func insertTokenUsers(t *testing.T, db *gorm.DB) (int, int) {
+	t.Helper()
+
+	db.Exec(`INSERT INTO users (uuid, username, email, password_hash, status, roles) VALUES (?, 'payroll', 'payroll@example.com', 'hashed_password', 'active', '{employee,manager}')`, tokenUserUUID)
+	db.Exec(`INSERT INTO users (uuid, username, email, password_hash, status, roles) VALUES (?, 'admin', 'admin@example.com', 'hashed_password', 'active', '{admin}')`, tokenAdminUUID)
+
+	var userID, adminID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", tokenUserUUID).Scan(&userID)
+	db.Raw("SELECT id FROM users WHERE uuid = ?", tokenAdminUUID).Scan(&adminID)
+
+	return userID, adminID
+}
+
+func TestCreateAndFindAPITokens(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAPITokenRepository(db)
+	userID, adminID := insertTokenUsers(t, db)
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.Create(model.APIToken{
+		UUID: "token-personal", UserID: userID, CreatedBy: &userID, Kind: model.KindPersonal, Name: "Script",
+		TokenHash: "hash-personal", TokenPrefix: "tm_aaaaaaaa", Scopes: CommonModel.StringArray{"kpi:read"},
+	}))
+	assert.NoError(t, repo.Create(model.APIToken{
+		UUID: "token-key", UserID: userID, CreatedBy: &adminID, Kind: model.KindAPIKey, Name: "Payroll sync",
+		TokenHash: "hash-key", TokenPrefix: "tm_bbbbbbbb", Scopes: CommonModel.StringArray{"users:read", "work-session:write"}, ExpiresAt: &expiresAt,
+	}))
+
+	token, err := repo.FindByUUID("token-key")
+	assert.NoError(t, err)
+	assert.NotZero(t, token.ID)
+	assert.Equal(t, tokenUserUUID, token.UserUUID)
+	if assert.NotNil(t, token.CreatedByUUID) {
+		assert.Equal(t, tokenAdminUUID, *token.CreatedByUUID)
+	}
+	assert.Equal(t, CommonModel.StringArray{"users:read", "work-session:write"}, token.Scopes)
+	if assert.NotNil(t, token.ExpiresAt) {
+		assert.True(t, expiresAt.Equal(*token.ExpiresAt))
+	}
+	assert.Nil(t, token.LastUsedAt)
+
+	missing, err := repo.FindByUUID("missing")
+	assert.NoError(t, err)
+	assert.Zero(t, missing.ID)
+
+	tokens, err := repo.FindByUserID(userID)
+	assert.NoError(t, err)
+	assert.Len(t, tokens, 2)
+
+	keys, err := repo.FindByKind(model.KindAPIKey)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, "token-key", keys[0].UUID)
+	}
+}
+
+func TestFindUserByHash(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewAPITokenRepository(db)
+	userID, _ := insertTokenUsers(t, db)
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+	assert.NoError(t, repo.Create(model.APIToken{
+		UUID: "token-valid", UserID: userID, Kind: model.KindPersonal, Name: "Valid",
+		TokenHash: "hash-valid", TokenPrefix: "tm_aaaaaaaa", Scopes: CommonModel.StringArray{"kpi:read"}, ExpiresAt: &expiresAt,
+	}))
+
+	user, err := repo.FindUserByHash("hash-valid", now)
+	assert.NoError(t, err)
+	assert.NotZero(t, user.TokenID)
+	assert.Equal(t, "token-valid", user.TokenUUID)
+	assert.Equal(t, tokenUserUUID, user.UUID)
+	assert.Equal(t, CommonModel.StringArray{"employee", "manager"}, user.Roles)
+	assert.Equal(t, CommonModel.StringArray{"kpi:read"}, user.Scopes)
+	tokenID := user.TokenID
+
+	// expired tokens are not found
+	user, err = repo.FindUserByHash("hash-valid", expiresAt)
+	assert.NoError(t, err)
+	assert.Zero(t, user.TokenID)
+
+	// the use is recorded once per period
+	assert.NoError(t, repo.UpdateLastUsed(tokenID, now, now.Add(-time.Minute)))
+	assert.NoError(t, repo.UpdateLastUsed(tokenID, now.Add(30*time.Second), now.Add(-30*time.Second)))
+	token, err := repo.FindByUUID("token-valid")
+	assert.NoError(t, err)
+	if assert.NotNil(t, token.LastUsedAt) {
+		assert.True(t, now.Equal(*token.LastUsedAt))
+	}
+
+	// revoked tokens are not found
+	assert.NoError(t, repo.Revoke("token-valid", now))
+	user, err = repo.FindUserByHash("hash-valid", now)
+	assert.NoError(t, err)
+	assert.Zero(t, user.TokenID)
+}
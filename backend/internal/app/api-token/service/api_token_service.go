@@ -0,0 +1,179 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"app/internal/app/api-token/model"
+	"app/internal/app/api-token/repository"
+	CommonModel "app/internal/app/common/model"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedPrecision is how often the last use of a token is recorded at most
+const lastUsedPrecision = time.Minute
+
+// APITokenService issues the personal access tokens and the API keys. A token acts as its user, restricted to
+// its scopes, until it expires or is revoked.
+type APITokenService interface {
+	CreatePersonal(request model.APITokenCreate, userUUID string) (model.APITokenCreated, error)
+	CreateAPIKey(request model.APIKeyCreate, adminUUID string) (model.APITokenCreated, error)
+	GetByUser(userUUID string) ([]model.APIToken, error)
+	GetAPIKeys() ([]model.APIToken, error)
+	Revoke(uuid string, requesterUUID string, isAdmin bool) error
+	Authenticate(token string) (model.APITokenUser, error)
+}
+
+type apiTokenService struct {
+	Repository  repository.APITokenRepository
+	UserService UserService.UserService
+}
+
+func NewAPITokenService(repo repository.APITokenRepository, userService UserService.UserService) APITokenService {
+	return &apiTokenService{
+		Repository:  repo,
+		UserService: userService,
+	}
+}
+
+// CreatePersonal issues a token acting as the user themselves.
+func (service *apiTokenService) CreatePersonal(request model.APITokenCreate, userUUID string) (model.APITokenCreated, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.APITokenCreated{}, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return service.create(request, model.KindPersonal, userID, userID)
+}
+
+// CreateAPIKey issues a key acting as the given user, usually the account of an integration.
+func (service *apiTokenService) CreateAPIKey(request model.APIKeyCreate, adminUUID string) (model.APITokenCreated, error) {
+	adminID, err := service.UserService.GetIdByUuid(adminUUID)
+	if err != nil {
+		return model.APITokenCreated{}, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	userID, err := service.UserService.GetIdByUuid(request.UserUUID)
+	if err != nil {
+		return model.APITokenCreated{}, fmt.Errorf("%s: user %s not found", Config.ErrorMessages()["INVALID_REQUEST"], request.UserUUID)
+	}
+
+	return service.create(request.APITokenCreate, model.KindAPIKey, userID, adminID)
+}
+
+func (service *apiTokenService) create(request model.APITokenCreate, kind string, userID int, createdBy int) (model.APITokenCreated, error) {
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return model.APITokenCreated{}, fmt.Errorf("%s: expires_at must be in the future", Config.ErrorMessages()["INVALID_REQUEST"])
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return model.APITokenCreated{}, err
+	}
+	token := model.TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+
+	apiToken := model.APIToken{
+		UUID:        uuid.New().String(),
+		UserID:      userID,
+		CreatedBy:   &createdBy,
+		Kind:        kind,
+		Name:        request.Name,
+		TokenHash:   hashToken(token),
+		TokenPrefix: token[:len(model.TokenPrefix)+8],
+		Scopes:      CommonModel.StringArray(slices.Compact(scopes)),
+		ExpiresAt:   request.ExpiresAt,
+	}
+
+	if err := service.Repository.Create(apiToken); err != nil {
+		return model.APITokenCreated{}, err
+	}
+
+	created, err := service.Repository.FindByUUID(apiToken.UUID)
+	if err != nil {
+		return model.APITokenCreated{}, err
+	}
+
+	return model.APITokenCreated{APIToken: created, Token: token}, nil
+}
+
+func (service *apiTokenService) GetByUser(userUUID string) ([]model.APIToken, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	tokens, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []model.APIToken{}
+	}
+	return tokens, nil
+}
+
+func (service *apiTokenService) GetAPIKeys() ([]model.APIToken, error) {
+	tokens, err := service.Repository.FindByKind(model.KindAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []model.APIToken{}
+	}
+	return tokens, nil
+}
+
+// Revoke revokes a token of the requester, admins can revoke any token. The tokens of the other users are
+// reported as not found to non admins.
+func (service *apiTokenService) Revoke(uuid string, requesterUUID string, isAdmin bool) error {
+	token, err := service.Repository.FindByUUID(uuid)
+	if err != nil {
+		return err
+	}
+
+	if token.ID == 0 || (token.UserUUID != requesterUUID && !isAdmin) {
+		return fmt.Errorf("%s: %s", Config.ErrorMessages()["API_TOKEN_NOT_FOUND"], uuid)
+	}
+
+	return service.Repository.Revoke(uuid, time.Now())
+}
+
+// Authenticate returns the user the token acts as, provided the token is valid and the user is not disabled.
+func (service *apiTokenService) Authenticate(token string) (model.APITokenUser, error) {
+	now := time.Now()
+
+	user, err := service.Repository.FindUserByHash(hashToken(token), now)
+	if err != nil {
+		return model.APITokenUser{}, err
+	}
+
+	if user.TokenID == 0 || (user.Status != nil && *user.Status == "disabled") {
+		return model.APITokenUser{}, errors.New(Config.ErrorMessages()["INVALID_API_TOKEN"])
+	}
+
+	if user.LastUsedAt == nil || now.Sub(*user.LastUsedAt) >= lastUsedPrecision {
+		if err := service.Repository.UpdateLastUsed(user.TokenID, now, now.Add(-lastUsedPrecision)); err != nil {
+			log.Printf("⚠️ Error recording the use of API token %s: %v", user.TokenUUID, err)
+		}
+	}
+
+	return user, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,22 +1,44 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	authService "app/internal/app/auth/service"
+	LoginAttemptService "app/internal/app/login-attempt/service"
+	MFAModel "app/internal/app/mfa/model"
 	"app/internal/app/user/model"
 	"app/internal/config"
+	"app/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 
 	"app/internal/app/common/response"
 )
 
+// refreshCookiePath restricts the refresh token cookie to the refresh and logout routes
+const refreshCookiePath = "/api/auth"
+
 type LoginRequest struct {
 	model.UserLogin
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// code of the authenticator app, or one of the recovery codes
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
 type AuthHandler struct {
 	service authService.AuthService
 }
@@ -28,14 +50,60 @@ func NewAuthHandler(service authService.AuthService) *AuthHandler {
 // LoginHandler authenticates a user using email or username and password.
 //
 // @Summary      Login a user
-// @Description  Authenticates a user via email or username and returns a JWT cookie on success.
+// @Description  Authenticates a user via email or username and returns on success a short-lived access token (JWT) cookie and a refresh token cookie. Disabled users cannot log in. The failed attempts are throttled per account and per IP address with progressive delays, and an account is temporarily locked after too many of them, an unlock link being sent by email. When the user has a second factor, or the policy requires one, an MFA token is returned instead, to send with a code to /auth/mfa/verify.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        body  body      LoginRequest  true  "Login credentials"
 // @Success      200   {object}  response.MessageResponse  "logged in successfully"
+// @Success      202   {object}  response.MFAChallengeResponse  "Second factor to verify"
+// @Failure      429   {object}  response.ErrorResponse  "too many failed login attempts, retry after the Retry-After header"
 // @Router       /auth/login [post]
 func (handler *AuthHandler) LoginHandler(c *gin.Context) {
+	tokens, ok := handler.authenticate(c)
+	if !ok {
+		return
+	}
+
+	if tokens.MFAToken != "" {
+		c.JSON(http.StatusAccepted, mfaChallengeResponse(tokens))
+		return
+	}
+
+	setAuthCookies(c, tokens)
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "logged in successfully"})
+}
+
+// TokenHandler authenticates a user like LoginHandler, but returns the tokens in the body rather than in cookies.
+//
+// @Summary      Login a non-browser client
+// @Description  Authenticates a user via email or username and returns a short-lived access token, to send in the **Authorization: Bearer** header, and a refresh token. Meant for the scripts and the mobile app. When the user has a second factor, or the policy requires one, an MFA token is returned instead, to send with a code to /auth/token/mfa/verify.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      LoginRequest  true  "Login credentials"
+// @Success      200   {object}  response.TokenResponse  "Tokens of the session"
+// @Success      202   {object}  response.MFAChallengeResponse  "Second factor to verify"
+// @Failure      401   {object}  response.ErrorResponse  "invalid credentials"
+// @Failure      429   {object}  response.ErrorResponse  "too many failed login attempts, retry after the Retry-After header"
+// @Router       /auth/token [post]
+func (handler *AuthHandler) TokenHandler(c *gin.Context) {
+	tokens, ok := handler.authenticate(c)
+	if !ok {
+		return
+	}
+
+	if tokens.MFAToken != "" {
+		c.JSON(http.StatusAccepted, mfaChallengeResponse(tokens))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
+}
+
+// authenticate checks the credentials of the request body, writing the error response when they are invalid.
+func (handler *AuthHandler) authenticate(c *gin.Context) (authService.Tokens, bool) {
 	var req LoginRequest
 
 	var login struct {
@@ -45,12 +113,12 @@ func (handler *AuthHandler) LoginHandler(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": config.ErrorMessages()["INVALID_REQUEST"]})
-		return
+		return authService.Tokens{}, false
 	}
 
 	if req.Email == nil && req.Username == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "email or username required"})
-		return
+		return authService.Tokens{}, false
 	}
 
 	if req.Email == nil {
@@ -61,38 +129,171 @@ func (handler *AuthHandler) LoginHandler(c *gin.Context) {
 		login.Type = "email"
 	}
 
-	user, token, err := handler.service.AuthenticateUser(login.Type, login.Login, req.Password)
-	if err != nil || user.UUID == "" || token == "" {
+	user, tokens, err := handler.service.AuthenticateUser(login.Type, login.Login, req.Password, c.ClientIP())
+	if throttled(c, err) {
+		return authService.Tokens{}, false
+	}
+	if err != nil || user.UUID == "" || (tokens.AccessToken == "" && tokens.MFAToken == "") {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return authService.Tokens{}, false
+	}
+
+	return tokens, true
+}
+
+// MFASetupHandler starts the enrollment of a second factor required by the policy, during the login.
+//
+// @Summary      Set up the second factor required at login
+// @Description  Generates the TOTP secret of a user whose login requires to enroll a second factor, and returns its provisioning URI to show as a QR code to an authenticator app. The enrollment is confirmed by sending a first code to the verify route.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      MFATokenRequest  true  "MFA token of the login"
+// @Success      200   {object}  MFAModel.MFASetup  "Secret to enroll"
+// @Failure      401   {object}  response.ErrorResponse  "invalid or expired two-factor authentication token"
+// @Router       /auth/mfa/setup [post]
+func (handler *AuthHandler) MFASetupHandler(c *gin.Context) {
+	var req MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": config.ErrorMessages()["INVALID_REQUEST"]})
 		return
 	}
 
-	jwtExpirationHours, err := strconv.Atoi(config.LoadConfig().JWTExpirationHours)
+	var setup MFAModel.MFASetup
+	setup, err := handler.service.SetupMFA(req.MFAToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid JWT expiration configuration"})
+		c.JSON(mfaLoginErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	expiration := jwtExpirationHours * 3600
 
-	c.SetSameSite(http.SameSiteStrictMode)
+	c.JSON(http.StatusOK, setup)
+}
 
-	c.SetCookie(
-		"token",
-		token,
-		expiration,
-		"/",
-		config.LoadConfig().FrontendURL,
-		config.LoadConfig().ProjectStatus == "PROD",
-		true,
-	)
+// MFAVerifyHandler completes a login with its second factor, setting the cookies of the session.
+//
+// @Summary      Verify the second factor of a login
+// @Description  Checks the code of the authenticator app, or a recovery code, for the MFA token of the login, then returns the access token cookie and the refresh token cookie. When the login required an enrollment, the code confirms it and the recovery codes are returned, only in this response.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      MFAVerifyRequest  true  "MFA token and code"
+// @Success      200   {object}  response.MFALoginResponse  "logged in successfully"
+// @Failure      401   {object}  response.ErrorResponse  "invalid two-factor authentication code"
+// @Failure      429   {object}  response.ErrorResponse  "too many failed attempts, retry after the Retry-After header"
+// @Router       /auth/mfa/verify [post]
+func (handler *AuthHandler) MFAVerifyHandler(c *gin.Context) {
+	tokens, recoveryCodes, ok := handler.verifyMFA(c)
+	if !ok {
+		return
+	}
 
-	c.JSON(http.StatusOK, response.MessageResponse{Message: "logged in successfully"})
+	setAuthCookies(c, tokens)
+
+	c.JSON(http.StatusOK, response.MFALoginResponse{Message: "logged in successfully", RecoveryCodes: recoveryCodes})
+}
+
+// TokenMFAVerifyHandler completes the login of a non-browser client with its second factor.
+//
+// @Summary      Verify the second factor of a non-browser client
+// @Description  Checks the code of the authenticator app, or a recovery code, for the MFA token of the login, then returns the access token and the refresh token in the body. When the login required an enrollment, the code confirms it and the recovery codes are returned, only in this response.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      MFAVerifyRequest  true  "MFA token and code"
+// @Success      200   {object}  response.TokenResponse  "Tokens of the session"
+// @Failure      401   {object}  response.ErrorResponse  "invalid two-factor authentication code"
+// @Failure      429   {object}  response.ErrorResponse  "too many failed attempts, retry after the Retry-After header"
+// @Router       /auth/token/mfa/verify [post]
+func (handler *AuthHandler) TokenMFAVerifyHandler(c *gin.Context) {
+	tokens, recoveryCodes, ok := handler.verifyMFA(c)
+	if !ok {
+		return
+	}
+
+	body := tokenResponse(tokens)
+	body.RecoveryCodes = recoveryCodes
+
+	c.JSON(http.StatusOK, body)
+}
+
+// verifyMFA checks the MFA token and the code of the request body, writing the error response when they are invalid.
+func (handler *AuthHandler) verifyMFA(c *gin.Context) (authService.Tokens, []string, bool) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": config.ErrorMessages()["INVALID_REQUEST"]})
+		return authService.Tokens{}, nil, false
+	}
+
+	tokens, recoveryCodes, err := handler.service.VerifyMFA(req.MFAToken, req.Code, c.ClientIP())
+	if throttled(c, err) {
+		return authService.Tokens{}, nil, false
+	}
+	if err != nil {
+		c.JSON(mfaLoginErrorStatus(err), gin.H{"error": err.Error()})
+		return authService.Tokens{}, nil, false
+	}
+
+	return tokens, recoveryCodes.RecoveryCodes, true
+}
+
+// RefreshHandler renews the access token of the session using the refresh token cookie.
+//
+// @Summary      Refresh the access token
+// @Description  Exchanges the refresh token cookie for a new access token cookie and a new refresh token cookie. A refresh token can be used only once, reusing it revokes the session.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  response.MessageResponse  "token refreshed successfully"
+// @Router       /auth/refresh [post]
+func (handler *AuthHandler) RefreshHandler(c *gin.Context) {
+	refreshToken, err := c.Cookie("refresh_token")
+	if err != nil || refreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": config.ErrorMessages()["INVALID_REFRESH_TOKEN"]})
+		return
+	}
+
+	tokens, err := handler.service.Refresh(refreshToken)
+	if err != nil {
+		clearAuthCookies(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": config.ErrorMessages()["INVALID_REFRESH_TOKEN"]})
+		return
+	}
+
+	setAuthCookies(c, tokens)
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "token refreshed successfully"})
+}
+
+// TokenRefreshHandler renews the tokens of a non-browser client.
+//
+// @Summary      Refresh the tokens of a non-browser client
+// @Description  Exchanges the refresh token of the body for a new access token and a new refresh token. A refresh token can be used only once, reusing it revokes the session.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      RefreshTokenRequest  true  "Refresh token"
+// @Success      200   {object}  response.TokenResponse  "Tokens of the session"
+// @Failure      401   {object}  response.ErrorResponse  "invalid or expired refresh token"
+// @Router       /auth/token/refresh [post]
+func (handler *AuthHandler) TokenRefreshHandler(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": config.ErrorMessages()["INVALID_REQUEST"]})
+		return
+	}
+
+	tokens, err := handler.service.Refresh(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": config.ErrorMessages()["INVALID_REFRESH_TOKEN"]})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(tokens))
 }
 
 // MeHandler retrieves the current logged-in user's information.
 //
 // @Summary      Get current user info
-// @Description  Retrieves information about the currently logged-in user based on the JWT token.
+// @Description  Retrieves information about the currently logged-in user based on the access token, sent in the **Authorization: Bearer** header or in the token cookie.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -100,17 +301,12 @@ func (handler *AuthHandler) LoginHandler(c *gin.Context) {
 // @Success      200  {object}  model.UserMeJWT  "Current user information"
 // @Router       /auth/me [get]
 func (handler *AuthHandler) MeHandler(c *gin.Context) {
-	tokenStr, err := c.Cookie("token")
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "no token"})
-		return
-	}
-
-	claims, err := handler.service.ValidateJWT(tokenStr)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+	userClaims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": config.ErrorMessages()["NO_CLAIMS"]})
 		return
 	}
+	claims := userClaims.(*authService.Claims)
 
 	c.JSON(http.StatusOK, model.UserMeJWT{
 		UserUUID:    claims.UUID,
@@ -123,26 +319,99 @@ func (handler *AuthHandler) MeHandler(c *gin.Context) {
 	})
 }
 
-// LogoutHandler logs out the current user by clearing the JWT cookie.
+// LogoutHandler logs out the current user by revoking the session and clearing the cookies.
 //
 // @Summary      Logout the current user
-// @Description  Clears the JWT cookie and invalidates the user's session.
+// @Description  Revokes the session of the refresh token, sent in its cookie or in the body, or else of the access token, sent in the **Authorization: Bearer** header or in its cookie. The cookies are cleared.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
-// @Security     BearerAuth
+// @Param        body  body      RefreshTokenRequest  false  "Refresh token of the non-browser clients"
 // @Success      200  {object}  response.MessageResponse  "Logout successful"
 // @Router       /auth/logout [post]
 func (handler *AuthHandler) LogoutHandler(c *gin.Context) {
+	accessToken := middleware.AccessToken(c)
+	refreshToken, _ := c.Cookie("refresh_token")
+	if refreshToken == "" {
+		// the non-browser clients may send their refresh token in the body
+		var req RefreshTokenRequest
+		if c.ShouldBindJSON(&req) == nil {
+			refreshToken = req.RefreshToken
+		}
+	}
+
+	// the cookies are cleared even when the session is already gone
+	_ = handler.service.Logout(accessToken, refreshToken)
+	clearAuthCookies(c)
+
+	c.JSON(http.StatusOK, response.MessageResponse{Message: "logged out successfully"})
+}
+
+// throttled writes the response of an attempt rejected by the login throttling, with the Retry-After header.
+func throttled(c *gin.Context, err error) bool {
+	var throttledErr *LoginAttemptService.ThrottledError
+	if !errors.As(err, &throttledErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(throttledErr.Seconds()))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
+
+func mfaChallengeResponse(tokens authService.Tokens) response.MFAChallengeResponse {
+	return response.MFAChallengeResponse{
+		MFARequired:        true,
+		MFAToken:           tokens.MFAToken,
+		EnrollmentRequired: tokens.MFAEnrollmentRequired,
+	}
+}
+
+func mfaLoginErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, config.ErrorMessages()["INVALID_MFA_TOKEN"]),
+		strings.HasPrefix(message, config.ErrorMessages()["INVALID_MFA_CODE"]):
+		return http.StatusUnauthorized
+	case strings.HasPrefix(message, config.ErrorMessages()["MFA_ALREADY_ENABLED"]):
+		return http.StatusConflict
+	case strings.HasPrefix(message, config.ErrorMessages()["MFA_NOT_ENABLED"]),
+		strings.HasPrefix(message, config.ErrorMessages()["MFA_SETUP_NOT_FOUND"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func tokenResponse(tokens authService.Tokens) response.TokenResponse {
+	return response.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    config.LoadConfig().Session.AccessTokenMinutes * 60,
+	}
+}
+
+func setAuthCookies(c *gin.Context, tokens authService.Tokens) {
+	sessionConfig := config.LoadConfig().Session
+
+	c.SetSameSite(http.SameSiteStrictMode)
+	setCookie(c, "token", tokens.AccessToken, sessionConfig.AccessTokenMinutes*60, "/")
+	setCookie(c, "refresh_token", tokens.RefreshToken, sessionConfig.RefreshTokenDays*24*3600, refreshCookiePath)
+}
+
+func clearAuthCookies(c *gin.Context) {
+	setCookie(c, "token", "", -1, "/")
+	setCookie(c, "refresh_token", "", -1, refreshCookiePath)
+}
+
+func setCookie(c *gin.Context, name string, value string, maxAge int, path string) {
 	c.SetCookie(
-		"token",
-		"",
-		-1,
-		"/",
+		name,
+		value,
+		maxAge,
+		path,
 		config.LoadConfig().FrontendURL,
 		config.LoadConfig().ProjectStatus == "PROD",
 		true,
 	)
-
-	c.JSON(http.StatusOK, response.MessageResponse{Message: "logged out successfully"})
 }
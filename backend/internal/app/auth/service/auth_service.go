@@ -2,9 +2,16 @@ package service
 
 import (
 	"errors"
-	"strconv"
+	"log"
+	"strings"
 	"time"
 
+	APITokenService "app/internal/app/api-token/service"
+	LoginAttemptModel "app/internal/app/login-attempt/model"
+	LoginAttemptService "app/internal/app/login-attempt/service"
+	MFAModel "app/internal/app/mfa/model"
+	MFAService "app/internal/app/mfa/service"
+	SessionService "app/internal/app/session/service"
 	"app/internal/app/user/model"
 	userService "app/internal/app/user/service"
 
@@ -19,6 +26,7 @@ import (
  */
 type Claims struct {
 	UUID        string   `json:"uuid"`
+	SessionID   string   `json:"sid"`
 	Roles       []string `json:"roles"`
 	FirstName   string   `json:"first_name"`
 	LastName    string   `json:"last_name"`
@@ -26,42 +34,79 @@ type Claims struct {
 	Username    string   `json:"username"`
 	PhoneNumber *string  `json:"phone_number,omitempty"`
 	WeeklyRate  int      `json:"weekly_rate"`
+	// set when the request is authenticated by an API token, never part of a JWT
+	APITokenUUID string   `json:"-"`
+	Scopes       []string `json:"-"`
+	jwt.RegisteredClaims
+}
+
+// IsAPIToken tells whether the claims come from an API token, restricted to its scopes, rather than from a session
+func (claims *Claims) IsAPIToken() bool {
+	return claims.APITokenUUID != ""
+}
+
+// mfaPurpose marks the short-lived JWT issued at login while the second factor remains to verify
+const mfaPurpose = "mfa"
+
+/**
+ * {Tokens} are the short-lived access token (JWT) and the refresh token of a session,
+ * or only the MFA token when the second factor of the user remains to verify
+ */
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+	// the user has to enroll a second factor, required by the policy, before the session is opened
+	MFAEnrollmentRequired bool
+}
+
+/**
+ * {mfaClaims} are the parameters of the MFA token, which has no session and cannot be used as an access token
+ */
+type mfaClaims struct {
+	UUID    string `json:"uuid"`
+	Purpose string `json:"purpose"`
+	Enroll  bool   `json:"enroll"`
 	jwt.RegisteredClaims
 }
 
 type AuthService interface {
-	AuthenticateUser(typeOf string, data string, password string) (model.UserReadJWT, string, error)
-	GenerateJWT(user model.UserReadJWT) (string, error)
+	AuthenticateUser(typeOf string, data string, password string, clientIP string) (model.UserReadJWT, Tokens, error)
+	SetupMFA(mfaToken string) (MFAModel.MFASetup, error)
+	VerifyMFA(mfaToken string, code string, clientIP string) (Tokens, MFAModel.MFARecoveryCodes, error)
+	Refresh(refreshToken string) (Tokens, error)
+	Logout(accessToken string, refreshToken string) error
+	GenerateJWT(user model.UserReadJWT, sessionID string) (string, error)
 	ValidateJWT(tokenStr string) (*Claims, error)
+	ValidateAPIToken(token string) (*Claims, error)
 }
 
 type authService struct {
-	userService userService.UserService
+	userService         userService.UserService
+	sessionService      SessionService.SessionService
+	apiTokenService     APITokenService.APITokenService
+	mfaService          MFAService.MFAService
+	loginAttemptService LoginAttemptService.LoginAttemptService
 }
 
-func NewAuthService(userService userService.UserService) AuthService {
-	return &authService{userService}
+func NewAuthService(userService userService.UserService, sessionService SessionService.SessionService, apiTokenService APITokenService.APITokenService, mfaService MFAService.MFAService, loginAttemptService LoginAttemptService.LoginAttemptService) AuthService {
+	return &authService{userService, sessionService, apiTokenService, mfaService, loginAttemptService}
 }
 
-func (service *authService) GenerateJWT(user model.UserReadJWT) (string, error) {
+func (service *authService) GenerateJWT(user model.UserReadJWT, sessionID string) (string, error) {
 	secret := config.LoadConfig().JWTSecret
-	expiration := config.LoadConfig().JWTExpirationHours
+	expiration := config.LoadConfig().Session.AccessTokenMinutes
 
 	if secret == "" {
 		return "", errors.New("missing JWT_SECRET")
 	}
 
-	// Convert expiration from string to int with Atoi from strconv package
-	expirationInt, err := strconv.Atoi(expiration)
-	if err != nil {
-		return "", errors.New("invalid JWT expiration value")
-	}
-
-	// Set expiration time to current time + expiration hours
-	expirationTime := time.Now().Add(time.Duration(expirationInt) * time.Hour)
+	// Set expiration time to current time + expiration minutes
+	expirationTime := time.Now().Add(time.Duration(expiration) * time.Minute)
 
 	claims := &Claims{
 		UUID:        user.UUID,
+		SessionID:   sessionID,
 		Roles:       user.Roles,
 		FirstName:   user.FirstName,
 		LastName:    user.LastName,
@@ -79,11 +124,49 @@ func (service *authService) GenerateJWT(user model.UserReadJWT) (string, error)
 	return token.SignedString([]byte(secret))
 }
 
+// ValidateJWT checks the signature and the expiration of the token, then that its session has not been revoked
 func (service *authService) ValidateJWT(tokenStr string) (*Claims, error) {
+	claims, err := parseJWT(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := service.sessionService.IsActive(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, errors.New("revoked session")
+	}
+
+	return claims, nil
+}
+
+// ValidateAPIToken returns the claims of the user the API token acts as, with the scopes of the token
+func (service *authService) ValidateAPIToken(token string) (*Claims, error) {
+	user, err := service.apiTokenService.Authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		UUID:         user.UUID,
+		Roles:        user.Roles,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Email:        user.Email,
+		Username:     user.Username,
+		PhoneNumber:  user.PhoneNumber,
+		APITokenUUID: user.TokenUUID,
+		Scopes:       user.Scopes,
+	}, nil
+}
+
+func parseJWT(tokenStr string) (*Claims, error) {
 	secret := config.LoadConfig().JWTSecret
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return nil, err
 	}
@@ -96,22 +179,219 @@ func (service *authService) ValidateJWT(tokenStr string) (*Claims, error) {
 	return claims, nil
 }
 
-// Authenticates a user using the userService
-func (service *authService) AuthenticateUser(typeOf string, data string, password string) (model.UserReadJWT, string, error) {
+// Authenticates a user using the userService, then opens a session,
+// unless the user has or must enroll a second factor: only an MFA token is returned then.
+// The attempts are reserved per account and per IP address before checking the password, a LoginAttemptService.ThrottledError
+// being returned without checking it while the account or the IP address is delayed or locked.
+func (service *authService) AuthenticateUser(typeOf string, data string, password string, clientIP string) (model.UserReadJWT, Tokens, error) {
 	// get user by email or username
 	user, err := service.userService.GetUserByEmailAuth(typeOf, data)
+	if err != nil || user == nil {
+		return model.UserReadJWT{}, Tokens{}, errors.New("invalid credentials")
+	}
+
+	attempt, err := service.loginAttemptService.Reserve(LoginAttemptModel.LoginAttempt{UserUUID: user.UUID, Login: data, IPAddress: clientIP})
 	if err != nil {
-		return model.UserReadJWT{}, "", errors.New("invalid credentials")
+		return model.UserReadJWT{}, Tokens{}, err
+	}
+
+	switch {
+	case user.UUID == "":
+		service.recordFailure(attempt, LoginAttemptModel.ReasonUnknownUser)
+		return model.UserReadJWT{}, Tokens{}, errors.New("invalid credentials")
+	case isDisabled(*user):
+		service.recordFailure(attempt, LoginAttemptModel.ReasonDisabled)
+		return model.UserReadJWT{}, Tokens{}, errors.New("invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return model.UserReadJWT{}, "", errors.New("invalid credentials")
+		service.recordFailure(attempt, LoginAttemptModel.ReasonInvalidPassword)
+		return model.UserReadJWT{}, Tokens{}, errors.New("invalid credentials")
+	}
+
+	// the password is right: the attempt only succeeds once a session is open, and is released otherwise
+	enabled, required, err := service.mfaService.Requirement(user.UUID, user.Roles)
+	if err != nil {
+		service.release(attempt)
+		return model.UserReadJWT{}, Tokens{}, err
+	}
+	if enabled || required {
+		service.release(attempt)
+		mfaToken, err := generateMFAToken(user.UUID, !enabled)
+		if err != nil {
+			return model.UserReadJWT{}, Tokens{}, errors.New("failed to generate token")
+		}
+		return *user, Tokens{MFAToken: mfaToken, MFAEnrollmentRequired: !enabled}, nil
+	}
+
+	tokens, err := service.openSession(*user)
+	if err != nil {
+		service.release(attempt)
+		return model.UserReadJWT{}, Tokens{}, err
+	}
+	service.recordSuccess(attempt)
+
+	return *user, tokens, nil
+}
+
+// SetupMFA starts the enrollment of the user who must enroll a second factor to log in
+func (service *authService) SetupMFA(mfaToken string) (MFAModel.MFASetup, error) {
+	claims, err := parseMFAToken(mfaToken)
+	if err != nil || !claims.Enroll {
+		return MFAModel.MFASetup{}, errors.New(config.ErrorMessages()["INVALID_MFA_TOKEN"])
+	}
+
+	return service.mfaService.Setup(claims.UUID)
+}
+
+// VerifyMFA checks the second factor of the MFA token, confirming the enrollment when there is one, then opens a session.
+// The recovery codes are only returned with the confirmation of an enrollment.
+// The invalid codes are throttled along with the failed logins of the account.
+func (service *authService) VerifyMFA(mfaToken string, code string, clientIP string) (Tokens, MFAModel.MFARecoveryCodes, error) {
+	claims, err := parseMFAToken(mfaToken)
+	if err != nil {
+		return Tokens{}, MFAModel.MFARecoveryCodes{}, errors.New(config.ErrorMessages()["INVALID_MFA_TOKEN"])
+	}
+
+	user, err := service.userService.GetUserByEmailAuth("uuid", claims.UUID)
+	if err != nil || user.UUID == "" || isDisabled(*user) {
+		return Tokens{}, MFAModel.MFARecoveryCodes{}, errors.New(config.ErrorMessages()["INVALID_MFA_TOKEN"])
+	}
+
+	attempt, err := service.loginAttemptService.Reserve(LoginAttemptModel.LoginAttempt{UserUUID: user.UUID, Login: user.Email, IPAddress: clientIP})
+	if err != nil {
+		return Tokens{}, MFAModel.MFARecoveryCodes{}, err
+	}
+
+	var recoveryCodes MFAModel.MFARecoveryCodes
+	if claims.Enroll {
+		recoveryCodes, err = service.mfaService.Confirm(user.UUID, code)
+	} else {
+		err = service.mfaService.Verify(user.UUID, code)
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), config.ErrorMessages()["INVALID_MFA_CODE"]) {
+			service.recordFailure(attempt, LoginAttemptModel.ReasonInvalidMFACode)
+		} else {
+			service.release(attempt)
+		}
+		return Tokens{}, MFAModel.MFARecoveryCodes{}, err
+	}
+
+	tokens, err := service.openSession(*user)
+	if err != nil {
+		service.release(attempt)
+		return Tokens{}, MFAModel.MFARecoveryCodes{}, err
+	}
+	service.recordSuccess(attempt)
+
+	return tokens, recoveryCodes, nil
+}
+
+// recordFailure counts the failure of the attempt, a failure to do so not changing the response of the login.
+func (service *authService) recordFailure(attempt LoginAttemptModel.LoginAttempt, reason string) {
+	if err := service.loginAttemptService.RecordFailure(attempt, reason); err != nil {
+		log.Printf("⚠️ Error recording login failure of %s : %v", attempt.Login, err)
 	}
+}
+
+func (service *authService) recordSuccess(attempt LoginAttemptModel.LoginAttempt) {
+	if err := service.loginAttemptService.RecordSuccess(attempt); err != nil {
+		log.Printf("⚠️ Error resetting login failures of %s : %v", attempt.Login, err)
+	}
+}
 
-	token, err := service.GenerateJWT(*user)
+func (service *authService) release(attempt LoginAttemptModel.LoginAttempt) {
+	if err := service.loginAttemptService.Release(attempt); err != nil {
+		log.Printf("⚠️ Error releasing login attempt of %s : %v", attempt.Login, err)
+	}
+}
+
+func (service *authService) openSession(user model.UserReadJWT) (Tokens, error) {
+	session, refreshToken, err := service.sessionService.Create(user.UUID)
 	if err != nil {
-		return model.UserReadJWT{}, "", errors.New("failed to generate token")
+		return Tokens{}, errors.New("failed to open session")
 	}
 
-	return *user, token, nil
+	token, err := service.GenerateJWT(user, session.ID)
+	if err != nil {
+		return Tokens{}, errors.New("failed to generate token")
+	}
+
+	return Tokens{AccessToken: token, RefreshToken: refreshToken}, nil
+}
+
+func generateMFAToken(userUUID string, enroll bool) (string, error) {
+	secret := config.LoadConfig().JWTSecret
+	if secret == "" {
+		return "", errors.New("missing JWT_SECRET")
+	}
+
+	claims := &mfaClaims{
+		UUID:    userUUID,
+		Purpose: mfaPurpose,
+		Enroll:  enroll,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(config.LoadConfig().MFA.LoginTokenMinutes) * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+func parseMFAToken(tokenStr string) (*mfaClaims, error) {
+	secret := config.LoadConfig().JWTSecret
+	token, err := jwt.ParseWithClaims(tokenStr, &mfaClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*mfaClaims)
+	if !ok || !token.Valid || claims.Purpose != mfaPurpose || claims.UUID == "" {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// Refresh rotates the refresh token and issues an access token with the current roles of the user
+func (service *authService) Refresh(refreshToken string) (Tokens, error) {
+	session, newRefreshToken, err := service.sessionService.Rotate(refreshToken)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	user, err := service.userService.GetUserByEmailAuth("uuid", session.UserUUID)
+	if err != nil || user.UUID == "" || isDisabled(*user) {
+		_ = service.sessionService.Revoke(session.ID)
+		return Tokens{}, errors.New(config.ErrorMessages()["INVALID_REFRESH_TOKEN"])
+	}
+
+	token, err := service.GenerateJWT(*user, session.ID)
+	if err != nil {
+		return Tokens{}, errors.New("failed to generate token")
+	}
+
+	return Tokens{AccessToken: token, RefreshToken: newRefreshToken}, nil
+}
+
+// Logout revokes the session of the refresh token, or of the access token when there is no refresh token
+func (service *authService) Logout(accessToken string, refreshToken string) error {
+	if refreshToken != "" {
+		return service.sessionService.RevokeRefreshToken(refreshToken)
+	}
+
+	claims, err := parseJWT(accessToken)
+	if err != nil {
+		return nil
+	}
+
+	return service.sessionService.Revoke(claims.SessionID)
+}
+
+func isDisabled(user model.UserReadJWT) bool {
+	return user.Status != nil && *user.Status == "disabled"
 }
@@ -13,6 +13,7 @@ type BreakRepository interface {
 	CreateBreak(uuid string, workSessionId int, status string) error
 	GetWorkSessionBreak(work_session_id int, status string) (BreakModel.BreakRead, error)
 	GetTotalBreakDurationByWorkSessionId(workSessionId int) (int, error)
+	CountBreaksByWorkSessionId(workSessionId int) (int, error)
 	DeleteRelatedBreaksToWorkSession(workSessionId int) error
 }
 
@@ -78,6 +79,16 @@ func (repo *breakRepository) GetTotalBreakDurationByWorkSessionId(workSessionId
 	return totalDuration, err
 }
 
+func (repo *breakRepository) CountBreaksByWorkSessionId(workSessionId int) (int, error) {
+	var count int
+	err := repo.db.Raw(`
+		SELECT COUNT(*)
+		FROM breaks
+		WHERE work_session_active_id = ?
+	`, workSessionId).Scan(&count).Error
+	return count, err
+}
+
 func (repo *breakRepository) DeleteRelatedBreaksToWorkSession(workSessionId int) error {
 	return repo.db.Exec(`
 		DELETE FROM breaks
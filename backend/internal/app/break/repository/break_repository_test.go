@@ -164,6 +164,29 @@ func TestGetTotalBreakDurationByWorkSessionId(t *testing.T) {
 	assert.Equal(t, 40, total)
 }
 
+func TestCountBreaksByWorkSessionId(t *testing.T) {
+	uuid1 := "123e4567-e89b-12d3-a456-426614174007"
+	uuid2 := "223e4567-e89b-12d3-a456-426614174008"
+	db := test.ResetDB(t)
+	repo := repository.NewBreakRepository(db)
+
+	workSessionID, err := addWorkSession(t, uuid1)
+
+	if err != nil {
+		t.Fatalf(WORK_SESSION_NEW_ERROR, err)
+	}
+
+	db.Exec(`
+		INSERT INTO breaks (uuid, work_session_active_id, duration_minutes, start_time)
+		VALUES (?, ?, ?, ?), (?, ?, ?, ?)
+	`, uuid1, workSessionID, 10, time.Now().Format(time.RFC3339), uuid2, workSessionID, 30, time.Now().Format(time.RFC3339),
+	)
+
+	count, err := repo.CountBreaksByWorkSessionId(workSessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
 func TestDeleteRelatedBreaksToWorkSession(t *testing.T) {
 	uuid := "123e4567-e89b-12d3-a456-426614174005"
 	db := test.ResetDB(t)
@@ -8,6 +8,7 @@ import (
 
 	BreakModel "app/internal/app/break/model"
 	BreakRepository "app/internal/app/break/repository"
+	UserService "app/internal/app/user/service"
 	WorkSessionRepository "app/internal/app/work-session/repository"
 
 	"github.com/google/uuid"
@@ -20,10 +21,11 @@ type BreakService interface {
 type breakService struct {
 	BreakRepo       BreakRepository.BreakRepository
 	WorkSessionRepo WorkSessionRepository.WorkSessionRepository
+	UserService     UserService.UserService
 }
 
-func NewBreakService(repo BreakRepository.BreakRepository, workSessionRepo WorkSessionRepository.WorkSessionRepository) BreakService {
-	return &breakService{BreakRepo: repo, WorkSessionRepo: workSessionRepo}
+func NewBreakService(repo BreakRepository.BreakRepository, workSessionRepo WorkSessionRepository.WorkSessionRepository, userService UserService.UserService) BreakService {
+	return &breakService{BreakRepo: repo, WorkSessionRepo: workSessionRepo, UserService: userService}
 }
 
 func (service *breakService) UpdateBreakClocking(data BreakModel.BreakUpdate) (BreakModel.BreakUpdateResponse, error) {
@@ -100,6 +102,12 @@ func (service *breakService) UpdateBreakClocking(data BreakModel.BreakUpdate) (B
 		response.EndTime = &clockOutTimeStr
 	}
 
+	if userUUID, err := service.WorkSessionRepo.FindUserUuidByUuid(data.WorkSessionUUID); err != nil {
+		log.Println("failed to find the user of the work session to invalidate their cache:", err)
+	} else {
+		service.UserService.InvalidateCache(userUUID)
+	}
+
 	response.Success = true
 	if !*data.IsBreaking {
 		response.Status = "break_ended"
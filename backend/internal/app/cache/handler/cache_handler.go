@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	CacheService "app/internal/app/cache/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CacheHandler struct {
+	service CacheService.CacheService
+}
+
+func NewCacheHandler(service CacheService.CacheService) *CacheHandler {
+	return &CacheHandler{service: service}
+}
+
+// GetStats Cache
+//
+// @Summary      Get the cache statistics
+// @Description  Retrieve the number of hits and misses of the cached KPIs and histories since the start of the server, by namespace. 🔒 Requires role: **admin**
+// @Tags         Cache
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   model.CacheStats
+// @Router       /cache/stats [get]
+func (handler *CacheHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, handler.service.Stats())
+}
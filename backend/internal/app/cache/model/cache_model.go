@@ -0,0 +1,11 @@
+package model
+
+// swagger:model CacheStats
+type CacheStats struct {
+	// namespace of the cached results, such as "kpi" or "history"
+	Namespace string `json:"namespace"`
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+	// share of the reads served from the cache, from 0 to 1
+	HitRatio float64 `json:"hit_ratio"`
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"app/internal/app/cache/model"
+	"app/internal/db"
+	"app/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// InvalidationChannel is the Postgres channel on which the triggers send the tags to invalidate
+	InvalidationChannel = "cache_invalidation"
+	// OrganizationTag marks the results computed over every user, invalidated by any change
+	OrganizationTag  = "organization"
+	KPINamespace     = "kpi"
+	HistoryNamespace = "history"
+)
+
+func UserTag(uuid string) string {
+	return "user:" + uuid
+}
+
+func TeamTag(uuid string) string {
+	return "team:" + uuid
+}
+
+// CacheService caches JSON results in Redis under a namespace, each result being tagged with the users and teams it was computed from.
+// Every result is also tagged with its namespace, so that a whole namespace can be invalidated at once.
+type CacheService interface {
+	Get(namespace string, key string, dest any) bool
+	Version(namespace string, tags []string) string
+	Set(namespace string, key string, value any, tags []string, version string)
+	Invalidate(tags ...string)
+	Stats() []model.CacheStats
+	StartInvalidationListener(ctx context.Context)
+}
+
+type cacheService struct {
+	client *redis.Client
+	ttl    time.Duration
+	mutex  sync.Mutex
+	hits   map[string]int64
+	misses map[string]int64
+}
+
+func NewCacheService(client *redis.Client, ttl time.Duration) CacheService {
+	return &cacheService{
+		client: client,
+		ttl:    ttl,
+		hits:   map[string]int64{},
+		misses: map[string]int64{},
+	}
+}
+
+// Remember returns the result cached under the key, or computes and caches it.
+// The result is not cached when one of its tags is invalidated while it is computed, since it may already be stale.
+func Remember[T any](cache CacheService, namespace string, key string, tags []string, compute func() (T, error)) (T, error) {
+	var cached T
+	if cache.Get(namespace, key, &cached) {
+		return cached, nil
+	}
+
+	version := cache.Version(namespace, tags)
+	value, err := compute()
+	if err != nil {
+		return value, err
+	}
+
+	cache.Set(namespace, key, value, tags, version)
+	return value, nil
+}
+
+func (service *cacheService) Get(namespace string, key string, dest any) bool {
+	cached, err := service.client.Get(context.Background(), entryKey(namespace, key)).Result()
+	hit := err == nil && json.Unmarshal([]byte(cached), dest) == nil
+
+	service.mutex.Lock()
+	if hit {
+		service.hits[namespace]++
+		metrics.CacheRequests.Inc(namespace, "hit")
+	} else {
+		service.misses[namespace]++
+		metrics.CacheRequests.Inc(namespace, "miss")
+	}
+	service.mutex.Unlock()
+
+	return hit
+}
+
+// Version returns the invalidation counters of the tags, to be given back to Set once the result is computed.
+func (service *cacheService) Version(namespace string, tags []string) string {
+	versions, err := service.client.MGet(context.Background(), versionKeys(namespace, tags)...).Result()
+	if err != nil {
+		return ""
+	}
+	return joinVersions(versions)
+}
+
+func (service *cacheService) Set(namespace string, key string, value any, tags []string, version string) {
+	ctx := context.Background()
+	cacheKey := entryKey(namespace, key)
+	watched := versionKeys(namespace, tags)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("⚠️ Error encoding cache entry %s : %v", cacheKey, err)
+		return
+	}
+
+	err = service.client.Watch(ctx, func(tx *redis.Tx) error {
+		versions, err := tx.MGet(ctx, watched...).Result()
+		if err != nil {
+			return err
+		}
+		if version == "" || joinVersions(versions) != version {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, cacheKey, data, service.ttl)
+			for _, tag := range append([]string{namespace}, tags...) {
+				pipe.SAdd(ctx, tagKey(tag), cacheKey)
+				pipe.Expire(ctx, tagKey(tag), service.ttl)
+			}
+			return nil
+		})
+		return err
+	}, watched...)
+	if err != nil {
+		log.Printf("⚠️ Error setting cache for %s : %v", cacheKey, err)
+	}
+}
+
+// Invalidate deletes every result tagged with one of the tags.
+// The counter of the tag is increased first, so that a result computed meanwhile is never cached.
+func (service *cacheService) Invalidate(tags ...string) {
+	ctx := context.Background()
+
+	for _, tag := range tags {
+		if err := service.client.Incr(ctx, versionKey(tag)).Err(); err != nil {
+			log.Printf("⚠️ Error invalidating cache tag %s : %v", tag, err)
+			continue
+		}
+
+		keys, err := service.client.SMembers(ctx, tagKey(tag)).Result()
+		if err != nil || len(keys) == 0 {
+			continue
+		}
+
+		members := make([]any, len(keys))
+		for i, key := range keys {
+			members[i] = key
+		}
+
+		_, err = service.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, keys...)
+			pipe.SRem(ctx, tagKey(tag), members...)
+			return nil
+		})
+		if err != nil {
+			log.Printf("⚠️ Error invalidating cache tag %s : %v", tag, err)
+		}
+	}
+}
+
+// Stats returns the hits and misses of each namespace since the start of the server.
+func (service *cacheService) Stats() []model.CacheStats {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	namespaces := make([]string, 0, len(service.hits)+len(service.misses))
+	for namespace := range service.hits {
+		namespaces = append(namespaces, namespace)
+	}
+	for namespace := range service.misses {
+		if _, exists := service.hits[namespace]; !exists {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	stats := make([]model.CacheStats, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		entry := model.CacheStats{
+			Namespace: namespace,
+			Hits:      service.hits[namespace],
+			Misses:    service.misses[namespace],
+		}
+		if total := entry.Hits + entry.Misses; total > 0 {
+			entry.HitRatio = float64(entry.Hits) / float64(total)
+		}
+		stats = append(stats, entry)
+	}
+	return stats
+}
+
+// StartInvalidationListener invalidates the tags notified by the database triggers until the context is done.
+// The services already invalidate their own writes, the notifications covering the changes made outside of them.
+// Every change also invalidates the results computed over the whole organization.
+func (service *cacheService) StartInvalidationListener(ctx context.Context) {
+	go func() {
+		for {
+			// notifications are lost while nobody listens, nothing cached before can be trusted
+			service.Invalidate(KPINamespace, HistoryNamespace)
+
+			err := db.ListenPostgres(ctx, InvalidationChannel, func(tag string) {
+				service.Invalidate(tag, OrganizationTag)
+			})
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("⚠️ Cache invalidation listener stopped, restarting: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+}
+
+func entryKey(namespace string, key string) string {
+	return "cache:" + namespace + ":" + key
+}
+
+func tagKey(tag string) string {
+	return "cache:tag:" + tag
+}
+
+func versionKey(tag string) string {
+	return "cache:version:" + tag
+}
+
+func versionKeys(namespace string, tags []string) []string {
+	keys := make([]string, 0, len(tags)+1)
+	for _, tag := range append([]string{namespace}, tags...) {
+		keys = append(keys, versionKey(tag))
+	}
+	return keys
+}
+
+func joinVersions(versions []any) string {
+	parts := make([]string, len(versions))
+	for i, version := range versions {
+		if value, ok := version.(string); ok {
+			parts[i] = value
+		} else {
+			parts[i] = "0"
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,223 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"app/internal/app/cache/service"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const testNamespace = "test"
+
+func newRedisClient(t *testing.T) *redis.Client {
+	ctx := context.Background()
+	const port = "6379/tcp"
+
+	redisC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{port},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("❌ Failed to start Redis: %v", err)
+	}
+	t.Cleanup(func() { _ = redisC.Terminate(ctx) })
+
+	host, _ := redisC.Host(ctx)
+	mappedPort, _ := redisC.MappedPort(ctx, port)
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, mappedPort.Port())})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+// newPostgres starts a database for the invalidation listener, which connects with the DB_* variables.
+func newPostgres(t *testing.T) *pgx.Conn {
+	ctx := context.Background()
+	const port = "5432/tcp"
+
+	pgC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image: "postgres:16",
+			Env: map[string]string{
+				"POSTGRES_PASSWORD": "test",
+				"POSTGRES_DB":       "testdb",
+				"POSTGRES_USER":     "postgres",
+			},
+			ExposedPorts: []string{port},
+			WaitingFor: wait.ForAll(
+				wait.ForListeningPort(port),
+				wait.ForLog("database system is ready to accept connections").WithStartupTimeout(60*time.Second),
+			),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("❌ Failed to start Postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = pgC.Terminate(ctx) })
+
+	host, _ := pgC.Host(ctx)
+	mappedPort, _ := pgC.MappedPort(ctx, port)
+
+	t.Setenv("DB_HOST", host)
+	t.Setenv("DB_PORT", mappedPort.Port())
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "test")
+	t.Setenv("DB_DATABASE", "testdb")
+
+	var conn *pgx.Conn
+	for range 5 {
+		conn, err = pgx.Connect(ctx, fmt.Sprintf("postgres://postgres:test@%s:%s/testdb?sslmode=disable", host, mappedPort.Port()))
+		if err == nil {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if err != nil {
+		t.Fatalf("❌ Failed to connect Postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(ctx) })
+
+	return conn
+}
+
+// remember caches the value under the key, counting how many times it is computed.
+func remember(t *testing.T, cache service.CacheService, key string, tags []string, value string, computed *int) string {
+	cached, err := service.Remember(cache, testNamespace, key, tags, func() (string, error) {
+		*computed++
+		return value, nil
+	})
+	assert.NoError(t, err)
+	return cached
+}
+
+func TestRememberHitAndMiss(t *testing.T) {
+	cache := service.NewCacheService(newRedisClient(t), time.Minute)
+	tags := []string{service.UserTag("user-1")}
+
+	computed := 0
+	assert.Equal(t, "first", remember(t, cache, "presence", tags, "first", &computed))
+	assert.Equal(t, "first", remember(t, cache, "presence", tags, "second", &computed))
+	assert.Equal(t, 1, computed)
+
+	// a failed computation is not cached
+	_, err := service.Remember(cache, testNamespace, "failing", tags, func() (string, error) {
+		return "", errors.New("failed")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "computed", remember(t, cache, "failing", tags, "computed", &computed))
+
+	stats := cache.Stats()
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, testNamespace, stats[0].Namespace)
+		assert.Equal(t, int64(1), stats[0].Hits)
+		assert.Equal(t, int64(3), stats[0].Misses)
+		assert.InDelta(t, 0.25, stats[0].HitRatio, 1e-9)
+	}
+}
+
+func TestInvalidateByTag(t *testing.T) {
+	client := newRedisClient(t)
+	cache := service.NewCacheService(client, time.Minute)
+
+	computed := 0
+	remember(t, cache, "user-1", []string{service.UserTag("user-1"), service.TeamTag("team-1")}, "user-1", &computed)
+	remember(t, cache, "user-2", []string{service.UserTag("user-2")}, "user-2", &computed)
+	assert.Equal(t, 2, computed)
+
+	ttl, err := client.TTL(context.Background(), "cache:test:user-1").Result()
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute.Seconds(), ttl.Seconds(), 5)
+
+	// only the results of the invalidated user are computed again
+	cache.Invalidate(service.UserTag("user-1"))
+	remember(t, cache, "user-1", []string{service.UserTag("user-1"), service.TeamTag("team-1")}, "user-1", &computed)
+	remember(t, cache, "user-2", []string{service.UserTag("user-2")}, "user-2", &computed)
+	assert.Equal(t, 3, computed)
+
+	// the tag of a team invalidates the results of its members
+	cache.Invalidate(service.TeamTag("team-1"))
+	var cached string
+	assert.False(t, cache.Get(testNamespace, "user-1", &cached))
+	assert.True(t, cache.Get(testNamespace, "user-2", &cached))
+
+	// the namespace invalidates every result of it
+	cache.Invalidate(testNamespace)
+	assert.False(t, cache.Get(testNamespace, "user-2", &cached))
+
+	// unknown tags are ignored
+	cache.Invalidate(service.UserTag("unknown"))
+}
+
+func TestWriteAfterInvalidationIsNotCached(t *testing.T) {
+	cache := service.NewCacheService(newRedisClient(t), time.Minute)
+	tags := []string{service.UserTag("user-1")}
+
+	// the data of the user changes while the result is computed: the stale result is returned but not cached
+	stale, err := service.Remember(cache, testNamespace, "presence", tags, func() (string, error) {
+		cache.Invalidate(service.UserTag("user-1"))
+		return "stale", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", stale)
+
+	var cached string
+	assert.False(t, cache.Get(testNamespace, "presence", &cached))
+
+	// a write racing with an invalidation of any of its tags, or of its namespace, is dropped
+	version := cache.Version(testNamespace, tags)
+	cache.Invalidate(service.UserTag("user-1"))
+	cache.Set(testNamespace, "presence", "stale", tags, version)
+	assert.False(t, cache.Get(testNamespace, "presence", &cached))
+
+	version = cache.Version(testNamespace, tags)
+	cache.Invalidate(testNamespace)
+	cache.Set(testNamespace, "presence", "stale", tags, version)
+	assert.False(t, cache.Get(testNamespace, "presence", &cached))
+
+	// once the invalidation is over, the result is cached again
+	computed := 0
+	assert.Equal(t, "fresh", remember(t, cache, "presence", tags, "fresh", &computed))
+	assert.True(t, cache.Get(testNamespace, "presence", &cached))
+	assert.Equal(t, "fresh", cached)
+}
+
+func TestInvalidationListener(t *testing.T) {
+	conn := newPostgres(t)
+	cache := service.NewCacheService(newRedisClient(t), time.Minute)
+
+	computed := 0
+	remember(t, cache, "user-1", []string{service.UserTag("user-1")}, "user-1", &computed)
+	remember(t, cache, "user-2", []string{service.UserTag("user-2")}, "user-2", &computed)
+	remember(t, cache, "overview", []string{service.OrganizationTag}, "overview", &computed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	cache.StartInvalidationListener(ctx)
+
+	// the notifications sent before the listener is connected are lost, so they are sent until one is received
+	var cached string
+	assert.Eventually(t, func() bool {
+		_, err := conn.Exec(context.Background(), "SELECT pg_notify($1, $2)", service.InvalidationChannel, service.UserTag("user-1"))
+		assert.NoError(t, err)
+		return !cache.Get(testNamespace, "user-1", &cached)
+	}, 30*time.Second, 200*time.Millisecond)
+
+	// every change also invalidates the results of the whole organization
+	assert.False(t, cache.Get(testNamespace, "overview", &cached))
+	assert.True(t, cache.Get(testNamespace, "user-2", &cached))
+}
@@ -14,6 +14,39 @@ type ErrorResponse struct {
 	Error string `json:"error" example:"invalid credentials"`
 }
 
+// TokenResponse represents the tokens returned to the non-browser clients.
+//
+// swagger:model
+type TokenResponse struct {
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"4bc3df44-491c-4073-9e89-682bb0acfca0.Jx0..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	// lifetime of the access token, in seconds
+	ExpiresIn int `json:"expires_in" example:"900"`
+	// only returned when the login confirmed the enrollment of a second factor
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// MFAChallengeResponse represents the response of a login whose second factor remains to verify.
+//
+// swagger:model
+type MFAChallengeResponse struct {
+	MFARequired bool `json:"mfa_required" example:"true"`
+	// short-lived token to send with the code to the verify route
+	MFAToken string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	// the policy requires a second factor the user has not enrolled yet, to set up with the MFA token first
+	EnrollmentRequired bool `json:"enrollment_required" example:"false"`
+}
+
+// MFALoginResponse represents the response of a login completed by its second factor.
+//
+// swagger:model
+type MFALoginResponse struct {
+	Message string `json:"message" example:"logged in successfully"`
+	// only returned when the login confirmed the enrollment of a second factor
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
 // UserCreatedResponse represents a response for user creation.
 //
 // swagger:model
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"app/internal/app/contract/model"
+	ContractService "app/internal/app/contract/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContractHandler struct {
+	service ContractService.ContractService
+}
+
+func NewContractHandler(service ContractService.ContractService) *ContractHandler {
+	return &ContractHandler{service: service}
+}
+
+// GetByUser Contracts
+//
+// @Summary      Get the contracts of a user
+// @Description  Retrieve the contract history of a user, most recent first. **is_active** tells if the contract covers the current date. 🔒 Requires role: **admin, manager**
+// @Tags         Contracts
+// @Produce      json
+// @Param        user_uuid  path      string  true  "User UUID"
+// @Success      200  {array}   model.ContractRead
+// @Router       /users/contracts/{user_uuid} [get]
+func (handler *ContractHandler) GetByUser(c *gin.Context) {
+	userUUID := c.Param("user_uuid")
+	if userUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_uuid is required"})
+		return
+	}
+
+	contracts, err := handler.service.GetByUserUUID(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, contracts)
+}
+
+// Create Contract
+//
+// @Summary      Create a contract
+// @Description  Create a contract for a user. Dates are formatted as YYYY-MM-DD, a missing **end_date** means the contract is still running. Contracts of a same user can not overlap. When **weekly_rate_uuid** is omitted, the weekly rate of the user applies during the contract. 🔒 Requires role: **admin**
+// @Tags         Contracts
+// @Accept       json
+// @Produce      json
+// @Param        contract  body      model.ContractCreate  true  "Contract Data"
+// @Success      201 		"Contract created successfully"
+// @Router       /users/contracts/create [post]
+func (handler *ContractHandler) Create(c *gin.Context) {
+	var newContract model.ContractCreate
+	if err := c.ShouldBindJSON(&newContract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := handler.service.Create(newContract)
+	if err != nil {
+		c.JSON(contractErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Contract created successfully"})
+}
+
+// Update Contract
+//
+// @Summary      Update a contract
+// @Description  Update an existing contract, omitted fields are left unchanged. An empty **end_date** reopens the contract and an empty **weekly_rate_uuid** unlinks its weekly rate. 🔒 Requires role: **admin**
+// @Tags         Contracts
+// @Accept       json
+// @Produce      json
+// @Param        uuid      path      string                true  "Contract UUID"
+// @Param        contract  body      model.ContractUpdate  true  "Contract Data"
+// @Success      200 		"Contract updated successfully"
+// @Router       /users/contracts/{uuid}/update [put]
+func (handler *ContractHandler) Update(c *gin.Context) {
+	uuid := c.Param("uuid")
+	var updatedContract model.ContractUpdate
+	if err := c.ShouldBindJSON(&updatedContract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := handler.service.Update(uuid, updatedContract)
+	if err != nil {
+		c.JSON(contractErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contract updated successfully"})
+}
+
+// Delete Contract
+//
+// @Summary      Delete a contract
+// @Description  Delete a contract by its UUID. 🔒 Requires role: **admin**
+// @Tags         Contracts
+// @Param        uuid  path      string  true  "Contract UUID"
+// @Success      200 		"Contract deleted successfully"
+// @Router       /users/contracts/{uuid}/delete [delete]
+func (handler *ContractHandler) Delete(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if uuid == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UUID is required"})
+		return
+	}
+
+	err := handler.service.Delete(uuid)
+	if err != nil {
+		c.JSON(contractErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contract deleted successfully"})
+}
+
+func contractErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["CONTRACT_NOT_FOUND"]),
+		strings.HasPrefix(message, Config.ErrorMessages()["WEEKLY_RATE_NOT_FOUND"]):
+		return http.StatusNotFound
+	case strings.HasPrefix(message, Config.ErrorMessages()["CONTRACT_OVERLAP"]):
+		return http.StatusConflict
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_CONTRACT_DATES"]),
+		strings.HasPrefix(message, Config.ErrorMessages()["INVALID_REQUEST"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
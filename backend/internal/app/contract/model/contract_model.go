@@ -0,0 +1,110 @@
+package model
+
+import (
+	"time"
+
+	WeeklyRateModel "app/internal/app/weekly-rate/model"
+)
+
+type ContractBase struct {
+	// contract type is either "permanent", "fixed_term", "intern" or "interim"
+	ContractType string `json:"contract_type"`
+	// dates are formatted as YYYY-MM-DD
+	StartDate  string   `json:"start_date"`
+	EndDate    *string  `json:"end_date"`
+	HourlyCost *float64 `json:"hourly_cost,omitempty"`
+}
+
+// swagger:model ContractRead
+type ContractRead struct {
+	ContractBase
+	UUID           string    `json:"uuid"`
+	UserUUID       string    `json:"user_uuid"`
+	WeeklyRateUUID *string   `json:"weekly_rate_uuid"`
+	WeeklyRateName *string   `json:"weekly_rate_name"`
+	WeeklyRate     *float64  `json:"weekly_rate"`
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// swagger:model ContractCreate
+type ContractCreate struct {
+	UserUUID       string   `json:"user_uuid" binding:"required,uuid"`
+	ContractType   string   `json:"contract_type" binding:"required,oneof=permanent fixed_term intern interim"`
+	StartDate      string   `json:"start_date" binding:"required" example:"2026-01-05"`
+	EndDate        *string  `json:"end_date" example:"2026-06-30"`
+	WeeklyRateUUID *string  `json:"weekly_rate_uuid"`
+	HourlyCost     *float64 `json:"hourly_cost"`
+}
+
+// swagger:model ContractUpdate
+type ContractUpdate struct {
+	ContractType   *string  `json:"contract_type" binding:"omitempty,oneof=permanent fixed_term intern interim"`
+	StartDate      *string  `json:"start_date"`
+	EndDate        *string  `json:"end_date"`
+	WeeklyRateUUID *string  `json:"weekly_rate_uuid"`
+	HourlyCost     *float64 `json:"hourly_cost"`
+}
+
+type ContractEntry struct {
+	UUID         string
+	UserID       int
+	ContractType string
+	StartDate    string
+	EndDate      *string
+	WeeklyRateID *int
+	HourlyCost   *float64
+}
+
+type ContractPeriodRow struct {
+	StartDate     string  `gorm:"column:start_date"`
+	EndDate       *string `gorm:"column:end_date"`
+	HasWeeklyRate bool    `gorm:"column:has_weekly_rate"`
+	WeeklyRateModel.WeeklyRateDistribution
+}
+
+// ContractPeriod is a contract clipped to the dates used by the KPI computations.
+type ContractPeriod struct {
+	StartDate time.Time
+	// nil when the contract has no end date
+	EndDate *time.Time
+	// nil when the contract has no weekly rate, the user's weekly rate applies
+	Distribution *WeeklyRateModel.WeeklyRateDistribution
+}
+
+// ContractSchedule tells which days of a date range are covered by a contract.
+// Users without any contract are not managed and are considered under contract every day.
+type ContractSchedule struct {
+	Managed bool
+	Periods []ContractPeriod
+}
+
+// DistributionOn returns the weekly rate distribution that applies on the given day
+// and false when the day is not covered by any contract.
+func (schedule ContractSchedule) DistributionOn(day time.Time, fallback WeeklyRateModel.WeeklyRateDistribution) (WeeklyRateModel.WeeklyRateDistribution, bool) {
+	if !schedule.Managed {
+		return fallback, true
+	}
+
+	for _, period := range schedule.Periods {
+		if day.Before(period.StartDate) || (period.EndDate != nil && day.After(*period.EndDate)) {
+			continue
+		}
+		if period.Distribution != nil {
+			return *period.Distribution, true
+		}
+		return fallback, true
+	}
+
+	return WeeklyRateModel.WeeklyRateDistribution{}, false
+}
+
+// ExpectedHoursOn returns the expected working hours on the given day, 0 when it is outside every contract.
+func (schedule ContractSchedule) ExpectedHoursOn(day time.Time, fallback WeeklyRateModel.WeeklyRateDistribution) (float64, bool) {
+	distribution, underContract := schedule.DistributionOn(day, fallback)
+	if !underContract {
+		return 0, false
+	}
+	return distribution.HoursFor(day.Weekday()), true
+}
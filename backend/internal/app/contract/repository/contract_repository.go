@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	ContractModel "app/internal/app/contract/model"
+)
+
+// ErrContractOverlap is returned when a write is rejected by the exclusion constraint on the dates of the contracts of a user.
+var ErrContractOverlap = errors.New("contract overlaps an existing contract")
+
+// exclusionViolation is the SQLSTATE of a row rejected by an EXCLUDE constraint.
+const exclusionViolation = "23P01"
+
+type ContractRepository interface {
+	FindByUserID(userID int) ([]ContractModel.ContractRead, error)
+	FindIdByUuid(uuid string) (int, error)
+	FindByID(id int) (ContractModel.ContractEntry, error)
+	Create(contract ContractModel.ContractEntry) error
+	Update(id int, contract ContractModel.ContractEntry) error
+	Delete(id int) error
+	HasOverlap(userID int, startDate string, endDate *string, excludedID int) (bool, error)
+	CountByUserID(userID int) (int, error)
+	FindPeriodsByUserIDAndDateRange(userID int, startDate string, endDate string) ([]ContractModel.ContractPeriodRow, error)
+}
+
+type contractRepository struct {
+	db *gorm.DB
+}
+
+func NewContractRepository(db *gorm.DB) ContractRepository {
+	return &contractRepository{db}
+}
+
+func (repo *contractRepository) FindByUserID(userID int) ([]ContractModel.ContractRead, error) {
+	var contracts []ContractModel.ContractRead
+	err := repo.db.Raw(`
+		SELECT
+			c.uuid,
+			u.uuid AS user_uuid,
+			c.contract_type,
+			TO_CHAR(c.start_date, 'YYYY-MM-DD') AS start_date,
+			TO_CHAR(c.end_date, 'YYYY-MM-DD') AS end_date,
+			c.hourly_cost::float8 AS hourly_cost,
+			wr.uuid AS weekly_rate_uuid,
+			wr.rate_name AS weekly_rate_name,
+			wr.amount::float8 AS weekly_rate,
+			(c.start_date <= CURRENT_DATE AND (c.end_date IS NULL OR c.end_date >= CURRENT_DATE)) AS is_active,
+			c.created_at,
+			c.updated_at
+		FROM contracts c
+		INNER JOIN users u ON u.id = c.user_id
+		LEFT JOIN weekly_rate wr ON wr.id = c.weekly_rate_id
+		WHERE c.user_id = ?
+		ORDER BY c.start_date DESC
+	`, userID).Scan(&contracts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+	return contracts, nil
+}
+
+func (repo *contractRepository) FindIdByUuid(uuid string) (int, error) {
+	var contractID int
+	err := repo.db.Raw("SELECT id FROM contracts WHERE uuid = ?", uuid).Scan(&contractID).Error
+	if err != nil {
+		return 0, err
+	}
+	if contractID == 0 {
+		return 0, fmt.Errorf("contract not found")
+	}
+	return contractID, nil
+}
+
+func (repo *contractRepository) FindByID(id int) (ContractModel.ContractEntry, error) {
+	var contract ContractModel.ContractEntry
+	err := repo.db.Raw(`
+		SELECT
+			uuid,
+			user_id,
+			contract_type,
+			TO_CHAR(start_date, 'YYYY-MM-DD') AS start_date,
+			TO_CHAR(end_date, 'YYYY-MM-DD') AS end_date,
+			weekly_rate_id,
+			hourly_cost::float8 AS hourly_cost
+		FROM contracts
+		WHERE id = ?
+	`, id).Scan(&contract).Error
+	if err != nil {
+		return ContractModel.ContractEntry{}, err
+	}
+	if contract.UUID == "" {
+		return ContractModel.ContractEntry{}, fmt.Errorf("contract with id %d not found", id)
+	}
+	return contract, nil
+}
+
+func (repo *contractRepository) Create(contract ContractModel.ContractEntry) error {
+	return overlapError(repo.db.Exec(`
+		INSERT INTO contracts (uuid, user_id, contract_type, start_date, end_date, weekly_rate_id, hourly_cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, contract.UUID, contract.UserID, contract.ContractType, contract.StartDate, contract.EndDate, contract.WeeklyRateID, contract.HourlyCost).Error)
+}
+
+func (repo *contractRepository) Update(id int, contract ContractModel.ContractEntry) error {
+	return overlapError(repo.db.Exec(`
+		UPDATE contracts
+		SET contract_type = ?,
+			start_date = ?,
+			end_date = ?,
+			weekly_rate_id = ?,
+			hourly_cost = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, contract.ContractType, contract.StartDate, contract.EndDate, contract.WeeklyRateID, contract.HourlyCost, id).Error)
+}
+
+// overlapError maps the violation of the exclusion constraint to ErrContractOverlap.
+func overlapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+		return ErrContractOverlap
+	}
+	return err
+}
+
+func (repo *contractRepository) Delete(id int) error {
+	return repo.db.Exec("DELETE FROM contracts WHERE id = ?", id).Error
+}
+
+// HasOverlap checks if the user already has a contract overlapping the given dates, a NULL end date being open-ended.
+func (repo *contractRepository) HasOverlap(userID int, startDate string, endDate *string, excludedID int) (bool, error) {
+	var overlaps bool
+	err := repo.db.Raw(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM contracts
+			WHERE user_id = ?
+				AND id <> ?
+				AND daterange(start_date, end_date, '[]') && daterange(?::date, ?::date, '[]')
+		)
+	`, userID, excludedID, startDate, endDate).Scan(&overlaps).Error
+	return overlaps, err
+}
+
+func (repo *contractRepository) CountByUserID(userID int) (int, error) {
+	var count int
+	err := repo.db.Raw("SELECT COUNT(*) FROM contracts WHERE user_id = ?", userID).Scan(&count).Error
+	return count, err
+}
+
+// FindPeriodsByUserIDAndDateRange returns the contracts of the user overlapping the date range
+// with the distribution of their own weekly rate, if any.
+func (repo *contractRepository) FindPeriodsByUserIDAndDateRange(userID int, startDate string, endDate string) ([]ContractModel.ContractPeriodRow, error) {
+	var periods []ContractModel.ContractPeriodRow
+	err := repo.db.Raw(`
+		SELECT
+			TO_CHAR(c.start_date, 'YYYY-MM-DD') AS start_date,
+			TO_CHAR(c.end_date, 'YYYY-MM-DD') AS end_date,
+			wr.id IS NOT NULL AS has_weekly_rate,
+			COALESCE(wr.monday_hours, 0) AS monday_hours,
+			COALESCE(wr.tuesday_hours, 0) AS tuesday_hours,
+			COALESCE(wr.wednesday_hours, 0) AS wednesday_hours,
+			COALESCE(wr.thursday_hours, 0) AS thursday_hours,
+			COALESCE(wr.friday_hours, 0) AS friday_hours,
+			COALESCE(wr.saturday_hours, 0) AS saturday_hours,
+			COALESCE(wr.sunday_hours, 0) AS sunday_hours
+		FROM contracts c
+		LEFT JOIN weekly_rate wr ON wr.id = c.weekly_rate_id
+		WHERE c.user_id = ?
+			AND c.start_date <= ?::date
+			AND (c.end_date IS NULL OR c.end_date >= ?::date)
+		ORDER BY c.start_date
+	`, userID, endDate, startDate).Scan(&periods).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract periods: %w", err)
+	}
+	return periods, nil
+}
@@ -0,0 +1,233 @@
+package repository_test
+
+import (
+	"app/internal/app/contract/model"
+	"app/internal/app/contract/repository"
+	"app/internal/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const insertContractUserQuery = "INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)"
+const selectIdUserUuid = "SELECT id FROM users WHERE uuid = ?"
+
+func createContractUser(t *testing.T, db *gorm.DB, uuid string, username string) int {
+	t.Helper()
+
+	err := db.Exec(insertContractUserQuery, uuid, username, username+"@example.com", "hashed_password", "active").Error
+	assert.NoError(t, err)
+
+	var userID int
+	db.Raw(selectIdUserUuid, uuid).Scan(&userID)
+	return userID
+}
+
+func strPtr(value string) *string {
+	return &value
+}
+
+//
+// CREATE & FIND
+//
+
+func TestCreateAndFindContractsByUserID(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewContractRepository(db)
+
+	userID := createContractUser(t, db, "contract-user-1", "contractone")
+	db.Exec(`INSERT INTO weekly_rate (uuid, rate_name, amount, monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours)
+		VALUES (?, ?, ?, 7, 7, 7, 7, 7)`, "wr-contract-1", "Temps pleins", 35)
+	weeklyRateID := 1
+	hourlyCost := 18.5
+
+	err := repo.Create(model.ContractEntry{
+		UUID:         "contract-1",
+		UserID:       userID,
+		ContractType: "fixed_term",
+		StartDate:    "2025-01-06",
+		EndDate:      strPtr("2025-06-30"),
+		WeeklyRateID: &weeklyRateID,
+		HourlyCost:   &hourlyCost,
+	})
+	assert.NoError(t, err)
+
+	err = repo.Create(model.ContractEntry{
+		UUID:         "contract-2",
+		UserID:       userID,
+		ContractType: "permanent",
+		StartDate:    "2025-07-01",
+	})
+	assert.NoError(t, err)
+
+	contracts, err := repo.FindByUserID(userID)
+	assert.NoError(t, err)
+	assert.Len(t, contracts, 2)
+
+	// most recent first
+	assert.Equal(t, "contract-2", contracts[0].UUID)
+	assert.Equal(t, "permanent", contracts[0].ContractType)
+	assert.Nil(t, contracts[0].EndDate)
+	assert.Nil(t, contracts[0].WeeklyRateUUID)
+	assert.True(t, contracts[0].IsActive)
+
+	assert.Equal(t, "contract-1", contracts[1].UUID)
+	assert.Equal(t, "contract-user-1", contracts[1].UserUUID)
+	assert.Equal(t, "2025-01-06", contracts[1].StartDate)
+	assert.Equal(t, "2025-06-30", *contracts[1].EndDate)
+	assert.Equal(t, "wr-contract-1", *contracts[1].WeeklyRateUUID)
+	assert.Equal(t, 35.0, *contracts[1].WeeklyRate)
+	assert.Equal(t, 18.5, *contracts[1].HourlyCost)
+	assert.False(t, contracts[1].IsActive)
+
+	id, err := repo.FindIdByUuid("contract-1")
+	assert.NoError(t, err)
+
+	contract, err := repo.FindByID(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "fixed_term", contract.ContractType)
+	assert.Equal(t, weeklyRateID, *contract.WeeklyRateID)
+}
+
+func TestFindContractIdByUuidNotFound(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewContractRepository(db)
+
+	id, err := repo.FindIdByUuid("not-existing-uuid")
+	assert.Error(t, err)
+	assert.Equal(t, 0, id)
+}
+
+//
+// UPDATE & DELETE
+//
+
+func TestUpdateAndDeleteContract(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewContractRepository(db)
+
+	userID := createContractUser(t, db, "contract-user-2", "contracttwo")
+	err := repo.Create(model.ContractEntry{UUID: "contract-3", UserID: userID, ContractType: "intern", StartDate: "2025-02-03"})
+	assert.NoError(t, err)
+
+	id, err := repo.FindIdByUuid("contract-3")
+	assert.NoError(t, err)
+
+	contract, err := repo.FindByID(id)
+	assert.NoError(t, err)
+	contract.EndDate = strPtr("2025-07-31")
+	contract.ContractType = "fixed_term"
+
+	err = repo.Update(id, contract)
+	assert.NoError(t, err)
+
+	updated, err := repo.FindByID(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "fixed_term", updated.ContractType)
+	assert.Equal(t, "2025-07-31", *updated.EndDate)
+
+	err = repo.Delete(id)
+	assert.NoError(t, err)
+
+	count, err := repo.CountByUserID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+//
+// OVERLAP
+//
+
+func TestContractHasOverlap(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewContractRepository(db)
+
+	userID := createContractUser(t, db, "contract-user-3", "contractthree")
+	err := repo.Create(model.ContractEntry{UUID: "contract-4", UserID: userID, ContractType: "fixed_term", StartDate: "2025-01-01", EndDate: strPtr("2025-03-31")})
+	assert.NoError(t, err)
+
+	overlaps, err := repo.HasOverlap(userID, "2025-03-31", nil, 0)
+	assert.NoError(t, err)
+	assert.True(t, overlaps, "end dates are inclusive")
+
+	overlaps, err = repo.HasOverlap(userID, "2025-04-01", nil, 0)
+	assert.NoError(t, err)
+	assert.False(t, overlaps)
+
+	overlaps, err = repo.HasOverlap(userID, "2024-06-01", strPtr("2024-12-31"), 0)
+	assert.NoError(t, err)
+	assert.False(t, overlaps)
+
+	// the contract itself is excluded when it is being updated
+	id, _ := repo.FindIdByUuid("contract-4")
+	overlaps, err = repo.HasOverlap(userID, "2025-02-01", strPtr("2025-05-31"), id)
+	assert.NoError(t, err)
+	assert.False(t, overlaps)
+}
+
+func TestOverlappingContractsAreRejected(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewContractRepository(db)
+
+	userID := createContractUser(t, db, "contract-user-5", "contractfive")
+	otherUserID := createContractUser(t, db, "contract-user-6", "contractsix")
+	assert.NoError(t, repo.Create(model.ContractEntry{UUID: "contract-8", UserID: userID, ContractType: "fixed_term", StartDate: "2025-01-01", EndDate: strPtr("2025-03-31")}))
+
+	// written without checking HasOverlap first, as a concurrent write would
+	err := repo.Create(model.ContractEntry{UUID: "contract-9", UserID: userID, ContractType: "permanent", StartDate: "2025-03-31"})
+	assert.ErrorIs(t, err, repository.ErrContractOverlap)
+
+	// the contracts of another user do not overlap
+	assert.NoError(t, repo.Create(model.ContractEntry{UUID: "contract-10", UserID: otherUserID, ContractType: "permanent", StartDate: "2025-02-01"}))
+
+	assert.NoError(t, repo.Create(model.ContractEntry{UUID: "contract-11", UserID: userID, ContractType: "permanent", StartDate: "2025-04-01"}))
+
+	id, err := repo.FindIdByUuid("contract-8")
+	assert.NoError(t, err)
+	contract, err := repo.FindByID(id)
+	assert.NoError(t, err)
+	contract.EndDate = strPtr("2025-04-15")
+
+	err = repo.Update(id, contract)
+	assert.ErrorIs(t, err, repository.ErrContractOverlap)
+
+	count, err := repo.CountByUserID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+//
+// PERIODS
+//
+
+func TestFindContractPeriodsByUserIDAndDateRange(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewContractRepository(db)
+
+	userID := createContractUser(t, db, "contract-user-4", "contractfour")
+	db.Exec(`INSERT INTO weekly_rate (uuid, rate_name, amount, monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours)
+		VALUES (?, ?, ?, 5, 5, 5, 5, 0)`, "wr-contract-2", "Temps partiel", 20)
+	weeklyRateID := 1
+
+	assert.NoError(t, repo.Create(model.ContractEntry{UUID: "contract-5", UserID: userID, ContractType: "intern", StartDate: "2024-09-01", EndDate: strPtr("2024-12-31")}))
+	assert.NoError(t, repo.Create(model.ContractEntry{UUID: "contract-6", UserID: userID, ContractType: "fixed_term", StartDate: "2025-01-15", EndDate: strPtr("2025-02-28"), WeeklyRateID: &weeklyRateID}))
+	assert.NoError(t, repo.Create(model.ContractEntry{UUID: "contract-7", UserID: userID, ContractType: "permanent", StartDate: "2025-03-10"}))
+
+	periods, err := repo.FindPeriodsByUserIDAndDateRange(userID, "2025-01-01", "2025-03-31")
+	assert.NoError(t, err)
+	assert.Len(t, periods, 2)
+
+	assert.Equal(t, "2025-01-15", periods[0].StartDate)
+	assert.True(t, periods[0].HasWeeklyRate)
+	assert.Equal(t, 5.0, periods[0].MondayHours)
+	assert.Equal(t, 0.0, periods[0].FridayHours)
+
+	assert.Equal(t, "2025-03-10", periods[1].StartDate)
+	assert.Nil(t, periods[1].EndDate)
+	assert.False(t, periods[1].HasWeeklyRate)
+
+	count, err := repo.CountByUserID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	CacheService "app/internal/app/cache/service"
+	ContractModel "app/internal/app/contract/model"
+	ContractRepository "app/internal/app/contract/repository"
+	WeeklyRateService "app/internal/app/weekly-rate/service"
+	Config "app/internal/config"
+
+	"github.com/google/uuid"
+)
+
+const contractDateLayout = "2006-01-02"
+
+type UserLookup interface {
+	GetIdByUuid(id string) (int, error)
+}
+
+type ContractService interface {
+	GetByUserUUID(userUUID string) ([]ContractModel.ContractRead, error)
+	Create(input ContractModel.ContractCreate) error
+	Update(uuid string, input ContractModel.ContractUpdate) error
+	Delete(uuid string) error
+	GetSchedule(userID int, startDate time.Time, endDate time.Time) (ContractModel.ContractSchedule, error)
+}
+
+type contractService struct {
+	ContractRepo      ContractRepository.ContractRepository
+	UserLookup        UserLookup
+	WeeklyRateService WeeklyRateService.WeeklyRateService
+	Cache             CacheService.CacheService
+}
+
+func NewContractService(repo ContractRepository.ContractRepository, userLookup UserLookup, weeklyRateService WeeklyRateService.WeeklyRateService, cache CacheService.CacheService) ContractService {
+	return &contractService{
+		ContractRepo:      repo,
+		UserLookup:        userLookup,
+		WeeklyRateService: weeklyRateService,
+		Cache:             cache,
+	}
+}
+
+func (service *contractService) GetByUserUUID(userUUID string) ([]ContractModel.ContractRead, error) {
+	userID, err := service.UserLookup.GetIdByUuid(userUUID)
+	if err != nil || userID == 0 {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	contracts, err := service.ContractRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if contracts == nil {
+		contracts = []ContractModel.ContractRead{}
+	}
+	return contracts, nil
+}
+
+func (service *contractService) Create(input ContractModel.ContractCreate) error {
+	userID, err := service.UserLookup.GetIdByUuid(input.UserUUID)
+	if err != nil || userID == 0 {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	entry := ContractModel.ContractEntry{
+		UUID:         uuid.New().String(),
+		UserID:       userID,
+		ContractType: input.ContractType,
+		StartDate:    input.StartDate,
+		EndDate:      input.EndDate,
+		HourlyCost:   input.HourlyCost,
+	}
+
+	if input.WeeklyRateUUID != nil {
+		weeklyRateID, err := service.resolveWeeklyRate(*input.WeeklyRateUUID)
+		if err != nil {
+			return err
+		}
+		entry.WeeklyRateID = weeklyRateID
+	}
+
+	if err := service.validate(entry, 0); err != nil {
+		return err
+	}
+
+	if err := service.ContractRepo.Create(entry); err != nil {
+		// a concurrent write created an overlapping contract since the check
+		if errors.Is(err, ContractRepository.ErrContractOverlap) {
+			return fmt.Errorf("%s", Config.ErrorMessages()["CONTRACT_OVERLAP"])
+		}
+		return fmt.Errorf("failed to create contract: %w", err)
+	}
+	// the contracts decide the expected hours and the days counted in every KPI
+	service.Cache.Invalidate(CacheService.KPINamespace)
+	return nil
+}
+
+func (service *contractService) Update(uuid string, input ContractModel.ContractUpdate) error {
+	contractID, err := service.ContractRepo.FindIdByUuid(uuid)
+	if err != nil {
+		return fmt.Errorf(Config.ErrorMessages()["CONTRACT_NOT_FOUND"]+": %w", err)
+	}
+
+	entry, err := service.ContractRepo.FindByID(contractID)
+	if err != nil {
+		return fmt.Errorf(Config.ErrorMessages()["CONTRACT_NOT_FOUND"]+": %w", err)
+	}
+
+	if input.ContractType != nil {
+		entry.ContractType = *input.ContractType
+	}
+	if input.StartDate != nil {
+		entry.StartDate = *input.StartDate
+	}
+	// an empty end date reopens the contract
+	if input.EndDate != nil {
+		if *input.EndDate == "" {
+			entry.EndDate = nil
+		} else {
+			entry.EndDate = input.EndDate
+		}
+	}
+	if input.HourlyCost != nil {
+		entry.HourlyCost = input.HourlyCost
+	}
+	// an empty weekly rate uuid unlinks the weekly rate
+	if input.WeeklyRateUUID != nil {
+		if *input.WeeklyRateUUID == "" {
+			entry.WeeklyRateID = nil
+		} else {
+			weeklyRateID, err := service.resolveWeeklyRate(*input.WeeklyRateUUID)
+			if err != nil {
+				return err
+			}
+			entry.WeeklyRateID = weeklyRateID
+		}
+	}
+
+	if err := service.validate(entry, contractID); err != nil {
+		return err
+	}
+
+	if err := service.ContractRepo.Update(contractID, entry); err != nil {
+		if errors.Is(err, ContractRepository.ErrContractOverlap) {
+			return fmt.Errorf("%s", Config.ErrorMessages()["CONTRACT_OVERLAP"])
+		}
+		return fmt.Errorf("failed to update contract: %w", err)
+	}
+	service.Cache.Invalidate(CacheService.KPINamespace)
+	return nil
+}
+
+func (service *contractService) Delete(uuid string) error {
+	contractID, err := service.ContractRepo.FindIdByUuid(uuid)
+	if err != nil {
+		return fmt.Errorf(Config.ErrorMessages()["CONTRACT_NOT_FOUND"]+": %w", err)
+	}
+
+	if err := service.ContractRepo.Delete(contractID); err != nil {
+		return fmt.Errorf("failed to delete contract: %w", err)
+	}
+	service.Cache.Invalidate(CacheService.KPINamespace)
+	return nil
+}
+
+// GetSchedule returns the contract periods of the user between the two dates.
+// Users without any contract are not managed by contracts and keep being considered under contract every day.
+func (service *contractService) GetSchedule(userID int, startDate time.Time, endDate time.Time) (ContractModel.ContractSchedule, error) {
+	count, err := service.ContractRepo.CountByUserID(userID)
+	if err != nil {
+		return ContractModel.ContractSchedule{}, fmt.Errorf("failed to count contracts: %w", err)
+	}
+	if count == 0 {
+		return ContractModel.ContractSchedule{Managed: false}, nil
+	}
+
+	rows, err := service.ContractRepo.FindPeriodsByUserIDAndDateRange(userID, startDate.Format(contractDateLayout), endDate.Format(contractDateLayout))
+	if err != nil {
+		return ContractModel.ContractSchedule{}, err
+	}
+
+	schedule := ContractModel.ContractSchedule{Managed: true, Periods: []ContractModel.ContractPeriod{}}
+	for _, row := range rows {
+		start, err := time.Parse(contractDateLayout, row.StartDate)
+		if err != nil {
+			return ContractModel.ContractSchedule{}, fmt.Errorf("invalid contract start date %q: %w", row.StartDate, err)
+		}
+
+		period := ContractModel.ContractPeriod{StartDate: start}
+		if row.EndDate != nil {
+			end, err := time.Parse(contractDateLayout, *row.EndDate)
+			if err != nil {
+				return ContractModel.ContractSchedule{}, fmt.Errorf("invalid contract end date %q: %w", *row.EndDate, err)
+			}
+			period.EndDate = &end
+		}
+		if row.HasWeeklyRate {
+			distribution := row.WeeklyRateDistribution
+			period.Distribution = &distribution
+		}
+
+		schedule.Periods = append(schedule.Periods, period)
+	}
+
+	return schedule, nil
+}
+
+func (service *contractService) resolveWeeklyRate(weeklyRateUUID string) (*int, error) {
+	weeklyRateID, err := service.WeeklyRateService.GetIdByUuid(weeklyRateUUID)
+	if err != nil || weeklyRateID == 0 {
+		return nil, fmt.Errorf(Config.ErrorMessages()["WEEKLY_RATE_NOT_FOUND"]+": %w", err)
+	}
+	return &weeklyRateID, nil
+}
+
+// validate checks the dates of the contract and that it does not overlap another contract of the same user.
+func (service *contractService) validate(entry ContractModel.ContractEntry, excludedID int) error {
+	start, err := time.Parse(contractDateLayout, entry.StartDate)
+	if err != nil {
+		return fmt.Errorf("%s: start_date must be formatted as YYYY-MM-DD", Config.ErrorMessages()["INVALID_CONTRACT_DATES"])
+	}
+
+	if entry.EndDate != nil {
+		end, err := time.Parse(contractDateLayout, *entry.EndDate)
+		if err != nil {
+			return fmt.Errorf("%s: end_date must be formatted as YYYY-MM-DD", Config.ErrorMessages()["INVALID_CONTRACT_DATES"])
+		}
+		if end.Before(start) {
+			return fmt.Errorf(Config.ErrorMessages()["INVALID_CONTRACT_DATES"]+": end_date %s is before start_date %s", *entry.EndDate, entry.StartDate)
+		}
+	}
+
+	if entry.HourlyCost != nil && *entry.HourlyCost < 0 {
+		return fmt.Errorf("%s: hourly_cost can not be negative", Config.ErrorMessages()["INVALID_REQUEST"])
+	}
+
+	overlaps, err := service.ContractRepo.HasOverlap(entry.UserID, entry.StartDate, entry.EndDate, excludedID)
+	if err != nil {
+		return fmt.Errorf("failed to check contract overlap: %w", err)
+	}
+	if overlaps {
+		return fmt.Errorf("%s", Config.ErrorMessages()["CONTRACT_OVERLAP"])
+	}
+
+	return nil
+}
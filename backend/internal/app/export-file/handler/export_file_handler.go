@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	AuthService "app/internal/app/auth/service"
+	"app/internal/app/export-file/model"
+	ExportFileService "app/internal/app/export-file/service"
+	"app/internal/app/kpi/export"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportFileHandler struct {
+	service ExportFileService.ExportFileService
+}
+
+func NewExportFileHandler(service ExportFileService.ExportFileService) *ExportFileHandler {
+	return &ExportFileHandler{service: service}
+}
+
+// DownloadFile handles the HTTP request to download an export file.
+//
+// @Summary Download a KPI export file
+// @Description Downloads a KPI CSV, XLSX or PDF file by its uuid. Only the user who requested the export and the admins can download it, until it expires. 🔒 Requires role: **all**
+// @Tags KPI
+// @Security     BearerAuth
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param file_uuid path string true "Export file UUID"
+// @Success 200 {file} file
+// @Router /kpi/files/{file_uuid} [get]
+func (handler *ExportFileHandler) DownloadFile(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	file, err := handler.service.GetForRequester(c.Param("file_uuid"), authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		c.JSON(exportFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	handler.serveFile(c, file)
+}
+
+// DownloadSignedFile handles the HTTP request to download an export file through a signed link.
+//
+// @Summary Download a KPI export file through a signed link
+// @Description Downloads a KPI export file with the signed link returned by its export job, without authentication, until the file expires.
+// @Tags KPI
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Param file_uuid path string true "Export file UUID"
+// @Param expires query string true "Expiration of the link as a Unix timestamp"
+// @Param signature query string true "Signature of the link"
+// @Success 200 {file} file
+// @Router /kpi/downloads/{file_uuid} [get]
+func (handler *ExportFileHandler) DownloadSignedFile(c *gin.Context) {
+	file, err := handler.service.GetSigned(c.Param("file_uuid"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.JSON(exportFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	handler.serveFile(c, file)
+}
+
+// serveFile streams the file from the storage as an attachment.
+func (handler *ExportFileHandler) serveFile(c *gin.Context, file model.ExportFile) {
+	reader, err := handler.service.Open(file)
+	if err != nil {
+		c.JSON(exportFileErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	contentType, ok := export.ContentType(file.Filename)
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+
+	c.DataFromReader(http.StatusOK, file.SizeBytes, contentType, reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", file.Filename),
+		"Cache-Control":       "private, no-store",
+	})
+}
+
+func exportFileErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["EXPORT_FILE_NOT_FOUND"]):
+		return http.StatusNotFound
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_DOWNLOAD_LINK"]):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
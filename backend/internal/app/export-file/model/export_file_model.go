@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// ExportFile is a file generated by a KPI export, stored under its uuid.
+type ExportFile struct {
+	ID        int    `json:"-" gorm:"column:id"`
+	UUID      string `json:"uuid" gorm:"column:uuid"`
+	OwnerID   int    `json:"-" gorm:"column:owner_id"`
+	OwnerUUID string `json:"owner_uuid" gorm:"column:owner_uuid"`
+	KPIType   string `json:"kpi_type" gorm:"column:kpi_type"`
+	Format    string `json:"format" gorm:"column:format"`
+	Filename  string `json:"filename" gorm:"column:filename"`
+	// key of the file in the storage
+	Path      string    `json:"-" gorm:"column:path"`
+	SizeBytes int64     `json:"size_bytes" gorm:"column:size_bytes"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at"`
+}
+
+type ExportFileConfig struct {
+	// files and their download links expire TTLHours after the export
+	TTLHours int
+	// key of the HMAC signing the download links
+	SigningSecret          string
+	CleanupIntervalMinutes int
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"app/internal/app/export-file/model"
+)
+
+// selectExportFiles reads the files with the uuid of their owner.
+const selectExportFiles = `
+	SELECT
+		f.id, f.uuid, f.owner_id, u.uuid AS owner_uuid, f.kpi_type, f.format, f.filename, f.path, f.size_bytes,
+		f.created_at, f.expires_at
+	FROM export_files f
+	JOIN users u ON u.id = f.owner_id
+`
+
+type ExportFileRepository interface {
+	Create(file model.ExportFile) error
+	FindByUUID(uuid string) (model.ExportFile, error)
+	FindExpired(now time.Time) ([]model.ExportFile, error)
+	DeleteByUUID(uuid string) error
+}
+
+type exportFileRepository struct {
+	db *gorm.DB
+}
+
+func NewExportFileRepository(db *gorm.DB) ExportFileRepository {
+	return &exportFileRepository{db}
+}
+
+func (repo *exportFileRepository) Create(file model.ExportFile) error {
+	err := repo.db.Exec(`
+		INSERT INTO export_files (uuid, owner_id, kpi_type, format, filename, path, size_bytes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, file.UUID, file.OwnerID, file.KPIType, file.Format, file.Filename, file.Path, file.SizeBytes, file.ExpiresAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	return nil
+}
+
+// FindByUUID returns the file, with an ID of 0 when it does not exist.
+func (repo *exportFileRepository) FindByUUID(uuid string) (model.ExportFile, error) {
+	var file model.ExportFile
+
+	err := repo.db.Raw(selectExportFiles+" WHERE f.uuid = ?", uuid).Scan(&file).Error
+	if err != nil {
+		return model.ExportFile{}, fmt.Errorf("failed to fetch export file: %w", err)
+	}
+
+	return file, nil
+}
+
+// FindExpired returns the files which expired at now, oldest first.
+func (repo *exportFileRepository) FindExpired(now time.Time) ([]model.ExportFile, error) {
+	var files []model.ExportFile
+
+	err := repo.db.Raw(selectExportFiles+" WHERE f.expires_at <= ? ORDER BY f.expires_at", now).Scan(&files).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expired export files: %w", err)
+	}
+
+	return files, nil
+}
+
+func (repo *exportFileRepository) DeleteByUUID(uuid string) error {
+	return repo.db.Exec("DELETE FROM export_files WHERE uuid = ?", uuid).Error
+}
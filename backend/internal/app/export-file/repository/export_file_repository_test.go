@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"app/internal/app/export-file/model"
+	"app/internal/app/export-file/repository"
+	"app/internal/test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAndFindExportFile(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewExportFileRepository(db)
+
+	var ownerID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", "123e4567-e89b-12d3-a456-426614174009").Scan(&ownerID)
+
+	expiresAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	err := repo.Create(model.ExportFile{
+		UUID:      "file-uuid-1",
+		OwnerID:   ownerID,
+		KPIType:   "presence_rate",
+		Format:    "pdf",
+		Filename:  "kpi_presence_rate.pdf",
+		Path:      "/app/data/kpi/file-uuid-1.pdf",
+		SizeBytes: 2048,
+		ExpiresAt: expiresAt,
+	})
+	assert.NoError(t, err)
+
+	file, err := repo.FindByUUID("file-uuid-1")
+	assert.NoError(t, err)
+	assert.NotZero(t, file.ID)
+	assert.Equal(t, ownerID, file.OwnerID)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174009", file.OwnerUUID)
+	assert.Equal(t, "kpi_presence_rate.pdf", file.Filename)
+	assert.Equal(t, "/app/data/kpi/file-uuid-1.pdf", file.Path)
+	assert.Equal(t, int64(2048), file.SizeBytes)
+	assert.True(t, expiresAt.Equal(file.ExpiresAt))
+
+	missing, err := repo.FindByUUID("missing")
+	assert.NoError(t, err)
+	assert.Zero(t, missing.ID)
+}
+
+func TestFindExpiredAndDeleteExportFiles(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewExportFileRepository(db)
+
+	var ownerID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", "123e4567-e89b-12d3-a456-426614174009").Scan(&ownerID)
+
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	for uuid, expiresAt := range map[string]time.Time{
+		"file-expired-late":  now.Add(-time.Hour),
+		"file-expired-early": now.Add(-48 * time.Hour),
+		"file-valid":         now.Add(time.Hour),
+	} {
+		err := repo.Create(model.ExportFile{UUID: uuid, OwnerID: ownerID, KPIType: "presence_rate", Format: "csv", Filename: uuid + ".csv", Path: uuid, ExpiresAt: expiresAt})
+		assert.NoError(t, err)
+	}
+
+	expired, err := repo.FindExpired(now)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 2)
+	assert.Equal(t, "file-expired-early", expired[0].UUID)
+	assert.Equal(t, "file-expired-late", expired[1].UUID)
+
+	assert.NoError(t, repo.DeleteByUUID("file-expired-early"))
+
+	expired, err = repo.FindExpired(now)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "file-expired-late", expired[0].UUID)
+}
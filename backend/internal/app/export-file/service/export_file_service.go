@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"app/internal/app/export-file/model"
+	"app/internal/app/export-file/repository"
+	"app/internal/app/kpi/export"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+	"app/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// keyPrefix is the folder of the export files in the storage.
+const keyPrefix = "kpi/"
+
+// ExportFileService stores the exported files under unique ids. A file can be downloaded by its owner and the admins,
+// or by anyone holding its signed link, until it expires and is purged.
+type ExportFileService interface {
+	Store(ownerUUID string, kpiType string, format string, filename string, write func(w io.Writer) error) (model.ExportFile, error)
+	Open(file model.ExportFile) (io.ReadCloser, error)
+	SignedURL(file model.ExportFile) string
+	GetForRequester(fileUUID string, requesterUUID string, isAdmin bool) (model.ExportFile, error)
+	GetSigned(fileUUID string, expires string, signature string) (model.ExportFile, error)
+	PurgeExpired() (int, error)
+	StartCleanup(ctx context.Context)
+}
+
+type exportFileService struct {
+	Repository  repository.ExportFileRepository
+	UserService UserService.UserService
+	Storage     storage.Storage
+	Config      model.ExportFileConfig
+}
+
+func NewExportFileService(repo repository.ExportFileRepository, userService UserService.UserService, fileStorage storage.Storage, config model.ExportFileConfig) ExportFileService {
+	return &exportFileService{
+		Repository:  repo,
+		UserService: userService,
+		Storage:     fileStorage,
+		Config:      config,
+	}
+}
+
+// Store writes the file to a local temporary file first, so that a failed export never leaves a partial file
+// in the storage, then uploads it.
+func (service *exportFileService) Store(ownerUUID string, kpiType string, format string, filename string, write func(w io.Writer) error) (model.ExportFile, error) {
+	ownerID, err := service.UserService.GetIdByUuid(ownerUUID)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+
+	tmp, err := os.CreateTemp("", "export-*."+format)
+	if err != nil {
+		log.Printf("Error creating temporary export file: %v", err)
+		return model.ExportFile{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := write(tmp); err != nil {
+		return model.ExportFile{}, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+
+	fileUUID := uuid.New().String()
+	key := keyPrefix + fileUUID + "." + format
+	contentType, _ := export.ContentType(filename)
+
+	if err := service.Storage.Put(context.Background(), key, tmp, contentType); err != nil {
+		return model.ExportFile{}, err
+	}
+
+	file := model.ExportFile{
+		UUID:      fileUUID,
+		OwnerID:   ownerID,
+		OwnerUUID: ownerUUID,
+		KPIType:   kpiType,
+		Format:    format,
+		Filename:  filename,
+		Path:      key,
+		SizeBytes: size,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Duration(service.Config.TTLHours) * time.Hour),
+	}
+
+	if err := service.Repository.Create(file); err != nil {
+		_ = service.Storage.Delete(context.Background(), key)
+		return model.ExportFile{}, err
+	}
+
+	return file, nil
+}
+
+// Open returns the content of the file, to be closed by the caller.
+func (service *exportFileService) Open(file model.ExportFile) (io.ReadCloser, error) {
+	reader, err := service.Storage.Get(context.Background(), file.Path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("%s: %s", Config.ErrorMessages()["EXPORT_FILE_NOT_FOUND"], file.UUID)
+	}
+	return reader, err
+}
+
+// SignedURL returns the public download link of the file, valid until the file expires.
+func (service *exportFileService) SignedURL(file model.ExportFile) string {
+	expires := strconv.FormatInt(file.ExpiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", service.sign(file.UUID, expires))
+
+	return "/api/kpi/downloads/" + file.UUID + "?" + query.Encode()
+}
+
+func (service *exportFileService) sign(fileUUID string, expires string) string {
+	mac := hmac.New(sha256.New, []byte(service.Config.SigningSecret))
+	mac.Write([]byte(fileUUID + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetForRequester returns the file to its owner or to an admin, the file of another user being reported as not found.
+func (service *exportFileService) GetForRequester(fileUUID string, requesterUUID string, isAdmin bool) (model.ExportFile, error) {
+	file, err := service.get(fileUUID)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+
+	if file.OwnerUUID != requesterUUID && !isAdmin {
+		return model.ExportFile{}, fmt.Errorf("%s: %s", Config.ErrorMessages()["EXPORT_FILE_NOT_FOUND"], fileUUID)
+	}
+
+	return file, nil
+}
+
+// GetSigned returns the file when the signature of the link matches and the link has not expired.
+func (service *exportFileService) GetSigned(fileUUID string, expires string, signature string) (model.ExportFile, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt || !hmac.Equal([]byte(signature), []byte(service.sign(fileUUID, expires))) {
+		return model.ExportFile{}, errors.New(Config.ErrorMessages()["INVALID_DOWNLOAD_LINK"])
+	}
+
+	return service.get(fileUUID)
+}
+
+// get returns the file when it exists and has not expired yet, the expired files waiting to be purged.
+func (service *exportFileService) get(fileUUID string) (model.ExportFile, error) {
+	file, err := service.Repository.FindByUUID(fileUUID)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+
+	if file.ID == 0 || !time.Now().Before(file.ExpiresAt) {
+		return model.ExportFile{}, fmt.Errorf("%s: %s", Config.ErrorMessages()["EXPORT_FILE_NOT_FOUND"], fileUUID)
+	}
+
+	return file, nil
+}
+
+// PurgeExpired removes the expired files from the storage, then their metadata.
+func (service *exportFileService) PurgeExpired() (int, error) {
+	files, err := service.Repository.FindExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, file := range files {
+		if err := service.Storage.Delete(context.Background(), file.Path); err != nil {
+			log.Printf("⚠️ Error removing export file %s: %v", file.Path, err)
+			continue
+		}
+
+		if err := service.Repository.DeleteByUUID(file.UUID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// StartCleanup purges the expired files every CleanupIntervalMinutes until the context is done.
+func (service *exportFileService) StartCleanup(ctx context.Context) {
+	if service.Config.CleanupIntervalMinutes <= 0 {
+		log.Println("⚠️ Export files cleanup disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(service.Config.CleanupIntervalMinutes) * time.Minute)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := service.PurgeExpired()
+				if err != nil {
+					log.Printf("⚠️ Error purging export files: %v", err)
+					continue
+				}
+				if purged > 0 {
+					log.Printf("✅ Purged %d expired export files", purged)
+				}
+			}
+		}
+	}()
+}
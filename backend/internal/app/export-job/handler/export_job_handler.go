@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	AuthService "app/internal/app/auth/service"
+	ExportJobService "app/internal/app/export-job/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportJobHandler struct {
+	service ExportJobService.ExportJobService
+}
+
+func NewExportJobHandler(service ExportJobService.ExportJobService) *ExportJobHandler {
+	return &ExportJobHandler{service: service}
+}
+
+// GetExportJob handles the HTTP request to get the status of an export job.
+//
+// @Summary Get the status of a KPI export job
+// @Description Retrieves the status and progress of an export job: queued, running, completed or failed. Once completed, the job holds the URL to download the file. Only the user who requested the export and the admins can see it. 🔒 Requires role: **manager, admin**
+// @Tags KPI
+// @Security     BearerAuth
+// @Produce json
+// @Param job_id path string true "Export job ID"
+// @Success 200 {object} model.ExportJob
+// @Router /kpi/export/jobs/{job_id} [get]
+func (handler *ExportJobHandler) GetExportJob(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	job, err := handler.service.Get(c.Param("job_id"), authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		c.JSON(exportJobErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func exportJobErrorStatus(err error) int {
+	if strings.HasPrefix(err.Error(), Config.ErrorMessages()["EXPORT_JOB_NOT_FOUND"]) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+
+	// BundleKPIType is the KPI type of the jobs exporting several KPI types at once
+	BundleKPIType = "bundle"
+)
+
+// swagger:model ExportJob
+type ExportJob struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	// number of times a worker started the job, interrupted runs included
+	Attempts     int    `json:"attempts"`
+	KPIType      string `json:"kpi_type"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	UUIDToSearch string `json:"uuid_to_search"`
+	// KPI types and targets of the bundles
+	KPITypes      []string `json:"kpi_types,omitempty"`
+	UserUUIDs     []string `json:"user_uuids,omitempty"`
+	TeamUUIDs     []string `json:"team_uuids,omitempty"`
+	Format        string   `json:"format"`
+	RequestedBy   string   `json:"requested_by"`
+	NotifyByEmail bool     `json:"notify_by_email"`
+	FileUUID      string   `json:"file_uuid,omitempty"`
+	File          string   `json:"file,omitempty"`
+	// signed download link, valid until the file expires
+	URL       string     `json:"url,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// swagger:model ExportJobResponse
+type ExportJobResponse struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
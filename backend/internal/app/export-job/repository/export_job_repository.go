@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/app/export-job/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	queueKey     = "export:queue"
+	instancesKey = "export:instances"
+	// jobTTL is how long the status of a job stays available once it is saved for the last time
+	jobTTL = 7 * 24 * time.Hour
+)
+
+// ErrJobNotFound is returned when the job does not exist or has expired.
+var ErrJobNotFound = errors.New("export job not found")
+
+// claimScript moves the jobs of an instance whose lease expired to the processing list of the claiming instance,
+// oldest first, and forgets the expired instance. Nothing is claimed while the lease is alive.
+var claimScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return {}
+end
+local ids = {}
+while true do
+	local id = redis.call('LMOVE', KEYS[2], KEYS[3], 'RIGHT', 'LEFT')
+	if not id then
+		break
+	end
+	table.insert(ids, id)
+end
+redis.call('SREM', KEYS[4], ARGV[1])
+return ids
+`)
+
+// ExportJobRepository stores the export jobs in Redis. Each instance moves the jobs it dequeues to its own
+// processing list until they are acknowledged, and keeps a lease alive while it runs: the jobs of an instance
+// whose lease expired were interrupted, and are claimed by another one to be put back in the queue.
+type ExportJobRepository interface {
+	Save(job model.ExportJob) error
+	Get(id string) (model.ExportJob, error)
+	Enqueue(id string) error
+	Dequeue(ctx context.Context, instanceID string, timeout time.Duration) (string, error)
+	Ack(instanceID string, id string) error
+	Heartbeat(instanceID string, lease time.Duration) error
+	ClaimExpired(instanceID string) ([]string, error)
+	Requeue(instanceID string, ids ...string) error
+}
+
+type exportJobRepository struct {
+	client *redis.Client
+}
+
+func NewExportJobRepository(client *redis.Client) ExportJobRepository {
+	return &exportJobRepository{client}
+}
+
+func jobKey(id string) string {
+	return "export:job:" + id
+}
+
+func processingKey(instanceID string) string {
+	return "export:processing:" + instanceID
+}
+
+func leaseKey(instanceID string) string {
+	return "export:lease:" + instanceID
+}
+
+func (repo *exportJobRepository) Save(job model.ExportJob) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.client.Set(context.Background(), jobKey(job.ID), payload, jobTTL).Err(); err != nil {
+		return fmt.Errorf("failed to save export job: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *exportJobRepository) Get(id string) (model.ExportJob, error) {
+	payload, err := repo.client.Get(context.Background(), jobKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return model.ExportJob{}, ErrJobNotFound
+	}
+	if err != nil {
+		return model.ExportJob{}, fmt.Errorf("failed to fetch export job: %w", err)
+	}
+
+	var job model.ExportJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return model.ExportJob{}, err
+	}
+
+	return job, nil
+}
+
+func (repo *exportJobRepository) Enqueue(id string) error {
+	if err := repo.client.LPush(context.Background(), queueKey, id).Err(); err != nil {
+		return fmt.Errorf("failed to enqueue export job: %w", err)
+	}
+
+	return nil
+}
+
+// Dequeue waits up to timeout for the oldest job of the queue and moves it to the processing list of the instance.
+// An empty id is returned when no job was queued in time.
+func (repo *exportJobRepository) Dequeue(ctx context.Context, instanceID string, timeout time.Duration) (string, error) {
+	id, err := repo.client.BLMove(ctx, queueKey, processingKey(instanceID), "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to dequeue export job: %w", err)
+	}
+
+	return id, nil
+}
+
+// Ack removes the job from the processing list of the instance once it is done, whatever its outcome.
+func (repo *exportJobRepository) Ack(instanceID string, id string) error {
+	return repo.client.LRem(context.Background(), processingKey(instanceID), 1, id).Err()
+}
+
+// Heartbeat renews the lease of the instance for the given duration, and registers the instance so that
+// its jobs are claimed once the lease expires.
+func (repo *exportJobRepository) Heartbeat(instanceID string, lease time.Duration) error {
+	ctx := context.Background()
+
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, leaseKey(instanceID), 1, lease)
+		pipe.SAdd(ctx, instancesKey, instanceID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to renew the export worker lease: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimExpired moves the jobs of every instance whose lease expired to the processing list of the given instance,
+// and returns their ids, oldest first. They stay there until they are requeued or acknowledged.
+func (repo *exportJobRepository) ClaimExpired(instanceID string) ([]string, error) {
+	ctx := context.Background()
+
+	instances, err := repo.client.SMembers(ctx, instancesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the export workers: %w", err)
+	}
+
+	var claimed []string
+	for _, instance := range instances {
+		if instance == instanceID {
+			continue
+		}
+
+		keys := []string{leaseKey(instance), processingKey(instance), processingKey(instanceID), instancesKey}
+		ids, err := claimScript.Run(ctx, repo.client, keys, instance).StringSlice()
+		if err != nil {
+			return claimed, fmt.Errorf("failed to claim export jobs: %w", err)
+		}
+		claimed = append(claimed, ids...)
+	}
+
+	return claimed, nil
+}
+
+// Requeue moves the jobs from the processing list of the instance back to the head of the queue,
+// the first given being dequeued first.
+func (repo *exportJobRepository) Requeue(instanceID string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := len(ids) - 1; i >= 0; i-- {
+			pipe.LRem(ctx, processingKey(instanceID), 1, ids[i])
+			pipe.RPush(ctx, queueKey, ids[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to requeue export jobs: %w", err)
+	}
+
+	return nil
+}
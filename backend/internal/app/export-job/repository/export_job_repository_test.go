@@ -0,0 +1,200 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"app/internal/app/export-job/model"
+	"app/internal/app/export-job/repository"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func newRedisClient(t *testing.T) *redis.Client {
+	ctx := context.Background()
+	const port = "6379/tcp"
+
+	redisC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{port},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("❌ Failed to start Redis: %v", err)
+	}
+	t.Cleanup(func() { _ = redisC.Terminate(ctx) })
+
+	host, _ := redisC.Host(ctx)
+	mappedPort, _ := redisC.MappedPort(ctx, port)
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, mappedPort.Port())})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+func TestSaveAndGet(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewExportJobRepository(client)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	job := model.ExportJob{
+		ID:          "job-1",
+		Status:      model.StatusQueued,
+		KPIType:     model.BundleKPIType,
+		StartDate:   "2026-01-05",
+		EndDate:     "2026-01-11",
+		KPITypes:    []string{"presence_rate"},
+		UserUUIDs:   []string{"user-1"},
+		Format:      "zip",
+		RequestedBy: "manager-1",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	assert.NoError(t, repo.Save(job))
+
+	saved, err := repo.Get("job-1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusQueued, saved.Status)
+	assert.Equal(t, []string{"presence_rate"}, saved.KPITypes)
+	assert.Equal(t, []string{"user-1"}, saved.UserUUIDs)
+	assert.True(t, now.Equal(saved.CreatedAt))
+
+	// the status stays available for a week after the last save
+	ttl, err := client.TTL(context.Background(), "export:job:job-1").Result()
+	assert.NoError(t, err)
+	assert.InDelta(t, (7 * 24 * time.Hour).Seconds(), ttl.Seconds(), 60)
+
+	job.Status = model.StatusRunning
+	job.Progress = 50
+	assert.NoError(t, repo.Save(job))
+	saved, err = repo.Get("job-1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusRunning, saved.Status)
+	assert.Equal(t, 50, saved.Progress)
+
+	_, err = repo.Get("missing")
+	assert.True(t, errors.Is(err, repository.ErrJobNotFound))
+}
+
+func TestQueue(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewExportJobRepository(client)
+	ctx := context.Background()
+
+	// no job was queued in time
+	id, err := repo.Dequeue(ctx, "instance-1", time.Second)
+	assert.NoError(t, err)
+	assert.Empty(t, id)
+
+	for _, id := range []string{"job-1", "job-2", "job-3"} {
+		assert.NoError(t, repo.Enqueue(id))
+	}
+
+	// the jobs are dequeued in the order they were queued, and kept in the processing list of the instance until acknowledged
+	id, err = repo.Dequeue(ctx, "instance-1", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "job-1", id)
+
+	id, err = repo.Dequeue(ctx, "instance-2", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "job-2", id)
+
+	processing, err := client.LRange(ctx, "export:processing:instance-1", 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-1"}, processing)
+
+	assert.NoError(t, repo.Ack("instance-1", "job-1"))
+	processing, err = client.LRange(ctx, "export:processing:instance-1", 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Empty(t, processing)
+
+	// acknowledging twice, or from another instance, does nothing
+	assert.NoError(t, repo.Ack("instance-1", "job-1"))
+	assert.NoError(t, repo.Ack("instance-1", "job-2"))
+	processing, err = client.LRange(ctx, "export:processing:instance-2", 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-2"}, processing)
+
+	id, err = repo.Dequeue(ctx, "instance-1", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "job-3", id)
+
+	id, err = repo.Dequeue(ctx, "instance-1", time.Second)
+	assert.NoError(t, err)
+	assert.Empty(t, id)
+
+	// a worker waiting on the queue stops with its context
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = repo.Dequeue(cancelled, "instance-1", 5*time.Second)
+	assert.Error(t, err)
+}
+
+func TestClaimExpired(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewExportJobRepository(client)
+	ctx := context.Background()
+
+	for _, id := range []string{"job-1", "job-2", "job-3", "job-4", "job-5"} {
+		assert.NoError(t, repo.Enqueue(id))
+	}
+
+	// instance-1 stops while running job-2 and job-3, instance-2 keeps running job-4
+	assert.NoError(t, repo.Heartbeat("instance-1", time.Second))
+	assert.NoError(t, repo.Heartbeat("instance-2", time.Minute))
+	assert.NoError(t, repo.Heartbeat("instance-3", time.Minute))
+	for _, instance := range []string{"instance-1", "instance-1", "instance-1", "instance-2"} {
+		_, err := repo.Dequeue(ctx, instance, time.Second)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, repo.Ack("instance-1", "job-1"))
+
+	// nothing is claimed while the leases are alive
+	claimed, err := repo.ClaimExpired("instance-3")
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	// only the jobs of the expired instance are claimed, oldest first, and the instance is forgotten
+	claimed, err = repo.ClaimExpired("instance-3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-2", "job-3"}, claimed)
+
+	instances, err := client.SMembers(ctx, "export:instances").Result()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"instance-2", "instance-3"}, instances)
+
+	processing, err := client.LRange(ctx, "export:processing:instance-3", 0, -1).Result()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"job-2", "job-3"}, processing)
+
+	// the claimed jobs are claimed once
+	claimed, err = repo.ClaimExpired("instance-2")
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// the requeued jobs are generated first, in the order they were queued
+	assert.NoError(t, repo.Requeue("instance-3", "job-2", "job-3"))
+	processing, err = client.LRange(ctx, "export:processing:instance-3", 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Empty(t, processing)
+
+	for _, expected := range []string{"job-2", "job-3", "job-5"} {
+		id, err := repo.Dequeue(ctx, "instance-3", time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, id)
+	}
+
+	assert.NoError(t, repo.Requeue("instance-3"))
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	ExportFileService "app/internal/app/export-file/service"
+	"app/internal/app/export-job/model"
+	"app/internal/app/export-job/repository"
+	"app/internal/app/kpi/export"
+	KPIModel "app/internal/app/kpi/model"
+	KPIService "app/internal/app/kpi/service"
+	MailModel "app/internal/app/mailer/model"
+	MailerService "app/internal/app/mailer/service"
+	MailTemplate "app/internal/app/mailer/template"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// dequeueTimeout bounds the wait of a worker on the queue, so that it notices when its context is done
+const dequeueTimeout = 5 * time.Second
+
+// storedProgress is the progress of a job once its file is generated, until it is stored
+const storedProgress = 95
+
+// leaseTTL is how long the jobs of an instance stay its own without a heartbeat, before another instance requeues them
+const leaseTTL = 30 * time.Second
+
+// maxAttempts is how many times a job is started before an interruption marks it as failed,
+// so that a job crashing the process is not retried forever
+const maxAttempts = 3
+
+type ExportJobService interface {
+	Create(request KPIModel.KPIExportRequest, requestedBy string) (model.ExportJob, error)
+	CreateBundle(request KPIModel.KPIBundleExportRequest, requestedBy string) (model.ExportJob, error)
+	Get(jobID string, requesterUUID string, isAdmin bool) (model.ExportJob, error)
+	StartWorkers(ctx context.Context)
+}
+
+type exportJobService struct {
+	Repository        repository.ExportJobRepository
+	KPIService        KPIService.KPIService
+	ExportFileService ExportFileService.ExportFileService
+	UserService       UserService.UserService
+	MailerService     MailerService.MailerService
+	Workers           int
+	// InstanceID identifies the processing list and the lease of this instance
+	InstanceID string
+}
+
+func NewExportJobService(repo repository.ExportJobRepository, kpiService KPIService.KPIService, exportFileService ExportFileService.ExportFileService, userService UserService.UserService, mailerService MailerService.MailerService, workers int) ExportJobService {
+	return &exportJobService{
+		Repository:        repo,
+		KPIService:        kpiService,
+		ExportFileService: exportFileService,
+		UserService:       userService,
+		MailerService:     mailerService,
+		Workers:           workers,
+		InstanceID:        uuid.New().String(),
+	}
+}
+
+// Create stores the export as a queued job, generated later by one of the workers.
+func (service *exportJobService) Create(request KPIModel.KPIExportRequest, requestedBy string) (model.ExportJob, error) {
+	format := request.Format
+	if format == "" {
+		format = export.CSVFormat
+	}
+
+	now := time.Now()
+	job := model.ExportJob{
+		ID:            uuid.New().String(),
+		Status:        model.StatusQueued,
+		KPIType:       request.KPIType,
+		StartDate:     request.StartDate,
+		EndDate:       request.EndDate,
+		UUIDToSearch:  request.UUIDToSearch,
+		Format:        format,
+		RequestedBy:   requestedBy,
+		NotifyByEmail: request.NotifyByEmail,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := service.enqueue(job); err != nil {
+		return model.ExportJob{}, err
+	}
+
+	return job, nil
+}
+
+// CreateBundle stores the bundled export as a queued job, generated later by one of the workers.
+func (service *exportJobService) CreateBundle(request KPIModel.KPIBundleExportRequest, requestedBy string) (model.ExportJob, error) {
+	format := request.Format
+	if format == "" {
+		format = export.ZIPFormat
+	}
+
+	now := time.Now()
+	job := model.ExportJob{
+		ID:            uuid.New().String(),
+		Status:        model.StatusQueued,
+		KPIType:       model.BundleKPIType,
+		StartDate:     request.StartDate,
+		EndDate:       request.EndDate,
+		KPITypes:      request.KPITypes,
+		UserUUIDs:     request.UserUUIDs,
+		TeamUUIDs:     request.TeamUUIDs,
+		Format:        format,
+		RequestedBy:   requestedBy,
+		NotifyByEmail: request.NotifyByEmail,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := service.enqueue(job); err != nil {
+		return model.ExportJob{}, err
+	}
+
+	return job, nil
+}
+
+func (service *exportJobService) enqueue(job model.ExportJob) error {
+	if err := service.Repository.Save(job); err != nil {
+		return err
+	}
+
+	return service.Repository.Enqueue(job.ID)
+}
+
+// Get returns the job to the user who requested it, or to an admin.
+func (service *exportJobService) Get(jobID string, requesterUUID string, isAdmin bool) (model.ExportJob, error) {
+	job, err := service.Repository.Get(jobID)
+	if errors.Is(err, repository.ErrJobNotFound) || (err == nil && job.RequestedBy != requesterUUID && !isAdmin) {
+		return model.ExportJob{}, fmt.Errorf("%s: %s", Config.ErrorMessages()["EXPORT_JOB_NOT_FOUND"], jobID)
+	}
+	if err != nil {
+		return model.ExportJob{}, err
+	}
+
+	return job, nil
+}
+
+// StartWorkers starts the workers generating the queued jobs one at a time until the context is done.
+// While they run, the lease of the instance is renewed and the jobs of the instances whose lease expired,
+// stopped or crashed, are put back in the queue.
+func (service *exportJobService) StartWorkers(ctx context.Context) {
+	if service.Workers <= 0 {
+		log.Println("⚠️ Export workers disabled")
+		return
+	}
+
+	if err := service.Repository.Heartbeat(service.InstanceID, leaseTTL); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	service.recoverInterrupted()
+
+	go service.keepAlive(ctx)
+	for range service.Workers {
+		go service.work(ctx)
+	}
+}
+
+// keepAlive renews the lease well before it expires, and recovers the interrupted jobs of the other instances.
+func (service *exportJobService) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := service.Repository.Heartbeat(service.InstanceID, leaseTTL); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+			service.recoverInterrupted()
+		}
+	}
+}
+
+// recoverInterrupted claims the jobs of the instances whose lease expired and puts them back in the queue,
+// unless they were already started maxAttempts times: those are marked as failed.
+func (service *exportJobService) recoverInterrupted() {
+	claimed, err := service.Repository.ClaimExpired(service.InstanceID)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	var requeued []string
+	for _, jobID := range claimed {
+		job, err := service.Repository.Get(jobID)
+		if err != nil {
+			log.Printf("⚠️ Skipping interrupted export job %s: %v", jobID, err)
+			service.ack(jobID)
+			continue
+		}
+
+		if job.Attempts >= maxAttempts {
+			service.fail(&job, fmt.Errorf("export interrupted %d times", job.Attempts))
+			service.ack(jobID)
+			continue
+		}
+
+		service.update(&job, model.StatusQueued, 0)
+		requeued = append(requeued, jobID)
+	}
+
+	if err := service.Repository.Requeue(service.InstanceID, requeued...); err != nil {
+		log.Printf("⚠️ %v", err)
+		return
+	}
+	if len(requeued) > 0 {
+		log.Printf("✅ Requeued %d interrupted export jobs", len(requeued))
+	}
+}
+
+func (service *exportJobService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobID, err := service.Repository.Dequeue(ctx, service.InstanceID, dequeueTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("⚠️ %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(dequeueTimeout):
+			}
+			continue
+		}
+		if jobID == "" {
+			continue
+		}
+
+		service.process(jobID)
+		service.ack(jobID)
+	}
+}
+
+func (service *exportJobService) ack(jobID string) {
+	if err := service.Repository.Ack(service.InstanceID, jobID); err != nil {
+		log.Printf("⚠️ Error acknowledging export job %s: %v", jobID, err)
+	}
+}
+
+// process generates the file of the job and notifies the requester when asked to.
+// A job failing, even by panicking, is marked as failed rather than retried. Only the runs interrupted
+// with their instance are retried, each start counting as an attempt.
+func (service *exportJobService) process(jobID string) {
+	job, err := service.Repository.Get(jobID)
+	if err != nil {
+		log.Printf("⚠️ Skipping export job %s: %v", jobID, err)
+		return
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			service.fail(&job, fmt.Errorf("export panicked: %v", recovered))
+		}
+	}()
+
+	job.Attempts++
+	service.update(&job, model.StatusRunning, 0)
+
+	// the file is stored once generated, so the generation only goes up to storedProgress
+	progress := func(done int, total int) {
+		if current := done * storedProgress / total; current > job.Progress {
+			service.update(&job, model.StatusRunning, current)
+		}
+	}
+
+	filename := fmt.Sprintf("kpi_%s_%s.%s", job.KPIType, job.CreatedAt.Format("20060102_150405"), job.Format)
+	file, err := service.ExportFileService.Store(job.RequestedBy, job.KPIType, job.Format, filename, func(w io.Writer) error {
+		if job.KPIType == model.BundleKPIType {
+			return service.KPIService.ExportKPIBundle(KPIModel.KPIBundleExportRequest{
+				KPITypes:  job.KPITypes,
+				UserUUIDs: job.UserUUIDs,
+				TeamUUIDs: job.TeamUUIDs,
+				StartDate: job.StartDate,
+				EndDate:   job.EndDate,
+				Format:    job.Format,
+			}, job.RequestedBy, w, progress)
+		}
+		return service.KPIService.ExportKPIData(job.StartDate, job.EndDate, job.KPIType, job.UUIDToSearch, job.Format, w, progress)
+	})
+	if err != nil {
+		service.fail(&job, err)
+		return
+	}
+
+	job.FileUUID = file.UUID
+	job.File = file.Filename
+	job.URL = service.ExportFileService.SignedURL(file)
+	job.ExpiresAt = &file.ExpiresAt
+	service.update(&job, model.StatusCompleted, 100)
+	service.notify(job)
+}
+
+func (service *exportJobService) fail(job *model.ExportJob, err error) {
+	log.Printf("⚠️ Export job %s failed: %v", job.ID, err)
+	job.Error = err.Error()
+	service.update(job, model.StatusFailed, job.Progress)
+	service.notify(*job)
+}
+
+func (service *exportJobService) update(job *model.ExportJob, status string, progress int) {
+	job.Status = status
+	job.Progress = progress
+	job.UpdatedAt = time.Now()
+	if err := service.Repository.Save(*job); err != nil {
+		log.Printf("⚠️ Error saving export job %s: %v", job.ID, err)
+	}
+}
+
+// notify mails the download link of the file, or the failure, to the user who requested the export.
+func (service *exportJobService) notify(job model.ExportJob) {
+	if !job.NotifyByEmail {
+		return
+	}
+
+	user, err := service.UserService.GetUserByUUID(job.RequestedBy)
+	if err != nil {
+		log.Printf("⚠️ Error fetching the requester of export job %s: %v", job.ID, err)
+		return
+	}
+
+	period := fmt.Sprintf("%s to %s", job.StartDate, job.EndDate)
+
+	var mail MailModel.Mail
+	if job.Status == model.StatusCompleted {
+		mail = MailModel.Mail{
+			To:      user.Email,
+			Subject: "Your KPI export is ready",
+			Body: MailTemplate.BaseMailTemplate(
+				"Your KPI export is ready",
+				fmt.Sprintf("Hello %s,<br><br>Your <span style=\"font-weight:bold;\">%s</span> export from %s is ready to download.<br><br>Best regards,<br>The TimeManager Team", user.FirstName, job.KPIType, period),
+				"Download",
+				Config.LoadConfig().FrontendURL+job.URL,
+			),
+		}
+	} else {
+		mail = MailModel.Mail{
+			To:      user.Email,
+			Subject: "Your KPI export failed",
+			Body: MailTemplate.BaseMailTemplate(
+				"Your KPI export failed",
+				fmt.Sprintf("Hello %s,<br><br>Your <span style=\"font-weight:bold;\">%s</span> export from %s could not be generated, please try again or contact your administrator.<br><br>Best regards,<br>The TimeManager Team", user.FirstName, job.KPIType, period),
+				"",
+				"",
+			),
+		}
+	}
+
+	if err := service.MailerService.Send(mail); err != nil {
+		log.Printf("⚠️ Error mailing export job %s: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,161 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Manifest describes the parameters a bundle was generated with and where each of its reports lies.
+type Manifest struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	RequestedBy string          `json:"requested_by"`
+	StartDate   string          `json:"start_date"`
+	EndDate     string          `json:"end_date"`
+	Format      string          `json:"format"`
+	KPITypes    []string        `json:"kpi_types"`
+	UserUUIDs   []string        `json:"user_uuids"`
+	TeamUUIDs   []string        `json:"team_uuids"`
+	Entries     []ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry is a report of a bundle: File is the name of its CSV file in ZIP archives,
+// or of its worksheet in workbooks.
+type ManifestEntry struct {
+	KPIType    string `json:"kpi_type"`
+	TargetType string `json:"target_type"`
+	TargetUUID string `json:"target_uuid"`
+	TargetName string `json:"target_name"`
+	File       string `json:"file"`
+}
+
+// BundleEntry is a report of a bundle along with its manifest entry, File being filled when the bundle is written.
+type BundleEntry struct {
+	ManifestEntry
+	Report Report
+}
+
+// Bundle gathers several reports in a single export.
+type Bundle struct {
+	Manifest Manifest
+	Entries  []BundleEntry
+}
+
+// WriteBundle writes the bundle to w, either as a ZIP archive of CSV files or as a single workbook.
+// Both start with the manifest.
+func WriteBundle(w io.Writer, bundle Bundle, format string) error {
+	switch format {
+	case ZIPFormat:
+		return writeZIPBundle(w, bundle)
+	case XLSXFormat:
+		return writeWorkbookBundle(w, bundle)
+	default:
+		return fmt.Errorf("unknown bundle format: %s", format)
+	}
+}
+
+// writeZIPBundle writes manifest.json, then one CSV file per report named after its KPI type and target.
+func writeZIPBundle(w io.Writer, bundle Bundle) error {
+	manifest := bundle.Manifest
+	manifest.Entries = make([]ManifestEntry, len(bundle.Entries))
+	used := map[string]bool{"manifest.json": true}
+	for i, entry := range bundle.Entries {
+		manifest.Entries[i] = entry.ManifestEntry
+		manifest.Entries[i].File = fileName(entry.KPIType+"_"+entry.TargetName, CSVFormat, used)
+	}
+
+	archive := zip.NewWriter(w)
+
+	file, err := archive.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return err
+	}
+
+	for i, entry := range bundle.Entries {
+		file, err := archive.Create(manifest.Entries[i].File)
+		if err != nil {
+			return err
+		}
+		if err := WriteCSV(file, entry.Report); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// writeWorkbookBundle writes a Manifest worksheet, then one worksheet per report laid out as a single table.
+func writeWorkbookBundle(w io.Writer, bundle Bundle) error {
+	manifest := bundle.Manifest
+	used := map[string]bool{"manifest": true}
+
+	sheets := make([]Sheet, 0, len(bundle.Entries)+1)
+	files := Sheet{Headers: []string{"kpi_type", "target_type", "target_uuid", "target_name", "sheet"}}
+	for _, entry := range bundle.Entries {
+		entry.File = sheetName(entry.KPIType+" "+entry.TargetName, used)
+		files.Rows = append(files.Rows, []Cell{Text(entry.KPIType), Text(entry.TargetType), Text(entry.TargetUUID), Text(entry.TargetName), Text(entry.File)})
+		sheets = append(sheets, entry.Report.Table(entry.File))
+	}
+
+	// the parameters come first, followed by an empty row and the list of the sheets
+	parameters := Sheet{
+		Name:    "Manifest",
+		Headers: []string{"parameter", "value"},
+		Rows: [][]Cell{
+			{Text("generated_at"), Text(manifest.GeneratedAt.Format(time.RFC3339))},
+			{Text("requested_by"), Text(manifest.RequestedBy)},
+			{Text("start_date"), Date(manifest.StartDate)},
+			{Text("end_date"), Date(manifest.EndDate)},
+			{Text("format"), Text(manifest.Format)},
+			{Text("kpi_types"), Text(strings.Join(manifest.KPITypes, ", "))},
+			{Text("user_uuids"), Text(strings.Join(manifest.UserUUIDs, ", "))},
+			{Text("team_uuids"), Text(strings.Join(manifest.TeamUUIDs, ", "))},
+			{},
+			textCells(files.Headers),
+		},
+	}
+	parameters.Rows = append(parameters.Rows, files.Rows...)
+
+	return WriteWorkbook(w, append([]Sheet{parameters}, sheets...))
+}
+
+func textCells(values []string) []Cell {
+	cells := make([]Cell, len(values))
+	for i, value := range values {
+		cells[i] = Text(value)
+	}
+	return cells
+}
+
+// fileName makes a unique file name for the archive, keeping letters, digits, dashes and underscores only.
+func fileName(name string, extension string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			return r
+		case unicode.IsSpace(r):
+			return '_'
+		}
+		return -1
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "report"
+	}
+
+	candidate := truncate(name, 100) + "." + extension
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s_%d.%s", truncate(name, 100), i, extension)
+	}
+	used[strings.ToLower(candidate)] = true
+
+	return candidate
+}
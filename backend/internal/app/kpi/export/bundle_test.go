@@ -0,0 +1,156 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"app/internal/app/kpi/export"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBundle() export.Bundle {
+	presence := func(name string) export.Report {
+		return export.Report{
+			Summary: export.Sheet{
+				Name:    name,
+				Headers: []string{"firstname", "presence rate"},
+				Rows:    [][]export.Cell{{export.Text(name), export.Number(87.5)}},
+			},
+		}
+	}
+
+	team := export.Report{
+		Summary: export.Sheet{
+			Name:    "Core",
+			Headers: []string{"team name", "total minutes"},
+			Rows:    [][]export.Cell{{export.Text("Core"), export.Duration(960)}},
+		},
+		MemberHeaders: []string{"firstname", "member total minutes"},
+	}
+	team.AddMember("Jane", export.Text("Jane"), export.Duration(480))
+	team.AddMember("John", export.Text("John"), export.Duration(480))
+
+	return export.Bundle{
+		Manifest: export.Manifest{
+			GeneratedAt: time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC),
+			RequestedBy: "manager-1",
+			StartDate:   "2026-01-05",
+			EndDate:     "2026-01-11",
+			KPITypes:    []string{"presence_rate", "work_session_team_weekly_total"},
+			UserUUIDs:   []string{"user-1", "user-2", "user-3"},
+			TeamUUIDs:   []string{"team-1"},
+		},
+		Entries: []export.BundleEntry{
+			// two users share a name, and a name holds characters out of the file names
+			{ManifestEntry: export.ManifestEntry{KPIType: "presence_rate", TargetType: "user", TargetUUID: "user-1", TargetName: "Jane Doe"}, Report: presence("Jane Doe")},
+			{ManifestEntry: export.ManifestEntry{KPIType: "presence_rate", TargetType: "user", TargetUUID: "user-2", TargetName: "Jane Doe"}, Report: presence("Jane Doe")},
+			{ManifestEntry: export.ManifestEntry{KPIType: "presence_rate", TargetType: "user", TargetUUID: "user-3", TargetName: "../J.R. Doe"}, Report: presence("../J.R. Doe")},
+			{ManifestEntry: export.ManifestEntry{KPIType: "work_session_team_weekly_total", TargetType: "team", TargetUUID: "team-1", TargetName: "Core"}, Report: team},
+		},
+	}
+}
+
+func TestWriteZIPBundle(t *testing.T) {
+	bundle := testBundle()
+	bundle.Manifest.Format = export.ZIPFormat
+
+	var out bytes.Buffer
+	assert.NoError(t, export.WriteBundle(&out, bundle, export.ZIPFormat))
+
+	files := unzip(t, out.Bytes())
+	if !assert.Contains(t, files, "manifest.json") {
+		return
+	}
+
+	var manifest export.Manifest
+	assert.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.True(t, bundle.Manifest.GeneratedAt.Equal(manifest.GeneratedAt))
+	assert.Equal(t, "manager-1", manifest.RequestedBy)
+	assert.Equal(t, "2026-01-05", manifest.StartDate)
+	assert.Equal(t, "2026-01-11", manifest.EndDate)
+	assert.Equal(t, export.ZIPFormat, manifest.Format)
+	assert.Equal(t, bundle.Manifest.KPITypes, manifest.KPITypes)
+	assert.Equal(t, bundle.Manifest.UserUUIDs, manifest.UserUUIDs)
+	assert.Equal(t, bundle.Manifest.TeamUUIDs, manifest.TeamUUIDs)
+
+	expected := []string{
+		"presence_rate_Jane_Doe.csv",
+		"presence_rate_Jane_Doe_2.csv",
+		"presence_rate_JR_Doe.csv",
+		"work_session_team_weekly_total_Core.csv",
+	}
+	if !assert.Len(t, manifest.Entries, len(expected)) {
+		return
+	}
+	for i, entry := range manifest.Entries {
+		assert.Equal(t, expected[i], entry.File)
+		assert.Equal(t, bundle.Entries[i].TargetUUID, entry.TargetUUID)
+		assert.Equal(t, bundle.Entries[i].KPIType, entry.KPIType)
+		assert.Contains(t, files, entry.File)
+	}
+	// the manifest and one file per report, nothing else
+	assert.Len(t, files, len(expected)+1)
+
+	// the team reports are flattened, the member rows leaving the summary columns empty
+	reader := csv.NewReader(bytes.NewReader(files["work_session_team_weekly_total_Core.csv"]))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"team name", "total minutes", "firstname", "member total minutes"},
+		{"Core", "960"},
+		{"", "", "Jane", "480"},
+		{"", "", "John", "480"},
+	}, records)
+
+	records, err = csv.NewReader(bytes.NewReader(files["presence_rate_Jane_Doe_2.csv"])).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"firstname", "presence rate"}, {"Jane Doe", "87.50"}}, records)
+}
+
+func TestWriteWorkbookBundle(t *testing.T) {
+	bundle := testBundle()
+	bundle.Manifest.Format = export.XLSXFormat
+
+	var out bytes.Buffer
+	assert.NoError(t, export.WriteBundle(&out, bundle, export.XLSXFormat))
+
+	files := unzip(t, out.Bytes())
+	sheets := []string{
+		"Manifest",
+		"presence_rate Jane Doe",
+		"presence_rate Jane Doe (2)",
+		"presence_rate ..-J.R. Doe",
+		"work_session_team_weekly_total",
+	}
+	assert.Equal(t, sheets, sheetNames(t, files))
+
+	// the manifest lists the parameters, then the sheet of each report
+	manifest := parseWorksheet(t, files["xl/worksheets/sheet1.xml"])
+	if !assert.Len(t, manifest.Rows, 1+8+1+1+len(bundle.Entries)) {
+		return
+	}
+	assert.Equal(t, "requested_by", manifest.Rows[2].Cells[0].Text)
+	assert.Equal(t, "manager-1", manifest.Rows[2].Cells[1].Text)
+	// the dates of the range are typed
+	assert.Equal(t, "5", manifest.Rows[3].Cells[1].Style)
+	assert.Equal(t, 46027.0, cellNumber(t, manifest.Rows[3].Cells[1]))
+	assert.Equal(t, "sheet", manifest.Rows[10].Cells[4].Text)
+	for i, entry := range bundle.Entries {
+		row := manifest.Rows[11+i].Cells
+		assert.Equal(t, entry.TargetUUID, row[2].Text)
+		assert.Equal(t, sheets[1+i], row[4].Text)
+	}
+
+	team := parseWorksheet(t, files["xl/worksheets/sheet5.xml"])
+	assert.Len(t, team.Rows, 4)
+}
+
+func TestWriteBundleRejectsUnknownFormats(t *testing.T) {
+	var out bytes.Buffer
+	assert.Error(t, export.WriteBundle(&out, testBundle(), export.PDFFormat))
+}
@@ -2,30 +2,20 @@ package export
 
 import (
 	"encoding/csv"
-	"log"
-	"os"
+	"io"
 )
 
-func ExportCSV(headers []string, rows [][]string, filePath string) error {
-	f, err := os.Create(filePath)
-	if err != nil {
-		log.Printf("Error creating CSV file at %s: %v", filePath, err)
-		return err
-	}
-	defer f.Close()
-
-	w := csv.NewWriter(f)
-	defer w.Flush()
+// WriteCSV writes the report flattened in a single table to w.
+func WriteCSV(w io.Writer, report Report) error {
+	headers, rows := report.Flatten()
 
-	if err := w.Write(headers); err != nil {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
 		return err
 	}
-
-	for _, row := range rows {
-		if err := w.Write(row); err != nil {
-			return err
-		}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
 	}
 
-	return nil
+	return writer.Error()
 }
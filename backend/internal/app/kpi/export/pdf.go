@@ -0,0 +1,459 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Layout of the PDF reports in points, on A4 pages.
+const (
+	pdfPageWidth    = 595.28
+	pdfPageHeight   = 841.89
+	pdfMargin       = 40.0
+	pdfFontSize     = 8.0
+	pdfLineHeight   = 10.0
+	pdfCellPadding  = 3.0
+	pdfFooterHeight = 20.0
+)
+
+const (
+	regularFont = "F1"
+	boldFont    = "F2"
+)
+
+// Widths of the printable ASCII characters in the Helvetica fonts, in thousandths of the font size.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// Characters of Windows-1252 outside of Latin-1, the encoding of the standard fonts.
+var windows1252 = map[rune]byte{
+	'€': 0x80, '…': 0x85, 'Š': 0x8A, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99, 'š': 0x9A, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+type pdfDocument struct {
+	width  float64
+	height float64
+	pages  []*bytes.Buffer
+	page   *bytes.Buffer
+	y      float64
+}
+
+// pdfColumn is a column of a table with its width and alignment.
+type pdfColumn struct {
+	width float64
+	right bool
+}
+
+// WritePDF writes the report to w: a table for the summary, a table with a row per member for team reports,
+// then the signature fields. Pages are turned to landscape when a table does not fit in portrait.
+func WritePDF(w io.Writer, report Report) error {
+	sections := []Sheet{report.Summary}
+	if len(report.Members) > 0 {
+		members := Sheet{Name: "Members", Headers: report.MemberHeaders}
+		for _, member := range report.Members {
+			members.Rows = append(members.Rows, member.Rows...)
+		}
+		sections = append(sections, members)
+	}
+
+	title := report.Title
+	if title == "" {
+		title = report.Summary.Name
+	}
+
+	landscape := false
+	for _, section := range sections {
+		if sum(naturalWidths(section)) > pdfPageWidth-2*pdfMargin {
+			landscape = true
+		}
+	}
+
+	doc := &pdfDocument{width: pdfPageWidth, height: pdfPageHeight}
+	if landscape {
+		doc.width, doc.height = pdfPageHeight, pdfPageWidth
+	}
+	doc.newPage()
+
+	doc.text(boldFont, 16, pdfMargin, doc.y-16, title)
+	doc.y -= 32
+
+	for _, section := range sections {
+		doc.table(section)
+	}
+
+	if len(report.Signatures) > 0 {
+		doc.signatures(report.Signatures)
+	}
+
+	doc.footers(title)
+
+	return doc.write(w, title)
+}
+
+func (doc *pdfDocument) newPage() {
+	doc.page = &bytes.Buffer{}
+	doc.pages = append(doc.pages, doc.page)
+	doc.y = doc.height - pdfMargin
+}
+
+// ensure starts a new page when less than height is left above the footer, reporting whether it did.
+func (doc *pdfDocument) ensure(height float64) bool {
+	if doc.y-height < pdfMargin+pdfFooterHeight {
+		doc.newPage()
+		return true
+	}
+	return false
+}
+
+func (doc *pdfDocument) text(font string, size float64, x float64, y float64, value string) {
+	fmt.Fprintf(doc.page, "BT /%s %s Tf %s %s Td %s Tj ET\n", font, number(size), number(x), number(y), pdfString(value))
+}
+
+func (doc *pdfDocument) fill(x float64, y float64, width float64, height float64, gray float64) {
+	fmt.Fprintf(doc.page, "%s g %s %s %s %s re f 0 g\n", number(gray), number(x), number(y), number(width), number(height))
+}
+
+func (doc *pdfDocument) line(x1 float64, y1 float64, x2 float64, y2 float64, gray float64, width float64) {
+	fmt.Fprintf(doc.page, "%s G %s w %s %s m %s %s l S\n", number(gray), number(width), number(x1), number(y1), number(x2), number(y2))
+}
+
+// table draws the sheet with its header row repeated on each page, texts being cut to the width of their column.
+func (doc *pdfDocument) table(sheet Sheet) {
+	columns := doc.columns(sheet)
+	titles := headerTitles(sheet)
+
+	headerLines := make([][]string, len(titles))
+	lineCount := 1
+	for i, title := range titles {
+		headerLines[i] = wrap(title, columns[i].width-2*pdfCellPadding)
+		lineCount = max(lineCount, len(headerLines[i]))
+	}
+	headerHeight := float64(lineCount)*pdfLineHeight + 2*pdfCellPadding
+	rowHeight := pdfLineHeight + 2*pdfCellPadding
+
+	header := func() {
+		doc.fill(pdfMargin, doc.y-headerHeight, tableWidth(columns), headerHeight, 0.85)
+		x := pdfMargin
+		for i, lines := range headerLines {
+			for l, line := range lines {
+				doc.text(boldFont, pdfFontSize, x+pdfCellPadding, doc.y-pdfCellPadding-float64(l+1)*pdfLineHeight+2, line)
+			}
+			x += columns[i].width
+		}
+		doc.y -= headerHeight
+	}
+
+	if sheet.Name != "" {
+		doc.ensure(20 + headerHeight + rowHeight)
+		doc.text(boldFont, 11, pdfMargin, doc.y-11, sheet.Name)
+		doc.y -= 18
+	} else {
+		doc.ensure(headerHeight + rowHeight)
+	}
+	header()
+
+	for _, row := range sheet.Rows {
+		if doc.ensure(rowHeight) {
+			header()
+		}
+
+		x := pdfMargin
+		for i, cell := range row {
+			if i >= len(columns) {
+				break
+			}
+			width := columns[i].width - 2*pdfCellPadding
+			value := truncateWidth(displayText(cell), regularFont, width)
+			textX := x + pdfCellPadding
+			if columns[i].right {
+				textX = x + columns[i].width - pdfCellPadding - textWidth(value, regularFont, pdfFontSize)
+			}
+			doc.text(regularFont, pdfFontSize, textX, doc.y-pdfCellPadding-pdfLineHeight+2, value)
+			x += columns[i].width
+		}
+		doc.y -= rowHeight
+		doc.line(pdfMargin, doc.y, pdfMargin+tableWidth(columns), doc.y, 0.8, 0.5)
+	}
+
+	doc.y -= 20
+}
+
+// columns sizes the columns after their content, shrunk proportionally when the table is wider than the page.
+func (doc *pdfDocument) columns(sheet Sheet) []pdfColumn {
+	widths := naturalWidths(sheet)
+	available := doc.width - 2*pdfMargin
+	scale := 1.0
+	if total := sum(widths); total > available {
+		scale = available / total
+	}
+
+	columns := make([]pdfColumn, len(widths))
+	for i, width := range widths {
+		columns[i].width = width * scale
+		columns[i].right = true
+		for _, row := range sheet.Rows {
+			if i < len(row) && (row[i].Type == TextCell || row[i].Type == BoolCell) && row[i].Text != "" {
+				columns[i].right = false
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// naturalWidths returns the width of the longest text of each column, headers being allowed on two lines
+// without cutting their words.
+func naturalWidths(sheet Sheet) []float64 {
+	widths := make([]float64, len(sheet.Headers))
+	for i, title := range headerTitles(sheet) {
+		widths[i] = math.Max(textWidth(title, boldFont, pdfFontSize)/2, 30)
+		for _, word := range strings.Fields(title) {
+			widths[i] = math.Max(widths[i], textWidth(word, boldFont, pdfFontSize))
+		}
+	}
+	for _, row := range sheet.Rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = math.Max(widths[i], textWidth(displayText(cell), regularFont, pdfFontSize))
+			}
+		}
+	}
+	for i := range widths {
+		widths[i] += 2 * pdfCellPadding
+	}
+	return widths
+}
+
+// signatures draws a field to sign and date for each label.
+func (doc *pdfDocument) signatures(labels []string) {
+	doc.ensure(80)
+	doc.y -= 10
+
+	width := (doc.width - 2*pdfMargin) / float64(len(labels))
+	for i, label := range labels {
+		x := pdfMargin + float64(i)*width
+		doc.text(boldFont, 9, x, doc.y-9, label)
+		doc.text(regularFont, pdfFontSize, x, doc.y-26, "Date:")
+		doc.line(x+25, doc.y-27, x+width-20, doc.y-27, 0, 0.5)
+		doc.line(x, doc.y-60, x+width-20, doc.y-60, 0, 0.5)
+	}
+	doc.y -= 70
+}
+
+// footers numbers the pages once they are all laid out.
+func (doc *pdfDocument) footers(title string) {
+	for i, page := range doc.pages {
+		doc.page = page
+		doc.text(regularFont, 7, pdfMargin, pdfMargin/2, truncateWidth(title, regularFont, doc.width/2))
+		pageNumber := fmt.Sprintf("Page %d / %d", i+1, len(doc.pages))
+		doc.text(regularFont, 7, doc.width-pdfMargin-textWidth(pageNumber, regularFont, 7), pdfMargin/2, pageNumber)
+	}
+}
+
+// write serializes the document with the standard Helvetica fonts, which do not need to be embedded.
+func (doc *pdfDocument) write(w io.Writer, title string) error {
+	var objects []string
+	pageCount := len(doc.pages)
+	firstPage := 6
+
+	kids := make([]string, pageCount)
+	for i := range doc.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title %s /Producer (Time Manager) >>", pdfString(title)),
+	)
+
+	for i, page := range doc.pages {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				number(doc.width), number(doc.height), firstPage+2*i+1),
+			fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// displayText is the printed text of a cell, durations being shown as hours and minutes.
+func displayText(cell Cell) string {
+	switch cell.Type {
+	case DurationCell:
+		minutes := int(math.Round(cell.Number))
+		sign := ""
+		if minutes < 0 {
+			sign, minutes = "-", -minutes
+		}
+		return fmt.Sprintf("%s%d:%02d", sign, minutes/60, minutes%60)
+	case DateCell:
+		if cell.Time.Hour() != 0 || cell.Time.Minute() != 0 || cell.Time.Second() != 0 {
+			return cell.Time.Format("2006-01-02 15:04")
+		}
+		return cell.Time.Format("2006-01-02")
+	case BoolCell:
+		if cell.Number != 0 {
+			return "yes"
+		}
+		return "no"
+	default:
+		return cell.Text
+	}
+}
+
+// encode converts the text to Windows-1252, characters out of it being replaced by a question mark.
+func encode(value string) []byte {
+	encoded := make([]byte, 0, len(value))
+	for _, r := range value {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			encoded = append(encoded, byte(r))
+		case windows1252[r] != 0:
+			encoded = append(encoded, windows1252[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+func pdfString(value string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range encode(value) {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n', '\r', '\t':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func textWidth(value string, font string, size float64) float64 {
+	widths := helveticaWidths
+	if font == boldFont {
+		widths = helveticaBoldWidths
+	}
+
+	total := 0
+	for _, c := range encode(value) {
+		if c >= 32 && c < 127 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// truncateWidth cuts the text with an ellipsis so that it fits in width.
+func truncateWidth(value string, font string, width float64) string {
+	if textWidth(value, font, pdfFontSize) <= width {
+		return value
+	}
+	runes := []rune(value)
+	for len(runes) > 0 && textWidth(string(runes)+"...", font, pdfFontSize) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// wrap splits the header on words to fit in width, on two lines at most.
+func wrap(value string, width float64) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(value) {
+		candidate := strings.TrimSpace(current + " " + word)
+		if current != "" && textWidth(candidate, boldFont, pdfFontSize) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current = candidate
+	}
+	lines = append(lines, current)
+
+	if len(lines) > 2 {
+		lines = []string{lines[0], strings.Join(lines[1:], " ")}
+	}
+	for i, line := range lines {
+		lines[i] = truncateWidth(line, boldFont, width)
+	}
+	return lines
+}
+
+func tableWidth(columns []pdfColumn) float64 {
+	total := 0.0
+	for _, column := range columns {
+		total += column.width
+	}
+	return total
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+func number(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
@@ -0,0 +1,160 @@
+package export_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"app/internal/app/kpi/export"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	startXrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	pageCountPattern = regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`)
+	mediaBoxPattern  = regexp.MustCompile(`/MediaBox \[0 0 ([\d.]+) ([\d.]+)\]`)
+	streamPattern    = regexp.MustCompile(`(?s)<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+)
+
+type pdfFile struct {
+	content  []byte
+	objects  int
+	pages    int
+	streams  []string
+	mediaBox []string
+}
+
+// parsePDF checks that every object lies at the offset of the cross-reference table,
+// then returns the page count and the decompressed content of the pages.
+func parsePDF(t *testing.T, content []byte) pdfFile {
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4\n")))
+
+	match := startXrefPattern.FindSubmatch(content)
+	if match == nil {
+		t.Fatalf("❌ Missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !assert.True(t, bytes.HasPrefix(content[xref:], []byte("xref\n0 "))) {
+		t.FailNow()
+	}
+
+	lines := strings.Split(string(content[xref:]), "\n")
+	var first, size int
+	_, err := fmt.Sscanf(lines[1], "%d %d", &first, &size)
+	assert.NoError(t, err)
+	assert.Zero(t, first)
+	assert.Equal(t, "0000000000 65535 f ", lines[2])
+	for object := 1; object < size; object++ {
+		entry := lines[2+object]
+		assert.Len(t, entry, 19)
+		offset, err := strconv.Atoi(entry[:10])
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(content[offset:], fmt.Appendf(nil, "%d 0 obj\n", object)), "object %d", object)
+	}
+	assert.Contains(t, string(content[xref:]), fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R", size))
+
+	file := pdfFile{content: content, objects: size - 1}
+
+	pages := pageCountPattern.FindSubmatch(content)
+	if assert.NotNil(t, pages) {
+		file.pages, _ = strconv.Atoi(string(pages[1]))
+	}
+	assert.Equal(t, file.pages, bytes.Count(content, []byte("/Type /Page /Parent 2 0 R")))
+
+	if mediaBox := mediaBoxPattern.FindSubmatch(content); assert.NotNil(t, mediaBox) {
+		file.mediaBox = []string{string(mediaBox[1]), string(mediaBox[2])}
+	}
+
+	for _, loc := range streamPattern.FindAllSubmatchIndex(content, -1) {
+		length, _ := strconv.Atoi(string(content[loc[2]:loc[3]]))
+		data := content[loc[1] : loc[1]+length]
+		assert.True(t, bytes.HasPrefix(content[loc[1]+length:], []byte("\nendstream")))
+
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		page, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		file.streams = append(file.streams, string(page))
+	}
+	assert.Len(t, file.streams, file.pages)
+
+	return file
+}
+
+func TestWritePDF(t *testing.T) {
+	report := export.Report{
+		Title:      "Team summary, 2026-01-05 to 2026-01-11",
+		Signatures: []string{"Manager", "Employee"},
+		Summary: export.Sheet{
+			Name:    "Core (team)",
+			Headers: []string{"team name", "total minutes", "start date"},
+			Rows:    [][]export.Cell{{export.Text("Core (team)"), export.Duration(125), export.Date("2026-01-05")}},
+		},
+		MemberHeaders: []string{"firstname", "lastname", "member total minutes", "active"},
+	}
+	// enough members to fill several pages
+	for i := range 150 {
+		report.AddMember(fmt.Sprintf("Member %d", i), export.Text(fmt.Sprintf("Member %d", i)), export.Text("Doe"), export.Duration(480), export.Bool(i%2 == 0))
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, export.WritePDF(&out, report))
+
+	file := parsePDF(t, out.Bytes())
+	assert.Greater(t, file.pages, 1)
+	// catalog, pages, two fonts and info, then a page and its content per page
+	assert.Equal(t, 5+2*file.pages, file.objects)
+	assert.Equal(t, []string{"595.28", "841.89"}, file.mediaBox)
+	assert.Contains(t, string(file.content), `/Title (Team summary, 2026-01-05 to 2026-01-11)`)
+
+	all := strings.Join(file.streams, "")
+	// parentheses are escaped, durations shown as hours and minutes, booleans as yes or no
+	assert.Contains(t, all, `(Core \(team\)) Tj`)
+	assert.Contains(t, all, "(2:05) Tj")
+	assert.Contains(t, all, "(8:00) Tj")
+	assert.Contains(t, all, "(yes) Tj")
+	assert.Contains(t, all, "(no) Tj")
+	assert.Contains(t, all, "(2026-01-05) Tj")
+	assert.Contains(t, all, "(Member 149) Tj")
+
+	// the header of the members is repeated on each page, and every page is numbered
+	for i, stream := range file.streams {
+		assert.Contains(t, stream, fmt.Sprintf("(Page %d / %d) Tj", i+1, file.pages))
+		if i > 0 {
+			assert.Contains(t, stream, "(firstname) Tj")
+		}
+	}
+
+	// the signature fields close the report
+	last := file.streams[len(file.streams)-1]
+	assert.Contains(t, last, "(Manager) Tj")
+	assert.Contains(t, last, "(Employee) Tj")
+}
+
+func TestWritePDFTurnsWideTablesToLandscape(t *testing.T) {
+	headers := make([]string, 12)
+	row := make([]export.Cell, 12)
+	for i := range headers {
+		headers[i] = fmt.Sprintf("a rather long column header %d", i)
+		row[i] = export.Text(fmt.Sprintf("a rather long value %d", i))
+	}
+
+	report := export.Report{Summary: export.Sheet{Name: "Wide – report €", Headers: headers, Rows: [][]export.Cell{row}}}
+
+	var out bytes.Buffer
+	assert.NoError(t, export.WritePDF(&out, report))
+
+	file := parsePDF(t, out.Bytes())
+	assert.Equal(t, 1, file.pages)
+	assert.Equal(t, []string{"841.89", "595.28"}, file.mediaBox)
+	// the title falls back to the name of the summary, encoded in Windows-1252
+	assert.Contains(t, string(file.content), "/Title (Wide \x96 report \x80)")
+}
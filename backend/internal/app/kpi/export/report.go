@@ -0,0 +1,227 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type CellType int
+
+const (
+	TextCell CellType = iota
+	NumberCell
+	IntegerCell
+	DurationCell
+	DateCell
+	ClockCell
+	BoolCell
+)
+
+// Cell is a typed value of a report; Text keeps the representation written to CSV files.
+type Cell struct {
+	Type   CellType
+	Text   string
+	Number float64
+	Time   time.Time
+}
+
+// Sheet is a table with a header row, written as one worksheet in XLSX files.
+type Sheet struct {
+	Name    string
+	Headers []string
+	Rows    [][]Cell
+}
+
+// Report is a KPI export: a summary sheet and, for team exports, one sheet per member.
+// Title and Signatures are only printed in PDF files.
+type Report struct {
+	Title         string
+	Signatures    []string
+	Summary       Sheet
+	MemberHeaders []string
+	Members       []Sheet
+}
+
+// Progress is told how many steps of an export are done out of its total, a nil Progress ignoring it.
+type Progress func(done int, total int)
+
+// Step reports that done steps out of total are done.
+func (progress Progress) Step(done int, total int) {
+	if progress != nil {
+		progress(done, total)
+	}
+}
+
+const (
+	CSVFormat  = "csv"
+	XLSXFormat = "xlsx"
+	PDFFormat  = "pdf"
+	// ZIPFormat is only available for bundles
+	ZIPFormat = "zip"
+)
+
+// contentTypes maps the extension of the exported files to the type they are served with.
+var contentTypes = map[string]string{
+	"." + CSVFormat:  "text/csv",
+	"." + XLSXFormat: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"." + PDFFormat:  "application/pdf",
+	"." + ZIPFormat:  "application/zip",
+}
+
+// ContentType returns the type of an exported file from its name, false when it is not an export.
+func ContentType(filename string) (string, bool) {
+	contentType, ok := contentTypes[strings.ToLower(filepath.Ext(filename))]
+	return contentType, ok
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func Text(value string) Cell {
+	return Cell{Type: TextCell, Text: value}
+}
+
+// Number is a decimal value, written with two decimals to CSV files.
+func Number(value float64) Cell {
+	return Cell{Type: NumberCell, Text: fmt.Sprintf("%.2f", value), Number: value}
+}
+
+// Float is a decimal value written as is to CSV files.
+func Float(value float64) Cell {
+	return Cell{Type: NumberCell, Text: fmt.Sprint(value), Number: value}
+}
+
+func Integer(value int) Cell {
+	return Cell{Type: IntegerCell, Text: fmt.Sprint(value), Number: float64(value)}
+}
+
+// Duration is a number of minutes, shown as hours and minutes in XLSX files.
+func Duration(minutes int) Cell {
+	return Cell{Type: DurationCell, Text: fmt.Sprint(minutes), Number: float64(minutes)}
+}
+
+// AverageDuration is a fractional number of minutes, written with two decimals to CSV files.
+func AverageDuration(minutes float64) Cell {
+	return Cell{Type: DurationCell, Text: fmt.Sprintf("%.2f", minutes), Number: minutes}
+}
+
+// Date parses an ISO 8601 date or timestamp, falling back to a text cell when it is not one.
+func Date(value string) Cell {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return Cell{Type: DateCell, Text: value, Time: parsed}
+		}
+	}
+	return Text(value)
+}
+
+// Clock is a time of day formatted as HH:MM, an empty value giving an empty cell.
+func Clock(value string) Cell {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return Text(value)
+	}
+	return Cell{Type: ClockCell, Text: value, Time: parsed}
+}
+
+func Bool(value bool) Cell {
+	number := 0.0
+	if value {
+		number = 1
+	}
+	return Cell{Type: BoolCell, Text: fmt.Sprint(value), Number: number}
+}
+
+// AddMember adds the sheet of a team member, with the member headers of the report.
+func (report *Report) AddMember(name string, row ...Cell) {
+	report.Members = append(report.Members, Sheet{Name: name, Headers: report.MemberHeaders, Rows: [][]Cell{row}})
+}
+
+// WriteReport writes the report to w in the given format.
+func WriteReport(w io.Writer, report Report, format string) error {
+	switch format {
+	case CSVFormat:
+		return WriteCSV(w, report)
+	case XLSXFormat:
+		return WriteXLSX(w, report)
+	case PDFFormat:
+		return WritePDF(w, report)
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// Table lays the report out as a single sheet: the member columns follow the summary ones
+// and each member row leaves the summary columns empty.
+func (report Report) Table(name string) Sheet {
+	table := Sheet{Name: name, Headers: append([]string{}, report.Summary.Headers...)}
+	table.Rows = append(table.Rows, report.Summary.Rows...)
+
+	if len(report.MemberHeaders) == 0 {
+		return table
+	}
+
+	table.Headers = append(table.Headers, report.MemberHeaders...)
+	padding := make([]Cell, len(report.Summary.Headers))
+	for i := range padding {
+		padding[i] = Text("")
+	}
+	for _, member := range report.Members {
+		for _, row := range member.Rows {
+			table.Rows = append(table.Rows, append(append([]Cell{}, padding...), row...))
+		}
+	}
+
+	return table
+}
+
+// Flatten returns the texts of the single table of the report, as written to CSV files.
+func (report Report) Flatten() ([]string, [][]string) {
+	table := report.Table("")
+	rows := make([][]string, len(table.Rows))
+	for i, row := range table.Rows {
+		rows[i] = texts(row)
+	}
+	return table.Headers, rows
+}
+
+func texts(row []Cell) []string {
+	values := make([]string, len(row))
+	for i, cell := range row {
+		values[i] = cell.Text
+	}
+	return values
+}
+
+// sheetName makes a valid and unique worksheet name: at most 31 characters, none of []:*?/\.
+func sheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Sheet"
+	}
+
+	// a name cut before a space does not end with it
+	candidate := strings.TrimSpace(truncate(name, 31))
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = strings.TrimSpace(truncate(name, 31-len(suffix))) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+
+	return candidate
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) > length {
+		return string(runes[:length])
+	}
+	return value
+}
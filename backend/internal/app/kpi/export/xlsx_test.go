@@ -0,0 +1,206 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"testing"
+
+	"app/internal/app/kpi/export"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type worksheet struct {
+	Pane struct {
+		YSplit      string `xml:"ySplit,attr"`
+		TopLeftCell string `xml:"topLeftCell,attr"`
+		State       string `xml:"state,attr"`
+	} `xml:"sheetViews>sheetView>pane"`
+	Rows []struct {
+		Cells []worksheetCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type worksheetCell struct {
+	Ref   string `xml:"r,attr"`
+	Style string `xml:"s,attr"`
+	Type  string `xml:"t,attr"`
+	Value string `xml:"v"`
+	Text  string `xml:"is>t"`
+}
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// unzip returns the content of every file of the archive by name.
+func unzip(t *testing.T, archive []byte) map[string][]byte {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("❌ Invalid archive: %v", err)
+	}
+
+	files := map[string][]byte{}
+	for _, file := range reader.File {
+		content, err := file.Open()
+		if err != nil {
+			t.Fatalf("❌ Failed to open %s: %v", file.Name, err)
+		}
+		files[file.Name], err = io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			t.Fatalf("❌ Failed to read %s: %v", file.Name, err)
+		}
+	}
+
+	return files
+}
+
+func sheetNames(t *testing.T, files map[string][]byte) []string {
+	var book workbook
+	assert.NoError(t, xml.Unmarshal(files["xl/workbook.xml"], &book))
+
+	names := make([]string, len(book.Sheets))
+	for i, sheet := range book.Sheets {
+		names[i] = sheet.Name
+	}
+	return names
+}
+
+func parseWorksheet(t *testing.T, content []byte) worksheet {
+	var sheet worksheet
+	assert.NoError(t, xml.Unmarshal(content, &sheet))
+	return sheet
+}
+
+func cellNumber(t *testing.T, cell worksheetCell) float64 {
+	value, err := strconv.ParseFloat(cell.Value, 64)
+	assert.NoError(t, err, cell.Ref)
+	return value
+}
+
+func TestWriteXLSX(t *testing.T) {
+	report := export.Report{
+		Summary: export.Sheet{
+			Name:    "Jane Doe",
+			Headers: []string{"user uuid", "start date", "clock in date", "total minutes", "presence rate", "total shifts", "clock in", "active"},
+			Rows: [][]export.Cell{
+				{
+					export.Text("user-1"),
+					export.Date("2026-01-05"),
+					export.Date("2026-01-05T08:30:00"),
+					export.Duration(90),
+					export.Number(87.5),
+					export.Integer(3),
+					export.Clock("08:30"),
+					export.Bool(true),
+				},
+			},
+		},
+		MemberHeaders: []string{"member user uuid"},
+	}
+	// the names of the worksheets are cleaned, shortened and kept unique whatever their case
+	report.AddMember("Jane/Doe", export.Text("user-2"))
+	report.AddMember("jane-doe", export.Text("user-3"))
+	report.AddMember("A name far too long for a worksheet", export.Text("user-4"))
+
+	var out bytes.Buffer
+	assert.NoError(t, export.WriteXLSX(&out, report))
+
+	files := unzip(t, out.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(t, files, name)
+	}
+
+	assert.Equal(t, []string{"Jane Doe", "Jane-Doe", "jane-doe (2)", "A name far too long for a works"}, sheetNames(t, files))
+	for _, name := range []string{"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/worksheets/sheet3.xml", "xl/worksheets/sheet4.xml"} {
+		assert.Contains(t, files, name)
+	}
+
+	summary := parseWorksheet(t, files["xl/worksheets/sheet1.xml"])
+
+	// the header row stays visible
+	assert.Equal(t, "1", summary.Pane.YSplit)
+	assert.Equal(t, "A2", summary.Pane.TopLeftCell)
+	assert.Equal(t, "frozen", summary.Pane.State)
+
+	if !assert.Len(t, summary.Rows, 2) {
+		return
+	}
+
+	headers := summary.Rows[0].Cells
+	assert.Len(t, headers, 8)
+	for _, header := range headers {
+		assert.Equal(t, "inlineStr", header.Type)
+		assert.Equal(t, "1", header.Style)
+	}
+	assert.Equal(t, "A1", headers[0].Ref)
+	// the minutes are shown as hours and minutes
+	assert.Equal(t, "total h:mm", headers[3].Text)
+
+	cells := summary.Rows[1].Cells
+	if !assert.Len(t, cells, 8) {
+		return
+	}
+
+	assert.Equal(t, "A2", cells[0].Ref)
+	assert.Equal(t, "inlineStr", cells[0].Type)
+	assert.Equal(t, "user-1", cells[0].Text)
+
+	// dates are serial numbers of days since the Excel epoch
+	assert.Equal(t, "5", cells[1].Style)
+	assert.Empty(t, cells[1].Type)
+	assert.Equal(t, 46027.0, cellNumber(t, cells[1]))
+
+	assert.Equal(t, "6", cells[2].Style)
+	assert.InDelta(t, 46027+510.0/1440, cellNumber(t, cells[2]), 1e-9)
+
+	// durations and times of day are fractions of a day
+	assert.Equal(t, "4", cells[3].Style)
+	assert.Equal(t, 0.0625, cellNumber(t, cells[3]))
+
+	assert.Equal(t, "2", cells[4].Style)
+	assert.Equal(t, 87.5, cellNumber(t, cells[4]))
+
+	assert.Equal(t, "3", cells[5].Style)
+	assert.Equal(t, 3.0, cellNumber(t, cells[5]))
+
+	assert.Equal(t, "7", cells[6].Style)
+	assert.InDelta(t, 510.0/1440, cellNumber(t, cells[6]), 1e-9)
+
+	assert.Equal(t, "b", cells[7].Type)
+	assert.Equal(t, "1", cells[7].Value)
+	assert.Equal(t, "H2", cells[7].Ref)
+
+	member := parseWorksheet(t, files["xl/worksheets/sheet3.xml"])
+	if assert.Len(t, member.Rows, 2) {
+		assert.Equal(t, "member user uuid", member.Rows[0].Cells[0].Text)
+		assert.Equal(t, "user-3", member.Rows[1].Cells[0].Text)
+	}
+}
+
+func TestWriteXLSXEscapesTexts(t *testing.T) {
+	report := export.Report{
+		Summary: export.Sheet{
+			Name:    "R&D <team>",
+			Headers: []string{"team name"},
+			Rows:    [][]export.Cell{{export.Text(`Research & "Development" <lab>`)}},
+		},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, export.WriteXLSX(&out, report))
+
+	files := unzip(t, out.Bytes())
+	assert.Equal(t, []string{"R&D <team>"}, sheetNames(t, files))
+
+	sheet := parseWorksheet(t, files["xl/worksheets/sheet1.xml"])
+	if assert.Len(t, sheet.Rows, 2) {
+		assert.Equal(t, `Research & "Development" <lab>`, sheet.Rows[1].Cells[0].Text)
+	}
+}
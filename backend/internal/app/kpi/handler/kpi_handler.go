@@ -3,12 +3,13 @@ package handler
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	AuthService "app/internal/app/auth/service"
+	ExportJobModel "app/internal/app/export-job/model"
+	ExportJobService "app/internal/app/export-job/service"
 	"app/internal/app/kpi/model"
 	KPIService "app/internal/app/kpi/service"
 	Config "app/internal/config"
@@ -17,14 +18,20 @@ import (
 )
 
 type KPIHandler struct {
-	service KPIService.KPIService
+	service    KPIService.KPIService
+	exportJobs ExportJobService.ExportJobService
 }
 
-func NewKPIHandler(service KPIService.KPIService) *KPIHandler {
-	return &KPIHandler{service: service}
+func NewKPIHandler(service KPIService.KPIService, exportJobs ExportJobService.ExportJobService) *KPIHandler {
+	return &KPIHandler{service: service, exportJobs: exportJobs}
 }
 
 func (handler *KPIHandler) isValidISO8601(date string) bool {
+	_, err := parseDate(date)
+	return err == nil
+}
+
+func parseDate(date string) (time.Time, error) {
 	layouts := []string{
 		time.RFC3339,
 		time.RFC3339Nano,
@@ -33,26 +40,23 @@ func (handler *KPIHandler) isValidISO8601(date string) bool {
 		"2006-01-02 15:04:05.999999",
 	}
 
+	var err error
 	for _, layout := range layouts {
-		if _, err := time.Parse(layout, date); err == nil {
-			return true
+		var parsed time.Time
+		if parsed, err = time.Parse(layout, date); err == nil {
+			return parsed, nil
 		}
 	}
-	return false
+	return time.Time{}, err
 }
 
-func stripToDate(input string) (time.Time, error) {
-	t, err := time.Parse("2006-01-02 15:04:05.999999", input)
-	if err == nil {
-		return t.Truncate(24 * time.Hour), nil
-	}
-
-	t, err = time.Parse("2006-01-02", input)
-	if err == nil {
-		return t, nil
+// toDay returns the day of an ISO 8601 date, as written by the client, dropping its time part.
+func toDay(date string) string {
+	parsed, err := parseDate(date)
+	if err != nil {
+		return date
 	}
-
-	return time.Time{}, fmt.Errorf("invalid date format")
+	return parsed.Format("2006-01-02")
 }
 
 // GetWorkSessionUserWeeklyTotal handles the HTTP request to get the total work session time for a user within a date range.
@@ -63,8 +67,8 @@ func stripToDate(input string) (time.Time, error) {
 // @Accept json
 // @Security     BearerAuth
 // @Produce json
-// @Param start_date path string true "Start Date in ISO 8601 format"
-// @Param end_date path string true "End Date in ISO 8601 format"
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
 // @Param user_uuid path string true "User UUID"
 // @Success 200 {object} model.KPIWorkSessionUserWeeklyTotalResponse
 // @Router /kpi/work-session-user-weekly-total/{user_uuid}/{start_date}/{end_date} [get]
@@ -73,7 +77,7 @@ func (handler *KPIHandler) GetWorkSessionUserWeeklyTotal(c *gin.Context) {
 	endDate := c.Param("end_date")
 	userUUID := c.Param("user_uuid")
 
-	err := handler.validateDateRange(startDate, endDate)
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
 		return
@@ -103,8 +107,8 @@ func (handler *KPIHandler) GetWorkSessionUserWeeklyTotal(c *gin.Context) {
 // @Accept json
 // @Security     BearerAuth
 // @Produce json
-// @Param start_date path string true "Start Date in ISO 8601 format"
-// @Param end_date path string true "End Date in ISO 8601 format"
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
 // @Param team_uuid path string true "Team UUID"
 // @Success 200 {object} model.KPIWorkSessionTeamWeeklyTotalResponse
 // @Router /kpi/work-session-team-weekly-total/{team_uuid}/{start_date}/{end_date} [get]
@@ -113,7 +117,7 @@ func (handler *KPIHandler) GetWorkSessionTeamWeeklyTotal(c *gin.Context) {
 	endDate := c.Param("end_date")
 	teamUUID := c.Param("team_uuid")
 
-	err := handler.validateDateRange(startDate, endDate)
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
 		return
@@ -136,9 +140,11 @@ func (handler *KPIHandler) GetWorkSessionTeamWeeklyTotal(c *gin.Context) {
 	c.JSON(http.StatusOK, kpiResponse)
 }
 
-func (handler *KPIHandler) validateDateRange(startDate string, endDate string) error {
+// validateDateRange validates the range and returns its first and last days: the KPIs are read from the daily totals,
+// so the range is made of whole days, both included, and the time part of a date is dropped.
+func (handler *KPIHandler) validateDateRange(startDate string, endDate string) (string, string, error) {
 	if !handler.isValidISO8601(startDate) || !handler.isValidISO8601(endDate) {
-		return &gin.Error{
+		return "", "", &gin.Error{
 			Err:  http.ErrNotSupported,
 			Type: gin.ErrorTypeBind,
 		}
@@ -146,12 +152,13 @@ func (handler *KPIHandler) validateDateRange(startDate string, endDate string) e
 
 	start, _ := time.Parse(time.RFC3339, startDate)
 	end, _ := time.Parse(time.RFC3339, endDate)
+	startDate, endDate = toDay(startDate), toDay(endDate)
 	now := time.Now().Format(time.RFC3339Nano)
 	twoYearsAgo := time.Now().AddDate(-2, 0, 0).Format(time.RFC3339Nano)
 
 	// check if start date is before end date
 	if start.After(end) {
-		return &gin.Error{
+		return "", "", &gin.Error{
 			Err:  http.ErrNotSupported,
 			Type: gin.ErrorTypeBind,
 		}
@@ -159,24 +166,24 @@ func (handler *KPIHandler) validateDateRange(startDate string, endDate string) e
 
 	// check if start date is after end date
 	if startDate > endDate {
-		return fmt.Errorf("start_date cannot be after end_date")
+		return "", "", fmt.Errorf("start_date cannot be after end_date")
 	}
 
 	// check if start date is before end date
 	if endDate < startDate {
-		return fmt.Errorf("end_date cannot be before start_date")
+		return "", "", fmt.Errorf("end_date cannot be before start_date")
 	}
 
 	// check if end date is in the future
 	if startDate < twoYearsAgo {
-		return fmt.Errorf("date range cannot exceed 2 years from the current date")
+		return "", "", fmt.Errorf("date range cannot exceed 2 years from the current date")
 	}
 
 	// check if end date is in the future
 	if endDate > now {
-		return fmt.Errorf("end_date cannot be in the future")
+		return "", "", fmt.Errorf("end_date cannot be in the future")
 	}
-	return nil
+	return startDate, endDate, nil
 }
 
 // GetPresenceRate handles the HTTP request to get the presence rate for a user within a date range.
@@ -187,8 +194,8 @@ func (handler *KPIHandler) validateDateRange(startDate string, endDate string) e
 // @Accept json
 // @Security     BearerAuth
 // @Produce json
-// @Param start_date path string true "Start Date in ISO 8601 format"
-// @Param end_date path string true "End Date in ISO 8601 format"
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
 // @Param user_uuid path string true "User UUID"
 // @Success 200 {object} model.KPIPresenceRateResponse
 // @Router /kpi/presence-rate/{user_uuid}/{start_date}/{end_date} [get]
@@ -197,7 +204,7 @@ func (handler *KPIHandler) GetPresenceRate(c *gin.Context) {
 	endDate := c.Param("end_date")
 	userUUID := c.Param("user_uuid")
 
-	err := handler.validateDateRange(startDate, endDate)
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date ranges: " + err.Error()})
 		return
@@ -215,13 +222,13 @@ func (handler *KPIHandler) GetPresenceRate(c *gin.Context) {
 // ExportKPIData handles the HTTP request to export KPI data within a date range.
 //
 // @Summary Export KPI data within a date range
-// @Description Exports KPI data for the specified date range. 🔒 Requires role: **manager, admin**
+// @Description Queues the export of KPI data for the specified date range as a CSV file, an XLSX workbook with one sheet per team member or a printable PDF. The timesheet covers the month of the start date. The file is generated in the background: poll the returned status URL, or set notify_by_email to receive the download link by email. The link is signed and valid until the file expires. 🔒 Requires role: **manager, admin**
 // @Tags KPI
 // @Accept json
 // @Security     BearerAuth
 // @Produce json
 // @Param kpi_export_request body model.KPIExportRequest true "KPI Export Request"
-// @Success 200 {object} model.KPIExportResponse
+// @Success 202 {object} ExportJobModel.ExportJobResponse
 // @Router /kpi/export [post]
 func (handler *KPIHandler) ExportKPIData(c *gin.Context) {
 	var exportRequest model.KPIExportRequest
@@ -238,60 +245,104 @@ func (handler *KPIHandler) ExportKPIData(c *gin.Context) {
 
 	authClaims := claims.(*AuthService.Claims)
 
-	err := handler.validateDateRange(exportRequest.StartDate, exportRequest.EndDate)
+	startDate, endDate, err := handler.validateDateRange(exportRequest.StartDate, exportRequest.EndDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date ranges: " + err.Error()})
 		return
 	}
+	exportRequest.StartDate, exportRequest.EndDate = startDate, endDate
 
-	exportResponse, err := handler.service.ExportKPIData(exportRequest.StartDate, exportRequest.EndDate, authClaims.UUID, exportRequest.KPIType, exportRequest.UUIDToSearch)
+	job, err := handler.exportJobs.Create(exportRequest, authClaims.UUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export KPI data: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue KPI export: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, exportResponse)
+	c.JSON(http.StatusAccepted, ExportJobModel.ExportJobResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		StatusURL: "/api/kpi/export/jobs/" + job.ID,
+	})
 }
 
-// GetAverageBreakTime handles the HTTP request to get the average break time for a user within a date range.
+// ExportKPIBundle handles the HTTP request to export several KPIs of several users and teams at once.
 //
-// @Summary Get average break time for a user within a date range
-// @Description Retrieves the average break time in minutes for a specified user UUID between the provided start and end dates. These dates must be 5 days! (work days) Not less, not more 🔒 Requires role: **all**
+// @Summary Export several KPIs of several users and teams within a date range
+// @Description Queues the export of every requested KPI type for each of its targets, the users for the user KPI types and the teams for the team ones, in a single file: a ZIP archive with one CSV file per report, or a workbook with one sheet per report. Both come with a manifest describing the parameters of the export and listing its reports. Up to 200 users and 50 teams can be exported at once. The file is generated in the background like the other exports. 🔒 Requires role: **manager, admin**
 // @Tags KPI
 // @Accept json
 // @Security     BearerAuth
 // @Produce json
-// @Param start_date path string true "Start Date in ISO 8601 format"
-// @Param end_date path string true "End Date in ISO 8601 format"
-// @Param user_uuid path string true "User UUID"
-// @Success 200 {object} model.KPIAverageBreakTimeResponse
-// @Router /kpi/weekly-average-break-time/{user_uuid}/{start_date}/{end_date} [get]
-func (handler *KPIHandler) GetAverageBreakTime(c *gin.Context) {
-	startDate := c.Param("start_date")
-	endDate := c.Param("end_date")
-	userUUID := c.Param("user_uuid")
+// @Param kpi_bundle_export_request body model.KPIBundleExportRequest true "KPI Bundle Export Request"
+// @Success 202 {object} ExportJobModel.ExportJobResponse
+// @Router /kpi/export/bundle [post]
+func (handler *KPIHandler) ExportKPIBundle(c *gin.Context) {
+	var bundleRequest model.KPIBundleExportRequest
+	if err := c.ShouldBindJSON(&bundleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
 
-	err := handler.validateDateRange(startDate, endDate)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+	for _, kpiType := range bundleRequest.KPITypes {
+		if KPIService.IsTeamKPIType(kpiType) && len(bundleRequest.TeamUUIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + kpiType + " requires at least one team uuid"})
+			return
+		}
+		if !KPIService.IsTeamKPIType(kpiType) && len(bundleRequest.UserUUIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + kpiType + " requires at least one user uuid"})
+			return
+		}
+	}
+
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
 		return
 	}
 
-	start, err := stripToDate(startDate)
+	authClaims := claims.(*AuthService.Claims)
+
+	startDate, endDate, err := handler.validateDateRange(bundleRequest.StartDate, bundleRequest.EndDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date ranges: " + err.Error()})
 		return
 	}
+	bundleRequest.StartDate, bundleRequest.EndDate = startDate, endDate
 
-	end, err := stripToDate(endDate)
+	job, err := handler.exportJobs.CreateBundle(bundleRequest, authClaims.UUID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue KPI bundle export: " + err.Error()})
 		return
 	}
 
-	diffDays := int(end.Sub(start).Hours() / 24)
-	if diffDays != 4 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range must be exactly 5 days apart"})
+	c.JSON(http.StatusAccepted, ExportJobModel.ExportJobResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		StatusURL: "/api/kpi/export/jobs/" + job.ID,
+	})
+}
+
+// GetAverageBreakTime handles the HTTP request to get the average break time for a user within a date range.
+//
+// @Summary Get average break time for a user within a date range
+// @Description Retrieves the average break time in minutes per worked day for a specified user UUID between the provided start and end dates. 🔒 Requires role: **all**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param user_uuid path string true "User UUID"
+// @Success 200 {object} model.KPIAverageBreakTimeResponse
+// @Router /kpi/weekly-average-break-time/{user_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetAverageBreakTime(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	userUUID := c.Param("user_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
 		return
 	}
 
@@ -313,6 +364,39 @@ func (handler *KPIHandler) GetAverageBreakTime(c *gin.Context) {
 	c.JSON(http.StatusOK, kpiResponse)
 }
 
+// GetBreakAnalytics handles the HTTP request to get the break statistics of a user within a date range.
+//
+// @Summary Get break analytics for a user within a date range
+// @Description Retrieves the break statistics of the work sessions, running ones included, of a specified user UUID between the provided start and end dates: average break time per worked day and per session, median and 90th percentile of the break time per session, number of breaks per session and share of the sessions without any break. Times are in minutes. 🔒 Requires role: **all**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param user_uuid path string true "User UUID"
+// @Success 200 {object} model.KPIBreakAnalyticsResponse
+// @Router /kpi/break-analytics/{user_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetBreakAnalytics(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	userUUID := c.Param("user_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetBreakAnalytics(startDate, endDate, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve break analytics: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetAverageTimePerShift handles the HTTP request to get the average time per shift for a user within a date range.
 //
 // @Summary Get average time per shift for a user within a date range
@@ -321,8 +405,8 @@ func (handler *KPIHandler) GetAverageBreakTime(c *gin.Context) {
 // @Accept json
 // @Security     BearerAuth
 // @Produce json
-// @Param start_date path string true "Start Date in ISO 8601 format"
-// @Param end_date path string true "End Date in ISO 8601 format"
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
 // @Param user_uuid path string true "User UUID"
 // @Success 200 {object} model.KPIAverageTimePerShiftResponse
 // @Router /kpi/average-time-per-shift/{user_uuid}/{start_date}/{end_date} [get]
@@ -331,7 +415,7 @@ func (handler *KPIHandler) GetAverageTimePerShift(c *gin.Context) {
 	endDate := c.Param("end_date")
 	userUUID := c.Param("user_uuid")
 
-	err := handler.validateDateRange(startDate, endDate)
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
 		return
@@ -357,39 +441,441 @@ func (handler *KPIHandler) GetAverageTimePerShift(c *gin.Context) {
 	c.JSON(http.StatusOK, kpiResponse)
 }
 
-// DownloadKPIFile handles the HTTP request to download a KPI CSV file.
+// GetDailyPresence handles the HTTP request to get the day by day presence of a user within a date range.
+//
+// @Summary Get daily presence for a user within a date range
+// @Description Retrieves, for each day between the provided start and end dates, the expected hours from the user's weekly rate distribution, the hours done and whether the user was absent on a working day. 🔒 Requires role: **manager, admin**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param user_uuid path string true "User UUID"
+// @Success 200 {object} model.KPIDailyPresenceResponse
+// @Router /kpi/daily-presence/{user_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetDailyPresence(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	userUUID := c.Param("user_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetDailyPresence(startDate, endDate, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve daily presence: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTimeSeries handles the HTTP request to get the KPIs of a user bucketed by day, week or month within a date range.
+//
+// @Summary Get time series KPIs for a user within a date range
+// @Description Retrieves one data point per bucket between the provided start and end dates with the worked time, break time, shifts, expected hours and presence rate. Weeks start on the user's **first_day_of_week** and buckets without any work session are filled with zeros. 🔒 Requires role: **manager, admin**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param user_uuid path string true "User UUID"
+// @Param bucket query string false "Bucket size" Enums(day, week, month) default(day)
+// @Success 200 {object} model.KPITimeSeriesResponse
+// @Router /kpi/time-series/{user_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetTimeSeries(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	userUUID := c.Param("user_uuid")
+	bucket := c.DefaultQuery("bucket", "day")
+
+	if bucket != "day" && bucket != "week" && bucket != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be one of day, week or month"})
+		return
+	}
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetTimeSeries(startDate, endDate, userUUID, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve time series: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTeamPresenceRate handles the HTTP request to get the presence rate of a team within a date range.
+//
+// @Summary Get presence rate for a team within a date range
+// @Description Retrieves the presence rate of the whole team, computed from the hours done and expected by all its members, along with the presence rate of each member between the provided start and end dates. 🔒 Requires role: **manager**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param team_uuid path string true "Team UUID"
+// @Success 200 {object} model.KPITeamPresenceRateResponse
+// @Router /kpi/team-presence-rate/{team_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetTeamPresenceRate(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	teamUUID := c.Param("team_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetTeamPresenceRate(startDate, endDate, teamUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve team presence rate: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTeamAverageBreakTime handles the HTTP request to get the average break time of a team within a date range.
+//
+// @Summary Get average break time for a team within a date range
+// @Description Retrieves the average break time in minutes of the team, computed over all the worked days of its members, along with the average break time of each member between the provided start and end dates. 🔒 Requires role: **manager**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param team_uuid path string true "Team UUID"
+// @Success 200 {object} model.KPITeamAverageBreakTimeResponse
+// @Router /kpi/team-average-break-time/{team_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetTeamAverageBreakTime(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	teamUUID := c.Param("team_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetTeamAverageBreakTime(startDate, endDate, teamUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve team average break time: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTeamAverageTimePerShift handles the HTTP request to get the average time per shift of a team within a date range.
+//
+// @Summary Get average time per shift for a team within a date range
+// @Description Retrieves the average time per shift in minutes of the team, computed over all the shifts of its members, along with the average time per shift of each member between the provided start and end dates. 🔒 Requires role: **manager**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param team_uuid path string true "Team UUID"
+// @Success 200 {object} model.KPITeamAverageTimePerShiftResponse
+// @Router /kpi/team-average-time-per-shift/{team_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetTeamAverageTimePerShift(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	teamUUID := c.Param("team_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetTeamAverageTimePerShift(startDate, endDate, teamUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve team average time per shift: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOrganizationOverview handles the HTTP request to get the organization-wide KPIs.
 //
-// @Summary Download a KPI CSV file
-// @Description Downloads a specific KPI CSV file by filename. 🔒 Requires role: **manager, admin**
+// @Summary Get organization-wide KPIs
+// @Description Retrieves the live headcount by work session status, the totals of every user and the teams ranked from the lowest presence rate. The date range defaults to the current week, from monday to today, and the result is cached for 30 seconds. 🔒 Requires role: **admin**
 // @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date query string false "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date query string false "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Success 200 {object} model.KPIOrganizationOverviewResponse
+// @Router /kpi/organization/overview [get]
+func (handler *KPIHandler) GetOrganizationOverview(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	if (startDate == "") != (endDate == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date must be provided together"})
+		return
+	}
+
+	if startDate != "" {
+		var err error
+		startDate, endDate, err = handler.validateDateRange(startDate, endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := handler.service.GetOrganizationOverview(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve organization overview: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CompareUser handles the HTTP request to compare the KPIs of a user with a previous period.
+//
+// @Summary Compare the KPIs of a user with a previous period
+// @Description Computes the KPIs of a specified user UUID between the provided start and end dates and over a previous period, and returns the absolute and percentage change of each metric. The previous period defaults to the period of the same length right before the start date. 🔒 Requires role: **manager, admin**
+// @Tags KPI
+// @Accept json
 // @Security     BearerAuth
-// @Produce text/csv
-// @Param filename path string true "Filename of the CSV to download"
-// @Success 200 {file} csv
-// @Router /kpi/files/{filename} [get]
-func (handler *KPIHandler) DownloadKPIFile(c *gin.Context) {
-	filename := c.Param("filename")
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param user_uuid path string true "User UUID"
+// @Param previous_start_date query string false "First day of the previous period, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param previous_end_date query string false "Last day of the previous period, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Success 200 {object} model.KPIComparisonResponse
+// @Router /kpi/compare/user/{user_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) CompareUser(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	userUUID := c.Param("user_uuid")
+	previousStartDate := c.Query("previous_start_date")
+	previousEndDate := c.Query("previous_end_date")
 
-	if strings.Contains(filename, "..") || strings.Contains(filename, "/") || strings.Contains(filename, "\\") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename"})
+	startDate, endDate, previousStartDate, previousEndDate, err := handler.validateComparisonRanges(startDate, endDate, previousStartDate, previousEndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
 		return
 	}
 
-	if !strings.HasSuffix(strings.ToLower(filename), ".csv") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only CSV files are allowed"})
+	response, err := handler.service.CompareUser(startDate, endDate, previousStartDate, previousEndDate, userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare user KPIs: " + err.Error()})
 		return
 	}
 
-	filePath := filepath.Join("/app/data/kpi", filename)
+	c.JSON(http.StatusOK, response)
+}
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+// CompareTeam handles the HTTP request to compare the KPIs of a team with a previous period.
+//
+// @Summary Compare the KPIs of a team with a previous period
+// @Description Computes the KPIs of a specified team UUID between the provided start and end dates and over a previous period, and returns the absolute and percentage change of each metric. The previous period defaults to the period of the same length right before the start date. 🔒 Requires role: **manager**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param team_uuid path string true "Team UUID"
+// @Param previous_start_date query string false "First day of the previous period, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param previous_end_date query string false "Last day of the previous period, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Success 200 {object} model.KPIComparisonResponse
+// @Router /kpi/compare/team/{team_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) CompareTeam(c *gin.Context) {
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	teamUUID := c.Param("team_uuid")
+	previousStartDate := c.Query("previous_start_date")
+	previousEndDate := c.Query("previous_end_date")
+
+	startDate, endDate, previousStartDate, previousEndDate, err := handler.validateComparisonRanges(startDate, endDate, previousStartDate, previousEndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
 		return
 	}
 
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-	c.Header("Content-Transfer-Encoding", "binary")
+	response, err := handler.service.CompareTeam(startDate, endDate, previousStartDate, previousEndDate, teamUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare team KPIs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// validateComparisonRanges validates the current period and, when provided, the previous one, and returns their days.
+func (handler *KPIHandler) validateComparisonRanges(startDate string, endDate string, previousStartDate string, previousEndDate string) (string, string, string, string, error) {
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	if previousStartDate == "" && previousEndDate == "" {
+		return startDate, endDate, "", "", nil
+	}
+	if previousStartDate == "" || previousEndDate == "" {
+		return "", "", "", "", fmt.Errorf("previous_start_date and previous_end_date must be provided together")
+	}
+
+	previousStartDate, previousEndDate, err = handler.validateDateRange(previousStartDate, previousEndDate)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	return startDate, endDate, previousStartDate, previousEndDate, nil
+}
 
-	c.File(filePath)
+// GetWellBeing handles the HTTP request to get the well-being indicators of a user within a date range.
+//
+// @Summary Get the well-being indicators and burnout risk of a user within a date range
+// @Description Computes from the session history of the user the longest streak of consecutive working days, the weeks above the weekly rate, the late-evening and weekend work frequency and the change of the break time against the previous period, combined into a risk score from 0 to 10 and a risk level (low, moderate or high). Each factor scores from 0 to 2 points, see **factors**. Only visible to the managers of the teams of the user. 🔒 Requires role: **manager**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param user_uuid path string true "User UUID"
+// @Success 200 {object} model.KPIWellBeingResponse
+// @Router /kpi/well-being/{user_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetWellBeing(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+
+	authClaims := claims.(*AuthService.Claims)
+
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	userUUID := c.Param("user_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetWellBeing(startDate, endDate, userUUID, authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), Config.ErrorMessages()["NOT_TEAM_MANAGER"]) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve well-being indicators: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTeamWellBeing handles the HTTP request to get the well-being indicators of the members of a team within a date range.
+//
+// @Summary Get the well-being indicators and burnout risk of a team within a date range
+// @Description Computes the well-being indicators and risk level of each member of the team, sorted from the highest risk score, along with the number of members by risk level. Only visible to the managers of the team. 🔒 Requires role: **manager**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param start_date path string true "First day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param end_date path string true "Last day of the range, included (YYYY-MM-DD, or ISO 8601 whose time part is ignored)"
+// @Param team_uuid path string true "Team UUID"
+// @Success 200 {object} model.KPITeamWellBeingResponse
+// @Router /kpi/team-well-being/{team_uuid}/{start_date}/{end_date} [get]
+func (handler *KPIHandler) GetTeamWellBeing(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+
+	authClaims := claims.(*AuthService.Claims)
+
+	startDate := c.Param("start_date")
+	endDate := c.Param("end_date")
+	teamUUID := c.Param("team_uuid")
+
+	startDate, endDate, err := handler.validateDateRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+
+	response, err := handler.service.GetTeamWellBeing(startDate, endDate, teamUUID, authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), Config.ErrorMessages()["NOT_TEAM_MANAGER"]) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve team well-being indicators: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// QueryKPIs handles the HTTP request to compute several KPIs for several users and teams at once.
+//
+// @Summary Compute several KPIs for several users and teams within a date range
+// @Description Computes the requested metrics (total_time, presence_rate, weekly_rate_expected, weekly_time_done, average_break_time, average_time_per_shift, total_shifts) for every given user and team over the same date range, with the same rules as their dedicated routes. The results are returned in the order of the request. Up to 200 users and 50 teams can be queried at once. 🔒 Requires role: **manager, admin**
+// @Tags KPI
+// @Accept json
+// @Security     BearerAuth
+// @Produce json
+// @Param kpi_query_request body model.KPIQueryRequest true "KPI Query Request"
+// @Success 200 {object} model.KPIQueryResponse
+// @Router /kpi/query [post]
+func (handler *KPIHandler) QueryKPIs(c *gin.Context) {
+	var queryRequest model.KPIQueryRequest
+	if err := c.ShouldBindJSON(&queryRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if len(queryRequest.UserUUIDs) == 0 && len(queryRequest.TeamUUIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: at least one user or team uuid is required"})
+		return
+	}
+
+	startDate, endDate, err := handler.validateDateRange(queryRequest.StartDate, queryRequest.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date range: " + err.Error()})
+		return
+	}
+	queryRequest.StartDate, queryRequest.EndDate = startDate, endDate
+
+	response, err := handler.service.QueryKPIs(queryRequest)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), Config.ErrorMessages()["INVALID_REQUEST"]) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query KPIs: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
 }
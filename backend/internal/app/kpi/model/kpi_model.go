@@ -44,16 +44,29 @@ type KPIPresenceRateResponse struct {
 
 // swagger:model KPIExportRequest
 type KPIExportRequest struct {
-	KPIType      string `json:"kpi_type" binding:"required,oneof=work_session_user_weekly_total work_session_team_weekly_total presence_rate weekly_average_break_time average_time_per_shift"`
+	KPIType string `json:"kpi_type" binding:"required,oneof=work_session_user_weekly_total work_session_team_weekly_total presence_rate weekly_average_break_time average_time_per_shift daily_presence team_presence_rate team_average_break_time team_average_time_per_shift timesheet team_summary"`
+	// first and last days of the range, both included
 	StartDate    string `json:"start_date" binding:"required"`
 	EndDate      string `json:"end_date" binding:"required"`
 	UUIDToSearch string `json:"uuid_to_search"`
+	Format       string `json:"format" binding:"omitempty,oneof=csv xlsx pdf"`
+	// mail the download link to the requester once the file is generated
+	NotifyByEmail bool `json:"notify_by_email"`
 }
 
-// swagger:model KPIExportResponse
-type KPIExportResponse struct {
-	File string `json:"file"`
-	URL  string `json:"url"`
+// swagger:model KPIBundleExportRequest
+type KPIBundleExportRequest struct {
+	// each user KPI type is exported for every user and each team KPI type for every team
+	KPITypes  []string `json:"kpi_types" binding:"required,min=1,dive,oneof=work_session_user_weekly_total work_session_team_weekly_total presence_rate weekly_average_break_time average_time_per_shift daily_presence team_presence_rate team_average_break_time team_average_time_per_shift timesheet team_summary"`
+	UserUUIDs []string `json:"user_uuids" binding:"max=200"`
+	TeamUUIDs []string `json:"team_uuids" binding:"max=50"`
+	// first and last days of the range, both included
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date" binding:"required"`
+	// format is either "zip", an archive of CSV files (default), or "xlsx", a single workbook
+	Format string `json:"format" binding:"omitempty,oneof=zip xlsx"`
+	// mail the download link to the requester once the file is generated
+	NotifyByEmail bool `json:"notify_by_email"`
 }
 
 // swagger:model KPIAverageBreakTimeResponse
@@ -77,3 +90,355 @@ type KPIAverageTimePerShiftResponse struct {
 	StartDate           string  `json:"start_date"`
 	EndDate             string  `json:"end_date"`
 }
+
+// swagger:model KPIDailyPresenceDay
+type KPIDailyPresenceDay struct {
+	Date         string `json:"date"`
+	Weekday      string `json:"weekday"`
+	IsWorkingDay bool   `json:"is_working_day"`
+	// false when the user has contracts but none of them covers the day
+	UnderContract bool    `json:"under_contract"`
+	ExpectedHours float64 `json:"expected_hours"`
+	DoneHours     float64 `json:"done_hours"`
+	PresenceRate  float64 `json:"presence_rate"`
+	IsAbsent      bool    `json:"is_absent"`
+}
+
+// swagger:model KPIDailyPresenceResponse
+type KPIDailyPresenceResponse struct {
+	FirstName     string                `json:"first_name"`
+	LastName      string                `json:"last_name"`
+	UserUUID      string                `json:"user_uuid"`
+	StartDate     string                `json:"start_date"`
+	EndDate       string                `json:"end_date"`
+	ExpectedHours float64               `json:"expected_hours"`
+	DoneHours     float64               `json:"done_hours"`
+	PresenceRate  float64               `json:"presence_rate"`
+	WorkingDays   int                   `json:"working_days"`
+	AbsentDays    int                   `json:"absent_days"`
+	Days          []KPIDailyPresenceDay `json:"days"`
+}
+
+// swagger:model KPITimeSeriesPoint
+type KPITimeSeriesPoint struct {
+	// first and last day of the bucket, clipped to the requested date range
+	BucketStart         string  `json:"bucket_start"`
+	BucketEnd           string  `json:"bucket_end"`
+	TotalTime           int     `json:"total_time"`
+	TotalBreakTime      int     `json:"total_break_time"`
+	TotalShifts         int     `json:"total_shifts"`
+	WorkedDays          int     `json:"worked_days"`
+	AverageTimePerShift float64 `json:"average_time_per_shift"`
+	AverageBreakTime    float64 `json:"average_break_time"`
+	ExpectedHours       float64 `json:"expected_hours"`
+	DoneHours           float64 `json:"done_hours"`
+	PresenceRate        float64 `json:"presence_rate"`
+}
+
+// swagger:model KPITimeSeriesResponse
+type KPITimeSeriesResponse struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	UserUUID  string `json:"user_uuid"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// bucket is either "day", "week" or "month"
+	Bucket         string               `json:"bucket"`
+	FirstDayOfWeek int                  `json:"first_day_of_week"`
+	Points         []KPITimeSeriesPoint `json:"points"`
+}
+
+// KPITimeSeriesRow is one bucket of worked time as returned by the database, gaps being filled with zeros.
+type KPITimeSeriesRow struct {
+	BucketStart  string `gorm:"column:bucket_start"`
+	TotalMinutes int    `gorm:"column:total_minutes"`
+	BreakMinutes int    `gorm:"column:break_minutes"`
+	TotalShifts  int    `gorm:"column:total_shifts"`
+	WorkedDays   int    `gorm:"column:worked_days"`
+}
+
+// swagger:model KPITeamMemberPresenceRate
+type KPITeamMemberPresenceRate struct {
+	UserUUID           string  `json:"user_uuid"`
+	FirstName          string  `json:"first_name"`
+	LastName           string  `json:"last_name"`
+	PresenceRate       float64 `json:"presence_rate"`
+	WeeklyRateExpected float64 `json:"weekly_rate_expected"`
+	WeeklyTimeDone     float64 `json:"weekly_time_done"`
+}
+
+// swagger:model KPITeamPresenceRateResponse
+type KPITeamPresenceRateResponse struct {
+	TeamUUID  string `json:"team_uuid"`
+	TeamName  string `json:"team_name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// hours done by the whole team over the hours expected from the whole team
+	PresenceRate       float64                     `json:"presence_rate"`
+	WeeklyRateExpected float64                     `json:"weekly_rate_expected"`
+	WeeklyTimeDone     float64                     `json:"weekly_time_done"`
+	Members            []KPITeamMemberPresenceRate `json:"members"`
+}
+
+// swagger:model KPITeamMemberAverageBreakTime
+type KPITeamMemberAverageBreakTime struct {
+	UserUUID         string  `json:"user_uuid"`
+	FirstName        string  `json:"first_name"`
+	LastName         string  `json:"last_name"`
+	AverageBreakTime float64 `json:"average_break_time"`
+}
+
+// swagger:model KPITeamAverageBreakTimeResponse
+type KPITeamAverageBreakTimeResponse struct {
+	TeamUUID  string `json:"team_uuid"`
+	TeamName  string `json:"team_name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// mean of the members average break time
+	AverageBreakTime float64                         `json:"average_break_time"`
+	Members          []KPITeamMemberAverageBreakTime `json:"members"`
+}
+
+// swagger:model KPITeamMemberAverageTimePerShift
+type KPITeamMemberAverageTimePerShift struct {
+	UserUUID            string  `json:"user_uuid"`
+	FirstName           string  `json:"first_name"`
+	LastName            string  `json:"last_name"`
+	AverageTimePerShift float64 `json:"average_time_per_shift"`
+	TotalShifts         int     `json:"total_shifts"`
+	TotalTime           int     `json:"total_time"`
+}
+
+// swagger:model KPITeamAverageTimePerShiftResponse
+type KPITeamAverageTimePerShiftResponse struct {
+	TeamUUID  string `json:"team_uuid"`
+	TeamName  string `json:"team_name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// total time of the team over its total number of shifts
+	AverageTimePerShift float64                            `json:"average_time_per_shift"`
+	TotalShifts         int                                `json:"total_shifts"`
+	TotalTime           int                                `json:"total_time"`
+	Members             []KPITeamMemberAverageTimePerShift `json:"members"`
+}
+
+// swagger:model KPIOrganizationHeadcount
+type KPIOrganizationHeadcount struct {
+	// users that are not disabled
+	TotalUsers int `json:"total_users" gorm:"column:total_users"`
+	ClockedIn  int `json:"clocked_in" gorm:"column:clocked_in"`
+	OnBreak    int `json:"on_break" gorm:"column:on_break"`
+	ClockedOut int `json:"clocked_out" gorm:"column:clocked_out"`
+}
+
+// swagger:model KPIOrganizationTotals
+type KPIOrganizationTotals struct {
+	TotalTime      int `json:"total_time" gorm:"column:total_time"`
+	TotalBreakTime int `json:"total_break_time" gorm:"column:total_break_time"`
+	TotalShifts    int `json:"total_shifts" gorm:"column:total_shifts"`
+	// users with at least one work session in the date range
+	WorkingUsers       int     `json:"working_users" gorm:"column:working_users"`
+	AverageTimePerUser float64 `json:"average_time_per_user" gorm:"-"`
+}
+
+// swagger:model KPIOrganizationTeamRank
+type KPIOrganizationTeamRank struct {
+	Rank               int     `json:"rank"`
+	TeamUUID           string  `json:"team_uuid"`
+	TeamName           string  `json:"team_name"`
+	MembersCount       int     `json:"members_count"`
+	PresenceRate       float64 `json:"presence_rate"`
+	WeeklyRateExpected float64 `json:"weekly_rate_expected"`
+	WeeklyTimeDone     float64 `json:"weekly_time_done"`
+}
+
+// swagger:model KPIOrganizationOverviewResponse
+type KPIOrganizationOverviewResponse struct {
+	StartDate   string                   `json:"start_date"`
+	EndDate     string                   `json:"end_date"`
+	GeneratedAt string                   `json:"generated_at"`
+	Headcount   KPIOrganizationHeadcount `json:"headcount"`
+	Totals      KPIOrganizationTotals    `json:"totals"`
+	// teams ordered from the lowest to the highest presence rate
+	TeamRanking []KPIOrganizationTeamRank `json:"team_ranking"`
+}
+
+// KPIBreakAnalyticsRow holds the raw break statistics of a user as returned by the database.
+type KPIBreakAnalyticsRow struct {
+	TotalSessions        int     `gorm:"column:total_sessions"`
+	WorkedDays           int     `gorm:"column:worked_days"`
+	TotalBreakTime       int     `gorm:"column:total_break_time"`
+	MedianBreakTime      float64 `gorm:"column:median_break_time"`
+	P90BreakTime         float64 `gorm:"column:p90_break_time"`
+	SessionsWithoutBreak int     `gorm:"column:sessions_without_break"`
+	// sessions closed before breaks were counted have no breaks count
+	CountedSessions int `gorm:"column:counted_sessions"`
+	TotalBreaks     int `gorm:"column:total_breaks"`
+}
+
+// swagger:model KPIBreakAnalyticsResponse
+type KPIBreakAnalyticsResponse struct {
+	FirstName                  string  `json:"first_name"`
+	LastName                   string  `json:"last_name"`
+	UserUUID                   string  `json:"user_uuid"`
+	StartDate                  string  `json:"start_date"`
+	EndDate                    string  `json:"end_date"`
+	TotalSessions              int     `json:"total_sessions"`
+	WorkedDays                 int     `json:"worked_days"`
+	TotalBreakTime             int     `json:"total_break_time"`
+	AverageBreakTimePerDay     float64 `json:"average_break_time_per_day"`
+	AverageBreakTimePerSession float64 `json:"average_break_time_per_session"`
+	MedianBreakTime            float64 `json:"median_break_time"`
+	P90BreakTime               float64 `json:"p90_break_time"`
+	TotalBreaks                int     `json:"total_breaks"`
+	BreaksPerSession           float64 `json:"breaks_per_session"`
+	SessionsWithoutBreak       int     `json:"sessions_without_break"`
+	// percentage of the sessions without any break
+	NoBreakRate float64 `json:"no_break_rate"`
+}
+
+// swagger:model KPIMetricComparison
+type KPIMetricComparison struct {
+	Metric         string  `json:"metric"`
+	Current        float64 `json:"current"`
+	Previous       float64 `json:"previous"`
+	AbsoluteChange float64 `json:"absolute_change"`
+	// null when the previous value is 0
+	PercentageChange *float64 `json:"percentage_change"`
+}
+
+// swagger:model KPIComparisonResponse
+type KPIComparisonResponse struct {
+	// scope is either "user" or "team"
+	Scope             string                `json:"scope"`
+	UUID              string                `json:"uuid"`
+	Name              string                `json:"name"`
+	StartDate         string                `json:"start_date"`
+	EndDate           string                `json:"end_date"`
+	PreviousStartDate string                `json:"previous_start_date"`
+	PreviousEndDate   string                `json:"previous_end_date"`
+	Metrics           []KPIMetricComparison `json:"metrics"`
+}
+
+// KPIWorkingDayRow holds the work of a user on a single day as returned by the database.
+type KPIWorkingDayRow struct {
+	Day          string `gorm:"column:day"`
+	TotalMinutes int    `gorm:"column:total_minutes"`
+	BreakMinutes int    `gorm:"column:break_minutes"`
+	// a session of the day ended late in the evening or after midnight
+	LateEvening bool `gorm:"column:late_evening"`
+}
+
+// KPITimesheetDayRow holds the sessions of a user on a single day as printed on the timesheet.
+type KPITimesheetDayRow struct {
+	Day           string `gorm:"column:day"`
+	ClockIn       string `gorm:"column:clock_in"`
+	ClockOut      string `gorm:"column:clock_out"`
+	BreakMinutes  int    `gorm:"column:break_minutes"`
+	WorkedMinutes int    `gorm:"column:worked_minutes"`
+	SessionsCount int    `gorm:"column:sessions_count"`
+}
+
+// swagger:model KPIWellBeingFactor
+type KPIWellBeingFactor struct {
+	// factor is one of "consecutive_working_days", "weeks_above_weekly_rate", "late_evening_work", "weekend_work" or "shrinking_breaks"
+	Factor string  `json:"factor"`
+	Value  float64 `json:"value"`
+	// from 0 to 2
+	Points int `json:"points"`
+}
+
+// swagger:model KPIWellBeingResponse
+type KPIWellBeingResponse struct {
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	UserUUID       string `json:"user_uuid"`
+	StartDate      string `json:"start_date"`
+	EndDate        string `json:"end_date"`
+	WorkedDays     int    `json:"worked_days"`
+	LongestStreak  int    `json:"longest_streak"`
+	Weeks          int    `json:"weeks"`
+	WeeksAboveRate int    `json:"weeks_above_rate"`
+	// percentages of the worked days
+	LateEveningDays int     `json:"late_evening_days"`
+	LateEveningRate float64 `json:"late_evening_rate"`
+	WeekendDays     int     `json:"weekend_days"`
+	WeekendRate     float64 `json:"weekend_rate"`
+	// average break time per worked day, in minutes, over the period and the previous period of the same length
+	AverageBreakTime         float64 `json:"average_break_time"`
+	PreviousAverageBreakTime float64 `json:"previous_average_break_time"`
+	// null when no break was taken during the previous period
+	BreakTimeChange *float64             `json:"break_time_change"`
+	Factors         []KPIWellBeingFactor `json:"factors"`
+	// sum of the points of the factors, from 0 to 10
+	RiskScore int `json:"risk_score"`
+	// risk level is either "low", "moderate" or "high"
+	RiskLevel string `json:"risk_level"`
+}
+
+// swagger:model KPITeamWellBeingResponse
+type KPITeamWellBeingResponse struct {
+	TeamUUID         string  `json:"team_uuid"`
+	TeamName         string  `json:"team_name"`
+	StartDate        string  `json:"start_date"`
+	EndDate          string  `json:"end_date"`
+	AverageRiskScore float64 `json:"average_risk_score"`
+	LowRisk          int     `json:"low_risk"`
+	ModerateRisk     int     `json:"moderate_risk"`
+	HighRisk         int     `json:"high_risk"`
+	// members are sorted from the highest risk score
+	Members []KPIWellBeingResponse `json:"members"`
+}
+
+// swagger:model KPIQueryRequest
+type KPIQueryRequest struct {
+	// metric is one of "total_time", "presence_rate", "weekly_rate_expected", "weekly_time_done", "average_break_time", "average_time_per_shift" or "total_shifts"
+	Metrics   []string `json:"metrics" binding:"required,min=1,dive,oneof=total_time presence_rate weekly_rate_expected weekly_time_done average_break_time average_time_per_shift total_shifts"`
+	UserUUIDs []string `json:"user_uuids" binding:"max=200"`
+	TeamUUIDs []string `json:"team_uuids" binding:"max=50"`
+	// first and last days of the range, both included
+	StartDate string `json:"start_date" binding:"required" example:"2026-01-05"`
+	EndDate   string `json:"end_date" binding:"required" example:"2026-01-11"`
+}
+
+// swagger:model KPIQueryResult
+type KPIQueryResult struct {
+	// scope is either "user" or "team"
+	Scope   string             `json:"scope"`
+	UUID    string             `json:"uuid"`
+	Name    string             `json:"name"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// swagger:model KPIQueryResponse
+type KPIQueryResponse struct {
+	StartDate string           `json:"start_date"`
+	EndDate   string           `json:"end_date"`
+	Users     []KPIQueryResult `json:"users"`
+	Teams     []KPIQueryResult `json:"teams"`
+}
+
+// KPIQueryUserRow holds the totals of a user over the queried range as returned by the database.
+type KPIQueryUserRow struct {
+	UserID       int     `gorm:"column:user_id"`
+	UserUUID     string  `gorm:"column:user_uuid"`
+	FirstName    string  `gorm:"column:first_name"`
+	LastName     string  `gorm:"column:last_name"`
+	WeeklyRate   float64 `gorm:"column:weekly_rate"`
+	TotalMinutes int     `gorm:"column:total_minutes"`
+	TotalShifts  int     `gorm:"column:total_shifts"`
+	BreakMinutes int     `gorm:"column:break_minutes"`
+	WorkedDays   int     `gorm:"column:worked_days"`
+	// the user has at least one contract, the expected hours are then clipped to the contracts
+	Managed bool `gorm:"column:managed"`
+	// expected hours of the range from the weekly rate of the user, and within the contracts
+	FullExpected     float64 `gorm:"column:full_expected"`
+	ContractExpected float64 `gorm:"column:contract_expected"`
+}
+
+// KPIQueryTeamMemberRow links a team to one of its members, UserUUID being nil for a team without members.
+type KPIQueryTeamMemberRow struct {
+	TeamUUID string  `gorm:"column:team_uuid"`
+	TeamName string  `gorm:"column:team_name"`
+	UserUUID *string `gorm:"column:user_uuid"`
+}
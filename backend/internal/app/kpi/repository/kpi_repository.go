@@ -1,16 +1,32 @@
 package repository
 
 import (
+	"fmt"
 	"math"
 
 	"gorm.io/gorm"
+
+	"app/internal/app/kpi/model"
+	WeeklyRateModel "app/internal/app/weekly-rate/model"
 )
 
 type KPIRepository interface {
 	GetWeeklyRatesByUserIDAndDateRange(userID int, startDate string, endDate string) (int, error)
 	GetUserAverageBreakTime(userID int, startDate, endDate string) (float64, error)
+	GetUserBreakTotals(userID int, startDate, endDate string) (int, int, error)
+	GetUserBreakAnalytics(userID int, startDate, endDate string) (model.KPIBreakAnalyticsRow, error)
 	GetUserPresenceRate(userID int, startDate, endDate string) (float64, float64, float64, error)
 	GetUserAverageTimePerShift(userID int, startDate, endDate string) (float64, int, int, error)
+	GetUserWeeklyRateDistribution(userID int) (WeeklyRateModel.WeeklyRateDistribution, error)
+	GetUserDailyWorkedMinutes(userID int, startDate, endDate string) (map[string]int, error)
+	GetUserTimeSeries(userID int, startDate, endDate string, bucket string, firstDayOfWeek int) ([]model.KPITimeSeriesRow, error)
+	GetUserWorkingDays(userID int, startDate, endDate string, lateEveningHour int) ([]model.KPIWorkingDayRow, error)
+	GetUserTimesheetDays(userID int, startDate, endDate string) ([]model.KPITimesheetDayRow, error)
+	GetUsersQueryRows(userUUIDs []string, startDate, endDate string) ([]model.KPIQueryUserRow, error)
+	GetTeamsMembers(teamUUIDs []string) ([]model.KPIQueryTeamMemberRow, error)
+	GetOrganizationHeadcount() (model.KPIOrganizationHeadcount, error)
+	GetOrganizationTotals(startDate, endDate string) (model.KPIOrganizationTotals, error)
+	RebuildDailyTotals(startDate, endDate string) (int, error)
 }
 
 type kpiRepository struct {
@@ -27,25 +43,11 @@ func (repo *kpiRepository) GetWeeklyRatesByUserIDAndDateRange(userID int, startD
 	}
 	err := repo.db.Raw(
 		`SELECT
-			SUM(ws.duration_minutes) AS total_duration_minutes
-		FROM users AS u
-		INNER JOIN (
-			SELECT
-				user_id,
-				clock_in,
-				duration_minutes
-			FROM work_session_active
-			UNION ALL
-			SELECT
-				user_id,
-				clock_in,
-				duration_minutes
-			FROM work_session_archived
-		) AS ws(user_id, clock_in, duration_minutes)
-			ON u.id = ws.user_id
+			COALESCE(SUM(worked_minutes), 0) AS total_duration_minutes
+		FROM work_session_daily_totals
 		WHERE
-			u.id = ?
-			AND ws.clock_in BETWEEN ? AND ?`,
+			user_id = ?
+			AND day BETWEEN ?::timestamp::date AND ?::timestamp::date`,
 		userID, startDate, endDate,
 	).Scan(&weeklyRates).Error
 
@@ -72,13 +74,10 @@ func (repo *kpiRepository) GetUserPresenceRate(userID int, startDate, endDate st
 	// Minutes to hours for weekly rate
 	var totalMinutes int
 	err = repo.db.Raw(`
-		SELECT COALESCE(SUM(duration_minutes), 0)
-		FROM (
-			SELECT duration_minutes FROM work_session_active WHERE user_id = ? AND clock_in BETWEEN ? AND ?
-			UNION ALL
-			SELECT duration_minutes FROM work_session_archived WHERE user_id = ? AND clock_in BETWEEN ? AND ?
-		) AS all_sessions
-	`, userID, startDate, endDate, userID, startDate, endDate).Scan(&totalMinutes).Error
+		SELECT COALESCE(SUM(worked_minutes), 0)
+		FROM work_session_daily_totals
+		WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date
+	`, userID, startDate, endDate).Scan(&totalMinutes).Error
 	if err != nil {
 		return 0, 0, 0, err
 	}
@@ -99,55 +98,90 @@ func (repo *kpiRepository) GetUserPresenceRate(userID int, startDate, endDate st
 	return presenceRate, weeklyRateDB, doneHours, nil
 }
 
+// GetUserAverageBreakTime returns the break minutes of the user per worked day, 0 when no day was worked.
 func (repo *kpiRepository) GetUserAverageBreakTime(userID int, startDate, endDate string) (float64, error) {
-	var totalBreakMinutes float64
-
-	err := repo.db.Raw(`
-        SELECT COALESCE(SUM(breaks_duration_minutes), 0) AS total_break
-        FROM (
-            SELECT breaks_duration_minutes, clock_in
-            FROM work_session_active
-            WHERE user_id = ? AND clock_in BETWEEN ? AND ?
-
-            UNION ALL
-
-            SELECT breaks_duration_minutes, clock_in
-            FROM work_session_archived
-            WHERE user_id = ? AND clock_in BETWEEN ? AND ?
-        ) AS all_sessions;
-    `, userID, startDate, endDate, userID, startDate, endDate).Scan(&totalBreakMinutes).Error
-
+	totalBreak, workedDays, err := repo.GetUserBreakTotals(userID, startDate, endDate)
 	if err != nil {
 		return 0, err
 	}
 
-	days := 5
+	if workedDays == 0 {
+		return 0, nil
+	}
 
-	return totalBreakMinutes / float64(days), nil
+	return float64(totalBreak) / float64(workedDays), nil
 }
 
-func (repo *kpiRepository) GetUserAverageTimePerShift(userID int, startDate, endDate string) (float64, int, int, error) {
+// GetUserBreakTotals returns the break minutes of the user and the number of days worked on.
+func (repo *kpiRepository) GetUserBreakTotals(userID int, startDate, endDate string) (int, int, error) {
 	var result struct {
-		TotalMinutes int `gorm:"column:total_minutes"`
-		TotalShifts  int `gorm:"column:total_shifts"`
+		TotalBreak int `gorm:"column:total_break"`
+		WorkedDays int `gorm:"column:worked_days"`
 	}
 
 	err := repo.db.Raw(`
-		SELECT 
-			COALESCE(SUM(duration_minutes), 0) AS total_minutes,
-			COUNT(*) AS total_shifts
+        SELECT
+            COALESCE(SUM(break_minutes), 0) AS total_break,
+            COUNT(*) AS worked_days
+        FROM work_session_daily_totals
+        WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date;
+    `, userID, startDate, endDate).Scan(&result).Error
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return result.TotalBreak, result.WorkedDays, nil
+}
+
+// GetUserBreakAnalytics returns the break statistics of the work sessions of the user.
+// Percentiles are computed over the break minutes of each session, so the sessions are read instead of the daily totals,
+// with the same rules: every session counts, running ones included, on the day of its clock-in, the end day being included.
+func (repo *kpiRepository) GetUserBreakAnalytics(userID int, startDate, endDate string) (model.KPIBreakAnalyticsRow, error) {
+	var row model.KPIBreakAnalyticsRow
+
+	err := repo.db.Raw(`
+		SELECT
+			COUNT(*) AS total_sessions,
+			COUNT(DISTINCT clock_in::date) AS worked_days,
+			COALESCE(SUM(breaks_minutes), 0) AS total_break_time,
+			COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY breaks_minutes), 0) AS median_break_time,
+			COALESCE(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY breaks_minutes), 0) AS p90_break_time,
+			COUNT(*) FILTER (WHERE breaks_minutes = 0) AS sessions_without_break,
+			COUNT(breaks_count) AS counted_sessions,
+			COALESCE(SUM(breaks_count), 0) AS total_breaks
 		FROM (
-			SELECT duration_minutes, clock_in
+			SELECT clock_in, COALESCE(breaks_duration_minutes, 0) AS breaks_minutes, breaks_count
 			FROM work_session_active
-			WHERE user_id = ? AND clock_in BETWEEN ? AND ?
+			WHERE user_id = ? AND clock_in >= ?::timestamp::date AND clock_in < ?::timestamp::date + 1
 
 			UNION ALL
 
-			SELECT duration_minutes, clock_in
+			SELECT clock_in, COALESCE(breaks_duration_minutes, 0) AS breaks_minutes, breaks_count
 			FROM work_session_archived
-			WHERE user_id = ? AND clock_in BETWEEN ? AND ?
-		) AS all_sessions;
-	`, userID, startDate, endDate, userID, startDate, endDate).Scan(&result).Error
+			WHERE user_id = ? AND clock_in >= ?::timestamp::date AND clock_in < ?::timestamp::date + 1
+		) AS all_sessions
+	`, userID, startDate, endDate, userID, startDate, endDate).Scan(&row).Error
+	if err != nil {
+		return model.KPIBreakAnalyticsRow{}, fmt.Errorf("failed to fetch break analytics: %w", err)
+	}
+
+	return row, nil
+}
+
+func (repo *kpiRepository) GetUserAverageTimePerShift(userID int, startDate, endDate string) (float64, int, int, error) {
+	var result struct {
+		TotalMinutes int `gorm:"column:total_minutes"`
+		TotalShifts  int `gorm:"column:total_shifts"`
+	}
+
+	err := repo.db.Raw(`
+		SELECT
+			COALESCE(SUM(worked_minutes), 0) AS total_minutes,
+			COALESCE(SUM(sessions_count), 0) AS total_shifts
+		FROM work_session_daily_totals
+		WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date;
+	`, userID, startDate, endDate).Scan(&result).Error
 
 	if err != nil {
 		return 0, 0, 0, err
@@ -163,3 +197,365 @@ func (repo *kpiRepository) GetUserAverageTimePerShift(userID int, startDate, end
 
 	return averageTimePerShift, result.TotalShifts, result.TotalMinutes, nil
 }
+
+// GetUserWeeklyRateDistribution returns the expected hours per weekday of the user's weekly rate,
+// falling back to 40 hours from monday to friday when no weekly rate is assigned.
+func (repo *kpiRepository) GetUserWeeklyRateDistribution(userID int) (WeeklyRateModel.WeeklyRateDistribution, error) {
+	var result struct {
+		HasWeeklyRate bool `gorm:"column:has_weekly_rate"`
+		WeeklyRateModel.WeeklyRateDistribution
+	}
+
+	err := repo.db.Raw(`
+		SELECT
+			wr.id IS NOT NULL AS has_weekly_rate,
+			COALESCE(wr.monday_hours, 0) AS monday_hours,
+			COALESCE(wr.tuesday_hours, 0) AS tuesday_hours,
+			COALESCE(wr.wednesday_hours, 0) AS wednesday_hours,
+			COALESCE(wr.thursday_hours, 0) AS thursday_hours,
+			COALESCE(wr.friday_hours, 0) AS friday_hours,
+			COALESCE(wr.saturday_hours, 0) AS saturday_hours,
+			COALESCE(wr.sunday_hours, 0) AS sunday_hours
+		FROM users u
+		LEFT JOIN weekly_rate wr ON wr.id = u.weekly_rate_id
+		WHERE u.id = ?
+	`, userID).Scan(&result).Error
+	if err != nil {
+		return WeeklyRateModel.WeeklyRateDistribution{}, err
+	}
+
+	if !result.HasWeeklyRate {
+		return WeeklyRateModel.DefaultDistribution(40), nil
+	}
+
+	return result.WeeklyRateDistribution, nil
+}
+
+// GetUserDailyWorkedMinutes returns the worked minutes of the user indexed by day (YYYY-MM-DD).
+// Days without any work session are not part of the result.
+func (repo *kpiRepository) GetUserDailyWorkedMinutes(userID int, startDate, endDate string) (map[string]int, error) {
+	var rows []struct {
+		Day          string `gorm:"column:day"`
+		TotalMinutes int    `gorm:"column:total_minutes"`
+	}
+
+	err := repo.db.Raw(`
+		SELECT
+			TO_CHAR(day, 'YYYY-MM-DD') AS day,
+			worked_minutes AS total_minutes
+		FROM work_session_daily_totals
+		WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date
+		ORDER BY day
+	`, userID, startDate, endDate).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dailyMinutes := make(map[string]int, len(rows))
+	for _, row := range rows {
+		dailyMinutes[row.Day] = row.TotalMinutes
+	}
+
+	return dailyMinutes, nil
+}
+
+// GetUserWorkingDays returns the worked and break minutes of the user for each day worked on, ordered by day.
+// A day is worked late in the evening when its last session ended from lateEveningHour or after midnight.
+func (repo *kpiRepository) GetUserWorkingDays(userID int, startDate, endDate string, lateEveningHour int) ([]model.KPIWorkingDayRow, error) {
+	var rows []model.KPIWorkingDayRow
+
+	err := repo.db.Raw(`
+		SELECT
+			TO_CHAR(day, 'YYYY-MM-DD') AS day,
+			worked_minutes AS total_minutes,
+			break_minutes,
+			COALESCE(
+				EXTRACT(HOUR FROM last_clock_out) >= ? OR last_clock_out::date > day,
+				FALSE
+			) AS late_evening
+		FROM work_session_daily_totals
+		WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date
+		ORDER BY day
+	`, lateEveningHour, userID, startDate, endDate).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch working days: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetUserTimesheetDays returns the first clock-in, last clock-out, break and worked minutes of the user
+// for each day worked on, ordered by day. The clock-out is empty while the last session of the day is running.
+func (repo *kpiRepository) GetUserTimesheetDays(userID int, startDate, endDate string) ([]model.KPITimesheetDayRow, error) {
+	var rows []model.KPITimesheetDayRow
+
+	err := repo.db.Raw(`
+		SELECT
+			TO_CHAR(day, 'YYYY-MM-DD') AS day,
+			TO_CHAR(first_clock_in, 'HH24:MI') AS clock_in,
+			COALESCE(TO_CHAR(last_clock_out, 'HH24:MI'), '') AS clock_out,
+			break_minutes,
+			worked_minutes,
+			sessions_count
+		FROM work_session_daily_totals
+		WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date
+		ORDER BY day
+	`, userID, startDate, endDate).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch timesheet days: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetUsersQueryRows returns the totals of every given user over the date range in a single query.
+// The expected hours are summed day by day from the weekly rate distribution of the user, or 8 hours from monday
+// to friday without weekly rate, and within the contracts from the weekly rate of the contract when it has one.
+func (repo *kpiRepository) GetUsersQueryRows(userUUIDs []string, startDate, endDate string) ([]model.KPIQueryUserRow, error) {
+	var rows []model.KPIQueryUserRow
+
+	userHours := weekdayHours("s", "d.day")
+	contractHours := weekdayHours("cwr", "d.day")
+
+	query := fmt.Sprintf(`
+		WITH selected AS (
+			SELECT
+				u.id AS user_id,
+				u.uuid AS user_uuid,
+				COALESCE(u.first_name, '') AS first_name,
+				COALESCE(u.last_name, '') AS last_name,
+				COALESCE(wr.amount, 40)::float8 AS weekly_rate,
+				CASE WHEN wr.id IS NULL THEN 8 ELSE wr.monday_hours END AS monday_hours,
+				CASE WHEN wr.id IS NULL THEN 8 ELSE wr.tuesday_hours END AS tuesday_hours,
+				CASE WHEN wr.id IS NULL THEN 8 ELSE wr.wednesday_hours END AS wednesday_hours,
+				CASE WHEN wr.id IS NULL THEN 8 ELSE wr.thursday_hours END AS thursday_hours,
+				CASE WHEN wr.id IS NULL THEN 8 ELSE wr.friday_hours END AS friday_hours,
+				COALESCE(wr.saturday_hours, 0) AS saturday_hours,
+				COALESCE(wr.sunday_hours, 0) AS sunday_hours,
+				EXISTS (SELECT 1 FROM contracts c WHERE c.user_id = u.id) AS managed
+			FROM users u
+			LEFT JOIN weekly_rate wr ON wr.id = u.weekly_rate_id
+			WHERE u.uuid IN ?
+		),
+		totals AS (
+			SELECT
+				user_id,
+				SUM(worked_minutes) AS total_minutes,
+				SUM(sessions_count) AS total_shifts,
+				SUM(break_minutes) AS break_minutes,
+				COUNT(*) AS worked_days
+			FROM work_session_daily_totals
+			WHERE user_id IN (SELECT user_id FROM selected) AND day BETWEEN ?::timestamp::date AND ?::timestamp::date
+			GROUP BY user_id
+		),
+		expected AS (
+			SELECT
+				s.user_id,
+				SUM(%[1]s)::float8 AS full_expected,
+				SUM(CASE
+					WHEN c.id IS NULL THEN 0
+					WHEN cwr.id IS NULL THEN %[1]s
+					ELSE %[2]s
+				END)::float8 AS contract_expected
+			FROM selected s
+			CROSS JOIN generate_series(?::timestamp::date, ?::timestamp::date, INTERVAL '1 day') AS d(day)
+			LEFT JOIN contracts c ON c.user_id = s.user_id
+				AND c.start_date <= d.day::date
+				AND (c.end_date IS NULL OR c.end_date >= d.day::date)
+			LEFT JOIN weekly_rate cwr ON cwr.id = c.weekly_rate_id
+			GROUP BY s.user_id
+		)
+		SELECT
+			s.user_id,
+			s.user_uuid,
+			s.first_name,
+			s.last_name,
+			s.weekly_rate,
+			s.managed,
+			COALESCE(t.total_minutes, 0) AS total_minutes,
+			COALESCE(t.total_shifts, 0) AS total_shifts,
+			COALESCE(t.break_minutes, 0) AS break_minutes,
+			COALESCE(t.worked_days, 0) AS worked_days,
+			COALESCE(e.full_expected, 0) AS full_expected,
+			COALESCE(e.contract_expected, 0) AS contract_expected
+		FROM selected s
+		LEFT JOIN totals t ON t.user_id = s.user_id
+		LEFT JOIN expected e ON e.user_id = s.user_id
+	`, userHours, contractHours)
+
+	err := repo.db.Raw(query, userUUIDs, startDate, endDate, startDate, endDate).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users KPIs: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetTeamsMembers returns the members of every given team in a single query, a team without members being returned once with no user.
+func (repo *kpiRepository) GetTeamsMembers(teamUUIDs []string) ([]model.KPIQueryTeamMemberRow, error) {
+	var rows []model.KPIQueryTeamMemberRow
+
+	err := repo.db.Raw(`
+		SELECT
+			t.uuid AS team_uuid,
+			t.name AS team_name,
+			u.uuid AS user_uuid
+		FROM teams t
+		LEFT JOIN teams_members tm ON tm.team_id = t.id
+		LEFT JOIN users u ON u.id = tm.user_id
+		WHERE t.uuid IN ?
+		ORDER BY t.name, u.uuid
+	`, teamUUIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch teams members: %w", err)
+	}
+
+	return rows, nil
+}
+
+// weekdayHours returns the SQL expression of the expected hours on the day from the distribution columns of the given table.
+func weekdayHours(table string, day string) string {
+	return fmt.Sprintf(`CASE EXTRACT(ISODOW FROM %[2]s)
+					WHEN 1 THEN %[1]s.monday_hours
+					WHEN 2 THEN %[1]s.tuesday_hours
+					WHEN 3 THEN %[1]s.wednesday_hours
+					WHEN 4 THEN %[1]s.thursday_hours
+					WHEN 5 THEN %[1]s.friday_hours
+					WHEN 6 THEN %[1]s.saturday_hours
+					ELSE %[1]s.sunday_hours
+				END`, table, day)
+}
+
+// GetUserTimeSeries returns the worked time of the user grouped by bucket ("day", "week" or "month") in a single query.
+// Every bucket of the date range is returned, buckets without any work session being filled with zeros.
+// Weeks start on firstDayOfWeek (ISO numbering, 1 for monday to 7 for sunday, 0 is also accepted for sunday).
+func (repo *kpiRepository) GetUserTimeSeries(userID int, startDate, endDate string, bucket string, firstDayOfWeek int) ([]model.KPITimeSeriesRow, error) {
+	var interval string
+	switch bucket {
+	case "day":
+		interval = "1 day"
+	case "week":
+		interval = "7 days"
+	case "month":
+		interval = "1 month"
+	default:
+		return nil, fmt.Errorf("invalid bucket: %s", bucket)
+	}
+
+	// the same expression maps the bounds of the range and every session to the start of its bucket
+	bucketStart := func(column string) string {
+		switch bucket {
+		case "week":
+			return fmt.Sprintf("((%[1]s)::date - ((EXTRACT(ISODOW FROM %[1]s)::int - %[2]d + 7) %% 7))", column, firstDayOfWeek%7)
+		case "month":
+			return fmt.Sprintf("DATE_TRUNC('month', %s)::date", column)
+		default:
+			return fmt.Sprintf("(%s)::date", column)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		WITH days AS (
+			SELECT day, worked_minutes, break_minutes, sessions_count
+			FROM work_session_daily_totals
+			WHERE user_id = ? AND day BETWEEN ?::timestamp::date AND ?::timestamp::date
+		),
+		buckets AS (
+			SELECT GENERATE_SERIES(%s, %s, INTERVAL '%s')::date AS bucket_start
+		)
+		SELECT
+			TO_CHAR(b.bucket_start, 'YYYY-MM-DD') AS bucket_start,
+			COALESCE(SUM(d.worked_minutes), 0) AS total_minutes,
+			COALESCE(SUM(d.break_minutes), 0) AS break_minutes,
+			COALESCE(SUM(d.sessions_count), 0) AS total_shifts,
+			COUNT(d.day) AS worked_days
+		FROM buckets b
+		LEFT JOIN days d ON %s = b.bucket_start
+		GROUP BY b.bucket_start
+		ORDER BY b.bucket_start
+	`, bucketStart("?::timestamp"), bucketStart("?::timestamp"), interval, bucketStart("d.day"))
+
+	// the week expression uses its column twice
+	boundArgs := []any{startDate, endDate}
+	if bucket == "week" {
+		boundArgs = []any{startDate, startDate, endDate, endDate}
+	}
+
+	args := []any{userID, startDate, endDate}
+	args = append(args, boundArgs...)
+
+	var rows []model.KPITimeSeriesRow
+	if err := repo.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch time series: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetOrganizationHeadcount counts the users that are not disabled by the status of their latest active work session.
+func (repo *kpiRepository) GetOrganizationHeadcount() (model.KPIOrganizationHeadcount, error) {
+	var headcount model.KPIOrganizationHeadcount
+	err := repo.db.Raw(`
+		SELECT
+			COUNT(*) AS total_users,
+			COUNT(*) FILTER (WHERE ws.status = 'active') AS clocked_in,
+			COUNT(*) FILTER (WHERE ws.status = 'paused') AS on_break,
+			COUNT(*) FILTER (WHERE ws.status IS NULL OR ws.status = 'completed') AS clocked_out
+		FROM users u
+		LEFT JOIN LATERAL (
+			SELECT
+				wsa.status
+			FROM work_session_active wsa
+			WHERE wsa.user_id = u.id
+			ORDER BY wsa.created_at DESC
+			LIMIT 1
+		) ws ON TRUE
+		WHERE u.status <> 'disabled'
+	`).Scan(&headcount).Error
+	if err != nil {
+		return model.KPIOrganizationHeadcount{}, fmt.Errorf("failed to fetch organization headcount: %w", err)
+	}
+
+	return headcount, nil
+}
+
+// GetOrganizationTotals sums the daily totals of every user within the date range.
+func (repo *kpiRepository) GetOrganizationTotals(startDate, endDate string) (model.KPIOrganizationTotals, error) {
+	var totals model.KPIOrganizationTotals
+	err := repo.db.Raw(`
+		SELECT
+			COALESCE(SUM(worked_minutes), 0) AS total_time,
+			COALESCE(SUM(break_minutes), 0) AS total_break_time,
+			COALESCE(SUM(sessions_count), 0) AS total_shifts,
+			COUNT(DISTINCT user_id) AS working_users
+		FROM work_session_daily_totals
+		WHERE day BETWEEN ?::timestamp::date AND ?::timestamp::date
+	`, startDate, endDate).Scan(&totals).Error
+	if err != nil {
+		return model.KPIOrganizationTotals{}, fmt.Errorf("failed to fetch organization totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// RebuildDailyTotals recomputes from the work sessions the daily totals of the days between the two dates (YYYY-MM-DD), both included.
+// An empty date leaves the range open on its side. Returns the number of days written.
+func (repo *kpiRepository) RebuildDailyTotals(startDate, endDate string) (int, error) {
+	bound := func(date string) any {
+		if date == "" {
+			return nil
+		}
+		return date
+	}
+
+	var written int
+	err := repo.db.Raw(
+		"SELECT rebuild_work_session_daily_totals(?::date, ?::date)",
+		bound(startDate), bound(endDate),
+	).Scan(&written).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild daily totals: %w", err)
+	}
+
+	return written, nil
+}
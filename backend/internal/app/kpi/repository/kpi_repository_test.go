@@ -1,6 +1,7 @@
 package repository_test
 
 import (
+	"app/internal/app/kpi/model"
 	"app/internal/app/kpi/repository"
 	"context"
 	"fmt"
@@ -100,6 +101,13 @@ func setupKPITestDB(t *testing.T) *gorm.DB {
 			uuid VARCHAR(36) NOT NULL UNIQUE,
 			rate_name VARCHAR(255) NOT NULL,
 			amount SMALLINT NOT NULL,
+			monday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			tuesday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			wednesday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			thursday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			friday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			saturday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+			sunday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
@@ -115,6 +123,8 @@ func setupKPITestDB(t *testing.T) *gorm.DB {
 			weekly_rate_id INT NULL REFERENCES weekly_rate (id),
 			dashboard_layout JSON DEFAULT NULL,
 			first_day_of_week INT DEFAULT 1,
+			first_name VARCHAR(100),
+			last_name VARCHAR(100),
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
@@ -128,6 +138,7 @@ func setupKPITestDB(t *testing.T) *gorm.DB {
 			duration_minutes INT,
 			status work_session_status DEFAULT 'active',
 			breaks_duration_minutes INTEGER DEFAULT 0,
+			breaks_count INTEGER DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
@@ -141,10 +152,142 @@ func setupKPITestDB(t *testing.T) *gorm.DB {
 			duration_minutes INT,
 			status work_session_status DEFAULT 'active',
 			breaks_duration_minutes INTEGER DEFAULT 0,
+			breaks_count INTEGER DEFAULT 0,
 			archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
+
+		CREATE TYPE contract_type AS ENUM('permanent', 'fixed_term', 'intern', 'interim');
+
+		CREATE TABLE contracts (
+			id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			uuid VARCHAR(36) NOT NULL UNIQUE,
+			user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			contract_type contract_type NOT NULL,
+			start_date DATE NOT NULL,
+			end_date DATE,
+			weekly_rate_id INT REFERENCES weekly_rate (id) ON DELETE SET NULL,
+			hourly_cost NUMERIC(10, 2),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE teams (
+			id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			uuid VARCHAR(36) NOT NULL UNIQUE,
+			name VARCHAR(100) NOT NULL,
+			description TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE teams_members (
+			id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			uuid VARCHAR(36) NOT NULL UNIQUE,
+			user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			team_id INT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+			is_manager BOOLEAN DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE work_session_daily_totals (
+			user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			day DATE NOT NULL,
+			worked_minutes INT NOT NULL DEFAULT 0,
+			break_minutes INT NOT NULL DEFAULT 0,
+			sessions_count INT NOT NULL DEFAULT 0,
+			first_clock_in TIMESTAMP NOT NULL,
+			last_clock_out TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, day)
+		);
+
+		CREATE INDEX idx_work_session_daily_totals_day ON work_session_daily_totals (day);
+
+		CREATE FUNCTION refresh_work_session_daily_total(p_user_id INT, p_day DATE) RETURNS VOID AS $$
+		BEGIN
+			PERFORM pg_advisory_xact_lock(p_user_id, p_day - DATE '2000-01-01');
+
+			DELETE FROM work_session_daily_totals WHERE user_id = p_user_id AND day = p_day;
+
+			INSERT INTO work_session_daily_totals (user_id, day, worked_minutes, break_minutes, sessions_count, first_clock_in, last_clock_out)
+			SELECT
+				p_user_id,
+				p_day,
+				COALESCE(SUM(duration_minutes), 0),
+				COALESCE(SUM(breaks_duration_minutes), 0),
+				COUNT(*),
+				MIN(clock_in),
+				MAX(clock_out)
+			FROM (
+				SELECT clock_in, clock_out, duration_minutes, breaks_duration_minutes
+				FROM work_session_active
+				WHERE user_id = p_user_id AND clock_in >= p_day AND clock_in < p_day + 1
+				UNION ALL
+				SELECT clock_in, clock_out, duration_minutes, breaks_duration_minutes
+				FROM work_session_archived
+				WHERE user_id = p_user_id AND clock_in >= p_day AND clock_in < p_day + 1
+			) AS day_sessions
+			HAVING COUNT(*) > 0;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE FUNCTION work_session_daily_totals_trigger() RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP IN ('UPDATE', 'DELETE') THEN
+				PERFORM refresh_work_session_daily_total(OLD.user_id, OLD.clock_in::date);
+			END IF;
+
+			IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND (NEW.user_id <> OLD.user_id OR NEW.clock_in::date <> OLD.clock_in::date)) THEN
+				PERFORM refresh_work_session_daily_total(NEW.user_id, NEW.clock_in::date);
+			END IF;
+
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+
+		CREATE TRIGGER trg_work_session_active_daily_totals
+		AFTER INSERT OR DELETE OR UPDATE OF user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes
+		ON work_session_active
+		FOR EACH ROW EXECUTE FUNCTION work_session_daily_totals_trigger();
+
+		CREATE TRIGGER trg_work_session_archived_daily_totals
+		AFTER INSERT OR DELETE OR UPDATE OF user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes
+		ON work_session_archived
+		FOR EACH ROW EXECUTE FUNCTION work_session_daily_totals_trigger();
+
+		CREATE FUNCTION rebuild_work_session_daily_totals(p_start DATE, p_end DATE) RETURNS INT AS $$
+		DECLARE
+			written INT;
+		BEGIN
+			LOCK TABLE work_session_daily_totals IN EXCLUSIVE MODE;
+
+			DELETE FROM work_session_daily_totals
+			WHERE (p_start IS NULL OR day >= p_start) AND (p_end IS NULL OR day <= p_end);
+
+			INSERT INTO work_session_daily_totals (user_id, day, worked_minutes, break_minutes, sessions_count, first_clock_in, last_clock_out)
+			SELECT
+				user_id,
+				clock_in::date,
+				COALESCE(SUM(duration_minutes), 0),
+				COALESCE(SUM(breaks_duration_minutes), 0),
+				COUNT(*),
+				MIN(clock_in),
+				MAX(clock_out)
+			FROM (
+				SELECT user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes FROM work_session_active
+				UNION ALL
+				SELECT user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes FROM work_session_archived
+			) AS all_sessions
+			WHERE (p_start IS NULL OR clock_in >= p_start) AND (p_end IS NULL OR clock_in < p_end + 1)
+			GROUP BY user_id, clock_in::date;
+
+			GET DIAGNOSTICS written = ROW_COUNT;
+			RETURN written;
+		END;
+		$$ LANGUAGE plpgsql;
 	`
 
 	if err := db.Exec(schema).Error; err != nil {
@@ -295,7 +438,12 @@ func TestGetUserAverageBreakTime(t *testing.T) {
 
 	averageBreakTime, err := repo.GetUserAverageBreakTime(userID, "2026-01-06 00:00:00", "2026-01-10 23:59:59")
 	assert.NoError(t, err)
-	assert.Equal(t, 20.0, averageBreakTime) // (30 + 45 + 25) / 5
+	assert.InDelta(t, 33.33, averageBreakTime, 0.01) // (30 + 45 + 25) / 3 worked days
+
+	totalBreak, workedDays, err := repo.GetUserBreakTotals(userID, "2026-01-06", "2026-01-10")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, totalBreak)
+	assert.Equal(t, 3, workedDays)
 }
 
 func TestGetUserAverageBreakTimeNoBreaks(t *testing.T) {
@@ -400,3 +548,510 @@ func TestGetUserAverageTimePerShiftMultipleWeeks(t *testing.T) {
 	assert.Equal(t, 4, totalShifts)
 	assert.Equal(t, 1800, totalTime)
 }
+
+func TestGetUserWeeklyRateDistribution(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	wrID := insertWeeklyRate(t, db, "wr-1", "Four days", 32)
+	db.Exec(`
+		UPDATE weekly_rate
+		SET monday_hours = 8, tuesday_hours = 8, wednesday_hours = 8, thursday_hours = 8
+		WHERE id = ?
+	`, wrID)
+	userID := insertUser(t, db, "user-uuid-1", "testuser", &wrID)
+
+	distribution, err := repo.GetUserWeeklyRateDistribution(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 32.0, distribution.Total())
+	assert.Equal(t, 0.0, distribution.FridayHours)
+}
+
+func TestGetUserWeeklyRateDistributionWithoutWeeklyRate(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-2", "testuser2", nil)
+
+	distribution, err := repo.GetUserWeeklyRateDistribution(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 40.0, distribution.Total())
+	assert.Equal(t, 8.0, distribution.FridayHours)
+}
+
+func TestGetUserDailyWorkedMinutes(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, duration_minutes, status)
+		VALUES ('ws-a-1', ?, '2026-01-06 09:00:00', 240, 'completed'), ('ws-a-2', ?, '2026-01-06 14:00:00', 200, 'completed')
+	`, userID, userID)
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, duration_minutes, status)
+		VALUES ('ws-ar-1', ?, '2026-01-08 09:00:00', 500, 'completed')
+	`, userID)
+
+	dailyMinutes, err := repo.GetUserDailyWorkedMinutes(userID, "2026-01-06 00:00:00", "2026-01-08 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"2026-01-06": 440, "2026-01-08": 500}, dailyMinutes)
+}
+
+func TestGetUserTimeSeriesByDay(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, duration_minutes, breaks_duration_minutes, status)
+		VALUES ('ws-a-1', ?, '2026-01-05 09:00:00', 240, 15, 'completed'), ('ws-a-2', ?, '2026-01-05 14:00:00', 200, 10, 'completed')
+	`, userID, userID)
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, duration_minutes, breaks_duration_minutes, status)
+		VALUES ('ws-ar-1', ?, '2026-01-07 09:00:00', 480, 30, 'completed')
+	`, userID)
+
+	rows, err := repo.GetUserTimeSeries(userID, "2026-01-05 00:00:00", "2026-01-07 23:59:59", "day", 1)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.Equal(t, "2026-01-05", rows[0].BucketStart)
+	assert.Equal(t, 440, rows[0].TotalMinutes)
+	assert.Equal(t, 25, rows[0].BreakMinutes)
+	assert.Equal(t, 2, rows[0].TotalShifts)
+	assert.Equal(t, 1, rows[0].WorkedDays)
+
+	// gaps are filled with zeros
+	assert.Equal(t, "2026-01-06", rows[1].BucketStart)
+	assert.Equal(t, 0, rows[1].TotalMinutes)
+	assert.Equal(t, 0, rows[1].TotalShifts)
+
+	assert.Equal(t, "2026-01-07", rows[2].BucketStart)
+	assert.Equal(t, 480, rows[2].TotalMinutes)
+}
+
+func TestGetUserTimeSeriesByWeekAlignedOnFirstDayOfWeek(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	// 2026-01-04 is a sunday, 2026-01-10 a saturday and 2026-01-11 a sunday
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, duration_minutes, status)
+		VALUES ('ws-a-1', ?, '2026-01-04 09:00:00', 100, 'completed'), ('ws-a-2', ?, '2026-01-10 09:00:00', 200, 'completed'), ('ws-a-3', ?, '2026-01-11 09:00:00', 300, 'completed')
+	`, userID, userID, userID)
+
+	// weeks starting on monday
+	rows, err := repo.GetUserTimeSeries(userID, "2026-01-04 00:00:00", "2026-01-11 23:59:59", "week", 1)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "2025-12-29", rows[0].BucketStart)
+	assert.Equal(t, 100, rows[0].TotalMinutes)
+	assert.Equal(t, "2026-01-05", rows[1].BucketStart)
+	assert.Equal(t, 500, rows[1].TotalMinutes)
+
+	// weeks starting on sunday
+	rows, err = repo.GetUserTimeSeries(userID, "2026-01-04 00:00:00", "2026-01-11 23:59:59", "week", 7)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "2026-01-04", rows[0].BucketStart)
+	assert.Equal(t, 300, rows[0].TotalMinutes)
+	assert.Equal(t, "2026-01-11", rows[1].BucketStart)
+	assert.Equal(t, 300, rows[1].TotalMinutes)
+}
+
+func TestGetUserTimeSeriesByMonth(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, duration_minutes, status)
+		VALUES ('ws-ar-1', ?, '2025-11-20 09:00:00', 120, 'completed'), ('ws-ar-2', ?, '2026-01-15 09:00:00', 240, 'completed')
+	`, userID, userID)
+
+	rows, err := repo.GetUserTimeSeries(userID, "2025-11-15 00:00:00", "2026-01-20 23:59:59", "month", 1)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "2025-11-01", rows[0].BucketStart)
+	assert.Equal(t, 120, rows[0].TotalMinutes)
+	assert.Equal(t, "2025-12-01", rows[1].BucketStart)
+	assert.Equal(t, 0, rows[1].TotalMinutes)
+	assert.Equal(t, "2026-01-01", rows[2].BucketStart)
+	assert.Equal(t, 240, rows[2].TotalMinutes)
+}
+
+func TestGetUserTimeSeriesInvalidBucket(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	_, err := repo.GetUserTimeSeries(1, "2026-01-01 00:00:00", "2026-01-31 23:59:59", "year", 1)
+	assert.Error(t, err)
+}
+
+func TestGetOrganizationHeadcount(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	activeID := insertUser(t, db, "user-uuid-1", "activeuser", nil)
+	pausedID := insertUser(t, db, "user-uuid-2", "pauseduser", nil)
+	completedID := insertUser(t, db, "user-uuid-3", "completeduser", nil)
+	insertUser(t, db, "user-uuid-4", "idleuser", nil)
+	disabledID := insertUser(t, db, "user-uuid-5", "disableduser", nil)
+	db.Exec("UPDATE users SET status = 'disabled' WHERE id = ?", disabledID)
+
+	// only the latest session of each user counts
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, status, created_at)
+		VALUES
+			('ws-a-1', ?, '2026-01-05 08:00:00', 'completed', '2026-01-05 08:00:00'),
+			('ws-a-2', ?, '2026-01-06 08:00:00', 'active', '2026-01-06 08:00:00'),
+			('ws-a-3', ?, '2026-01-06 08:00:00', 'paused', '2026-01-06 08:00:00'),
+			('ws-a-4', ?, '2026-01-06 08:00:00', 'completed', '2026-01-06 08:00:00'),
+			('ws-a-5', ?, '2026-01-06 08:00:00', 'active', '2026-01-06 08:00:00')
+	`, activeID, activeID, pausedID, completedID, disabledID)
+
+	headcount, err := repo.GetOrganizationHeadcount()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, headcount.TotalUsers)
+	assert.Equal(t, 1, headcount.ClockedIn)
+	assert.Equal(t, 1, headcount.OnBreak)
+	assert.Equal(t, 2, headcount.ClockedOut)
+}
+
+func TestGetOrganizationTotals(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	firstID := insertUser(t, db, "user-uuid-1", "firstuser", nil)
+	secondID := insertUser(t, db, "user-uuid-2", "seconduser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, duration_minutes, breaks_duration_minutes, status)
+		VALUES ('ws-a-1', ?, '2026-01-05 09:00:00', 240, 20, 'completed'), ('ws-a-2', ?, '2026-01-06 09:00:00', 300, 10, 'completed')
+	`, firstID, secondID)
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, duration_minutes, breaks_duration_minutes, status)
+		VALUES ('ws-ar-1', ?, '2026-01-07 09:00:00', 480, 30, 'completed'), ('ws-ar-2', ?, '2025-12-01 09:00:00', 480, 30, 'completed')
+	`, firstID, firstID)
+
+	totals, err := repo.GetOrganizationTotals("2026-01-05 00:00:00", "2026-01-11 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, 1020, totals.TotalTime)
+	assert.Equal(t, 60, totals.TotalBreakTime)
+	assert.Equal(t, 3, totals.TotalShifts)
+	assert.Equal(t, 2, totals.WorkingUsers)
+}
+
+func TestGetUserAverageBreakTimeNoSessions(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	averageBreakTime, err := repo.GetUserAverageBreakTime(userID, "2026-01-06 00:00:00", "2026-01-10 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, averageBreakTime)
+}
+
+func TestGetUserBreakAnalytics(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, duration_minutes, breaks_duration_minutes, breaks_count, status)
+		VALUES
+			('ws-a-1', ?, '2026-01-05 09:00:00', 240, 10, 1, 'completed'),
+			('ws-a-2', ?, '2026-01-05 14:00:00', 200, 0, 0, 'completed'),
+			('ws-a-3', ?, '2026-01-06 09:00:00', 480, 30, 2, 'completed'),
+			('ws-a-4', ?, '2026-01-07 09:00:00', 60, 0, 0, 'active')
+	`, userID, userID, userID, userID)
+	// archived before breaks were counted
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, duration_minutes, breaks_duration_minutes, breaks_count, status)
+		VALUES ('ws-ar-1', ?, '2026-01-02 09:00:00', 480, 40, NULL, 'completed')
+	`, userID)
+
+	// the running session counts, as it does in the daily totals
+	analytics, err := repo.GetUserBreakAnalytics(userID, "2026-01-01", "2026-01-07")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, analytics.TotalSessions)
+	assert.Equal(t, 4, analytics.WorkedDays)
+	assert.Equal(t, 80, analytics.TotalBreakTime)
+	assert.InDelta(t, 10.0, analytics.MedianBreakTime, 0.01)
+	assert.InDelta(t, 36.0, analytics.P90BreakTime, 0.01)
+	assert.Equal(t, 2, analytics.SessionsWithoutBreak)
+	assert.Equal(t, 4, analytics.CountedSessions)
+	assert.Equal(t, 3, analytics.TotalBreaks)
+
+	// the average break time per worked day agrees with the analytics
+	averageBreakTime, err := repo.GetUserAverageBreakTime(userID, "2026-01-01", "2026-01-07")
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(analytics.TotalBreakTime)/float64(analytics.WorkedDays), averageBreakTime, 0.01)
+
+	// the end day is included
+	analytics, err = repo.GetUserBreakAnalytics(userID, "2026-01-01", "2026-01-06")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, analytics.TotalSessions)
+	assert.Equal(t, 3, analytics.WorkedDays)
+}
+
+func TestGetUserWorkingDays(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES
+			('ws-a-1', ?, '2026-01-09 09:00:00', '2026-01-09 13:00:00', 240, 15, 'completed'),
+			('ws-a-2', ?, '2026-01-09 18:00:00', '2026-01-09 21:30:00', 210, 0, 'completed')
+	`, userID, userID)
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES
+			('ws-ar-1', ?, '2026-01-10 09:00:00', '2026-01-10 17:00:00', 480, 30, 'completed'),
+			('ws-ar-2', ?, '2026-01-11 20:00:00', '2026-01-12 01:00:00', 300, 0, 'completed')
+	`, userID, userID)
+
+	rows, err := repo.GetUserWorkingDays(userID, "2026-01-09 00:00:00", "2026-01-11 23:59:59", 21)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.Equal(t, "2026-01-09", rows[0].Day)
+	assert.Equal(t, 450, rows[0].TotalMinutes)
+	assert.Equal(t, 15, rows[0].BreakMinutes)
+	assert.True(t, rows[0].LateEvening)
+
+	assert.Equal(t, "2026-01-10", rows[1].Day)
+	assert.False(t, rows[1].LateEvening)
+
+	// ended after midnight
+	assert.Equal(t, "2026-01-11", rows[2].Day)
+	assert.True(t, rows[2].LateEvening)
+}
+
+func TestGetUserTimesheetDays(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES
+			('ws-ar-1', ?, '2026-01-09 08:30:00', '2026-01-09 12:00:00', 210, 10, 'completed'),
+			('ws-ar-2', ?, '2026-01-09 13:00:00', '2026-01-09 17:15:00', 255, 20, 'completed')
+	`, userID, userID)
+	// still running
+	db.Exec(`INSERT INTO work_session_active (uuid, user_id, clock_in, status) VALUES ('ws-a-1', ?, '2026-01-12 09:05:00', 'active')`, userID)
+
+	rows, err := repo.GetUserTimesheetDays(userID, "2026-01-01", "2026-01-31")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.KPITimesheetDayRow{
+		{Day: "2026-01-09", ClockIn: "08:30", ClockOut: "17:15", BreakMinutes: 30, WorkedMinutes: 465, SessionsCount: 2},
+		{Day: "2026-01-12", ClockIn: "09:05", ClockOut: "", BreakMinutes: 0, WorkedMinutes: 0, SessionsCount: 1},
+	}, rows)
+
+	rows, err = repo.GetUserTimesheetDays(userID, "2026-02-01", "2026-02-28")
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestGetUsersQueryRows(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	weeklyRateID := insertWeeklyRate(t, db, "wr-uuid-1", "35h", 35)
+	db.Exec(`
+		UPDATE weekly_rate
+		SET monday_hours = 7, tuesday_hours = 7, wednesday_hours = 7, thursday_hours = 7, friday_hours = 7
+		WHERE id = ?
+	`, weeklyRateID)
+
+	managedID := insertUser(t, db, "user-uuid-1", "manageduser", &weeklyRateID)
+	db.Exec(`UPDATE users SET first_name = 'Ada', last_name = 'Lovelace' WHERE id = ?`, managedID)
+	freeID := insertUser(t, db, "user-uuid-2", "freeuser", nil)
+	insertUser(t, db, "user-uuid-3", "otheruser", nil)
+
+	// the contract starts on wednesday, so only 3 of the 5 working days are expected
+	db.Exec(`
+		INSERT INTO contracts (uuid, user_id, contract_type, start_date)
+		VALUES ('contract-uuid-1', ?, 'permanent', '2026-01-07')
+	`, managedID)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES
+			('ws-a-1', ?, '2026-01-07 09:00:00', '2026-01-07 12:00:00', 180, 0, 'completed'),
+			('ws-a-2', ?, '2026-01-07 13:00:00', '2026-01-07 17:00:00', 240, 30, 'completed'),
+			('ws-a-3', ?, '2026-01-13 09:00:00', '2026-01-13 17:00:00', 480, 0, 'completed')
+	`, managedID, managedID, freeID)
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES ('ws-ar-1', ?, '2026-01-08 09:00:00', '2026-01-08 16:00:00', 420, 30, 'completed')
+	`, managedID)
+
+	rows, err := repo.GetUsersQueryRows([]string{"user-uuid-1", "user-uuid-2", "unknown-uuid"}, "2026-01-05 00:00:00", "2026-01-11 23:59:59")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	byUUID := map[string]model.KPIQueryUserRow{}
+	for _, row := range rows {
+		byUUID[row.UserUUID] = row
+	}
+
+	managed := byUUID["user-uuid-1"]
+	assert.Equal(t, "Ada", managed.FirstName)
+	assert.Equal(t, "Lovelace", managed.LastName)
+	assert.Equal(t, 35.0, managed.WeeklyRate)
+	assert.Equal(t, 840, managed.TotalMinutes)
+	assert.Equal(t, 3, managed.TotalShifts)
+	assert.Equal(t, 60, managed.BreakMinutes)
+	assert.Equal(t, 2, managed.WorkedDays)
+	assert.True(t, managed.Managed)
+	assert.Equal(t, 35.0, managed.FullExpected)
+	assert.Equal(t, 21.0, managed.ContractExpected)
+
+	// without weekly rate, 8 hours are expected from monday to friday and the session out of the range is ignored
+	free := byUUID["user-uuid-2"]
+	assert.Equal(t, 40.0, free.WeeklyRate)
+	assert.Equal(t, 0, free.TotalMinutes)
+	assert.Equal(t, 0, free.TotalShifts)
+	assert.False(t, free.Managed)
+	assert.Equal(t, 40.0, free.FullExpected)
+}
+
+func TestGetTeamsMembers(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	firstID := insertUser(t, db, "user-uuid-1", "firstuser", nil)
+	secondID := insertUser(t, db, "user-uuid-2", "seconduser", nil)
+
+	var teamID int
+	db.Raw(`INSERT INTO teams (uuid, name) VALUES ('team-uuid-1', 'Alpha') RETURNING id`).Scan(&teamID)
+	db.Exec(`INSERT INTO teams (uuid, name) VALUES ('team-uuid-2', 'Beta')`)
+	db.Exec(`
+		INSERT INTO teams_members (uuid, user_id, team_id, is_manager)
+		VALUES ('tm-uuid-1', ?, ?, true), ('tm-uuid-2', ?, ?, false)
+	`, firstID, teamID, secondID, teamID)
+
+	rows, err := repo.GetTeamsMembers([]string{"team-uuid-1", "team-uuid-2", "unknown-uuid"})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.Equal(t, "team-uuid-1", rows[0].TeamUUID)
+	assert.Equal(t, "Alpha", rows[0].TeamName)
+	assert.Equal(t, "user-uuid-1", *rows[0].UserUUID)
+	assert.Equal(t, "user-uuid-2", *rows[1].UserUUID)
+
+	// a team without members is returned once with no user
+	assert.Equal(t, "team-uuid-2", rows[2].TeamUUID)
+	assert.Nil(t, rows[2].UserUUID)
+}
+
+func TestDailyTotalsFollowSessionWrites(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	// clock-in then clock-out
+	db.Exec(`INSERT INTO work_session_active (uuid, user_id, clock_in, status) VALUES ('ws-a-1', ?, '2026-01-09 09:00:00', 'active')`, userID)
+	db.Exec(`
+		UPDATE work_session_active
+		SET clock_out = '2026-01-09 12:00:00', duration_minutes = 180, breaks_duration_minutes = 10, status = 'completed'
+		WHERE uuid = 'ws-a-1'
+	`)
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES ('ws-a-2', ?, '2026-01-09 13:00:00', '2026-01-09 17:00:00', 240, 20, 'completed')
+	`, userID)
+
+	daily, err := repo.GetUserDailyWorkedMinutes(userID, "2026-01-09 00:00:00", "2026-01-10 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"2026-01-09": 420}, daily)
+
+	averageTime, totalShifts, totalMinutes, err := repo.GetUserAverageTimePerShift(userID, "2026-01-09 00:00:00", "2026-01-10 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, 210.0, averageTime)
+	assert.Equal(t, 2, totalShifts)
+	assert.Equal(t, 420, totalMinutes)
+
+	// a correction moving the session to the next day updates both days
+	db.Exec(`
+		UPDATE work_session_active
+		SET clock_in = '2026-01-10 13:00:00', clock_out = '2026-01-10 17:30:00', duration_minutes = 270
+		WHERE uuid = 'ws-a-2'
+	`)
+
+	daily, err = repo.GetUserDailyWorkedMinutes(userID, "2026-01-09 00:00:00", "2026-01-10 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"2026-01-09": 180, "2026-01-10": 270}, daily)
+
+	// archiving a session keeps its day unchanged
+	db.Exec(`
+		WITH moved AS (DELETE FROM work_session_active WHERE uuid = 'ws-a-1' RETURNING *)
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		SELECT uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status FROM moved
+	`)
+
+	averageBreak, err := repo.GetUserAverageBreakTime(userID, "2026-01-09 00:00:00", "2026-01-09 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, averageBreak)
+
+	// deleting the last session of a day removes the day
+	db.Exec(`DELETE FROM work_session_active WHERE uuid = 'ws-a-2'`)
+
+	daily, err = repo.GetUserDailyWorkedMinutes(userID, "2026-01-09 00:00:00", "2026-01-10 23:59:59")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"2026-01-09": 180}, daily)
+}
+
+func TestRebuildDailyTotals(t *testing.T) {
+	db := setupKPITestDB(t)
+	repo := repository.NewKPIRepository(db)
+
+	userID := insertUser(t, db, "user-uuid-1", "testuser", nil)
+
+	// sessions written with the triggers disabled, as a bulk import would
+	db.Exec(`ALTER TABLE work_session_archived DISABLE TRIGGER trg_work_session_archived_daily_totals`)
+	db.Exec(`
+		INSERT INTO work_session_archived (uuid, user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes, status)
+		VALUES
+			('ws-ar-1', ?, '2026-01-05 09:00:00', '2026-01-05 17:00:00', 480, 30, 'completed'),
+			('ws-ar-2', ?, '2026-01-06 09:00:00', '2026-01-06 12:00:00', 180, 0, 'completed'),
+			('ws-ar-3', ?, '2026-01-06 13:00:00', '2026-01-06 22:00:00', 540, 15, 'completed')
+	`, userID, userID, userID)
+	db.Exec(`ALTER TABLE work_session_archived ENABLE TRIGGER trg_work_session_archived_daily_totals`)
+
+	daily, err := repo.GetUserDailyWorkedMinutes(userID, "2026-01-05 00:00:00", "2026-01-06 23:59:59")
+	assert.NoError(t, err)
+	assert.Empty(t, daily)
+
+	written, err := repo.RebuildDailyTotals("2026-01-06", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, written)
+
+	written, err = repo.RebuildDailyTotals("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	rows, err := repo.GetUserWorkingDays(userID, "2026-01-05 00:00:00", "2026-01-06 23:59:59", 21)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 480, rows[0].TotalMinutes)
+	assert.False(t, rows[0].LateEvening)
+	assert.Equal(t, 720, rows[1].TotalMinutes)
+	assert.Equal(t, 15, rows[1].BreakMinutes)
+	assert.True(t, rows[1].LateEvening)
+}
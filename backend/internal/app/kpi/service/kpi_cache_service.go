@@ -0,0 +1,199 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	CacheService "app/internal/app/cache/service"
+	"app/internal/app/kpi/model"
+)
+
+// cachedKPIService caches the results of the KPI service, tagged with the users and teams they were computed from.
+// The tags are invalidated by the database as soon as a session, a break, a contract, a weekly rate or a team of theirs changes.
+type cachedKPIService struct {
+	KPIService
+	Cache CacheService.CacheService
+}
+
+func NewCachedKPIService(kpiService KPIService, cache CacheService.CacheService) KPIService {
+	return &cachedKPIService{KPIService: kpiService, Cache: cache}
+}
+
+func (service *cachedKPIService) GetWorkSessionUserWeeklyTotal(startDate string, endDate string, userUUID string) (int, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("weekly-total", userUUID, startDate, endDate), userTags(userUUID),
+		func() (int, error) {
+			return service.KPIService.GetWorkSessionUserWeeklyTotal(startDate, endDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) GetWorkSessionTeamWeeklyTotal(startDate string, endDate string, teamUUID string) (model.KPIWorkSessionTeamWeeklyTotalResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		teamKey("weekly-total", teamUUID, startDate, endDate), teamTags(teamUUID),
+		func() (model.KPIWorkSessionTeamWeeklyTotalResponse, error) {
+			return service.KPIService.GetWorkSessionTeamWeeklyTotal(startDate, endDate, teamUUID)
+		})
+}
+
+func (service *cachedKPIService) GetPresenceRate(startDate string, endDate string, userUUID string) (model.KPIPresenceRateResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("presence-rate", userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPIPresenceRateResponse, error) {
+			return service.KPIService.GetPresenceRate(startDate, endDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) GetAverageBreakTime(startDate string, endDate string, userUUID string) (model.KPIAverageBreakTimeResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("average-break-time", userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPIAverageBreakTimeResponse, error) {
+			return service.KPIService.GetAverageBreakTime(startDate, endDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) GetAverageTimePerShift(startDate string, endDate string, userUUID string) (model.KPIAverageTimePerShiftResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("average-time-per-shift", userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPIAverageTimePerShiftResponse, error) {
+			return service.KPIService.GetAverageTimePerShift(startDate, endDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) GetDailyPresence(startDate string, endDate string, userUUID string) (model.KPIDailyPresenceResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("daily-presence", userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPIDailyPresenceResponse, error) {
+			return service.KPIService.GetDailyPresence(startDate, endDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) GetTimeSeries(startDate string, endDate string, userUUID string, bucket string) (model.KPITimeSeriesResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("time-series:"+bucket, userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPITimeSeriesResponse, error) {
+			return service.KPIService.GetTimeSeries(startDate, endDate, userUUID, bucket)
+		})
+}
+
+func (service *cachedKPIService) GetTeamPresenceRate(startDate string, endDate string, teamUUID string) (model.KPITeamPresenceRateResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		teamKey("presence-rate", teamUUID, startDate, endDate), teamTags(teamUUID),
+		func() (model.KPITeamPresenceRateResponse, error) {
+			return service.KPIService.GetTeamPresenceRate(startDate, endDate, teamUUID)
+		})
+}
+
+func (service *cachedKPIService) GetTeamAverageBreakTime(startDate string, endDate string, teamUUID string) (model.KPITeamAverageBreakTimeResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		teamKey("average-break-time", teamUUID, startDate, endDate), teamTags(teamUUID),
+		func() (model.KPITeamAverageBreakTimeResponse, error) {
+			return service.KPIService.GetTeamAverageBreakTime(startDate, endDate, teamUUID)
+		})
+}
+
+func (service *cachedKPIService) GetTeamAverageTimePerShift(startDate string, endDate string, teamUUID string) (model.KPITeamAverageTimePerShiftResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		teamKey("average-time-per-shift", teamUUID, startDate, endDate), teamTags(teamUUID),
+		func() (model.KPITeamAverageTimePerShiftResponse, error) {
+			return service.KPIService.GetTeamAverageTimePerShift(startDate, endDate, teamUUID)
+		})
+}
+
+// GetOrganizationOverview keys the default range on its monday, the end of the current week moving with every request.
+func (service *cachedKPIService) GetOrganizationOverview(startDate string, endDate string) (model.KPIOrganizationOverviewResponse, error) {
+	key := fmt.Sprintf("organization:overview:%s:%s", startDate, endDate)
+	if startDate == "" || endDate == "" {
+		monday, _ := currentWeekRange(time.Now())
+		key = fmt.Sprintf("organization:overview:current:%s", monday)
+	}
+
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		key, []string{CacheService.OrganizationTag},
+		func() (model.KPIOrganizationOverviewResponse, error) {
+			return service.KPIService.GetOrganizationOverview(startDate, endDate)
+		})
+}
+
+func (service *cachedKPIService) GetBreakAnalytics(startDate string, endDate string, userUUID string) (model.KPIBreakAnalyticsResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("break-analytics", userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPIBreakAnalyticsResponse, error) {
+			return service.KPIService.GetBreakAnalytics(startDate, endDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) CompareUser(startDate string, endDate string, previousStartDate string, previousEndDate string, userUUID string) (model.KPIComparisonResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey("compare:"+previousStartDate+":"+previousEndDate, userUUID, startDate, endDate), userTags(userUUID),
+		func() (model.KPIComparisonResponse, error) {
+			return service.KPIService.CompareUser(startDate, endDate, previousStartDate, previousEndDate, userUUID)
+		})
+}
+
+func (service *cachedKPIService) CompareTeam(startDate string, endDate string, previousStartDate string, previousEndDate string, teamUUID string) (model.KPIComparisonResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		teamKey("compare:"+previousStartDate+":"+previousEndDate, teamUUID, startDate, endDate), teamTags(teamUUID),
+		func() (model.KPIComparisonResponse, error) {
+			return service.KPIService.CompareTeam(startDate, endDate, previousStartDate, previousEndDate, teamUUID)
+		})
+}
+
+// GetWellBeing is restricted to the managers of the user, so the result is cached per requester,
+// and also tagged with the requester whose teams decide the access.
+func (service *cachedKPIService) GetWellBeing(startDate string, endDate string, userUUID string, requesterUUID string, isAdmin bool) (model.KPIWellBeingResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		userKey(fmt.Sprintf("well-being:%s:%t", requesterUUID, isAdmin), userUUID, startDate, endDate),
+		[]string{CacheService.UserTag(userUUID), CacheService.UserTag(requesterUUID)},
+		func() (model.KPIWellBeingResponse, error) {
+			return service.KPIService.GetWellBeing(startDate, endDate, userUUID, requesterUUID, isAdmin)
+		})
+}
+
+func (service *cachedKPIService) GetTeamWellBeing(startDate string, endDate string, teamUUID string, requesterUUID string, isAdmin bool) (model.KPITeamWellBeingResponse, error) {
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		teamKey(fmt.Sprintf("well-being:%s:%t", requesterUUID, isAdmin), teamUUID, startDate, endDate),
+		[]string{CacheService.TeamTag(teamUUID), CacheService.UserTag(requesterUUID)},
+		func() (model.KPITeamWellBeingResponse, error) {
+			return service.KPIService.GetTeamWellBeing(startDate, endDate, teamUUID, requesterUUID, isAdmin)
+		})
+}
+
+// QueryKPIs keys the request on its hash, the lists of users and teams being unbounded in size.
+func (service *cachedKPIService) QueryKPIs(request model.KPIQueryRequest) (model.KPIQueryResponse, error) {
+	encoded, _ := json.Marshal(request)
+	hash := sha256.Sum256(encoded)
+
+	tags := make([]string, 0, len(request.UserUUIDs)+len(request.TeamUUIDs))
+	for _, userUUID := range request.UserUUIDs {
+		tags = append(tags, CacheService.UserTag(userUUID))
+	}
+	for _, teamUUID := range request.TeamUUIDs {
+		tags = append(tags, CacheService.TeamTag(teamUUID))
+	}
+
+	return CacheService.Remember(service.Cache, CacheService.KPINamespace,
+		"query:"+hex.EncodeToString(hash[:]), tags,
+		func() (model.KPIQueryResponse, error) {
+			return service.KPIService.QueryKPIs(request)
+		})
+}
+
+func userKey(kpi string, userUUID string, startDate string, endDate string) string {
+	return strings.Join([]string{"user", userUUID, kpi, startDate, endDate}, ":")
+}
+
+func teamKey(kpi string, teamUUID string, startDate string, endDate string) string {
+	return strings.Join([]string{"team", teamUUID, kpi, startDate, endDate}, ":")
+}
+
+func userTags(userUUID string) []string {
+	return []string{CacheService.UserTag(userUUID)}
+}
+
+func teamTags(teamUUID string) []string {
+	return []string{CacheService.TeamTag(teamUUID)}
+}
@@ -2,11 +2,19 @@ package service
 
 import (
 	BreakService "app/internal/app/break/service"
+	ContractService "app/internal/app/contract/service"
+	TeamModel "app/internal/app/team/model"
 	TeamService "app/internal/app/team/service"
 	UserService "app/internal/app/user/service"
 	WeeklyRateService "app/internal/app/weekly-rate/service"
+	Config "app/internal/config"
 	"fmt"
-	"os"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"app/internal/app/kpi/model"
 	KPIRepository "app/internal/app/kpi/repository"
@@ -18,9 +26,22 @@ type KPIService interface {
 	GetWorkSessionUserWeeklyTotal(startDate string, endDate string, userUUID string) (int, error)
 	GetWorkSessionTeamWeeklyTotal(startDate string, endDate string, teamUUID string) (model.KPIWorkSessionTeamWeeklyTotalResponse, error)
 	GetPresenceRate(startDate string, endDate string, userUUID string) (model.KPIPresenceRateResponse, error)
-	ExportKPIData(startDate string, endDate string, requestedByUUID string, kpiType string, uuidToSearch string) (model.KPIExportResponse, error)
+	ExportKPIData(startDate string, endDate string, kpiType string, uuidToSearch string, format string, w io.Writer, progress export.Progress) error
+	ExportKPIBundle(request model.KPIBundleExportRequest, requestedBy string, w io.Writer, progress export.Progress) error
 	GetAverageBreakTime(startDate string, endDate string, userUUID string) (model.KPIAverageBreakTimeResponse, error)
 	GetAverageTimePerShift(startDate string, endDate string, userUUID string) (model.KPIAverageTimePerShiftResponse, error)
+	GetDailyPresence(startDate string, endDate string, userUUID string) (model.KPIDailyPresenceResponse, error)
+	GetTimeSeries(startDate string, endDate string, userUUID string, bucket string) (model.KPITimeSeriesResponse, error)
+	GetTeamPresenceRate(startDate string, endDate string, teamUUID string) (model.KPITeamPresenceRateResponse, error)
+	GetTeamAverageBreakTime(startDate string, endDate string, teamUUID string) (model.KPITeamAverageBreakTimeResponse, error)
+	GetTeamAverageTimePerShift(startDate string, endDate string, teamUUID string) (model.KPITeamAverageTimePerShiftResponse, error)
+	GetOrganizationOverview(startDate string, endDate string) (model.KPIOrganizationOverviewResponse, error)
+	GetBreakAnalytics(startDate string, endDate string, userUUID string) (model.KPIBreakAnalyticsResponse, error)
+	CompareUser(startDate string, endDate string, previousStartDate string, previousEndDate string, userUUID string) (model.KPIComparisonResponse, error)
+	CompareTeam(startDate string, endDate string, previousStartDate string, previousEndDate string, teamUUID string) (model.KPIComparisonResponse, error)
+	GetWellBeing(startDate string, endDate string, userUUID string, requesterUUID string, isAdmin bool) (model.KPIWellBeingResponse, error)
+	GetTeamWellBeing(startDate string, endDate string, teamUUID string, requesterUUID string, isAdmin bool) (model.KPITeamWellBeingResponse, error)
+	QueryKPIs(request model.KPIQueryRequest) (model.KPIQueryResponse, error)
 }
 
 type kpiService struct {
@@ -28,15 +49,17 @@ type kpiService struct {
 	TeamService       TeamService.TeamService
 	UserService       UserService.UserService
 	WeeklyRateService WeeklyRateService.WeeklyRateService
+	ContractService   ContractService.ContractService
 	KPIRepository     KPIRepository.KPIRepository
 }
 
-func NewKPIService(breakService BreakService.BreakService, teamService TeamService.TeamService, userService UserService.UserService, weeklyRateService WeeklyRateService.WeeklyRateService, kpiRepository KPIRepository.KPIRepository) KPIService {
+func NewKPIService(breakService BreakService.BreakService, teamService TeamService.TeamService, userService UserService.UserService, weeklyRateService WeeklyRateService.WeeklyRateService, contractService ContractService.ContractService, kpiRepository KPIRepository.KPIRepository) KPIService {
 	return &kpiService{
 		BreakService:      breakService,
 		TeamService:       teamService,
 		UserService:       userService,
 		WeeklyRateService: weeklyRateService,
+		ContractService:   contractService,
 		KPIRepository:     kpiRepository,
 	}
 }
@@ -106,7 +129,7 @@ func (service *kpiService) GetPresenceRate(startDate string, endDate string, use
 		return model.KPIPresenceRateResponse{}, err
 	}
 
-	presenceRate, weeklyRateExpected, weeklyTimeDone, err := service.KPIRepository.GetUserPresenceRate(userID, startDate, endDate)
+	presenceRate, weeklyRateExpected, weeklyTimeDone, err := service.getUserPresenceRate(userID, startDate, endDate)
 	if err != nil {
 		return model.KPIPresenceRateResponse{}, err
 	}
@@ -126,187 +149,1850 @@ func (service *kpiService) GetPresenceRate(startDate string, endDate string, use
 	}, nil
 }
 
-func (service *kpiService) ExportKPIData(startDate string, endDate string, requestedByUUID string, kpiType string, uuidToSearch string) (model.KPIExportResponse, error) {
-	filename := fmt.Sprintf("kpi_%s_%s.csv", kpiType, requestedByUUID)
-	tmpPath := "/app/tmp/kpi/" + filename
-	finalPath := "/app/data/kpi/" + filename
-
-	var headers []string
-	var rows [][]string
+// getUserPresenceRate returns the presence rate, the expected hours and the hours done by the user,
+// the expected hours being clipped to the contracts of the user.
+func (service *kpiService) getUserPresenceRate(userID int, startDate string, endDate string) (float64, float64, float64, error) {
+	presenceRate, weeklyRateExpected, weeklyTimeDone, err := service.KPIRepository.GetUserPresenceRate(userID, startDate, endDate)
+	if err != nil {
+		return 0, 0, 0, err
+	}
 
-	// generate data based on kpiType
-	switch kpiType {
-	case "work_session_user_weekly_total":
-		total, err := service.GetWorkSessionUserWeeklyTotal(startDate, endDate, uuidToSearch)
-		if err != nil {
-			return model.KPIExportResponse{}, err
+	coverage, err := service.getContractCoverage(userID, startDate, endDate)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if coverage != 1 {
+		weeklyRateExpected = roundTwoDecimals(weeklyRateExpected * coverage)
+		presenceRate = 0
+		if weeklyRateExpected > 0 {
+			presenceRate = roundTwoDecimals(weeklyTimeDone / weeklyRateExpected * 100)
 		}
+	}
 
-		user, err := service.UserService.GetUserByUUID(uuidToSearch)
-		if err != nil {
-			return model.KPIExportResponse{}, err
-		}
+	return presenceRate, weeklyRateExpected, weeklyTimeDone, nil
+}
 
-		headers = []string{"user uuid", "firstname", "lastname", "start date", "end date", "total minutes"}
-		rows = [][]string{
-			{uuidToSearch, user.FirstName, user.LastName, startDate, endDate, fmt.Sprint(total)},
-		}
+func (service *kpiService) GetTeamPresenceRate(startDate string, endDate string, teamUUID string) (model.KPITeamPresenceRateResponse, error) {
+	team, members, err := service.getTeamQueryRows(teamUUID, startDate, endDate)
+	if err != nil {
+		return model.KPITeamPresenceRateResponse{}, err
+	}
 
-	case "work_session_team_weekly_total":
-		data, err := service.GetWorkSessionTeamWeeklyTotal(startDate, endDate, uuidToSearch)
-		if err != nil {
-			return model.KPIExportResponse{}, err
-		}
+	response := model.KPITeamPresenceRateResponse{
+		TeamUUID:  teamUUID,
+		TeamName:  team.Name,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Members:   make([]model.KPITeamMemberPresenceRate, 0, len(members)),
+	}
+
+	for _, member := range members {
+		presenceRate, weeklyRateExpected, weeklyTimeDone := queryPresenceRate(member)
+
+		response.WeeklyRateExpected += weeklyRateExpected
+		response.WeeklyTimeDone += weeklyTimeDone
+		response.Members = append(response.Members, model.KPITeamMemberPresenceRate{
+			UserUUID:           member.UserUUID,
+			FirstName:          member.FirstName,
+			LastName:           member.LastName,
+			PresenceRate:       presenceRate,
+			WeeklyRateExpected: weeklyRateExpected,
+			WeeklyTimeDone:     weeklyTimeDone,
+		})
+	}
 
-		headers = []string{"team uuid", "team name", "start date", "end date", "total time"}
-		rows = [][]string{
-			{data.TeamUUID, data.TeamName, startDate, endDate, fmt.Sprint(data.TotalTime)},
+	if response.WeeklyRateExpected > 0 {
+		response.PresenceRate = roundTwoDecimals(response.WeeklyTimeDone / response.WeeklyRateExpected * 100)
+	}
+	response.WeeklyRateExpected = roundTwoDecimals(response.WeeklyRateExpected)
+	response.WeeklyTimeDone = roundTwoDecimals(response.WeeklyTimeDone)
+
+	return response, nil
+}
+
+func (service *kpiService) GetTeamAverageBreakTime(startDate string, endDate string, teamUUID string) (model.KPITeamAverageBreakTimeResponse, error) {
+	team, members, err := service.getTeamQueryRows(teamUUID, startDate, endDate)
+	if err != nil {
+		return model.KPITeamAverageBreakTimeResponse{}, err
+	}
+
+	response := model.KPITeamAverageBreakTimeResponse{
+		TeamUUID:  teamUUID,
+		TeamName:  team.Name,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Members:   make([]model.KPITeamMemberAverageBreakTime, 0, len(members)),
+	}
+
+	// the team average is computed over all the worked days of the members, a member who did not work not counting
+	var totalBreak, totalWorkedDays int
+	for _, member := range members {
+		var averageBreakTime float64
+		if member.WorkedDays > 0 {
+			averageBreakTime = float64(member.BreakMinutes) / float64(member.WorkedDays)
 		}
 
-		headers = append(headers, "member user uuid", "firstname", "lastname", "member total minutes")
-		for _, member := range data.Members {
-			rows = append(rows, []string{"", "", "", "", "", member.UserUUID, member.FirstName, member.LastName, fmt.Sprint(member.TotalTime)})
+		totalBreak += member.BreakMinutes
+		totalWorkedDays += member.WorkedDays
+		response.Members = append(response.Members, model.KPITeamMemberAverageBreakTime{
+			UserUUID:         member.UserUUID,
+			FirstName:        member.FirstName,
+			LastName:         member.LastName,
+			AverageBreakTime: averageBreakTime,
+		})
+	}
+
+	if totalWorkedDays > 0 {
+		response.AverageBreakTime = roundTwoDecimals(float64(totalBreak) / float64(totalWorkedDays))
+	}
+
+	return response, nil
+}
+
+func (service *kpiService) GetTeamAverageTimePerShift(startDate string, endDate string, teamUUID string) (model.KPITeamAverageTimePerShiftResponse, error) {
+	team, members, err := service.getTeamQueryRows(teamUUID, startDate, endDate)
+	if err != nil {
+		return model.KPITeamAverageTimePerShiftResponse{}, err
+	}
+
+	response := model.KPITeamAverageTimePerShiftResponse{
+		TeamUUID:  teamUUID,
+		TeamName:  team.Name,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Members:   make([]model.KPITeamMemberAverageTimePerShift, 0, len(members)),
+	}
+
+	for _, member := range members {
+		var averageTimePerShift float64
+		if member.TotalShifts > 0 {
+			averageTimePerShift = roundTwoDecimals(float64(member.TotalMinutes) / float64(member.TotalShifts))
 		}
 
-	case "presence_rate":
-		data, err := service.GetPresenceRate(startDate, endDate, uuidToSearch)
-		if err != nil {
-			return model.KPIExportResponse{}, err
-		}
-
-		headers = []string{"user uuid", "firstname", "lastname", "start date", "end date", "presence rate", "weekly rate expected", "weekly time done"}
-		rows = [][]string{
-			{
-				data.UserUUID,
-				data.FirstName,
-				data.LastName,
-				startDate,
-				endDate,
-				fmt.Sprintf("%.2f", data.PresenceRate),
-				fmt.Sprint(data.WeeklyRateExpected),
-				fmt.Sprint(data.WeeklyTimeDone),
-			},
+		response.TotalShifts += member.TotalShifts
+		response.TotalTime += member.TotalMinutes
+		response.Members = append(response.Members, model.KPITeamMemberAverageTimePerShift{
+			UserUUID:            member.UserUUID,
+			FirstName:           member.FirstName,
+			LastName:            member.LastName,
+			AverageTimePerShift: averageTimePerShift,
+			TotalShifts:         member.TotalShifts,
+			TotalTime:           member.TotalMinutes,
+		})
+	}
+
+	if response.TotalShifts > 0 {
+		response.AverageTimePerShift = roundTwoDecimals(float64(response.TotalTime) / float64(response.TotalShifts))
+	}
+
+	return response, nil
+}
+
+// GetOrganizationOverview aggregates over every user and team: the live headcount, the totals of the date range
+// and the teams ranked from the lowest presence rate. The date range defaults to the current week, from monday to today.
+// The presence rates of the teams are computed as GetTeamPresenceRate does, from the totals of all the members fetched at once.
+func (service *kpiService) GetOrganizationOverview(startDate string, endDate string) (model.KPIOrganizationOverviewResponse, error) {
+	if startDate == "" || endDate == "" {
+		startDate, endDate = currentWeekRange(time.Now())
+	}
+
+	headcount, err := service.KPIRepository.GetOrganizationHeadcount()
+	if err != nil {
+		return model.KPIOrganizationOverviewResponse{}, err
+	}
+
+	totals, err := service.KPIRepository.GetOrganizationTotals(startDate, endDate)
+	if err != nil {
+		return model.KPIOrganizationOverviewResponse{}, err
+	}
+	if totals.WorkingUsers > 0 {
+		totals.AverageTimePerUser = roundTwoDecimals(float64(totals.TotalTime) / float64(totals.WorkingUsers))
+	}
+
+	teams, err := service.TeamService.GetTeams()
+	if err != nil {
+		return model.KPIOrganizationOverviewResponse{}, err
+	}
+
+	teamMembers, users, err := service.getTeamsQueryRows(teams, startDate, endDate)
+	if err != nil {
+		return model.KPIOrganizationOverviewResponse{}, err
+	}
+
+	ranking := make([]model.KPIOrganizationTeamRank, 0, len(teams))
+	for _, team := range teams {
+		members := make([]model.KPIQueryUserRow, 0, len(teamMembers[team.UUID]))
+		for _, memberUUID := range teamMembers[team.UUID] {
+			members = append(members, users[memberUUID])
 		}
 
-	case "weekly_average_break_time":
-		data, err := service.GetAverageBreakTime(startDate, endDate, uuidToSearch)
-		if err != nil {
-			return model.KPIExportResponse{}, err
-		}
-
-		headers = []string{"user uuid", "firstname", "lastname", "start date", "end date", "average break time (minutes)"}
-		rows = [][]string{
-			{
-				data.UserUUID,
-				data.FirstName,
-				data.LastName,
-				startDate,
-				endDate,
-				fmt.Sprintf("%.2f", data.AverageBreakTime),
-			},
+		metrics := queryTeamMetrics(members)
+		ranking = append(ranking, model.KPIOrganizationTeamRank{
+			TeamUUID:           team.UUID,
+			TeamName:           team.Name,
+			MembersCount:       len(members),
+			PresenceRate:       metrics["presence_rate"],
+			WeeklyRateExpected: metrics["weekly_rate_expected"],
+			WeeklyTimeDone:     metrics["weekly_time_done"],
+		})
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].PresenceRate < ranking[j].PresenceRate
+	})
+	for i := range ranking {
+		ranking[i].Rank = i + 1
+	}
+
+	overview := model.KPIOrganizationOverviewResponse{
+		StartDate:   startDate,
+		EndDate:     endDate,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Headcount:   headcount,
+		Totals:      totals,
+		TeamRanking: ranking,
+	}
+
+	return overview, nil
+}
+
+// getTeamsQueryRows returns the members uuids of every team and the totals of all these members over the date range,
+// fetched with one query each whatever the number of teams.
+func (service *kpiService) getTeamsQueryRows(teams []TeamModel.TeamReadAll, startDate string, endDate string) (map[string][]string, map[string]model.KPIQueryUserRow, error) {
+	teamMembers := map[string][]string{}
+	users := map[string]model.KPIQueryUserRow{}
+	if len(teams) == 0 {
+		return teamMembers, users, nil
+	}
+
+	teamUUIDs := make([]string, 0, len(teams))
+	for _, team := range teams {
+		teamUUIDs = append(teamUUIDs, team.UUID)
+	}
+
+	memberRows, err := service.KPIRepository.GetTeamsMembers(teamUUIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var memberUUIDs []string
+	for _, row := range memberRows {
+		if row.UserUUID != nil {
+			teamMembers[row.TeamUUID] = append(teamMembers[row.TeamUUID], *row.UserUUID)
+			memberUUIDs = append(memberUUIDs, *row.UserUUID)
 		}
+	}
+	if len(memberUUIDs) == 0 {
+		return teamMembers, users, nil
+	}
 
-	case "average_time_per_shift":
-		data, err := service.GetAverageTimePerShift(startDate, endDate, uuidToSearch)
+	rows, err := service.KPIRepository.GetUsersQueryRows(uniqueStrings(memberUUIDs), startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, row := range rows {
+		users[row.UserUUID] = row
+	}
+
+	return teamMembers, users, nil
+}
+
+// getTeamQueryRows returns the team and the totals of its members over the date range,
+// fetched with one query whatever the number of members.
+func (service *kpiService) getTeamQueryRows(teamUUID string, startDate string, endDate string) (TeamModel.TeamReadAll, []model.KPIQueryUserRow, error) {
+	team, err := service.TeamService.GetTeamByUUID(teamUUID)
+	if err != nil {
+		return TeamModel.TeamReadAll{}, nil, err
+	}
+
+	teamMembers, users, err := service.getTeamsQueryRows([]TeamModel.TeamReadAll{team}, startDate, endDate)
+	if err != nil {
+		return TeamModel.TeamReadAll{}, nil, err
+	}
+
+	members := make([]model.KPIQueryUserRow, 0, len(teamMembers[team.UUID]))
+	for _, memberUUID := range teamMembers[team.UUID] {
+		members = append(members, users[memberUUID])
+	}
+
+	return team, members, nil
+}
+
+// CompareUser compares the KPIs of a user with a previous period, defaulting to the period of the same length right before.
+func (service *kpiService) CompareUser(startDate string, endDate string, previousStartDate string, previousEndDate string, userUUID string) (model.KPIComparisonResponse, error) {
+	if previousStartDate == "" || previousEndDate == "" {
+		var err error
+		previousStartDate, previousEndDate, err = previousPeriod(startDate, endDate)
 		if err != nil {
-			return model.KPIExportResponse{}, err
-		}
-
-		headers = []string{"user uuid", "firstname", "lastname", "start date", "end date", "average time per shift (minutes)", "total shifts", "total time (minutes)"}
-		rows = [][]string{
-			{
-				data.UserUUID,
-				data.FirstName,
-				data.LastName,
-				startDate,
-				endDate,
-				fmt.Sprintf("%.2f", data.AverageTimePerShift),
-				fmt.Sprint(data.TotalShifts),
-				fmt.Sprint(data.TotalTime),
-			},
+			return model.KPIComparisonResponse{}, err
 		}
+	}
 
-	default:
-		return model.KPIExportResponse{}, fmt.Errorf("unknown KPI type: %s", kpiType)
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
+	}
+
+	current, err := service.getUserMetrics(userID, startDate, endDate)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
 	}
 
-	if err := exportCSV(headers, rows, tmpPath); err != nil {
-		return model.KPIExportResponse{}, err
+	previous, err := service.getUserMetrics(userID, previousStartDate, previousEndDate)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
 	}
 
-	// if the file already exists in /data/kpi/, delete it
-	if _, err := os.Stat(finalPath); err == nil {
-		if err := os.Remove(finalPath); err != nil {
-			return model.KPIExportResponse{}, err
+	return model.KPIComparisonResponse{
+		Scope:             "user",
+		UUID:              userUUID,
+		Name:              fmt.Sprintf("%s %s", data.FirstName, data.LastName),
+		StartDate:         startDate,
+		EndDate:           endDate,
+		PreviousStartDate: previousStartDate,
+		PreviousEndDate:   previousEndDate,
+		Metrics:           compareMetrics(current, previous),
+	}, nil
+}
+
+// CompareTeam compares the KPIs of a team with a previous period, defaulting to the period of the same length right before.
+func (service *kpiService) CompareTeam(startDate string, endDate string, previousStartDate string, previousEndDate string, teamUUID string) (model.KPIComparisonResponse, error) {
+	if previousStartDate == "" || previousEndDate == "" {
+		var err error
+		previousStartDate, previousEndDate, err = previousPeriod(startDate, endDate)
+		if err != nil {
+			return model.KPIComparisonResponse{}, err
 		}
 	}
 
-	// move file from tmp to final destination
-	if err := os.Rename(tmpPath, finalPath); err != nil {
-		return model.KPIExportResponse{}, err
+	team, err := service.TeamService.GetTeamByUUID(teamUUID)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
+	}
+
+	current, err := service.getTeamMetrics(teamUUID, startDate, endDate)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
+	}
+
+	previous, err := service.getTeamMetrics(teamUUID, previousStartDate, previousEndDate)
+	if err != nil {
+		return model.KPIComparisonResponse{}, err
 	}
 
-	// return file info
-	return model.KPIExportResponse{
-		File: filename,
-		URL:  "/api/kpi/files/" + filename,
+	return model.KPIComparisonResponse{
+		Scope:             "team",
+		UUID:              teamUUID,
+		Name:              team.Name,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		PreviousStartDate: previousStartDate,
+		PreviousEndDate:   previousEndDate,
+		Metrics:           compareMetrics(current, previous),
 	}, nil
 }
 
-func exportCSV(headers []string, rows [][]string, path string) error {
-	return export.ExportCSV(headers, rows, path)
+// kpiMetric is a named KPI value, kept in a slice so the comparison keeps a stable order.
+type kpiMetric struct {
+	name  string
+	value float64
 }
 
-func (service *kpiService) GetAverageBreakTime(startDate string, endDate string, userUUID string) (model.KPIAverageBreakTimeResponse, error) {
-	userID, err := service.UserService.GetIdByUuid(userUUID)
+func (service *kpiService) getUserMetrics(userID int, startDate string, endDate string) ([]kpiMetric, error) {
+	totalTime, err := service.KPIRepository.GetWeeklyRatesByUserIDAndDateRange(userID, startDate, endDate)
 	if err != nil {
-		return model.KPIAverageBreakTimeResponse{}, err
+		return nil, err
+	}
+
+	presenceRate, weeklyRateExpected, weeklyTimeDone, err := service.getUserPresenceRate(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
 	averageBreakTime, err := service.KPIRepository.GetUserAverageBreakTime(userID, startDate, endDate)
 	if err != nil {
-		return model.KPIAverageBreakTimeResponse{}, err
+		return nil, err
 	}
 
-	data, err := service.UserService.GetUserByUUID(userUUID)
+	averageTimePerShift, totalShifts, _, err := service.KPIRepository.GetUserAverageTimePerShift(userID, startDate, endDate)
 	if err != nil {
-		return model.KPIAverageBreakTimeResponse{}, err
+		return nil, err
 	}
 
-	return model.KPIAverageBreakTimeResponse{
-		FirstName:        data.FirstName,
-		LastName:         data.LastName,
-		UserUUID:         userUUID,
-		AverageBreakTime: averageBreakTime,
-		StartDate:        startDate,
-		EndDate:          endDate,
+	return []kpiMetric{
+		{name: "total_time", value: float64(totalTime)},
+		{name: "presence_rate", value: presenceRate},
+		{name: "weekly_rate_expected", value: weeklyRateExpected},
+		{name: "weekly_time_done", value: weeklyTimeDone},
+		{name: "average_break_time", value: averageBreakTime},
+		{name: "average_time_per_shift", value: averageTimePerShift},
+		{name: "total_shifts", value: float64(totalShifts)},
 	}, nil
 }
 
-func (service *kpiService) GetAverageTimePerShift(startDate string, endDate string, userUUID string) (model.KPIAverageTimePerShiftResponse, error) {
-	userID, err := service.UserService.GetIdByUuid(userUUID)
+// getTeamMetrics computes the metrics of the team from the totals of all its members, fetched with one query.
+func (service *kpiService) getTeamMetrics(teamUUID string, startDate string, endDate string) ([]kpiMetric, error) {
+	_, members, err := service.getTeamQueryRows(teamUUID, startDate, endDate)
 	if err != nil {
-		return model.KPIAverageTimePerShiftResponse{}, err
+		return nil, err
 	}
 
-	averageTimePerShift, totalShifts, totalTime, err := service.KPIRepository.GetUserAverageTimePerShift(userID, startDate, endDate)
+	metrics := queryTeamMetrics(members)
+	return []kpiMetric{
+		{name: "total_time", value: metrics["total_time"]},
+		{name: "presence_rate", value: metrics["presence_rate"]},
+		{name: "weekly_rate_expected", value: metrics["weekly_rate_expected"]},
+		{name: "weekly_time_done", value: metrics["weekly_time_done"]},
+		{name: "average_break_time", value: metrics["average_break_time"]},
+		{name: "average_time_per_shift", value: metrics["average_time_per_shift"]},
+		{name: "total_shifts", value: metrics["total_shifts"]},
+	}, nil
+}
+
+// compareMetrics computes the absolute and percentage change of each metric, both lists holding the same metrics.
+func compareMetrics(current []kpiMetric, previous []kpiMetric) []model.KPIMetricComparison {
+	comparisons := make([]model.KPIMetricComparison, 0, len(current))
+	for i, metric := range current {
+		previousValue := previous[i].value
+
+		comparison := model.KPIMetricComparison{
+			Metric:         metric.name,
+			Current:        roundTwoDecimals(metric.value),
+			Previous:       roundTwoDecimals(previousValue),
+			AbsoluteChange: roundTwoDecimals(metric.value - previousValue),
+		}
+		if previousValue != 0 {
+			percentageChange := roundTwoDecimals((metric.value - previousValue) / math.Abs(previousValue) * 100)
+			comparison.PercentageChange = &percentageChange
+		}
+
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// previousPeriod returns the period of the same number of days ending right before the given one,
+// e.g. 2026-01-05..2026-01-11 gives 2025-12-29..2026-01-04.
+func previousPeriod(startDate string, endDate string) (string, string, error) {
+	start, err := parseKPIDate(startDate)
 	if err != nil {
-		return model.KPIAverageTimePerShiftResponse{}, err
+		return "", "", err
 	}
 
-	data, err := service.UserService.GetUserByUUID(userUUID)
+	end, err := parseKPIDate(endDate)
 	if err != nil {
-		return model.KPIAverageTimePerShiftResponse{}, err
+		return "", "", err
 	}
 
-	return model.KPIAverageTimePerShiftResponse{
-		FirstName:           data.FirstName,
-		LastName:            data.LastName,
-		UserUUID:            userUUID,
-		AverageTimePerShift: averageTimePerShift,
-		TotalShifts:         totalShifts,
-		TotalTime:           totalTime,
-		StartDate:           startDate,
-		EndDate:             endDate,
-	}, nil
+	const dateLayout = "2006-01-02"
+	days := int(truncateToDay(end).Sub(truncateToDay(start)).Hours()/24) + 1
+	previousEnd := truncateToDay(start).AddDate(0, 0, -1)
+	previousStart := previousEnd.AddDate(0, 0, -(days - 1))
+	return previousStart.Format(dateLayout), previousEnd.Format(dateLayout), nil
+}
+
+// currentWeekRange returns the days from monday of the current week to today, both included.
+func currentWeekRange(now time.Time) (string, string) {
+	offset := (int(now.Weekday()) + 6) % 7
+	monday := time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
+	return monday.Format("2006-01-02"), now.Format("2006-01-02")
+}
+
+// getTeamMembers returns the team and its members, used by the team KPIs computed member by member.
+func (service *kpiService) getTeamMembers(teamUUID string) (TeamModel.TeamReadAll, []TeamModel.TeamMemberLight, error) {
+	teamID, err := service.TeamService.GetIdByUuid(teamUUID)
+	if err != nil {
+		return TeamModel.TeamReadAll{}, nil, err
+	}
+
+	team, err := service.TeamService.GetTeamByUUID(teamUUID)
+	if err != nil {
+		return TeamModel.TeamReadAll{}, nil, err
+	}
+
+	members, err := service.TeamService.GetUserIDsByTeamID(teamID)
+	if err != nil {
+		return TeamModel.TeamReadAll{}, nil, err
+	}
+
+	return team, members, nil
+}
+
+// ExportKPIData writes the report of the KPI to w in the given format, CSV by default.
+// Its two steps, building the report then writing it, are reported to progress.
+func (service *kpiService) ExportKPIData(startDate string, endDate string, kpiType string, uuidToSearch string, format string, w io.Writer, progress export.Progress) error {
+	if format == "" {
+		format = export.CSVFormat
+	}
+
+	report, err := service.buildKPIReport(startDate, endDate, kpiType, uuidToSearch)
+	if err != nil {
+		return err
+	}
+	if report.Title == "" {
+		report.Title = fmt.Sprintf("%s, %s to %s", kpiReportTitles[kpiType], reportDate(startDate), reportDate(endDate))
+	}
+	progress.Step(1, 2)
+
+	if err := export.WriteReport(w, report, format); err != nil {
+		return err
+	}
+	progress.Step(2, 2)
+
+	return nil
+}
+
+// teamKPITypes are the KPI types computed for a team, the other ones being computed for a user.
+var teamKPITypes = []string{
+	"work_session_team_weekly_total",
+	"team_presence_rate",
+	"team_average_break_time",
+	"team_average_time_per_shift",
+	"team_summary",
+}
+
+// IsTeamKPIType reports whether the KPI type is computed for a team rather than for a user.
+func IsTeamKPIType(kpiType string) bool {
+	return slices.Contains(teamKPITypes, kpiType)
+}
+
+// ExportKPIBundle writes to w the report of every KPI type for each of its targets, the users for the user KPI types
+// and the teams for the team ones, in a ZIP archive of CSV files by default or in a single workbook.
+// Each report built, then the writing of the file, is reported to progress as one step.
+func (service *kpiService) ExportKPIBundle(request model.KPIBundleExportRequest, requestedBy string, w io.Writer, progress export.Progress) error {
+	format := request.Format
+	if format == "" {
+		format = export.ZIPFormat
+	}
+
+	bundle := export.Bundle{
+		Manifest: export.Manifest{
+			GeneratedAt: time.Now(),
+			RequestedBy: requestedBy,
+			StartDate:   request.StartDate,
+			EndDate:     request.EndDate,
+			Format:      format,
+			KPITypes:    request.KPITypes,
+			UserUUIDs:   append([]string{}, request.UserUUIDs...),
+			TeamUUIDs:   append([]string{}, request.TeamUUIDs...),
+		},
+	}
+
+	total := 1
+	for _, kpiType := range request.KPITypes {
+		if IsTeamKPIType(kpiType) {
+			total += len(request.TeamUUIDs)
+		} else {
+			total += len(request.UserUUIDs)
+		}
+	}
+
+	// the names of the targets are only fetched once, whatever the number of KPI types
+	names := map[string]string{}
+	for _, kpiType := range request.KPITypes {
+		targetType, targets := "user", request.UserUUIDs
+		if IsTeamKPIType(kpiType) {
+			targetType, targets = "team", request.TeamUUIDs
+		}
+
+		for _, targetUUID := range targets {
+			name, ok := names[targetUUID]
+			if !ok {
+				var err error
+				name, err = service.targetName(targetType, targetUUID)
+				if err != nil {
+					return err
+				}
+				names[targetUUID] = name
+			}
+
+			report, err := service.buildKPIReport(request.StartDate, request.EndDate, kpiType, targetUUID)
+			if err != nil {
+				return fmt.Errorf("failed to export %s of %s: %w", kpiType, targetUUID, err)
+			}
+
+			bundle.Entries = append(bundle.Entries, export.BundleEntry{
+				ManifestEntry: export.ManifestEntry{KPIType: kpiType, TargetType: targetType, TargetUUID: targetUUID, TargetName: name},
+				Report:        report,
+			})
+			progress.Step(len(bundle.Entries), total)
+		}
+	}
+
+	if err := export.WriteBundle(w, bundle, format); err != nil {
+		return err
+	}
+	progress.Step(total, total)
+
+	return nil
+}
+
+// targetName returns the full name of a user or the name of a team.
+func (service *kpiService) targetName(targetType string, uuid string) (string, error) {
+	if targetType == "team" {
+		team, err := service.TeamService.GetTeamByUUID(uuid)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", Config.ErrorMessages()["TEAM_NOT_FOUND"], uuid)
+		}
+		return team.Name, nil
+	}
+
+	user, err := service.UserService.GetUserByUUID(uuid)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName), nil
+}
+
+// buildKPIReport generates the typed rows of an export based on kpiType.
+func (service *kpiService) buildKPIReport(startDate string, endDate string, kpiType string, uuidToSearch string) (export.Report, error) {
+	var report export.Report
+
+	switch kpiType {
+	case "work_session_user_weekly_total":
+		total, err := service.GetWorkSessionUserWeeklyTotal(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		user, err := service.UserService.GetUserByUUID(uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    user.FirstName + " " + user.LastName,
+			Headers: []string{"user uuid", "firstname", "lastname", "start date", "end date", "total minutes"},
+			Rows: [][]export.Cell{
+				{export.Text(uuidToSearch), export.Text(user.FirstName), export.Text(user.LastName), export.Date(startDate), export.Date(endDate), export.Duration(total)},
+			},
+		}
+
+	case "work_session_team_weekly_total":
+		data, err := service.GetWorkSessionTeamWeeklyTotal(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.TeamName,
+			Headers: []string{"team uuid", "team name", "start date", "end date", "total time"},
+			Rows: [][]export.Cell{
+				{export.Text(data.TeamUUID), export.Text(data.TeamName), export.Date(startDate), export.Date(endDate), export.Duration(data.TotalTime)},
+			},
+		}
+
+		report.MemberHeaders = []string{"member user uuid", "firstname", "lastname", "member total minutes"}
+		for _, member := range data.Members {
+			report.AddMember(member.FirstName+" "+member.LastName, export.Text(member.UserUUID), export.Text(member.FirstName), export.Text(member.LastName), export.Duration(member.TotalTime))
+		}
+
+	case "presence_rate":
+		data, err := service.GetPresenceRate(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.FirstName + " " + data.LastName,
+			Headers: []string{"user uuid", "firstname", "lastname", "start date", "end date", "presence rate", "weekly rate expected", "weekly time done"},
+			Rows: [][]export.Cell{
+				{
+					export.Text(data.UserUUID),
+					export.Text(data.FirstName),
+					export.Text(data.LastName),
+					export.Date(startDate),
+					export.Date(endDate),
+					export.Number(data.PresenceRate),
+					export.Float(data.WeeklyRateExpected),
+					export.Float(data.WeeklyTimeDone),
+				},
+			},
+		}
+
+	case "weekly_average_break_time":
+		data, err := service.GetAverageBreakTime(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.FirstName + " " + data.LastName,
+			Headers: []string{"user uuid", "firstname", "lastname", "start date", "end date", "average break time (minutes)"},
+			Rows: [][]export.Cell{
+				{
+					export.Text(data.UserUUID),
+					export.Text(data.FirstName),
+					export.Text(data.LastName),
+					export.Date(startDate),
+					export.Date(endDate),
+					export.AverageDuration(data.AverageBreakTime),
+				},
+			},
+		}
+
+	case "average_time_per_shift":
+		data, err := service.GetAverageTimePerShift(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.FirstName + " " + data.LastName,
+			Headers: []string{"user uuid", "firstname", "lastname", "start date", "end date", "average time per shift (minutes)", "total shifts", "total time (minutes)"},
+			Rows: [][]export.Cell{
+				{
+					export.Text(data.UserUUID),
+					export.Text(data.FirstName),
+					export.Text(data.LastName),
+					export.Date(startDate),
+					export.Date(endDate),
+					export.AverageDuration(data.AverageTimePerShift),
+					export.Integer(data.TotalShifts),
+					export.Duration(data.TotalTime),
+				},
+			},
+		}
+
+	case "daily_presence":
+		data, err := service.GetDailyPresence(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.FirstName + " " + data.LastName,
+			Headers: []string{"user uuid", "firstname", "lastname", "date", "weekday", "working day", "expected hours", "done hours", "presence rate", "absent"},
+		}
+		for _, day := range data.Days {
+			report.Summary.Rows = append(report.Summary.Rows, []export.Cell{
+				export.Text(data.UserUUID),
+				export.Text(data.FirstName),
+				export.Text(data.LastName),
+				export.Date(day.Date),
+				export.Text(day.Weekday),
+				export.Bool(day.IsWorkingDay),
+				export.Float(day.ExpectedHours),
+				export.Float(day.DoneHours),
+				export.Number(day.PresenceRate),
+				export.Bool(day.IsAbsent),
+			})
+		}
+
+	case "team_presence_rate":
+		data, err := service.GetTeamPresenceRate(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.TeamName,
+			Headers: []string{"team uuid", "team name", "start date", "end date", "presence rate", "weekly rate expected", "weekly time done"},
+			Rows: [][]export.Cell{
+				{
+					export.Text(data.TeamUUID),
+					export.Text(data.TeamName),
+					export.Date(startDate),
+					export.Date(endDate),
+					export.Number(data.PresenceRate),
+					export.Float(data.WeeklyRateExpected),
+					export.Float(data.WeeklyTimeDone),
+				},
+			},
+		}
+
+		report.MemberHeaders = []string{"member user uuid", "firstname", "lastname", "member presence rate", "member weekly rate expected", "member weekly time done"}
+		for _, member := range data.Members {
+			report.AddMember(
+				member.FirstName+" "+member.LastName,
+				export.Text(member.UserUUID),
+				export.Text(member.FirstName),
+				export.Text(member.LastName),
+				export.Number(member.PresenceRate),
+				export.Float(member.WeeklyRateExpected),
+				export.Float(member.WeeklyTimeDone),
+			)
+		}
+
+	case "team_average_break_time":
+		data, err := service.GetTeamAverageBreakTime(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.TeamName,
+			Headers: []string{"team uuid", "team name", "start date", "end date", "average break time (minutes)"},
+			Rows: [][]export.Cell{
+				{export.Text(data.TeamUUID), export.Text(data.TeamName), export.Date(startDate), export.Date(endDate), export.AverageDuration(data.AverageBreakTime)},
+			},
+		}
+
+		report.MemberHeaders = []string{"member user uuid", "firstname", "lastname", "member average break time (minutes)"}
+		for _, member := range data.Members {
+			report.AddMember(member.FirstName+" "+member.LastName, export.Text(member.UserUUID), export.Text(member.FirstName), export.Text(member.LastName), export.AverageDuration(member.AverageBreakTime))
+		}
+
+	case "team_average_time_per_shift":
+		data, err := service.GetTeamAverageTimePerShift(startDate, endDate, uuidToSearch)
+		if err != nil {
+			return export.Report{}, err
+		}
+
+		report.Summary = export.Sheet{
+			Name:    data.TeamName,
+			Headers: []string{"team uuid", "team name", "start date", "end date", "average time per shift (minutes)", "total shifts", "total time (minutes)"},
+			Rows: [][]export.Cell{
+				{
+					export.Text(data.TeamUUID),
+					export.Text(data.TeamName),
+					export.Date(startDate),
+					export.Date(endDate),
+					export.AverageDuration(data.AverageTimePerShift),
+					export.Integer(data.TotalShifts),
+					export.Duration(data.TotalTime),
+				},
+			},
+		}
+
+		report.MemberHeaders = []string{"member user uuid", "firstname", "lastname", "member average time per shift (minutes)", "member total shifts", "member total time (minutes)"}
+		for _, member := range data.Members {
+			report.AddMember(
+				member.FirstName+" "+member.LastName,
+				export.Text(member.UserUUID),
+				export.Text(member.FirstName),
+				export.Text(member.LastName),
+				export.AverageDuration(member.AverageTimePerShift),
+				export.Integer(member.TotalShifts),
+				export.Duration(member.TotalTime),
+			)
+		}
+
+	case "timesheet":
+		return service.buildTimesheetReport(startDate, uuidToSearch)
+
+	case "team_summary":
+		return service.buildTeamSummaryReport(startDate, endDate, uuidToSearch)
+
+	default:
+		return export.Report{}, fmt.Errorf("unknown KPI type: %s", kpiType)
+	}
+
+	return report, nil
+}
+
+// buildTimesheetReport lists every day of the month of startDate with the sessions of the user,
+// followed by the totals of the month, to be signed by the employee and their manager.
+func (service *kpiService) buildTimesheetReport(startDate string, userUUID string) (export.Report, error) {
+	start, err := parseKPIDate(startDate)
+	if err != nil {
+		return export.Report{}, err
+	}
+	monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return export.Report{}, err
+	}
+
+	user, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return export.Report{}, err
+	}
+
+	days, err := service.KPIRepository.GetUserTimesheetDays(userID, monthStart.Format("2006-01-02"), monthEnd.Format("2006-01-02"))
+	if err != nil {
+		return export.Report{}, err
+	}
+
+	byDay := make(map[string]model.KPITimesheetDayRow, len(days))
+	for _, day := range days {
+		byDay[day.Day] = day
+	}
+
+	report := export.Report{
+		Title:      fmt.Sprintf("Timesheet of %s %s, %s", user.FirstName, user.LastName, monthStart.Format("January 2006")),
+		Signatures: []string{"Employee signature", "Manager signature"},
+		Summary: export.Sheet{
+			Name:    monthStart.Format("January 2006"),
+			Headers: []string{"date", "weekday", "clock in", "clock out", "sessions", "breaks (minutes)", "worked (minutes)"},
+		},
+	}
+
+	var totalSessions, totalBreaks, totalWorked int
+	for date := monthStart; !date.After(monthEnd); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+		day, worked := byDay[key]
+		if !worked {
+			report.Summary.Rows = append(report.Summary.Rows, []export.Cell{export.Date(key), export.Text(date.Weekday().String())})
+			continue
+		}
+
+		report.Summary.Rows = append(report.Summary.Rows, []export.Cell{
+			export.Date(key),
+			export.Text(date.Weekday().String()),
+			export.Clock(day.ClockIn),
+			export.Clock(day.ClockOut),
+			export.Integer(day.SessionsCount),
+			export.Duration(day.BreakMinutes),
+			export.Duration(day.WorkedMinutes),
+		})
+		totalSessions += day.SessionsCount
+		totalBreaks += day.BreakMinutes
+		totalWorked += day.WorkedMinutes
+	}
+
+	report.Summary.Rows = append(report.Summary.Rows, []export.Cell{
+		export.Text("total"),
+		export.Text(""),
+		export.Text(""),
+		export.Text(""),
+		export.Integer(totalSessions),
+		export.Duration(totalBreaks),
+		export.Duration(totalWorked),
+	})
+
+	return report, nil
+}
+
+// buildTeamSummaryReport gathers the presence rate, break and shift KPIs of the team and of each of its members.
+func (service *kpiService) buildTeamSummaryReport(startDate string, endDate string, teamUUID string) (export.Report, error) {
+	presence, err := service.GetTeamPresenceRate(startDate, endDate, teamUUID)
+	if err != nil {
+		return export.Report{}, err
+	}
+
+	breaks, err := service.GetTeamAverageBreakTime(startDate, endDate, teamUUID)
+	if err != nil {
+		return export.Report{}, err
+	}
+
+	shifts, err := service.GetTeamAverageTimePerShift(startDate, endDate, teamUUID)
+	if err != nil {
+		return export.Report{}, err
+	}
+
+	memberBreaks := make(map[string]model.KPITeamMemberAverageBreakTime, len(breaks.Members))
+	for _, member := range breaks.Members {
+		memberBreaks[member.UserUUID] = member
+	}
+	memberShifts := make(map[string]model.KPITeamMemberAverageTimePerShift, len(shifts.Members))
+	for _, member := range shifts.Members {
+		memberShifts[member.UserUUID] = member
+	}
+
+	report := export.Report{
+		Summary: export.Sheet{
+			Name:    presence.TeamName,
+			Headers: []string{"team uuid", "team name", "start date", "end date", "presence rate", "average break time (minutes)", "average time per shift (minutes)", "total shifts", "total time (minutes)"},
+			Rows: [][]export.Cell{
+				{
+					export.Text(presence.TeamUUID),
+					export.Text(presence.TeamName),
+					export.Date(startDate),
+					export.Date(endDate),
+					export.Number(presence.PresenceRate),
+					export.AverageDuration(breaks.AverageBreakTime),
+					export.AverageDuration(shifts.AverageTimePerShift),
+					export.Integer(shifts.TotalShifts),
+					export.Duration(shifts.TotalTime),
+				},
+			},
+		},
+		MemberHeaders: []string{"member user uuid", "firstname", "lastname", "member presence rate", "member average break time (minutes)", "member average time per shift (minutes)", "member total shifts", "member total time (minutes)"},
+	}
+
+	for _, member := range presence.Members {
+		report.AddMember(
+			member.FirstName+" "+member.LastName,
+			export.Text(member.UserUUID),
+			export.Text(member.FirstName),
+			export.Text(member.LastName),
+			export.Number(member.PresenceRate),
+			export.AverageDuration(memberBreaks[member.UserUUID].AverageBreakTime),
+			export.AverageDuration(memberShifts[member.UserUUID].AverageTimePerShift),
+			export.Integer(memberShifts[member.UserUUID].TotalShifts),
+			export.Duration(memberShifts[member.UserUUID].TotalTime),
+		)
+	}
+
+	return report, nil
+}
+
+// kpiReportTitles are the titles printed on the exports of each KPI type.
+var kpiReportTitles = map[string]string{
+	"work_session_user_weekly_total": "Worked time",
+	"work_session_team_weekly_total": "Team worked time",
+	"presence_rate":                  "Presence rate",
+	"weekly_average_break_time":      "Average break time",
+	"average_time_per_shift":         "Average time per shift",
+	"daily_presence":                 "Daily presence",
+	"team_presence_rate":             "Team presence rate",
+	"team_average_break_time":        "Team average break time",
+	"team_average_time_per_shift":    "Team average time per shift",
+	"team_summary":                   "Team KPI summary",
+}
+
+// reportDate formats a date of the request as YYYY-MM-DD, or returns it as is when it does not parse.
+func reportDate(date string) string {
+	parsed, err := parseKPIDate(date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format("2006-01-02")
+}
+
+func (service *kpiService) GetAverageBreakTime(startDate string, endDate string, userUUID string) (model.KPIAverageBreakTimeResponse, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPIAverageBreakTimeResponse{}, err
+	}
+
+	averageBreakTime, err := service.KPIRepository.GetUserAverageBreakTime(userID, startDate, endDate)
+	if err != nil {
+		return model.KPIAverageBreakTimeResponse{}, err
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPIAverageBreakTimeResponse{}, err
+	}
+
+	return model.KPIAverageBreakTimeResponse{
+		FirstName:        data.FirstName,
+		LastName:         data.LastName,
+		UserUUID:         userUUID,
+		AverageBreakTime: averageBreakTime,
+		StartDate:        startDate,
+		EndDate:          endDate,
+	}, nil
+}
+
+func (service *kpiService) GetBreakAnalytics(startDate string, endDate string, userUUID string) (model.KPIBreakAnalyticsResponse, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPIBreakAnalyticsResponse{}, err
+	}
+
+	analytics, err := service.KPIRepository.GetUserBreakAnalytics(userID, startDate, endDate)
+	if err != nil {
+		return model.KPIBreakAnalyticsResponse{}, err
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPIBreakAnalyticsResponse{}, err
+	}
+
+	response := model.KPIBreakAnalyticsResponse{
+		FirstName:            data.FirstName,
+		LastName:             data.LastName,
+		UserUUID:             userUUID,
+		StartDate:            startDate,
+		EndDate:              endDate,
+		TotalSessions:        analytics.TotalSessions,
+		WorkedDays:           analytics.WorkedDays,
+		TotalBreakTime:       analytics.TotalBreakTime,
+		MedianBreakTime:      roundTwoDecimals(analytics.MedianBreakTime),
+		P90BreakTime:         roundTwoDecimals(analytics.P90BreakTime),
+		TotalBreaks:          analytics.TotalBreaks,
+		SessionsWithoutBreak: analytics.SessionsWithoutBreak,
+	}
+
+	if analytics.WorkedDays > 0 {
+		response.AverageBreakTimePerDay = roundTwoDecimals(float64(analytics.TotalBreakTime) / float64(analytics.WorkedDays))
+	}
+	if analytics.TotalSessions > 0 {
+		response.AverageBreakTimePerSession = roundTwoDecimals(float64(analytics.TotalBreakTime) / float64(analytics.TotalSessions))
+		response.NoBreakRate = roundTwoDecimals(float64(analytics.SessionsWithoutBreak) / float64(analytics.TotalSessions) * 100)
+	}
+	// only sessions with a known number of breaks are taken into account
+	if analytics.CountedSessions > 0 {
+		response.BreaksPerSession = roundTwoDecimals(float64(analytics.TotalBreaks) / float64(analytics.CountedSessions))
+	}
+
+	return response, nil
+}
+
+func (service *kpiService) GetAverageTimePerShift(startDate string, endDate string, userUUID string) (model.KPIAverageTimePerShiftResponse, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPIAverageTimePerShiftResponse{}, err
+	}
+
+	averageTimePerShift, totalShifts, totalTime, err := service.KPIRepository.GetUserAverageTimePerShift(userID, startDate, endDate)
+	if err != nil {
+		return model.KPIAverageTimePerShiftResponse{}, err
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPIAverageTimePerShiftResponse{}, err
+	}
+
+	return model.KPIAverageTimePerShiftResponse{
+		FirstName:           data.FirstName,
+		LastName:            data.LastName,
+		UserUUID:            userUUID,
+		AverageTimePerShift: averageTimePerShift,
+		TotalShifts:         totalShifts,
+		TotalTime:           totalTime,
+		StartDate:           startDate,
+		EndDate:             endDate,
+	}, nil
+}
+
+func (service *kpiService) GetDailyPresence(startDate string, endDate string, userUUID string) (model.KPIDailyPresenceResponse, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	start, err := parseKPIDate(startDate)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	end, err := parseKPIDate(endDate)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	distribution, err := service.KPIRepository.GetUserWeeklyRateDistribution(userID)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	dailyMinutes, err := service.KPIRepository.GetUserDailyWorkedMinutes(userID, startDate, endDate)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	schedule, err := service.ContractService.GetSchedule(userID, start, end)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPIDailyPresenceResponse{}, err
+	}
+
+	response := model.KPIDailyPresenceResponse{
+		FirstName: data.FirstName,
+		LastName:  data.LastName,
+		UserUUID:  userUUID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Days:      make([]model.KPIDailyPresenceDay, 0),
+	}
+
+	today := truncateToDay(time.Now())
+	lastDay := truncateToDay(end)
+
+	for day := truncateToDay(start); !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		expectedHours, underContract := schedule.ExpectedHoursOn(day, distribution)
+		doneHours := float64(dailyMinutes[date]) / 60
+
+		presenceDay := model.KPIDailyPresenceDay{
+			Date:          date,
+			Weekday:       day.Weekday().String(),
+			IsWorkingDay:  expectedHours > 0,
+			UnderContract: underContract,
+			ExpectedHours: roundTwoDecimals(expectedHours),
+			DoneHours:     roundTwoDecimals(doneHours),
+		}
+
+		if presenceDay.IsWorkingDay {
+			presenceDay.PresenceRate = roundTwoDecimals(doneHours / expectedHours * 100)
+			// a working day is only missed once it is over
+			presenceDay.IsAbsent = doneHours == 0 && day.Before(today)
+			response.WorkingDays++
+		}
+
+		if presenceDay.IsAbsent {
+			response.AbsentDays++
+		}
+
+		response.ExpectedHours += expectedHours
+		response.DoneHours += doneHours
+		response.Days = append(response.Days, presenceDay)
+	}
+
+	if response.ExpectedHours > 0 {
+		response.PresenceRate = roundTwoDecimals(response.DoneHours / response.ExpectedHours * 100)
+	}
+	response.ExpectedHours = roundTwoDecimals(response.ExpectedHours)
+	response.DoneHours = roundTwoDecimals(response.DoneHours)
+
+	return response, nil
+}
+
+func (service *kpiService) GetTimeSeries(startDate string, endDate string, userUUID string, bucket string) (model.KPITimeSeriesResponse, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	start, err := parseKPIDate(startDate)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	end, err := parseKPIDate(endDate)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	firstDayOfWeek := 1
+	if data.FirstDayOfWeek != nil {
+		firstDayOfWeek = *data.FirstDayOfWeek
+	}
+
+	rows, err := service.KPIRepository.GetUserTimeSeries(userID, startDate, endDate, bucket, firstDayOfWeek)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	distribution, err := service.KPIRepository.GetUserWeeklyRateDistribution(userID)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	schedule, err := service.ContractService.GetSchedule(userID, start, end)
+	if err != nil {
+		return model.KPITimeSeriesResponse{}, err
+	}
+
+	response := model.KPITimeSeriesResponse{
+		FirstName:      data.FirstName,
+		LastName:       data.LastName,
+		UserUUID:       userUUID,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Bucket:         bucket,
+		FirstDayOfWeek: firstDayOfWeek,
+		Points:         make([]model.KPITimeSeriesPoint, 0, len(rows)),
+	}
+
+	firstDay := truncateToDay(start)
+	lastDay := truncateToDay(end)
+
+	for _, row := range rows {
+		bucketStart, err := time.Parse("2006-01-02", row.BucketStart)
+		if err != nil {
+			return model.KPITimeSeriesResponse{}, fmt.Errorf("invalid bucket start %q: %w", row.BucketStart, err)
+		}
+
+		// the first and last buckets may overflow the requested range
+		bucketEnd := nextBucket(bucketStart, bucket).AddDate(0, 0, -1)
+		if bucketStart.Before(firstDay) {
+			bucketStart = firstDay
+		}
+		if bucketEnd.After(lastDay) {
+			bucketEnd = lastDay
+		}
+
+		var expectedHours float64
+		for day := bucketStart; !day.After(bucketEnd); day = day.AddDate(0, 0, 1) {
+			hours, _ := schedule.ExpectedHoursOn(day, distribution)
+			expectedHours += hours
+		}
+
+		point := model.KPITimeSeriesPoint{
+			BucketStart:    bucketStart.Format("2006-01-02"),
+			BucketEnd:      bucketEnd.Format("2006-01-02"),
+			TotalTime:      row.TotalMinutes,
+			TotalBreakTime: row.BreakMinutes,
+			TotalShifts:    row.TotalShifts,
+			WorkedDays:     row.WorkedDays,
+			ExpectedHours:  roundTwoDecimals(expectedHours),
+			DoneHours:      roundTwoDecimals(float64(row.TotalMinutes) / 60),
+		}
+
+		if row.TotalShifts > 0 {
+			point.AverageTimePerShift = roundTwoDecimals(float64(row.TotalMinutes) / float64(row.TotalShifts))
+		}
+		if row.WorkedDays > 0 {
+			point.AverageBreakTime = roundTwoDecimals(float64(row.BreakMinutes) / float64(row.WorkedDays))
+		}
+		if expectedHours > 0 {
+			point.PresenceRate = roundTwoDecimals(float64(row.TotalMinutes) / 60 / expectedHours * 100)
+		}
+
+		response.Points = append(response.Points, point)
+	}
+
+	return response, nil
+}
+
+// nextBucket returns the start of the bucket following the one starting on the given day.
+func nextBucket(bucketStart time.Time, bucket string) time.Time {
+	switch bucket {
+	case "week":
+		return bucketStart.AddDate(0, 0, 7)
+	case "month":
+		return bucketStart.AddDate(0, 1, 0)
+	default:
+		return bucketStart.AddDate(0, 0, 1)
+	}
+}
+
+// getContractCoverage returns the share of the expected hours of the date range that falls within the contracts of the user,
+// 1 when the user has no contract at all. Weekly rates linked to the contracts are taken into account.
+func (service *kpiService) getContractCoverage(userID int, startDate string, endDate string) (float64, error) {
+	start, err := parseKPIDate(startDate)
+	if err != nil {
+		return 0, err
+	}
+
+	end, err := parseKPIDate(endDate)
+	if err != nil {
+		return 0, err
+	}
+
+	schedule, err := service.ContractService.GetSchedule(userID, start, end)
+	if err != nil {
+		return 0, err
+	}
+	if !schedule.Managed {
+		return 1, nil
+	}
+
+	distribution, err := service.KPIRepository.GetUserWeeklyRateDistribution(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var fullExpected, contractExpected float64
+	lastDay := truncateToDay(end)
+	for day := truncateToDay(start); !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		fullExpected += distribution.HoursFor(day.Weekday())
+		hours, _ := schedule.ExpectedHoursOn(day, distribution)
+		contractExpected += hours
+	}
+
+	if fullExpected == 0 {
+		return 1, nil
+	}
+	return contractExpected / fullExpected, nil
+}
+
+// QueryKPIs computes the requested metrics for every given user and team over the same date range.
+// Whatever the number of users and teams, the teams members and the totals of all the users are fetched with one query each.
+// The metrics are computed the same way as their dedicated routes.
+func (service *kpiService) QueryKPIs(request model.KPIQueryRequest) (model.KPIQueryResponse, error) {
+	userUUIDs := uniqueStrings(request.UserUUIDs)
+	teamUUIDs := uniqueStrings(request.TeamUUIDs)
+	if len(userUUIDs) == 0 && len(teamUUIDs) == 0 {
+		return model.KPIQueryResponse{}, fmt.Errorf("%s: at least one user or team uuid is required", Config.ErrorMessages()["INVALID_REQUEST"])
+	}
+
+	teams := map[string]*model.KPIQueryResult{}
+	teamMembers := map[string][]string{}
+	queriedUUIDs := append([]string{}, userUUIDs...)
+
+	if len(teamUUIDs) > 0 {
+		memberRows, err := service.KPIRepository.GetTeamsMembers(teamUUIDs)
+		if err != nil {
+			return model.KPIQueryResponse{}, err
+		}
+
+		for _, row := range memberRows {
+			if _, exists := teams[row.TeamUUID]; !exists {
+				teams[row.TeamUUID] = &model.KPIQueryResult{Scope: "team", UUID: row.TeamUUID, Name: row.TeamName}
+			}
+			if row.UserUUID != nil {
+				teamMembers[row.TeamUUID] = append(teamMembers[row.TeamUUID], *row.UserUUID)
+				queriedUUIDs = append(queriedUUIDs, *row.UserUUID)
+			}
+		}
+
+		if missing := missingKeys(teamUUIDs, teams); len(missing) > 0 {
+			return model.KPIQueryResponse{}, fmt.Errorf("%s: unknown team uuids: %s", Config.ErrorMessages()["INVALID_REQUEST"], strings.Join(missing, ", "))
+		}
+	}
+
+	rows, err := service.KPIRepository.GetUsersQueryRows(uniqueStrings(queriedUUIDs), request.StartDate, request.EndDate)
+	if err != nil {
+		return model.KPIQueryResponse{}, err
+	}
+
+	users := make(map[string]model.KPIQueryUserRow, len(rows))
+	for _, row := range rows {
+		users[row.UserUUID] = row
+	}
+
+	if missing := missingKeys(userUUIDs, users); len(missing) > 0 {
+		return model.KPIQueryResponse{}, fmt.Errorf("%s: unknown user uuids: %s", Config.ErrorMessages()["INVALID_REQUEST"], strings.Join(missing, ", "))
+	}
+
+	response := model.KPIQueryResponse{
+		StartDate: request.StartDate,
+		EndDate:   request.EndDate,
+		Users:     make([]model.KPIQueryResult, 0, len(userUUIDs)),
+		Teams:     make([]model.KPIQueryResult, 0, len(teamUUIDs)),
+	}
+
+	for _, userUUID := range userUUIDs {
+		row := users[userUUID]
+		response.Users = append(response.Users, model.KPIQueryResult{
+			Scope:   "user",
+			UUID:    userUUID,
+			Name:    strings.TrimSpace(row.FirstName + " " + row.LastName),
+			Metrics: selectMetrics(queryUserMetrics(row), request.Metrics),
+		})
+	}
+
+	for _, teamUUID := range teamUUIDs {
+		members := make([]model.KPIQueryUserRow, 0, len(teamMembers[teamUUID]))
+		for _, memberUUID := range teamMembers[teamUUID] {
+			members = append(members, users[memberUUID])
+		}
+
+		team := teams[teamUUID]
+		team.Metrics = selectMetrics(queryTeamMetrics(members), request.Metrics)
+		response.Teams = append(response.Teams, *team)
+	}
+
+	return response, nil
+}
+
+// queryUserMetrics computes the metrics of a user from their totals, as getUserMetrics does.
+func queryUserMetrics(row model.KPIQueryUserRow) map[string]float64 {
+	presenceRate, weeklyRateExpected, weeklyTimeDone := queryPresenceRate(row)
+
+	var averageBreakTime, averageTimePerShift float64
+	if row.WorkedDays > 0 {
+		averageBreakTime = roundTwoDecimals(float64(row.BreakMinutes) / float64(row.WorkedDays))
+	}
+	if row.TotalShifts > 0 {
+		averageTimePerShift = roundTwoDecimals(float64(row.TotalMinutes) / float64(row.TotalShifts))
+	}
+
+	return map[string]float64{
+		"total_time":             float64(row.TotalMinutes),
+		"presence_rate":          presenceRate,
+		"weekly_rate_expected":   weeklyRateExpected,
+		"weekly_time_done":       weeklyTimeDone,
+		"average_break_time":     averageBreakTime,
+		"average_time_per_shift": averageTimePerShift,
+		"total_shifts":           float64(row.TotalShifts),
+	}
+}
+
+// queryTeamMetrics computes the metrics of a team from the totals of its members, as the team KPIs do.
+func queryTeamMetrics(members []model.KPIQueryUserRow) map[string]float64 {
+	var totalTime, totalShifts, totalBreak, totalWorkedDays int
+	var weeklyRateExpected, weeklyTimeDone float64
+
+	for _, member := range members {
+		_, memberExpected, memberDone := queryPresenceRate(member)
+		weeklyRateExpected += memberExpected
+		weeklyTimeDone += memberDone
+		totalTime += member.TotalMinutes
+		totalShifts += member.TotalShifts
+		totalBreak += member.BreakMinutes
+		totalWorkedDays += member.WorkedDays
+	}
+
+	metrics := map[string]float64{
+		"total_time":             float64(totalTime),
+		"presence_rate":          0,
+		"weekly_rate_expected":   roundTwoDecimals(weeklyRateExpected),
+		"weekly_time_done":       roundTwoDecimals(weeklyTimeDone),
+		"average_break_time":     0,
+		"average_time_per_shift": 0,
+		"total_shifts":           float64(totalShifts),
+	}
+	if weeklyRateExpected > 0 {
+		metrics["presence_rate"] = roundTwoDecimals(weeklyTimeDone / weeklyRateExpected * 100)
+	}
+	if totalWorkedDays > 0 {
+		metrics["average_break_time"] = roundTwoDecimals(float64(totalBreak) / float64(totalWorkedDays))
+	}
+	if totalShifts > 0 {
+		metrics["average_time_per_shift"] = roundTwoDecimals(float64(totalTime) / float64(totalShifts))
+	}
+	return metrics
+}
+
+// queryPresenceRate returns the presence rate, the expected hours and the hours done by the user,
+// the weekly rate being clipped to the contracts as getUserPresenceRate does.
+func queryPresenceRate(row model.KPIQueryUserRow) (float64, float64, float64) {
+	weeklyTimeDone := roundTwoDecimals(float64(row.TotalMinutes) / 60)
+	weeklyRateExpected := roundTwoDecimals(row.WeeklyRate)
+
+	coverage := 1.0
+	if row.Managed && row.FullExpected > 0 {
+		coverage = row.ContractExpected / row.FullExpected
+	}
+	if coverage != 1 {
+		weeklyRateExpected = roundTwoDecimals(weeklyRateExpected * coverage)
+	}
+
+	var presenceRate float64
+	if weeklyRateExpected > 0 {
+		presenceRate = roundTwoDecimals(weeklyTimeDone / weeklyRateExpected * 100)
+	}
+	return presenceRate, weeklyRateExpected, weeklyTimeDone
+}
+
+// selectMetrics keeps the requested metrics only.
+func selectMetrics(metrics map[string]float64, names []string) map[string]float64 {
+	selected := make(map[string]float64, len(names))
+	for _, name := range names {
+		if value, exists := metrics[name]; exists {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+// uniqueStrings removes the empty and duplicated values, keeping the order of the first occurrences.
+func uniqueStrings(values []string) []string {
+	unique := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
+
+// missingKeys returns the keys not found in the map.
+func missingKeys[V any](keys []string, found map[string]V) []string {
+	var missing []string
+	for _, key := range keys {
+		if _, exists := found[key]; !exists {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// Well-being risk model. Every factor scores from 0 to 2 points and the risk score is their sum, from 0 to 10:
+//   - consecutive_working_days: longest streak of consecutive calendar days worked, 1 point from 6 days, 2 points from 10 days
+//   - weeks_above_weekly_rate: percentage of the weeks where the hours done exceed the expected hours, 1 point from 25%, 2 points from 50%
+//   - late_evening_work: percentage of the worked days with a session ending from 21:00 or after midnight, 1 point from 20%, 2 points from 40%
+//   - weekend_work: percentage of the worked days falling on a saturday or a sunday, 1 point from 20%, 2 points from 40%
+//   - shrinking_breaks: decrease of the average break time per worked day against the previous period of the same length, 1 point from 25%, 2 points from 50%
+//
+// The risk level is "low" below 3 points, "moderate" below 6 points and "high" from 6 points.
+const (
+	wellBeingLateEveningHour = 21
+	wellBeingModerateRisk    = 3
+	wellBeingHighRisk        = 6
+)
+
+func (service *kpiService) GetWellBeing(startDate string, endDate string, userUUID string, requesterUUID string, isAdmin bool) (model.KPIWellBeingResponse, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	if !isAdmin {
+		requesterID, err := service.UserService.GetIdByUuid(requesterUUID)
+		if err != nil {
+			return model.KPIWellBeingResponse{}, err
+		}
+
+		isManaged, err := service.TeamService.IsUserManagedBy(userID, requesterID)
+		if err != nil {
+			return model.KPIWellBeingResponse{}, err
+		}
+		if !isManaged {
+			return model.KPIWellBeingResponse{}, fmt.Errorf("%s", Config.ErrorMessages()["NOT_TEAM_MANAGER"])
+		}
+	}
+
+	data, err := service.UserService.GetUserByUUID(userUUID)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	firstDayOfWeek := 1
+	if data.FirstDayOfWeek != nil {
+		firstDayOfWeek = *data.FirstDayOfWeek
+	}
+
+	response, err := service.getUserWellBeing(userID, startDate, endDate, firstDayOfWeek)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	response.FirstName = data.FirstName
+	response.LastName = data.LastName
+	response.UserUUID = userUUID
+	return response, nil
+}
+
+func (service *kpiService) GetTeamWellBeing(startDate string, endDate string, teamUUID string, requesterUUID string, isAdmin bool) (model.KPITeamWellBeingResponse, error) {
+	if !isAdmin {
+		teamID, err := service.TeamService.GetIdByUuid(teamUUID)
+		if err != nil {
+			return model.KPITeamWellBeingResponse{}, err
+		}
+
+		requesterID, err := service.UserService.GetIdByUuid(requesterUUID)
+		if err != nil {
+			return model.KPITeamWellBeingResponse{}, err
+		}
+
+		isManager, err := service.TeamService.IsTeamManager(teamID, requesterID)
+		if err != nil {
+			return model.KPITeamWellBeingResponse{}, err
+		}
+		if !isManager {
+			return model.KPITeamWellBeingResponse{}, fmt.Errorf("%s", Config.ErrorMessages()["NOT_TEAM_MANAGER"])
+		}
+	}
+
+	team, members, err := service.getTeamMembers(teamUUID)
+	if err != nil {
+		return model.KPITeamWellBeingResponse{}, err
+	}
+
+	response := model.KPITeamWellBeingResponse{
+		TeamUUID:  teamUUID,
+		TeamName:  team.Name,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Members:   make([]model.KPIWellBeingResponse, 0, len(members)),
+	}
+
+	var totalRiskScore int
+	for _, member := range members {
+		data, err := service.UserService.GetUserByUUID(member.UserUUID)
+		if err != nil {
+			return model.KPITeamWellBeingResponse{}, err
+		}
+
+		firstDayOfWeek := 1
+		if data.FirstDayOfWeek != nil {
+			firstDayOfWeek = *data.FirstDayOfWeek
+		}
+
+		wellBeing, err := service.getUserWellBeing(member.UserID, startDate, endDate, firstDayOfWeek)
+		if err != nil {
+			return model.KPITeamWellBeingResponse{}, err
+		}
+		wellBeing.FirstName = member.FirstName
+		wellBeing.LastName = member.LastName
+		wellBeing.UserUUID = member.UserUUID
+
+		switch wellBeing.RiskLevel {
+		case "high":
+			response.HighRisk++
+		case "moderate":
+			response.ModerateRisk++
+		default:
+			response.LowRisk++
+		}
+		totalRiskScore += wellBeing.RiskScore
+		response.Members = append(response.Members, wellBeing)
+	}
+
+	if len(members) > 0 {
+		response.AverageRiskScore = roundTwoDecimals(float64(totalRiskScore) / float64(len(members)))
+	}
+	sort.SliceStable(response.Members, func(i, j int) bool {
+		return response.Members[i].RiskScore > response.Members[j].RiskScore
+	})
+
+	return response, nil
+}
+
+// getUserWellBeing computes the well-being indicators of the user and their risk level, see the risk model above.
+// Weeks start on firstDayOfWeek and the expected hours follow the contracts of the user.
+func (service *kpiService) getUserWellBeing(userID int, startDate string, endDate string, firstDayOfWeek int) (model.KPIWellBeingResponse, error) {
+	start, err := parseKPIDate(startDate)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	end, err := parseKPIDate(endDate)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	rows, err := service.KPIRepository.GetUserWorkingDays(userID, startDate, endDate, wellBeingLateEveningHour)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	distribution, err := service.KPIRepository.GetUserWeeklyRateDistribution(userID)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	schedule, err := service.ContractService.GetSchedule(userID, start, end)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	previousStartDate, previousEndDate, err := previousPeriod(startDate, endDate)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	averageBreakTime, err := service.KPIRepository.GetUserAverageBreakTime(userID, startDate, endDate)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	previousAverageBreakTime, err := service.KPIRepository.GetUserAverageBreakTime(userID, previousStartDate, previousEndDate)
+	if err != nil {
+		return model.KPIWellBeingResponse{}, err
+	}
+
+	response := model.KPIWellBeingResponse{
+		StartDate:                startDate,
+		EndDate:                  endDate,
+		WorkedDays:               len(rows),
+		AverageBreakTime:         roundTwoDecimals(averageBreakTime),
+		PreviousAverageBreakTime: roundTwoDecimals(previousAverageBreakTime),
+	}
+
+	// streaks of consecutive days, rows being ordered by day
+	workedMinutes := make(map[string]int, len(rows))
+	streak := 0
+	var previousDay time.Time
+	for _, row := range rows {
+		day, err := time.Parse("2006-01-02", row.Day)
+		if err != nil {
+			return model.KPIWellBeingResponse{}, fmt.Errorf("invalid day %q: %w", row.Day, err)
+		}
+
+		if streak > 0 && day.Equal(previousDay.AddDate(0, 0, 1)) {
+			streak++
+		} else {
+			streak = 1
+		}
+		previousDay = day
+		response.LongestStreak = max(response.LongestStreak, streak)
+
+		if row.LateEvening {
+			response.LateEveningDays++
+		}
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			response.WeekendDays++
+		}
+		workedMinutes[row.Day] = row.TotalMinutes
+	}
+
+	// weeks overflowing the range are clipped to it, weeks without any expected hour are ignored
+	firstDay := truncateToDay(start)
+	lastDay := truncateToDay(end)
+	offset := (int(firstDay.Weekday()) - firstDayOfWeek%7 + 7) % 7
+	for weekStart := firstDay.AddDate(0, 0, -offset); !weekStart.After(lastDay); weekStart = weekStart.AddDate(0, 0, 7) {
+		var expectedHours, doneHours float64
+		for day := weekStart; day.Before(weekStart.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+			if day.Before(firstDay) || day.After(lastDay) {
+				continue
+			}
+			hours, _ := schedule.ExpectedHoursOn(day, distribution)
+			expectedHours += hours
+			doneHours += float64(workedMinutes[day.Format("2006-01-02")]) / 60
+		}
+
+		if expectedHours == 0 {
+			continue
+		}
+		response.Weeks++
+		if doneHours > expectedHours {
+			response.WeeksAboveRate++
+		}
+	}
+
+	var weeksAboveRate float64
+	if response.Weeks > 0 {
+		weeksAboveRate = roundTwoDecimals(float64(response.WeeksAboveRate) / float64(response.Weeks) * 100)
+	}
+	if response.WorkedDays > 0 {
+		response.LateEveningRate = roundTwoDecimals(float64(response.LateEveningDays) / float64(response.WorkedDays) * 100)
+		response.WeekendRate = roundTwoDecimals(float64(response.WeekendDays) / float64(response.WorkedDays) * 100)
+	}
+
+	var breakDecrease float64
+	if previousAverageBreakTime > 0 {
+		breakTimeChange := roundTwoDecimals((averageBreakTime - previousAverageBreakTime) / previousAverageBreakTime * 100)
+		response.BreakTimeChange = &breakTimeChange
+		breakDecrease = -breakTimeChange
+	}
+
+	response.Factors = []model.KPIWellBeingFactor{
+		{Factor: "consecutive_working_days", Value: float64(response.LongestStreak), Points: riskPoints(float64(response.LongestStreak), 6, 10)},
+		{Factor: "weeks_above_weekly_rate", Value: weeksAboveRate, Points: riskPoints(weeksAboveRate, 25, 50)},
+		{Factor: "late_evening_work", Value: response.LateEveningRate, Points: riskPoints(response.LateEveningRate, 20, 40)},
+		{Factor: "weekend_work", Value: response.WeekendRate, Points: riskPoints(response.WeekendRate, 20, 40)},
+		{Factor: "shrinking_breaks", Value: roundTwoDecimals(breakDecrease), Points: riskPoints(breakDecrease, 25, 50)},
+	}
+
+	for _, factor := range response.Factors {
+		response.RiskScore += factor.Points
+	}
+
+	switch {
+	case response.RiskScore >= wellBeingHighRisk:
+		response.RiskLevel = "high"
+	case response.RiskScore >= wellBeingModerateRisk:
+		response.RiskLevel = "moderate"
+	default:
+		response.RiskLevel = "low"
+	}
+
+	return response, nil
+}
+
+// riskPoints scores a well-being factor: 1 point from the first threshold and 2 points from the second one.
+func riskPoints(value float64, onePoint float64, twoPoints float64) int {
+	switch {
+	case value >= twoPoints:
+		return 2
+	case value >= onePoint:
+		return 1
+	}
+	return 0
+}
+
+// parseKPIDate parses the date formats accepted by the KPI routes.
+func parseKPIDate(date string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339,
+		time.RFC3339Nano,
+		"2006-01-02",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04:05.999999",
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date format: %s", date)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func roundTwoDecimals(value float64) float64 {
+	return math.Round(value*100) / 100
 }
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"app/internal/app/login-attempt/model"
+	LoginAttemptService "app/internal/app/login-attempt/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginAttemptHandler struct {
+	service LoginAttemptService.LoginAttemptService
+}
+
+func NewLoginAttemptHandler(service LoginAttemptService.LoginAttemptService) *LoginAttemptHandler {
+	return &LoginAttemptHandler{service: service}
+}
+
+// UnlockWithToken Login Attempts
+//
+// @Summary      Unlock an account with the link sent by email
+// @Description  End the lockout of an account, locked after too many failed login attempts, with the token of the link sent to its owner. A link only unlocks the lockout it was sent for.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.UnlockRequest  true  "Token of the unlock link"
+// @Success      200 		"account unlocked successfully"
+// @Router       /auth/unlock [post]
+func (handler *LoginAttemptHandler) UnlockWithToken(c *gin.Context) {
+	var request model.UnlockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if err := handler.service.UnlockWithToken(request.Token); err != nil {
+		c.JSON(loginAttemptErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked successfully"})
+}
+
+// Unlock Login Attempts
+//
+// @Summary      Unlock an account
+// @Description  End the lockout of a user locked after too many failed login attempts, and forget the failures of the account. 🔒 Requires role: **admin**
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        uuid  path      string  true  "User UUID"
+// @Success      200 		"account unlocked successfully"
+// @Router       /users/unlock/{uuid} [post]
+func (handler *LoginAttemptHandler) Unlock(c *gin.Context) {
+	if err := handler.service.Unlock(c.Param("uuid")); err != nil {
+		c.JSON(loginAttemptErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked successfully"})
+}
+
+// GetHistory Login Attempts
+//
+// @Summary      Get the failed login attempts of a user
+// @Description  Retrieve whether the account of a user is locked, its failures within the current window, and its latest failed login attempts with their IP address and reason. 🔒 Requires role: **admin**
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        uuid  path      string  true  "User UUID"
+// @Success      200  {object}  model.LoginAttemptHistory
+// @Router       /users/login-attempts/{uuid} [get]
+func (handler *LoginAttemptHandler) GetHistory(c *gin.Context) {
+	history, err := handler.service.GetHistory(c.Param("uuid"))
+	if err != nil {
+		c.JSON(loginAttemptErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func loginAttemptErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_UNLOCK_TOKEN"]):
+		return http.StatusUnauthorized
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_REQUEST"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// Reasons of the failed login attempts
+const (
+	ReasonUnknownUser     = "unknown_user"
+	ReasonDisabled        = "disabled"
+	ReasonInvalidPassword = "invalid_password"
+	ReasonInvalidMFACode  = "invalid_mfa_code"
+	// the attempt was rejected before checking the credentials, during a delay or a lockout
+	ReasonThrottled = "throttled"
+	ReasonLocked    = "locked"
+)
+
+// LoginThrottleConfig holds the settings of the login throttling, loaded from the environment.
+type LoginThrottleConfig struct {
+	// failures are counted per account and per IP address during this window, restarted by the first failure
+	FailureWindowMinutes int
+	// failures of an account, then of an IP address, allowed before the delays start
+	AccountFreeAttempts int
+	IPFreeAttempts      int
+	// the delay before the next attempt doubles with each failure, from the base delay up to the max delay
+	BaseDelaySeconds int
+	MaxDelaySeconds  int
+	// failures of an account within the window locking it, until unlocked by email or by an admin
+	LockoutThreshold int
+	LockoutMinutes   int
+}
+
+// LoginAttempt is a login being checked: UserUUID is empty when the login matches no user.
+type LoginAttempt struct {
+	UserUUID  string
+	Login     string
+	IPAddress string
+	// set once reserved: the failures of the account, the attempt included
+	ReservationID string
+	Failures      int
+}
+
+// swagger:model FailedLoginAttempt
+type FailedLoginAttempt struct {
+	ID        int       `json:"-" gorm:"column:id"`
+	UserID    *int      `json:"-" gorm:"column:user_id"`
+	Login     string    `json:"login" gorm:"column:login" example:"jane@example.com"`
+	IPAddress string    `json:"ip_address" gorm:"column:ip_address" example:"203.0.113.7"`
+	Reason    string    `json:"reason" gorm:"column:reason" example:"invalid_password"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// swagger:model LoginAttemptHistory
+type LoginAttemptHistory struct {
+	Locked bool `json:"locked"`
+	// seconds left before the lockout ends
+	LockedFor int `json:"locked_for" example:"1200"`
+	// failures of the account within the current window
+	Failures int `json:"failures" example:"3"`
+	// latest failed attempts, most recent first
+	Attempts []FailedLoginAttempt `json:"attempts"`
+}
+
+// swagger:model UnlockRequest
+type UnlockRequest struct {
+	// token of the link sent by email when the account was locked
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"app/internal/app/login-attempt/model"
+)
+
+// LoginAttemptRepository records the failed login attempts.
+type LoginAttemptRepository interface {
+	Create(attempt model.FailedLoginAttempt) error
+	FindByUserID(userID int, limit int) ([]model.FailedLoginAttempt, error)
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db}
+}
+
+func (repo *loginAttemptRepository) Create(attempt model.FailedLoginAttempt) error {
+	err := repo.db.Exec(`
+		INSERT INTO login_attempts (user_id, login, ip_address, reason)
+		VALUES (?, ?, ?, ?)
+	`, attempt.UserID, attempt.Login, attempt.IPAddress, attempt.Reason).Error
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// FindByUserID returns the latest failed attempts of the user, most recent first.
+func (repo *loginAttemptRepository) FindByUserID(userID int, limit int) ([]model.FailedLoginAttempt, error) {
+	attempts := []model.FailedLoginAttempt{}
+
+	err := repo.db.Raw(`
+		SELECT id, user_id, login, ip_address, reason, created_at
+		FROM login_attempts
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, userID, limit).Scan(&attempts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
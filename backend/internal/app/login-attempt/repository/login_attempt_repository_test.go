@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"app/internal/app/login-attempt/model"
+	"app/internal/app/login-attempt/repository"
+	"app/internal/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const attemptUserUUID = "123e4567-e89b-12d3-a456-426614174012"
+
+func TestCreateAndFindLoginAttempts(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewLoginAttemptRepository(db)
+
+	db.Exec(`INSERT INTO users (uuid, username, email, password_hash, status, roles) VALUES (?, 'jane', 'jane@example.com', 'hashed_password', 'active', '{employee}')`, attemptUserUUID)
+	var userID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", attemptUserUUID).Scan(&userID)
+
+	assert.NoError(t, repo.Create(model.FailedLoginAttempt{UserID: &userID, Login: "jane@example.com", IPAddress: "203.0.113.7", Reason: model.ReasonInvalidPassword}))
+	assert.NoError(t, repo.Create(model.FailedLoginAttempt{UserID: &userID, Login: "jane", IPAddress: "203.0.113.8", Reason: model.ReasonThrottled}))
+	// attempts matching no user are recorded too
+	assert.NoError(t, repo.Create(model.FailedLoginAttempt{Login: "ghost@example.com", IPAddress: "203.0.113.7", Reason: model.ReasonUnknownUser}))
+
+	attempts, err := repo.FindByUserID(userID, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, attempts, 2) {
+		// most recent first
+		assert.Equal(t, model.ReasonThrottled, attempts[0].Reason)
+		assert.Equal(t, "203.0.113.8", attempts[0].IPAddress)
+		assert.Equal(t, model.ReasonInvalidPassword, attempts[1].Reason)
+		assert.Equal(t, "jane@example.com", attempts[1].Login)
+		assert.False(t, attempts[1].CreatedAt.IsZero())
+	}
+
+	attempts, err = repo.FindByUserID(userID, 1)
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+
+	var total int
+	db.Raw("SELECT COUNT(*) FROM login_attempts").Scan(&total)
+	assert.Equal(t, 3, total)
+}
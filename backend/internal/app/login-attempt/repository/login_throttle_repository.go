@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/app/login-attempt/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes the lock of the account, only when it is the given one unless none is given,
+// so that the link of an expired lockout cannot end the next one.
+var unlockScript = redis.NewScript(`
+if ARGV[1] ~= '' and redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// reserveScript rejects the attempt while the account is locked or while the account or the IP address is delayed,
+// returning 1 or 2 with the time left. Otherwise it counts the attempt as a failure of both, delays them for the base
+// delay doubled with each failure past their free attempts, and returns 0 with the failures of the account.
+// Checking and counting at once, concurrent attempts cannot all pass the check before the first of them is counted.
+var reserveScript = redis.NewScript(`
+local locked = redis.call('PTTL', KEYS[1])
+if locked > 0 then
+	return {1, locked}
+end
+local delayed = math.max(redis.call('PTTL', KEYS[3]), redis.call('PTTL', KEYS[5]))
+if delayed > 0 then
+	return {2, delayed}
+end
+
+local function count(failuresKey, delayKey, freeAttempts)
+	local failures = redis.call('INCR', failuresKey)
+	redis.call('PEXPIRE', failuresKey, ARGV[2], 'NX')
+	local extra = failures - tonumber(freeAttempts)
+	if extra > 0 then
+		local delay = tonumber(ARGV[6])
+		if extra <= 30 then
+			delay = math.min(tonumber(ARGV[5]) * 2 ^ (extra - 1), delay)
+		end
+		if delay > 0 then
+			redis.call('SET', delayKey, ARGV[1], 'PX', math.floor(delay))
+		end
+	end
+	return failures
+end
+
+local failures = count(KEYS[2], KEYS[3], ARGV[3])
+count(KEYS[4], KEYS[5], ARGV[4])
+return {0, failures}
+`)
+
+// releaseScript uncounts the reserved attempt from each subject, and lifts the delay it started, if any.
+var releaseScript = redis.NewScript(`
+for i = 1, #KEYS, 2 do
+	if tonumber(redis.call('GET', KEYS[i]) or '0') > 0 then
+		redis.call('DECR', KEYS[i])
+	end
+	if redis.call('GET', KEYS[i + 1]) == ARGV[1] then
+		redis.call('DEL', KEYS[i + 1])
+	end
+end
+return 0
+`)
+
+// Reservation is the outcome of reserving an attempt: RetryAfter is set when it is rejected,
+// Failures when it is counted.
+type Reservation struct {
+	Locked     bool
+	RetryAfter time.Duration
+	// failures of the account within the window, the reserved attempt included
+	Failures int
+}
+
+// LoginThrottleRepository stores in Redis the failures counted per subject, an account or an IP address,
+// the delays before their next attempt, and the lockouts of the accounts.
+type LoginThrottleRepository interface {
+	Reserve(userUUID string, account string, ip string, reservationID string, config model.LoginThrottleConfig) (Reservation, error)
+	Release(reservationID string, subjects ...string) error
+	Failures(subject string) (int, error)
+	Reset(subject string) error
+	Lock(userUUID string, lockID string, duration time.Duration) (bool, error)
+	FindLock(userUUID string) (string, time.Duration, error)
+	Unlock(userUUID string, lockID string) (bool, error)
+}
+
+type loginThrottleRepository struct {
+	client *redis.Client
+}
+
+func NewLoginThrottleRepository(client *redis.Client) LoginThrottleRepository {
+	return &loginThrottleRepository{client}
+}
+
+func failuresKey(subject string) string {
+	return "login:failures:" + subject
+}
+
+func delayKey(subject string) string {
+	return "login:delay:" + subject
+}
+
+func lockKey(userUUID string) string {
+	return "login:lock:" + userUUID
+}
+
+// Reserve checks and counts the attempt of the user, unknown when userUUID is empty, at once: the attempt
+// is counted as a failure of the account and of the IP address until it is released, and delays their next
+// attempts past their free ones. The delays it starts are marked with reservationID.
+func (repo *loginThrottleRepository) Reserve(userUUID string, account string, ip string, reservationID string, config model.LoginThrottleConfig) (Reservation, error) {
+	keys := []string{lockKey(userUUID), failuresKey(account), delayKey(account), failuresKey(ip), delayKey(ip)}
+	if userUUID == "" {
+		// no account is locked without a user, and this key is never set
+		keys[0] = lockKey("")
+	}
+
+	result, err := reserveScript.Run(context.Background(), repo.client, keys,
+		reservationID,
+		(time.Duration(config.FailureWindowMinutes) * time.Minute).Milliseconds(),
+		config.AccountFreeAttempts,
+		config.IPFreeAttempts,
+		(time.Duration(config.BaseDelaySeconds) * time.Second).Milliseconds(),
+		(time.Duration(config.MaxDelaySeconds) * time.Second).Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Reservation{}, fmt.Errorf("failed to reserve login attempt: %w", err)
+	}
+
+	switch result[0] {
+	case 1:
+		return Reservation{Locked: true, RetryAfter: time.Duration(result[1]) * time.Millisecond}, nil
+	case 2:
+		return Reservation{RetryAfter: time.Duration(result[1]) * time.Millisecond}, nil
+	}
+
+	return Reservation{Failures: int(result[1])}, nil
+}
+
+// Release uncounts the reserved attempt from the subjects, once it turned out not to be a failure,
+// and lifts the delays it started.
+func (repo *loginThrottleRepository) Release(reservationID string, subjects ...string) error {
+	keys := make([]string, 0, 2*len(subjects))
+	for _, subject := range subjects {
+		keys = append(keys, failuresKey(subject), delayKey(subject))
+	}
+
+	if err := releaseScript.Run(context.Background(), repo.client, keys, reservationID).Err(); err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *loginThrottleRepository) Failures(subject string) (int, error) {
+	count, err := repo.client.Get(context.Background(), failuresKey(subject)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch login failures: %w", err)
+	}
+
+	return count, nil
+}
+
+// Reset forgets the failures and the delay of the subject.
+func (repo *loginThrottleRepository) Reset(subject string) error {
+	if err := repo.client.Del(context.Background(), failuresKey(subject), delayKey(subject)).Err(); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	return nil
+}
+
+// Lock locks the account for the duration, false being returned when it is already locked.
+func (repo *loginThrottleRepository) Lock(userUUID string, lockID string, duration time.Duration) (bool, error) {
+	locked, err := repo.client.SetNX(context.Background(), lockKey(userUUID), lockID, duration).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to lock account: %w", err)
+	}
+
+	return locked, nil
+}
+
+// FindLock returns the ID of the lock of the account and the time left before it ends,
+// an empty ID when the account is not locked.
+func (repo *loginThrottleRepository) FindLock(userUUID string) (string, time.Duration, error) {
+	ctx := context.Background()
+
+	var lockID *redis.StringCmd
+	var ttl *redis.DurationCmd
+	_, err := repo.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		lockID = pipe.Get(ctx, lockKey(userUUID))
+		ttl = pipe.PTTL(ctx, lockKey(userUUID))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to fetch account lock: %w", err)
+	}
+
+	return lockID.Val(), max(ttl.Val(), 0), nil
+}
+
+// Unlock ends the lockout of the account when lockID is its lock, or whatever its lock when lockID is empty.
+// False is returned when the account was not locked by it.
+func (repo *loginThrottleRepository) Unlock(userUUID string, lockID string) (bool, error) {
+	deleted, err := unlockScript.Run(context.Background(), repo.client, []string{lockKey(userUUID)}, lockID).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	return deleted == 1, nil
+}
@@ -0,0 +1,187 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"app/internal/app/login-attempt/model"
+	"app/internal/app/login-attempt/repository"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func newRedisClient(t *testing.T) *redis.Client {
+	ctx := context.Background()
+	const port = "6379/tcp"
+
+	redisC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{port},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("❌ Failed to start Redis: %v", err)
+	}
+	t.Cleanup(func() { _ = redisC.Terminate(ctx) })
+
+	host, _ := redisC.Host(ctx)
+	mappedPort, _ := redisC.MappedPort(ctx, port)
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, mappedPort.Port())})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+var throttleConfig = model.LoginThrottleConfig{
+	FailureWindowMinutes: 1,
+	AccountFreeAttempts:  2,
+	IPFreeAttempts:       5,
+	BaseDelaySeconds:     30,
+	MaxDelaySeconds:      60,
+}
+
+func TestReserveAndRelease(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewLoginThrottleRepository(client)
+
+	for expected := 1; expected <= 2; expected++ {
+		reservation, err := repo.Reserve("user-1", "user:user-1", "ip:203.0.113.7", fmt.Sprintf("attempt-%d", expected), throttleConfig)
+		assert.NoError(t, err)
+		assert.Zero(t, reservation.RetryAfter)
+		assert.Equal(t, expected, reservation.Failures)
+	}
+
+	// the window starts with the first failure, it is not extended by the next ones
+	ttl, err := client.TTL(context.Background(), "login:failures:user:user-1").Result()
+	assert.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	// past the free attempts, the attempt is counted and delays the next one
+	reservation, err := repo.Reserve("user-1", "user:user-1", "ip:203.0.113.7", "attempt-3", throttleConfig)
+	assert.NoError(t, err)
+	assert.Zero(t, reservation.RetryAfter)
+	assert.Equal(t, 3, reservation.Failures)
+
+	reservation, err = repo.Reserve("user-1", "user:user-1", "ip:203.0.113.7", "attempt-4", throttleConfig)
+	assert.NoError(t, err)
+	assert.False(t, reservation.Locked)
+	assert.True(t, reservation.RetryAfter > 25*time.Second && reservation.RetryAfter <= 30*time.Second)
+
+	failures, err := repo.Failures("user:user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, failures)
+
+	// releasing the attempt uncounts it and lifts the delay it started
+	assert.NoError(t, repo.Release("attempt-3", "user:user-1", "ip:203.0.113.7"))
+	failures, err = repo.Failures("user:user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failures)
+	failures, err = repo.Failures("ip:203.0.113.7")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failures)
+
+	reservation, err = repo.Reserve("user-1", "user:user-1", "ip:203.0.113.7", "attempt-5", throttleConfig)
+	assert.NoError(t, err)
+	assert.Zero(t, reservation.RetryAfter)
+
+	// the release of an older attempt does not lift the delay of a newer one
+	assert.NoError(t, repo.Release("attempt-1", "user:user-1"))
+	reservation, err = repo.Reserve("user-1", "user:user-1", "ip:203.0.113.7", "attempt-6", throttleConfig)
+	assert.NoError(t, err)
+	assert.True(t, reservation.RetryAfter > 0)
+
+	assert.NoError(t, repo.Reset("user:user-1"))
+	failures, err = repo.Failures("user:user-1")
+	assert.NoError(t, err)
+	assert.Zero(t, failures)
+
+	// a lock rejects the attempts of the account, whatever its delays
+	_, err = repo.Lock("user-1", "lock-1", time.Hour)
+	assert.NoError(t, err)
+	reservation, err = repo.Reserve("user-1", "user:user-1", "ip:198.51.100.1", "attempt-7", throttleConfig)
+	assert.NoError(t, err)
+	assert.True(t, reservation.Locked)
+	assert.True(t, reservation.RetryAfter > 59*time.Minute)
+}
+
+func TestConcurrentAttemptsAreThrottled(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewLoginThrottleRepository(client)
+
+	var reserved atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, err := repo.Reserve("", "login:jane@example.com", "ip:203.0.113.7", fmt.Sprintf("attempt-%d", i), throttleConfig)
+			assert.NoError(t, err)
+			if reservation.RetryAfter == 0 {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the free attempts pass, then the first delayed one, and every other attempt waits for its delay
+	assert.Equal(t, int32(throttleConfig.AccountFreeAttempts+1), reserved.Load())
+
+	failures, err := repo.Failures("login:jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, throttleConfig.AccountFreeAttempts+1, failures)
+}
+
+func TestLockAndUnlock(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewLoginThrottleRepository(client)
+
+	lockID, lockedFor, err := repo.FindLock("user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, lockID)
+	assert.Zero(t, lockedFor)
+
+	locked, err := repo.Lock("user-1", "lock-1", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	// a locked account is not locked again, so that a single unlock email is sent
+	locked, err = repo.Lock("user-1", "lock-2", time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, locked)
+
+	lockID, lockedFor, err = repo.FindLock("user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "lock-1", lockID)
+	assert.True(t, lockedFor > 59*time.Minute)
+
+	// the link of another lockout does not unlock the account
+	unlocked, err := repo.Unlock("user-1", "lock-2")
+	assert.NoError(t, err)
+	assert.False(t, unlocked)
+
+	unlocked, err = repo.Unlock("user-1", "lock-1")
+	assert.NoError(t, err)
+	assert.True(t, unlocked)
+
+	lockID, _, err = repo.FindLock("user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, lockID)
+
+	// the admins unlock whatever the lock
+	_, err = repo.Lock("user-1", "lock-3", time.Hour)
+	assert.NoError(t, err)
+	unlocked, err = repo.Unlock("user-1", "")
+	assert.NoError(t, err)
+	assert.True(t, unlocked)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"app/internal/app/login-attempt/model"
+	"app/internal/app/login-attempt/repository"
+	MailModel "app/internal/app/mailer/model"
+	MailerService "app/internal/app/mailer/service"
+	MailTemplate "app/internal/app/mailer/template"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+	"app/internal/metrics"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// unlockPurpose marks the JWT of the unlock links sent by email
+	unlockPurpose = "unlock"
+	historyLimit  = 50
+)
+
+// ThrottledError is returned when an attempt is rejected, before checking the credentials,
+// because its account or its IP address is delayed or locked.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (err *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %d seconds", Config.ErrorMessages()["TOO_MANY_LOGIN_ATTEMPTS"], err.Seconds())
+}
+
+// Seconds returns the time left before the next attempt, rounded up, as sent in the Retry-After header.
+func (err *ThrottledError) Seconds() int {
+	return int(math.Ceil(err.RetryAfter.Seconds()))
+}
+
+/**
+ * {unlockClaims} are the parameters of the unlock links, only valid for the lockout they were sent for
+ */
+type unlockClaims struct {
+	UserUUID string `json:"user_uuid"`
+	LockID   string `json:"lock_id"`
+	Purpose  string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// LoginAttemptService throttles the logins: every attempt is reserved before checking the credentials, being counted
+// per account and per IP address as a failure until it succeeds or is released. Past the free attempts, each failure
+// doubles the delay before the next attempt, and an account is locked once it reaches the lockout threshold within
+// the window, an unlock link being sent by email.
+type LoginAttemptService interface {
+	Reserve(attempt model.LoginAttempt) (model.LoginAttempt, error)
+	RecordFailure(attempt model.LoginAttempt, reason string) error
+	RecordSuccess(attempt model.LoginAttempt) error
+	Release(attempt model.LoginAttempt) error
+	Unlock(userUUID string) error
+	UnlockWithToken(token string) error
+	GetHistory(userUUID string) (model.LoginAttemptHistory, error)
+}
+
+type loginAttemptService struct {
+	Repository         repository.LoginAttemptRepository
+	ThrottleRepository repository.LoginThrottleRepository
+	UserService        UserService.UserService
+	MailerService      MailerService.MailerService
+	Config             model.LoginThrottleConfig
+}
+
+func NewLoginAttemptService(repo repository.LoginAttemptRepository, throttleRepo repository.LoginThrottleRepository, userService UserService.UserService, mailerService MailerService.MailerService, config model.LoginThrottleConfig) LoginAttemptService {
+	return &loginAttemptService{
+		Repository:         repo,
+		ThrottleRepository: throttleRepo,
+		UserService:        userService,
+		MailerService:      mailerService,
+		Config:             config,
+	}
+}
+
+// accountSubject counts the failures of an unknown login apart, so that they are throttled the same way.
+func accountSubject(attempt model.LoginAttempt) string {
+	if attempt.UserUUID != "" {
+		return "user:" + attempt.UserUUID
+	}
+	return "login:" + strings.ToLower(strings.TrimSpace(attempt.Login))
+}
+
+func ipSubject(attempt model.LoginAttempt) string {
+	return "ip:" + attempt.IPAddress
+}
+
+// Reserve returns a ThrottledError when the account is locked, or when the account or the IP address is delayed,
+// the rejected attempt being recorded without being counted. Otherwise the attempt is counted as a failure at once,
+// delaying the next attempts past the free ones, so that concurrent attempts are throttled as sequential ones are.
+// The reserved attempt must then be passed to RecordFailure, RecordSuccess or Release.
+func (service *loginAttemptService) Reserve(attempt model.LoginAttempt) (model.LoginAttempt, error) {
+	attempt.ReservationID = uuid.New().String()
+
+	reservation, err := service.ThrottleRepository.Reserve(attempt.UserUUID, accountSubject(attempt), ipSubject(attempt), attempt.ReservationID, service.Config)
+	if err != nil {
+		return model.LoginAttempt{}, err
+	}
+
+	if reservation.RetryAfter > 0 {
+		reason := model.ReasonThrottled
+		if reservation.Locked {
+			reason = model.ReasonLocked
+		}
+		service.record(attempt, reason)
+		return model.LoginAttempt{}, &ThrottledError{RetryAfter: reservation.RetryAfter}
+	}
+
+	attempt.Failures = reservation.Failures
+	return attempt, nil
+}
+
+// RecordFailure records the failed attempt, already counted by its reservation,
+// and locks the account when it reached the lockout threshold.
+func (service *loginAttemptService) RecordFailure(attempt model.LoginAttempt, reason string) error {
+	service.record(attempt, reason)
+
+	if attempt.UserUUID != "" && attempt.Failures >= service.Config.LockoutThreshold {
+		return service.lock(attempt.UserUUID)
+	}
+
+	return nil
+}
+
+// RecordSuccess forgets the failures of the account once it is logged in. The failures of the IP address
+// are kept, so that an attacker cannot reset them with an account of their own, only the attempt being uncounted.
+func (service *loginAttemptService) RecordSuccess(attempt model.LoginAttempt) error {
+	if err := service.ThrottleRepository.Reset(accountSubject(attempt)); err != nil {
+		return err
+	}
+
+	return service.ThrottleRepository.Release(attempt.ReservationID, ipSubject(attempt))
+}
+
+// Release uncounts the attempt, neither failed nor succeeded, such as a right password waiting for its second factor.
+func (service *loginAttemptService) Release(attempt model.LoginAttempt) error {
+	return service.ThrottleRepository.Release(attempt.ReservationID, accountSubject(attempt), ipSubject(attempt))
+}
+
+// Unlock ends the lockout of the account and forgets its failures.
+func (service *loginAttemptService) Unlock(userUUID string) error {
+	if _, err := service.UserService.GetIdByUuid(userUUID); err != nil {
+		return fmt.Errorf("%s: user %s not found", Config.ErrorMessages()["INVALID_REQUEST"], userUUID)
+	}
+
+	if _, err := service.ThrottleRepository.Unlock(userUUID, ""); err != nil {
+		return err
+	}
+
+	return service.ThrottleRepository.Reset("user:" + userUUID)
+}
+
+// UnlockWithToken ends the lockout the unlock link was sent for.
+func (service *loginAttemptService) UnlockWithToken(token string) error {
+	claims, err := parseUnlockToken(token)
+	if err != nil {
+		return errors.New(Config.ErrorMessages()["INVALID_UNLOCK_TOKEN"])
+	}
+
+	unlocked, err := service.ThrottleRepository.Unlock(claims.UserUUID, claims.LockID)
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return errors.New(Config.ErrorMessages()["INVALID_UNLOCK_TOKEN"])
+	}
+
+	return service.ThrottleRepository.Reset("user:" + claims.UserUUID)
+}
+
+// GetHistory returns the lockout and the failures of the account, with its latest failed attempts.
+func (service *loginAttemptService) GetHistory(userUUID string) (model.LoginAttemptHistory, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.LoginAttemptHistory{}, fmt.Errorf("%s: user %s not found", Config.ErrorMessages()["INVALID_REQUEST"], userUUID)
+	}
+
+	lockID, lockedFor, err := service.ThrottleRepository.FindLock(userUUID)
+	if err != nil {
+		return model.LoginAttemptHistory{}, err
+	}
+
+	failures, err := service.ThrottleRepository.Failures("user:" + userUUID)
+	if err != nil {
+		return model.LoginAttemptHistory{}, err
+	}
+
+	attempts, err := service.Repository.FindByUserID(userID, historyLimit)
+	if err != nil {
+		return model.LoginAttemptHistory{}, err
+	}
+
+	return model.LoginAttemptHistory{
+		Locked:    lockID != "",
+		LockedFor: (&ThrottledError{RetryAfter: lockedFor}).Seconds(),
+		Failures:  failures,
+		Attempts:  attempts,
+	}, nil
+}
+
+// record stores the failed attempt, a failure to do so not preventing the throttling.
+func (service *loginAttemptService) record(attempt model.LoginAttempt, reason string) {
+	metrics.LoginFailures.Inc(reason)
+
+	failed := model.FailedLoginAttempt{
+		Login:     attempt.Login,
+		IPAddress: attempt.IPAddress,
+		Reason:    reason,
+	}
+	if attempt.UserUUID != "" {
+		if userID, err := service.UserService.GetIdByUuid(attempt.UserUUID); err == nil {
+			failed.UserID = &userID
+		}
+	}
+
+	if err := service.Repository.Create(failed); err != nil {
+		log.Printf("⚠️ Error recording login attempt of %s : %v", attempt.Login, err)
+	}
+}
+
+// lock locks the account, then sends the unlock link to its owner when it was not already locked.
+func (service *loginAttemptService) lock(userUUID string) error {
+	lockID := uuid.New().String()
+	duration := time.Duration(service.Config.LockoutMinutes) * time.Minute
+
+	locked, err := service.ThrottleRepository.Lock(userUUID, lockID, duration)
+	if err != nil || !locked {
+		return err
+	}
+
+	metrics.AccountLockouts.Inc()
+
+	if err := service.sendUnlockMail(userUUID, lockID, duration); err != nil {
+		log.Printf("⚠️ Error sending unlock email to user %s : %v", userUUID, err)
+	}
+
+	return nil
+}
+
+func (service *loginAttemptService) sendUnlockMail(userUUID string, lockID string, duration time.Duration) error {
+	user, err := service.UserService.GetUserByEmailAuth("uuid", userUUID)
+	if err != nil {
+		return err
+	}
+
+	secret := Config.LoadConfig().JWTSecret
+	claims := &unlockClaims{
+		UserUUID: userUUID,
+		LockID:   lockID,
+		Purpose:  unlockPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	unlockToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return fmt.Errorf("failed to generate unlock token: %w", err)
+	}
+
+	link := Config.LoadConfig().FrontendURL + "/unlock-account?token=" + unlockToken
+
+	subject := "Your TimeManager account has been locked 🔒"
+
+	body := MailTemplate.BaseMailTemplate(
+		"Account Locked",
+		fmt.Sprintf(
+			"Hello %s,<br><br>Your account %s has been locked for %d minutes after too many failed login attempts.<br><br>If these attempts were yours, you can unlock your account now with the button below. If they were not, we recommend changing your password once unlocked.",
+			user.FirstName,
+			user.Email,
+			service.Config.LockoutMinutes,
+		),
+		"Unlock my account",
+		link,
+	)
+
+	err = service.MailerService.Send(MailModel.Mail{
+		To:      user.Email,
+		Subject: subject,
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send unlock email: %w", err)
+	}
+
+	return nil
+}
+
+func parseUnlockToken(tokenStr string) (*unlockClaims, error) {
+	secret := Config.LoadConfig().JWTSecret
+	token, err := jwt.ParseWithClaims(tokenStr, &unlockClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*unlockClaims)
+	if !ok || !token.Valid || claims.Purpose != unlockPurpose || claims.UserUUID == "" || claims.LockID == "" {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
@@ -1,9 +1,15 @@
 package model
 
 type Mail struct {
-	To      string
-	Subject string
-	Body    string
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Name    string
+	Content []byte
 }
 
 type MailConfig struct {
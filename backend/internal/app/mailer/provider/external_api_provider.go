@@ -2,17 +2,20 @@ package provider
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
+
+	"app/internal/app/mailer/model"
 )
 
 type BrevoMailer struct {
 	APIKey string
 }
 
-func (b *BrevoMailer) Send(to, subject, body string) error {
+func (b *BrevoMailer) Send(to, subject, body string, attachments []model.Attachment) error {
 	payload := map[string]interface{}{
 		"sender": map[string]string{
 			"name":  "Time Manager",
@@ -25,6 +28,18 @@ func (b *BrevoMailer) Send(to, subject, body string) error {
 		"htmlContent": body,
 	}
 
+	// brevo expects the content of the attachments encoded in base64
+	if len(attachments) > 0 {
+		files := make([]map[string]string, 0, len(attachments))
+		for _, attachment := range attachments {
+			files = append(files, map[string]string{
+				"name":    attachment.Name,
+				"content": base64.StdEncoding.EncodeToString(attachment.Content),
+			})
+		}
+		payload["attachment"] = files
+	}
+
 	data, _ := json.Marshal(payload)
 
 	req, err := http.NewRequest(
@@ -1,9 +1,12 @@
 package service
 
-import "app/internal/app/mailer/model"
+import (
+	"app/internal/app/mailer/model"
+	"app/internal/metrics"
+)
 
 type MailProvider interface {
-	Send(to, subject, body string) error
+	Send(to, subject, body string, attachments []model.Attachment) error
 }
 
 type mailerService struct {
@@ -15,5 +18,9 @@ func NewMailerService(p MailProvider) MailerService {
 }
 
 func (m *mailerService) Send(mail model.Mail) error {
-	return m.provider.Send(mail.To, mail.Subject, mail.Body)
+	err := m.provider.Send(mail.To, mail.Subject, mail.Body, mail.Attachments)
+	if err != nil {
+		metrics.MailSendFailures.Inc()
+	}
+	return err
 }
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	MetricsService "app/internal/app/metrics/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+	service MetricsService.MetricsService
+	token   string
+}
+
+func NewMetricsHandler(service MetricsService.MetricsService, token string) *MetricsHandler {
+	return &MetricsHandler{service: service, token: token}
+}
+
+// GetMetrics Prometheus
+//
+// @Summary      Get the metrics in the Prometheus text format
+// @Description  Expose the HTTP metrics by route and status, the database and Redis call durations and the business gauges (active and paused sessions, clock-ins per minute, mail send failures). The endpoint is disabled while **METRICS_TOKEN** is empty, and requires it as a bearer token otherwise.
+// @Tags         Metrics
+// @Produce      plain
+// @Param        Authorization  header  string  true  "Bearer METRICS_TOKEN"
+// @Success      200  {string}  string
+// @Router       /metrics [get]
+func (handler *MetricsHandler) GetMetrics(c *gin.Context) {
+	if handler.token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Metrics are disabled"})
+		return
+	}
+
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), []byte(handler.token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := handler.service.WriteMetrics(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
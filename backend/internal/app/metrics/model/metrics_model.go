@@ -0,0 +1,8 @@
+package model
+
+// WorkSessionGauges is the live state of the work sessions exposed on every scrape.
+type WorkSessionGauges struct {
+	ActiveSessions    int `gorm:"column:active_sessions"`
+	PausedSessions    int `gorm:"column:paused_sessions"`
+	ClockInsPerMinute int `gorm:"column:clock_ins_per_minute"`
+}
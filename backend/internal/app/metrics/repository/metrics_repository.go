@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"app/internal/app/metrics/model"
+)
+
+type MetricsRepository interface {
+	GetWorkSessionGauges() (model.WorkSessionGauges, error)
+}
+
+type metricsRepository struct {
+	db *gorm.DB
+}
+
+func NewMetricsRepository(db *gorm.DB) MetricsRepository {
+	return &metricsRepository{db}
+}
+
+// GetWorkSessionGauges counts the running and paused sessions, and the sessions clocked in during the last minute.
+// Clock-ins are stored in the local time of the connection, hence LOCALTIMESTAMP.
+func (repo *metricsRepository) GetWorkSessionGauges() (model.WorkSessionGauges, error) {
+	var gauges model.WorkSessionGauges
+
+	err := repo.db.Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'active') AS active_sessions,
+			COUNT(*) FILTER (WHERE status = 'paused') AS paused_sessions,
+			COUNT(*) FILTER (WHERE clock_in >= LOCALTIMESTAMP - INTERVAL '1 minute') AS clock_ins_per_minute
+		FROM work_session_active
+		WHERE status IN ('active', 'paused') OR clock_in >= LOCALTIMESTAMP - INTERVAL '1 minute'
+	`).Scan(&gauges).Error
+	if err != nil {
+		return model.WorkSessionGauges{}, fmt.Errorf("failed to fetch work session gauges: %w", err)
+	}
+
+	return gauges, nil
+}
@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"app/internal/app/metrics/repository"
+	"app/internal/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const insertMetricsUserQuery = "INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)"
+const insertActiveSessionQuery = "INSERT INTO work_session_active (uuid, user_id, clock_in, status) VALUES (?, ?, LOCALTIMESTAMP - make_interval(mins => ?), ?)"
+
+func TestGetWorkSessionGauges(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewMetricsRepository(db)
+
+	gauges, err := repo.GetWorkSessionGauges()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, gauges.ActiveSessions)
+	assert.Equal(t, 0, gauges.PausedSessions)
+	assert.Equal(t, 0, gauges.ClockInsPerMinute)
+
+	userIDs := make([]int, 0, 3)
+	for _, uuid := range []string{"metrics-user-1", "metrics-user-2", "metrics-user-3"} {
+		err := db.Exec(insertMetricsUserQuery, uuid, uuid, uuid+"@example.com", "hashed_password", "active").Error
+		assert.NoError(t, err)
+
+		var userID int
+		db.Raw("SELECT id FROM users WHERE uuid = ?", uuid).Scan(&userID)
+		userIDs = append(userIDs, userID)
+	}
+
+	assert.NoError(t, db.Exec(insertActiveSessionQuery, "ws-just-in", userIDs[0], 0, "active").Error)
+	assert.NoError(t, db.Exec(insertActiveSessionQuery, "ws-running", userIDs[1], 120, "active").Error)
+	assert.NoError(t, db.Exec(insertActiveSessionQuery, "ws-paused", userIDs[2], 60, "paused").Error)
+
+	gauges, err = repo.GetWorkSessionGauges()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, gauges.ActiveSessions)
+	assert.Equal(t, 1, gauges.PausedSessions)
+	assert.Equal(t, 1, gauges.ClockInsPerMinute)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"io"
+	"log"
+
+	MetricsRepository "app/internal/app/metrics/repository"
+	"app/internal/metrics"
+)
+
+type MetricsService interface {
+	WriteMetrics(w io.Writer) error
+}
+
+type metricsService struct {
+	MetricsRepo MetricsRepository.MetricsRepository
+}
+
+func NewMetricsService(repo MetricsRepository.MetricsRepository) MetricsService {
+	return &metricsService{MetricsRepo: repo}
+}
+
+// WriteMetrics refreshes the business gauges then writes every metric.
+// The gauges keep their previous values when the database cannot be reached, the failure showing in the database metrics.
+func (service *metricsService) WriteMetrics(w io.Writer) error {
+	gauges, err := service.MetricsRepo.GetWorkSessionGauges()
+	if err != nil {
+		log.Printf("⚠️ Error refreshing the business gauges: %v", err)
+	} else {
+		metrics.ActiveWorkSessions.Set(float64(gauges.ActiveSessions))
+		metrics.PausedWorkSessions.Set(float64(gauges.PausedSessions))
+		metrics.ClockInsPerMinute.Set(float64(gauges.ClockInsPerMinute))
+	}
+
+	return metrics.WriteText(w)
+}
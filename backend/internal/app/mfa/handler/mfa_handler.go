@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	AuthService "app/internal/app/auth/service"
+	"app/internal/app/common/response"
+	LoginAttemptService "app/internal/app/login-attempt/service"
+	"app/internal/app/mfa/model"
+	MFAService "app/internal/app/mfa/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service MFAService.MFAService
+}
+
+func NewMFAHandler(service MFAService.MFAService) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+// GetStatus MFA
+//
+// @Summary      Get the two-factor authentication status of the current user
+// @Description  Tell whether the second factor of the current user is enabled, whether the policy requires it for their roles, and how many recovery codes are left. 🔒 Requires role: **all**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  model.MFAStatus
+// @Router       /mfa/status [get]
+func (handler *MFAHandler) GetStatus(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	status, err := handler.service.GetStatus(authClaims.UUID)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Setup MFA
+//
+// @Summary      Start the enrollment of a second factor
+// @Description  Generate a TOTP secret for the current user and return its provisioning URI, to show as a QR code to an authenticator app. The second factor is enabled once confirmed with a first code. 🔒 Requires role: **all**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  model.MFASetup
+// @Router       /mfa/setup [post]
+func (handler *MFAHandler) Setup(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	setup, err := handler.service.Setup(authClaims.UUID)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// Confirm MFA
+//
+// @Summary      Confirm the enrollment of a second factor
+// @Description  Enable the second factor of the current user with a first code of the authenticator app. The recovery codes are only returned in this response. 🔒 Requires role: **all**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code  body      model.MFACode  true  "Code of the authenticator app"
+// @Success      200  {object}  model.MFARecoveryCodes
+// @Router       /mfa/confirm [post]
+func (handler *MFAHandler) Confirm(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var request model.MFACode
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	codes, err := handler.service.Confirm(authClaims.UUID, request.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable MFA
+//
+// @Summary      Disable the second factor of the current user
+// @Description  Remove the second factor of the current user, given a code of the authenticator app or a recovery code. Forbidden when the policy requires a second factor for their roles. 🔒 Requires role: **all**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code  body      model.MFACode  true  "Code of the authenticator app or recovery code"
+// @Success      200 		"two-factor authentication disabled successfully"
+// @Failure      429  {object}  response.ErrorResponse  "too many failed attempts, retry after the Retry-After header"
+// @Router       /mfa/disable [post]
+func (handler *MFAHandler) Disable(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var request model.MFACode
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	if err := handler.service.Disable(authClaims.UUID, request.Code, c.ClientIP()); err != nil {
+		mfaThrottledError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled successfully"})
+}
+
+// RegenerateRecoveryCodes MFA
+//
+// @Summary      Regenerate the recovery codes of the current user
+// @Description  Replace every recovery code of the current user, given a code of the authenticator app or a recovery code. The new codes are only returned in this response. 🔒 Requires role: **all**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        code  body      model.MFACode  true  "Code of the authenticator app or recovery code"
+// @Success      200  {object}  model.MFARecoveryCodes
+// @Failure      429  {object}  response.ErrorResponse  "too many failed attempts, retry after the Retry-After header"
+// @Router       /mfa/recovery-codes [post]
+func (handler *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var request model.MFACode
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	codes, err := handler.service.RegenerateRecoveryCodes(authClaims.UUID, request.Code, c.ClientIP())
+	if err != nil {
+		mfaThrottledError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Reset MFA
+//
+// @Summary      Reset the second factor of a user
+// @Description  Remove the second factor and the recovery codes of a user who lost them, and log them out of every session. They enroll again at their next login when the policy requires it. 🔒 Requires role: **admin**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Produce      json
+// @Param        uuid  path      string  true  "User UUID"
+// @Success      200 		"two-factor authentication reset successfully"
+// @Router       /mfa/users/{uuid} [delete]
+func (handler *MFAHandler) Reset(c *gin.Context) {
+	if err := handler.service.Reset(c.Param("uuid")); err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset successfully"})
+}
+
+// GetPolicy MFA
+//
+// @Summary      Get the two-factor authentication policy
+// @Description  Retrieve the roles required to use a second factor. 🔒 Requires role: **admin**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  model.MFAPolicy
+// @Router       /mfa/policy [get]
+func (handler *MFAHandler) GetPolicy(c *gin.Context) {
+	policy, err := handler.service.GetPolicy()
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy MFA
+//
+// @Summary      Update the two-factor authentication policy
+// @Description  Set the roles required to use a second factor. The users having one of them without a second factor enroll at their next login, and cannot disable it. 🔒 Requires role: **admin**
+// @Tags         MFA
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        policy  body      model.MFAPolicyUpdate  true  "Required roles"
+// @Success      200  {object}  model.MFAPolicy
+// @Router       /mfa/policy [put]
+func (handler *MFAHandler) UpdatePolicy(c *gin.Context) {
+	var request model.MFAPolicyUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	policy, err := handler.service.UpdatePolicy(request)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// mfaThrottledError responds 429 with the Retry-After header to the codes rejected by the login throttling.
+func mfaThrottledError(c *gin.Context, err error) {
+	var throttledErr *LoginAttemptService.ThrottledError
+	if errors.As(err, &throttledErr) {
+		c.Header("Retry-After", strconv.Itoa(throttledErr.Seconds()))
+		c.JSON(http.StatusTooManyRequests, response.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(mfaErrorStatus(err), gin.H{"error": err.Error()})
+}
+
+func mfaErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_MFA_CODE"]):
+		return http.StatusUnauthorized
+	case strings.HasPrefix(message, Config.ErrorMessages()["MFA_REQUIRED"]):
+		return http.StatusForbidden
+	case strings.HasPrefix(message, Config.ErrorMessages()["MFA_ALREADY_ENABLED"]):
+		return http.StatusConflict
+	case strings.HasPrefix(message, Config.ErrorMessages()["MFA_NOT_ENABLED"]),
+		strings.HasPrefix(message, Config.ErrorMessages()["MFA_SETUP_NOT_FOUND"]),
+		strings.HasPrefix(message, Config.ErrorMessages()["INVALID_REQUEST"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
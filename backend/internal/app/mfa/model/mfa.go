@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"app/internal/app/common/model"
+)
+
+// MFAConfig holds the settings of the second factor, loaded from the environment.
+type MFAConfig struct {
+	// name shown by the authenticator apps
+	Issuer string
+	// key encrypting the TOTP secrets in the database
+	EncryptionKey string
+	// lifetime of the token exchanged for a session once the second factor is verified at login
+	LoginTokenMinutes int
+}
+
+// UserMFA is the TOTP second factor of a user, enabled once its enrollment is confirmed.
+type UserMFA struct {
+	UserID int `gorm:"column:user_id"`
+	// encrypted TOTP secret
+	Secret       string     `gorm:"column:secret"`
+	EnabledAt    *time.Time `gorm:"column:enabled_at"`
+	LastUsedStep int64      `gorm:"column:last_used_step"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+}
+
+// swagger:model MFAStatus
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+	// the roles of the user require a second factor
+	Required          bool       `json:"required"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// swagger:model MFASetup
+type MFASetup struct {
+	// base32 secret, for the apps which cannot scan the provisioning URI
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/TimeManager:jane@example.com?secret=JBSWY3DPEHPK3PXP&issuer=TimeManager"`
+}
+
+// swagger:model MFACode
+type MFACode struct {
+	// code of the authenticator app, or one of the recovery codes where accepted
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// swagger:model MFARecoveryCodes
+type MFARecoveryCodes struct {
+	// each code can be used once instead of a TOTP code, they are only shown in this response
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// swagger:model MFAPolicy
+type MFAPolicy struct {
+	RequiredRoles model.StringArray `json:"required_roles" gorm:"column:required_roles;type:text[]"`
+	UpdatedAt     *time.Time        `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// swagger:model MFAPolicyUpdate
+type MFAPolicyUpdate struct {
+	// the users having one of these roles have to use a second factor, the ones without one enroll at their next login
+	RequiredRoles []string `json:"required_roles" binding:"dive,oneof=admin manager employee" example:"admin,manager"`
+}
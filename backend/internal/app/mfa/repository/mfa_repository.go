@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	CommonModel "app/internal/app/common/model"
+	"app/internal/app/mfa/model"
+)
+
+type MFARepository interface {
+	FindByUserID(userID int) (model.UserMFA, error)
+	SavePending(userID int, secret string) (bool, error)
+	Enable(userID int, enabledAt time.Time, step int64) (bool, error)
+	UseStep(userID int, step int64) (bool, error)
+	Delete(userID int) error
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (bool, error)
+	CountRecoveryCodes(userID int) (int, error)
+	FindPolicy() (model.MFAPolicy, error)
+	UpdatePolicy(requiredRoles []string) error
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db}
+}
+
+// FindByUserID returns the second factor of the user, with a UserID of 0 when the user has none.
+func (repo *mfaRepository) FindByUserID(userID int) (model.UserMFA, error) {
+	var mfa model.UserMFA
+
+	err := repo.db.Raw(`
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_mfa
+		WHERE user_id = ?
+	`, userID).Scan(&mfa).Error
+	if err != nil {
+		return model.UserMFA{}, fmt.Errorf("failed to fetch two-factor authentication: %w", err)
+	}
+
+	return mfa, nil
+}
+
+// SavePending stores the secret of an enrollment to confirm, replacing a previous unconfirmed one.
+// False is returned when the second factor of the user is already enabled.
+func (repo *mfaRepository) SavePending(userID int, secret string) (bool, error) {
+	result := repo.db.Exec(`
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		WHERE user_mfa.enabled_at IS NULL
+	`, userID, secret)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to save two-factor authentication: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Enable confirms the enrollment with the step of its first code, false being returned when there is none to confirm.
+func (repo *mfaRepository) Enable(userID int, enabledAt time.Time, step int64) (bool, error) {
+	result := repo.db.Exec(`
+		UPDATE user_mfa
+		SET enabled_at = ?, last_used_step = ?
+		WHERE user_id = ? AND enabled_at IS NULL
+	`, enabledAt, step, userID)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to enable two-factor authentication: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// UseStep records the step of an accepted code, only when it is later than the last one,
+// so that concurrent requests cannot use the same code twice.
+func (repo *mfaRepository) UseStep(userID int, step int64) (bool, error) {
+	result := repo.db.Exec(`
+		UPDATE user_mfa
+		SET last_used_step = ?
+		WHERE user_id = ? AND last_used_step < ?
+	`, step, userID, step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use two-factor authentication code: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Delete removes the second factor of the user along with the recovery codes.
+func (repo *mfaRepository) Delete(userID int) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM user_mfa WHERE user_id = ?", userID).Error
+	})
+}
+
+func (repo *mfaRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		for _, codeHash := range codeHashes {
+			if err := tx.Exec("INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, codeHash).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseRecoveryCode marks the code as used, false being returned when it does not exist or was already used.
+func (repo *mfaRepository) UseRecoveryCode(userID int, codeHash string, usedAt time.Time) (bool, error) {
+	result := repo.db.Exec(`
+		UPDATE mfa_recovery_codes
+		SET used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, usedAt, userID, codeHash)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of the user.
+func (repo *mfaRepository) CountRecoveryCodes(userID int) (int, error) {
+	var count int
+
+	err := repo.db.Raw("SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = ? AND used_at IS NULL", userID).Scan(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// FindPolicy returns the policy, requiring no role when it was never set.
+func (repo *mfaRepository) FindPolicy() (model.MFAPolicy, error) {
+	var policy model.MFAPolicy
+
+	err := repo.db.Raw("SELECT required_roles, updated_at FROM mfa_policy WHERE id = 1").Scan(&policy).Error
+	if err != nil {
+		return model.MFAPolicy{}, fmt.Errorf("failed to fetch two-factor authentication policy: %w", err)
+	}
+	if policy.RequiredRoles == nil {
+		policy.RequiredRoles = CommonModel.StringArray{}
+	}
+
+	return policy, nil
+}
+
+func (repo *mfaRepository) UpdatePolicy(requiredRoles []string) error {
+	return repo.db.Exec(`
+		INSERT INTO mfa_policy (id, required_roles, updated_at)
+		VALUES (1, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE
+		SET required_roles = EXCLUDED.required_roles, updated_at = EXCLUDED.updated_at
+	`, CommonModel.StringArray(requiredRoles)).Error
+}
@@ -0,0 +1,132 @@
+package repository_test
+
+import (
+	CommonModel "app/internal/app/common/model"
+	"app/internal/app/mfa/repository"
+	"app/internal/test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const mfaUserUUID = "123e4567-e89b-12d3-a456-426614174011"
+
+func insertMFAUser(t *testing.T, db *gorm.DB) int {
+	t.Helper()
+
+	db.Exec(`INSERT INTO users (uuid, username, email, password_hash, status, roles) VALUES (?, 'jane', 'jane@example.com', 'hashed_password', 'active', '{employee,manager}')`, mfaUserUUID)
+
+	var userID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", mfaUserUUID).Scan(&userID)
+
+	return userID
+}
+
+func TestEnrollAndUseSteps(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewMFARepository(db)
+	userID := insertMFAUser(t, db)
+
+	mfa, err := repo.FindByUserID(userID)
+	assert.NoError(t, err)
+	assert.Zero(t, mfa.UserID)
+
+	saved, err := repo.SavePending(userID, "secret-1")
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	// a new setup replaces the unconfirmed one
+	saved, err = repo.SavePending(userID, "secret-2")
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	enabled, err := repo.Enable(userID, time.Now(), 100)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	mfa, err = repo.FindByUserID(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, mfa.UserID)
+	assert.Equal(t, "secret-2", mfa.Secret)
+	assert.NotNil(t, mfa.EnabledAt)
+	assert.Equal(t, int64(100), mfa.LastUsedStep)
+
+	// the secret of an enabled factor cannot be replaced, nor enabled again
+	saved, err = repo.SavePending(userID, "secret-3")
+	assert.NoError(t, err)
+	assert.False(t, saved)
+	enabled, err = repo.Enable(userID, time.Now(), 101)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	// a step is used once, and never before the last one
+	used, err := repo.UseStep(userID, 100)
+	assert.NoError(t, err)
+	assert.False(t, used)
+	used, err = repo.UseStep(userID, 101)
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, err = repo.UseStep(userID, 101)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, repo.Delete(userID))
+	mfa, err = repo.FindByUserID(userID)
+	assert.NoError(t, err)
+	assert.Zero(t, mfa.UserID)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewMFARepository(db)
+	userID := insertMFAUser(t, db)
+
+	assert.NoError(t, repo.ReplaceRecoveryCodes(userID, []string{"hash-1", "hash-2"}))
+
+	count, err := repo.CountRecoveryCodes(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	used, err := repo.UseRecoveryCode(userID, "hash-1", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, used)
+	used, err = repo.UseRecoveryCode(userID, "hash-1", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, used)
+	used, err = repo.UseRecoveryCode(userID, "unknown", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	count, err = repo.CountRecoveryCodes(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// regenerating replaces the used codes too
+	assert.NoError(t, repo.ReplaceRecoveryCodes(userID, []string{"hash-3", "hash-4", "hash-5"}))
+	count, err = repo.CountRecoveryCodes(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	assert.NoError(t, repo.Delete(userID))
+	count, err = repo.CountRecoveryCodes(userID)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestPolicy(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewMFARepository(db)
+
+	policy, err := repo.FindPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, CommonModel.StringArray{}, policy.RequiredRoles)
+
+	assert.NoError(t, repo.UpdatePolicy([]string{"admin", "manager"}))
+
+	policy, err = repo.FindPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, CommonModel.StringArray{"admin", "manager"}, policy.RequiredRoles)
+	assert.NotNil(t, policy.UpdatedAt)
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	LoginAttemptModel "app/internal/app/login-attempt/model"
+	LoginAttemptService "app/internal/app/login-attempt/service"
+	"app/internal/app/mfa/model"
+	"app/internal/app/mfa/repository"
+	"app/internal/app/mfa/totp"
+	SessionService "app/internal/app/session/service"
+	UserModel "app/internal/app/user/model"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// MFAService manages the TOTP second factor of the users: an enrollment is started with Setup and enabled by
+// Confirm with a first code, which also returns the recovery codes. Every code is accepted once only.
+type MFAService interface {
+	GetStatus(userUUID string) (model.MFAStatus, error)
+	Requirement(userUUID string, roles []string) (bool, bool, error)
+	Setup(userUUID string) (model.MFASetup, error)
+	Confirm(userUUID string, code string) (model.MFARecoveryCodes, error)
+	Verify(userUUID string, code string) error
+	Disable(userUUID string, code string, clientIP string) error
+	RegenerateRecoveryCodes(userUUID string, code string, clientIP string) (model.MFARecoveryCodes, error)
+	Reset(userUUID string) error
+	GetPolicy() (model.MFAPolicy, error)
+	UpdatePolicy(request model.MFAPolicyUpdate) (model.MFAPolicy, error)
+}
+
+type mfaService struct {
+	Repository          repository.MFARepository
+	UserService         UserService.UserService
+	SessionService      SessionService.SessionService
+	LoginAttemptService LoginAttemptService.LoginAttemptService
+	Config              model.MFAConfig
+}
+
+func NewMFAService(repo repository.MFARepository, userService UserService.UserService, sessionService SessionService.SessionService, loginAttemptService LoginAttemptService.LoginAttemptService, config model.MFAConfig) MFAService {
+	return &mfaService{
+		Repository:          repo,
+		UserService:         userService,
+		SessionService:      sessionService,
+		LoginAttemptService: loginAttemptService,
+		Config:              config,
+	}
+}
+
+func (service *mfaService) GetStatus(userUUID string) (model.MFAStatus, error) {
+	user, err := service.user(userUUID)
+	if err != nil {
+		return model.MFAStatus{}, err
+	}
+
+	mfa, err := service.Repository.FindByUserID(int(*user.ID))
+	if err != nil {
+		return model.MFAStatus{}, err
+	}
+
+	required, err := service.isRequired(user.Roles)
+	if err != nil {
+		return model.MFAStatus{}, err
+	}
+
+	status := model.MFAStatus{
+		Enabled:   mfa.EnabledAt != nil,
+		Required:  required,
+		EnabledAt: mfa.EnabledAt,
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = service.Repository.CountRecoveryCodes(int(*user.ID)); err != nil {
+			return model.MFAStatus{}, err
+		}
+	}
+
+	return status, nil
+}
+
+// Requirement tells whether the second factor of the user is enabled, and whether the policy requires it.
+func (service *mfaService) Requirement(userUUID string, roles []string) (bool, bool, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return false, false, err
+	}
+
+	mfa, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return false, false, err
+	}
+
+	required, err := service.isRequired(roles)
+	if err != nil {
+		return false, false, err
+	}
+
+	return mfa.EnabledAt != nil, required, nil
+}
+
+// Setup starts an enrollment, replacing the previous unconfirmed one.
+func (service *mfaService) Setup(userUUID string) (model.MFASetup, error) {
+	user, err := service.user(userUUID)
+	if err != nil {
+		return model.MFASetup{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.MFASetup{}, err
+	}
+
+	encrypted, err := service.encrypt(secret)
+	if err != nil {
+		return model.MFASetup{}, err
+	}
+
+	saved, err := service.Repository.SavePending(int(*user.ID), encrypted)
+	if err != nil {
+		return model.MFASetup{}, err
+	}
+	if !saved {
+		return model.MFASetup{}, errors.New(Config.ErrorMessages()["MFA_ALREADY_ENABLED"])
+	}
+
+	return model.MFASetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, service.Config.Issuer, user.Email),
+	}, nil
+}
+
+// Confirm enables the second factor with the first code of the authenticator app and returns the recovery codes.
+func (service *mfaService) Confirm(userUUID string, code string) (model.MFARecoveryCodes, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+
+	mfa, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+	if mfa.UserID == 0 {
+		return model.MFARecoveryCodes{}, errors.New(Config.ErrorMessages()["MFA_SETUP_NOT_FOUND"])
+	}
+	if mfa.EnabledAt != nil {
+		return model.MFARecoveryCodes{}, errors.New(Config.ErrorMessages()["MFA_ALREADY_ENABLED"])
+	}
+
+	secret, err := service.decrypt(mfa.Secret)
+	if err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return model.MFARecoveryCodes{}, errors.New(Config.ErrorMessages()["INVALID_MFA_CODE"])
+	}
+
+	enabled, err := service.Repository.Enable(userID, time.Now(), step)
+	if err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+	if !enabled {
+		return model.MFARecoveryCodes{}, errors.New(Config.ErrorMessages()["MFA_ALREADY_ENABLED"])
+	}
+
+	return service.newRecoveryCodes(userID)
+}
+
+// Verify accepts a code of the authenticator app, or an unused recovery code.
+func (service *mfaService) Verify(userUUID string, code string) error {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return err
+	}
+
+	return service.verify(userID, code)
+}
+
+func (service *mfaService) verify(userID int, code string) error {
+	mfa, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if mfa.EnabledAt == nil {
+		return errors.New(Config.ErrorMessages()["MFA_NOT_ENABLED"])
+	}
+
+	secret, err := service.decrypt(mfa.Secret)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := totp.Validate(secret, code, time.Now()); ok {
+		used, err := service.Repository.UseStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+		// the code was already used
+		return errors.New(Config.ErrorMessages()["INVALID_MFA_CODE"])
+	}
+
+	used, err := service.Repository.UseRecoveryCode(userID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.New(Config.ErrorMessages()["INVALID_MFA_CODE"])
+	}
+
+	return nil
+}
+
+// Disable removes the second factor of the user, unless the policy requires it.
+// The code is throttled as the logins are, a LoginAttemptService.ThrottledError being returned while delayed or locked.
+func (service *mfaService) Disable(userUUID string, code string, clientIP string) error {
+	user, err := service.user(userUUID)
+	if err != nil {
+		return err
+	}
+
+	required, err := service.isRequired(user.Roles)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New(Config.ErrorMessages()["MFA_REQUIRED"])
+	}
+
+	if err := service.verifyThrottled(user, code, clientIP); err != nil {
+		return err
+	}
+
+	return service.Repository.Delete(int(*user.ID))
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user, used or not.
+// The code is throttled as the logins are, a LoginAttemptService.ThrottledError being returned while delayed or locked.
+func (service *mfaService) RegenerateRecoveryCodes(userUUID string, code string, clientIP string) (model.MFARecoveryCodes, error) {
+	user, err := service.user(userUUID)
+	if err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+
+	if err := service.verifyThrottled(user, code, clientIP); err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+
+	return service.newRecoveryCodes(int(*user.ID))
+}
+
+// verifyThrottled checks the code as the second step of a login does: a wrong code counts as a failed login of the
+// account and of the IP address, so that a stolen access token is not enough to brute-force the second factor.
+func (service *mfaService) verifyThrottled(user *UserModel.UserReadJWT, code string, clientIP string) error {
+	attempt, err := service.LoginAttemptService.Reserve(LoginAttemptModel.LoginAttempt{UserUUID: user.UUID, Login: user.Email, IPAddress: clientIP})
+	if err != nil {
+		return err
+	}
+
+	// a right code only releases the attempt: it does not log in, so the failures of the account are kept
+	err = service.verify(int(*user.ID), code)
+	if err != nil && strings.HasPrefix(err.Error(), Config.ErrorMessages()["INVALID_MFA_CODE"]) {
+		if recordErr := service.LoginAttemptService.RecordFailure(attempt, LoginAttemptModel.ReasonInvalidMFACode); recordErr != nil {
+			log.Printf("⚠️ Error recording two-factor failure of %s : %v", user.Email, recordErr)
+		}
+	} else if releaseErr := service.LoginAttemptService.Release(attempt); releaseErr != nil {
+		log.Printf("⚠️ Error releasing two-factor attempt of %s : %v", user.Email, releaseErr)
+	}
+
+	return err
+}
+
+// Reset removes the second factor of a user who lost it, and logs them out of every session.
+// The user enrolls again at the next login when the policy requires it.
+func (service *mfaService) Reset(userUUID string) error {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return fmt.Errorf("%s: user %s not found", Config.ErrorMessages()["INVALID_REQUEST"], userUUID)
+	}
+
+	if err := service.Repository.Delete(userID); err != nil {
+		return err
+	}
+
+	return service.SessionService.RevokeUser(userUUID)
+}
+
+func (service *mfaService) GetPolicy() (model.MFAPolicy, error) {
+	return service.Repository.FindPolicy()
+}
+
+func (service *mfaService) UpdatePolicy(request model.MFAPolicyUpdate) (model.MFAPolicy, error) {
+	roles := slices.Clone(request.RequiredRoles)
+	slices.Sort(roles)
+
+	if err := service.Repository.UpdatePolicy(slices.Compact(roles)); err != nil {
+		return model.MFAPolicy{}, err
+	}
+
+	return service.Repository.FindPolicy()
+}
+
+func (service *mfaService) isRequired(roles []string) (bool, error) {
+	policy, err := service.Repository.FindPolicy()
+	if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(roles, func(role string) bool {
+		return slices.Contains(policy.RequiredRoles, role)
+	}), nil
+}
+
+func (service *mfaService) user(userUUID string) (*UserModel.UserReadJWT, error) {
+	user, err := service.UserService.GetUserByEmailAuth("uuid", userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return user, nil
+}
+
+func (service *mfaService) newRecoveryCodes(userID int) (model.MFARecoveryCodes, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return model.MFARecoveryCodes{}, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := service.Repository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return model.MFARecoveryCodes{}, err
+	}
+
+	return model.MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// newRecoveryCode returns a code such as "k7m2p-x9qha", without the characters easily mistaken for one another.
+func newRecoveryCode() (string, error) {
+	random := make([]byte, 10)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, len(random))
+	for i, b := range random {
+		code[i] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+	}
+
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+// hashRecoveryCode ignores the case, the spaces and the dashes typed by the user.
+func hashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func (service *mfaService) cipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(service.Config.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals the secret with AES-GCM, the nonce being prepended to the result.
+func (service *mfaService) encrypt(secret string) (string, error) {
+	aead, err := service.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (service *mfaService) decrypt(encrypted string) (string, error) {
+	aead, err := service.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid two-factor authentication secret")
+	}
+
+	secret, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt two-factor authentication secret: %w", err)
+	}
+
+	return string(secret), nil
+}
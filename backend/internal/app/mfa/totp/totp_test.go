@@ -0,0 +1,56 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCodeRFC6238 checks the SHA1 test vectors of the appendix B of RFC 6238.
+func TestCodeRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	for unix, expected := range map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	} {
+		assert.Equal(t, expected, code(key, Step(time.Unix(unix, 0)), 8), "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Date(2026, 3, 2, 10, 0, 15, 0, time.UTC)
+	current, err := Code(secret, Step(now))
+	assert.NoError(t, err)
+
+	step, ok := Validate(secret, current, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// the previous code is still accepted for the clock drift, not the older ones
+	previous, _ := Code(secret, Step(now)-1)
+	step, ok = Validate(secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	older, _ := Code(secret, Step(now)-2)
+	_, ok = Validate(secret, older, now)
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("JBSWY3DPEHPK3PXP", "Time Manager", "jane@example.com")
+
+	assert.Equal(t, "otpauth://totp/Time%20Manager:jane@example.com?algorithm=SHA1&digits=6&issuer=Time+Manager&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	AuthService "app/internal/app/auth/service"
+	"app/internal/app/report-subscription/model"
+	ReportSubscriptionService "app/internal/app/report-subscription/service"
+	Config "app/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportSubscriptionHandler struct {
+	service ReportSubscriptionService.ReportSubscriptionService
+}
+
+func NewReportSubscriptionHandler(service ReportSubscriptionService.ReportSubscriptionService) *ReportSubscriptionHandler {
+	return &ReportSubscriptionHandler{service: service}
+}
+
+// Create Report Subscription
+//
+// @Summary      Subscribe to a recurring KPI report
+// @Description  Mail a KPI export to the current user every day, every monday or every first day of the month, at the hour configured by **REPORT_SUBSCRIPTION_SEND_HOUR**. Daily reports cover the previous day, weekly ones the previous week and monthly ones the previous month. The report is about the current user, or about a team they manage for the team KPI types. The file is attached to the mail, or linked when delivery is "link" or when it is too large to be attached. 🔒 Requires role: **all**
+// @Tags         Report Subscriptions
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        subscription  body      model.ReportSubscriptionCreate  true  "Report Subscription"
+// @Success      201  {object}  model.ReportSubscription
+// @Router       /report-subscriptions [post]
+func (handler *ReportSubscriptionHandler) Create(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	var request model.ReportSubscriptionCreate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	subscription, err := handler.service.Create(request, authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// GetMine Report Subscriptions
+//
+// @Summary      Get the report subscriptions of the current user
+// @Description  Retrieve the recurring reports the current user subscribed to, with their next send time and the outcome of their last run. 🔒 Requires role: **all**
+// @Tags         Report Subscriptions
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   model.ReportSubscription
+// @Router       /report-subscriptions/me [get]
+func (handler *ReportSubscriptionHandler) GetMine(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	subscriptions, err := handler.service.GetByUser(authClaims.UUID)
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// Delete Report Subscription
+//
+// @Summary      Unsubscribe from a recurring KPI report
+// @Description  Delete a report subscription of the current user, admins can delete any subscription. 🔒 Requires role: **all**
+// @Tags         Report Subscriptions
+// @Security     BearerAuth
+// @Produce      json
+// @Param        uuid  path      string  true  "Report Subscription UUID"
+// @Success      200 		"Report subscription deleted successfully"
+// @Router       /report-subscriptions/{uuid} [delete]
+func (handler *ReportSubscriptionHandler) Delete(c *gin.Context) {
+	claims, exists := c.Get("userClaims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+		return
+	}
+	authClaims := claims.(*AuthService.Claims)
+
+	err := handler.service.Delete(c.Param("uuid"), authClaims.UUID, slices.Contains(authClaims.Roles, "admin"))
+	if err != nil {
+		c.JSON(reportSubscriptionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report subscription deleted successfully"})
+}
+
+func reportSubscriptionErrorStatus(err error) int {
+	message := err.Error()
+	switch {
+	case strings.HasPrefix(message, Config.ErrorMessages()["REPORT_SUBSCRIPTION_NOT_FOUND"]),
+		strings.HasPrefix(message, Config.ErrorMessages()["TEAM_NOT_FOUND"]):
+		return http.StatusNotFound
+	case strings.HasPrefix(message, Config.ErrorMessages()["NOT_TEAM_MANAGER"]):
+		return http.StatusForbidden
+	case strings.HasPrefix(message, Config.ErrorMessages()["INVALID_REQUEST"]):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+const (
+	TargetSelf = "self"
+	TargetTeam = "team"
+
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+
+	DeliveryAttachment = "attachment"
+	DeliveryLink       = "link"
+)
+
+// ReportSubscriptionConfig holds the settings of the report scheduler, loaded from the environment.
+type ReportSubscriptionConfig struct {
+	// interval between two checks of the due reports, 0 disables the scheduler
+	IntervalMinutes int
+	// hour of the day, in the server time zone, at which the reports are sent
+	SendHour int
+	// reports larger than this are linked rather than attached
+	MaxAttachmentBytes int
+}
+
+// swagger:model ReportSubscriptionCreate
+type ReportSubscriptionCreate struct {
+	KPIType string `json:"kpi_type" binding:"required,oneof=work_session_user_weekly_total work_session_team_weekly_total presence_rate weekly_average_break_time average_time_per_shift daily_presence team_presence_rate team_average_break_time team_average_time_per_shift timesheet team_summary"`
+	// target is either "self" or "team", a team being required for the team KPI types
+	Target   string `json:"target" binding:"required,oneof=self team"`
+	TeamUUID string `json:"team_uuid"`
+	// daily reports cover the previous day, weekly ones the previous week from monday and monthly ones the previous month
+	Frequency string `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	Format    string `json:"format" binding:"omitempty,oneof=csv xlsx pdf"`
+	// delivery is either "attachment" (default) or "link", files too large to be attached are always linked
+	Delivery string `json:"delivery" binding:"omitempty,oneof=attachment link"`
+}
+
+// swagger:model ReportSubscription
+type ReportSubscription struct {
+	ID        int        `json:"-" gorm:"column:id"`
+	UUID      string     `json:"uuid" gorm:"column:uuid"`
+	UserID    int        `json:"-" gorm:"column:user_id"`
+	UserUUID  string     `json:"user_uuid" gorm:"column:user_uuid"`
+	TeamID    *int       `json:"-" gorm:"column:team_id"`
+	TeamUUID  *string    `json:"team_uuid" gorm:"column:team_uuid"`
+	TeamName  *string    `json:"team_name" gorm:"column:team_name"`
+	KPIType   string     `json:"kpi_type" gorm:"column:kpi_type"`
+	Target    string     `json:"target" gorm:"column:target"`
+	Frequency string     `json:"frequency" gorm:"column:frequency"`
+	Format    string     `json:"format" gorm:"column:format"`
+	Delivery  string     `json:"delivery" gorm:"column:delivery"`
+	NextRunAt time.Time  `json:"next_run_at" gorm:"column:next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at" gorm:"column:last_run_at"`
+	// error of the last run, null when the last report was sent
+	LastError *string   `json:"last_error" gorm:"column:last_error"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"app/internal/app/report-subscription/model"
+)
+
+// selectReportSubscriptions reads the subscriptions with the uuid of their subscriber and team.
+const selectReportSubscriptions = `
+	SELECT
+		s.id, s.uuid, s.user_id, u.uuid AS user_uuid, s.team_id, t.uuid AS team_uuid, t.name AS team_name,
+		s.kpi_type, s.target, s.frequency, s.format, s.delivery, s.next_run_at, s.last_run_at, s.last_error, s.created_at
+	FROM report_subscriptions s
+	JOIN users u ON u.id = s.user_id
+	LEFT JOIN teams t ON t.id = s.team_id
+`
+
+type ReportSubscriptionRepository interface {
+	Create(subscription model.ReportSubscription) error
+	FindByUUID(uuid string) (model.ReportSubscription, error)
+	FindByUserID(userID int) ([]model.ReportSubscription, error)
+	FindDue(now time.Time) ([]model.ReportSubscription, error)
+	Claim(id int, scheduledAt time.Time, nextRunAt time.Time) (bool, error)
+	UpdateLastRun(id int, ranAt time.Time, lastError *string) error
+	DeleteByUUID(uuid string) error
+}
+
+type reportSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewReportSubscriptionRepository(db *gorm.DB) ReportSubscriptionRepository {
+	return &reportSubscriptionRepository{db}
+}
+
+func (repo *reportSubscriptionRepository) Create(subscription model.ReportSubscription) error {
+	err := repo.db.Exec(`
+		INSERT INTO report_subscriptions (uuid, user_id, team_id, kpi_type, target, frequency, format, delivery, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, subscription.UUID, subscription.UserID, subscription.TeamID, subscription.KPIType, subscription.Target,
+		subscription.Frequency, subscription.Format, subscription.Delivery, subscription.NextRunAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to create report subscription: %w", err)
+	}
+
+	return nil
+}
+
+// FindByUUID returns the subscription, with an ID of 0 when it does not exist.
+func (repo *reportSubscriptionRepository) FindByUUID(uuid string) (model.ReportSubscription, error) {
+	var subscription model.ReportSubscription
+
+	err := repo.db.Raw(selectReportSubscriptions+" WHERE s.uuid = ?", uuid).Scan(&subscription).Error
+	if err != nil {
+		return model.ReportSubscription{}, fmt.Errorf("failed to fetch report subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+func (repo *reportSubscriptionRepository) FindByUserID(userID int) ([]model.ReportSubscription, error) {
+	var subscriptions []model.ReportSubscription
+
+	err := repo.db.Raw(selectReportSubscriptions+" WHERE s.user_id = ? ORDER BY s.created_at, s.id", userID).Scan(&subscriptions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch report subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// FindDue returns the subscriptions whose report is due at now, the most late first.
+func (repo *reportSubscriptionRepository) FindDue(now time.Time) ([]model.ReportSubscription, error) {
+	var subscriptions []model.ReportSubscription
+
+	err := repo.db.Raw(selectReportSubscriptions+" WHERE s.next_run_at <= ? ORDER BY s.next_run_at, s.id", now).Scan(&subscriptions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch due report subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Claim moves the next run of the subscription forward, only when it is still scheduled at scheduledAt.
+// It returns false when another instance claimed the run first.
+func (repo *reportSubscriptionRepository) Claim(id int, scheduledAt time.Time, nextRunAt time.Time) (bool, error) {
+	result := repo.db.Exec(`
+		UPDATE report_subscriptions
+		SET next_run_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND next_run_at = ?
+	`, nextRunAt, id, scheduledAt)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim report subscription: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *reportSubscriptionRepository) UpdateLastRun(id int, ranAt time.Time, lastError *string) error {
+	return repo.db.Exec(`
+		UPDATE report_subscriptions
+		SET last_run_at = ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, ranAt, lastError, id).Error
+}
+
+func (repo *reportSubscriptionRepository) DeleteByUUID(uuid string) error {
+	return repo.db.Exec("DELETE FROM report_subscriptions WHERE uuid = ?", uuid).Error
+}
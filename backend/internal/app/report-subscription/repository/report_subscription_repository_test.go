@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"app/internal/app/report-subscription/model"
+	"app/internal/app/report-subscription/repository"
+	"app/internal/test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const subscriberUUID = "123e4567-e89b-12d3-a456-426614174009"
+
+func TestCreateAndFindReportSubscriptions(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewReportSubscriptionRepository(db)
+
+	var userID, teamID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", subscriberUUID).Scan(&userID)
+	db.Exec("INSERT INTO teams (uuid, name) VALUES (?, ?)", "report-team", "Team A")
+	db.Raw("SELECT id FROM teams WHERE uuid = ?", "report-team").Scan(&teamID)
+
+	nextRunAt := time.Date(2026, 3, 9, 7, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.Create(model.ReportSubscription{
+		UUID: "sub-self", UserID: userID, KPIType: "timesheet", Target: model.TargetSelf,
+		Frequency: model.FrequencyMonthly, Format: "pdf", Delivery: model.DeliveryAttachment, NextRunAt: nextRunAt,
+	}))
+	assert.NoError(t, repo.Create(model.ReportSubscription{
+		UUID: "sub-team", UserID: userID, TeamID: &teamID, KPIType: "team_summary", Target: model.TargetTeam,
+		Frequency: model.FrequencyWeekly, Format: "xlsx", Delivery: model.DeliveryLink, NextRunAt: nextRunAt,
+	}))
+
+	subscription, err := repo.FindByUUID("sub-team")
+	assert.NoError(t, err)
+	assert.NotZero(t, subscription.ID)
+	assert.Equal(t, subscriberUUID, subscription.UserUUID)
+	if assert.NotNil(t, subscription.TeamUUID) {
+		assert.Equal(t, "report-team", *subscription.TeamUUID)
+		assert.Equal(t, "Team A", *subscription.TeamName)
+	}
+	assert.Equal(t, model.FrequencyWeekly, subscription.Frequency)
+	assert.True(t, nextRunAt.Equal(subscription.NextRunAt))
+	assert.Nil(t, subscription.LastRunAt)
+
+	subscriptions, err := repo.FindByUserID(userID)
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+	assert.Equal(t, "sub-self", subscriptions[0].UUID)
+	assert.Nil(t, subscriptions[0].TeamUUID)
+
+	assert.NoError(t, repo.DeleteByUUID("sub-self"))
+
+	missing, err := repo.FindByUUID("sub-self")
+	assert.NoError(t, err)
+	assert.Zero(t, missing.ID)
+}
+
+func TestFindDueAndClaimReportSubscriptions(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewReportSubscriptionRepository(db)
+
+	var userID int
+	db.Raw("SELECT id FROM users WHERE uuid = ?", subscriberUUID).Scan(&userID)
+
+	now := time.Date(2026, 3, 9, 7, 5, 0, 0, time.UTC)
+	for uuid, nextRunAt := range map[string]time.Time{
+		"sub-due-late":  now.Add(-24 * time.Hour),
+		"sub-due":       now.Add(-5 * time.Minute),
+		"sub-scheduled": now.Add(time.Hour),
+	} {
+		assert.NoError(t, repo.Create(model.ReportSubscription{
+			UUID: uuid, UserID: userID, KPIType: "presence_rate", Target: model.TargetSelf,
+			Frequency: model.FrequencyDaily, Format: "csv", Delivery: model.DeliveryAttachment, NextRunAt: nextRunAt,
+		}))
+	}
+
+	due, err := repo.FindDue(now)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	assert.Equal(t, "sub-due-late", due[0].UUID)
+	assert.Equal(t, "sub-due", due[1].UUID)
+
+	nextRunAt := now.Add(24 * time.Hour)
+	claimed, err := repo.Claim(due[1].ID, due[1].NextRunAt, nextRunAt)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// the run was already claimed, a second instance must skip it
+	claimed, err = repo.Claim(due[1].ID, due[1].NextRunAt, nextRunAt)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	lastError := "mail provider unavailable"
+	assert.NoError(t, repo.UpdateLastRun(due[1].ID, now, &lastError))
+
+	subscription, err := repo.FindByUUID("sub-due")
+	assert.NoError(t, err)
+	assert.True(t, nextRunAt.Equal(subscription.NextRunAt))
+	if assert.NotNil(t, subscription.LastRunAt) {
+		assert.True(t, now.Equal(*subscription.LastRunAt))
+	}
+	if assert.NotNil(t, subscription.LastError) {
+		assert.Equal(t, lastError, *subscription.LastError)
+	}
+
+	due, err = repo.FindDue(now)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	assert.Equal(t, "sub-due-late", due[0].UUID)
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	ExportFileService "app/internal/app/export-file/service"
+	"app/internal/app/kpi/export"
+	KPIService "app/internal/app/kpi/service"
+	MailModel "app/internal/app/mailer/model"
+	MailerService "app/internal/app/mailer/service"
+	MailTemplate "app/internal/app/mailer/template"
+	"app/internal/app/report-subscription/model"
+	"app/internal/app/report-subscription/repository"
+	TeamService "app/internal/app/team/service"
+	UserService "app/internal/app/user/service"
+	Config "app/internal/config"
+
+	"github.com/google/uuid"
+)
+
+type ReportSubscriptionService interface {
+	Create(request model.ReportSubscriptionCreate, requesterUUID string, isAdmin bool) (model.ReportSubscription, error)
+	GetByUser(userUUID string) ([]model.ReportSubscription, error)
+	Delete(uuid string, requesterUUID string, isAdmin bool) error
+	RunDue(now time.Time) (int, error)
+	StartScheduler(ctx context.Context)
+}
+
+type reportSubscriptionService struct {
+	Repository        repository.ReportSubscriptionRepository
+	KPIService        KPIService.KPIService
+	ExportFileService ExportFileService.ExportFileService
+	TeamService       TeamService.TeamService
+	UserService       UserService.UserService
+	MailerService     MailerService.MailerService
+	Config            model.ReportSubscriptionConfig
+}
+
+func NewReportSubscriptionService(repo repository.ReportSubscriptionRepository, kpiService KPIService.KPIService, exportFileService ExportFileService.ExportFileService, teamService TeamService.TeamService, userService UserService.UserService, mailerService MailerService.MailerService, config model.ReportSubscriptionConfig) ReportSubscriptionService {
+	return &reportSubscriptionService{
+		Repository:        repo,
+		KPIService:        kpiService,
+		ExportFileService: exportFileService,
+		TeamService:       teamService,
+		UserService:       userService,
+		MailerService:     mailerService,
+		Config:            config,
+	}
+}
+
+// Create subscribes the requester to a recurring report about themselves or about a team they manage,
+// the first report being sent at the next scheduled time.
+func (service *reportSubscriptionService) Create(request model.ReportSubscriptionCreate, requesterUUID string, isAdmin bool) (model.ReportSubscription, error) {
+	userID, err := service.UserService.GetIdByUuid(requesterUUID)
+	if err != nil {
+		return model.ReportSubscription{}, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	isTeamKPI := KPIService.IsTeamKPIType(request.KPIType)
+
+	var teamID *int
+	if request.Target == model.TargetTeam {
+		if !isTeamKPI {
+			return model.ReportSubscription{}, fmt.Errorf("%s: %s is not a team KPI type", Config.ErrorMessages()["INVALID_REQUEST"], request.KPIType)
+		}
+		if request.TeamUUID == "" {
+			return model.ReportSubscription{}, fmt.Errorf("%s: team_uuid is required for a team report", Config.ErrorMessages()["INVALID_REQUEST"])
+		}
+
+		id, err := service.TeamService.GetIdByUuid(request.TeamUUID)
+		if err != nil || id == 0 {
+			return model.ReportSubscription{}, fmt.Errorf("%s: %s", Config.ErrorMessages()["TEAM_NOT_FOUND"], request.TeamUUID)
+		}
+
+		if !isAdmin {
+			isManager, err := service.TeamService.IsTeamManager(id, userID)
+			if err != nil {
+				return model.ReportSubscription{}, fmt.Errorf("failed to check team manager: %w", err)
+			}
+			if !isManager {
+				return model.ReportSubscription{}, fmt.Errorf("%s", Config.ErrorMessages()["NOT_TEAM_MANAGER"])
+			}
+		}
+		teamID = &id
+	} else if isTeamKPI {
+		return model.ReportSubscription{}, fmt.Errorf("%s: %s requires a team target", Config.ErrorMessages()["INVALID_REQUEST"], request.KPIType)
+	}
+
+	subscription := model.ReportSubscription{
+		UUID:      uuid.New().String(),
+		UserID:    userID,
+		TeamID:    teamID,
+		KPIType:   request.KPIType,
+		Target:    request.Target,
+		Frequency: request.Frequency,
+		Format:    request.Format,
+		Delivery:  request.Delivery,
+		NextRunAt: nextRun(request.Frequency, time.Now(), service.Config.SendHour),
+	}
+	if subscription.Format == "" {
+		subscription.Format = export.CSVFormat
+	}
+	if subscription.Delivery == "" {
+		subscription.Delivery = model.DeliveryAttachment
+	}
+
+	if err := service.Repository.Create(subscription); err != nil {
+		return model.ReportSubscription{}, err
+	}
+
+	return service.Repository.FindByUUID(subscription.UUID)
+}
+
+func (service *reportSubscriptionService) GetByUser(userUUID string) ([]model.ReportSubscription, error) {
+	userID, err := service.UserService.GetIdByUuid(userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	subscriptions, err := service.Repository.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if subscriptions == nil {
+		subscriptions = []model.ReportSubscription{}
+	}
+	return subscriptions, nil
+}
+
+// Delete unsubscribes from a report, the subscription of another user being reported as not found to non admins.
+func (service *reportSubscriptionService) Delete(uuid string, requesterUUID string, isAdmin bool) error {
+	subscription, err := service.Repository.FindByUUID(uuid)
+	if err != nil {
+		return err
+	}
+
+	if subscription.ID == 0 || (subscription.UserUUID != requesterUUID && !isAdmin) {
+		return fmt.Errorf("%s: %s", Config.ErrorMessages()["REPORT_SUBSCRIPTION_NOT_FOUND"], uuid)
+	}
+
+	return service.Repository.DeleteByUUID(uuid)
+}
+
+// RunDue sends the reports due at now and returns how many were sent. The runs missed while the server was down
+// are merged into a single report, from the start of the period of the oldest missed run to the end of the period of the latest one.
+func (service *reportSubscriptionService) RunDue(now time.Time) (int, error) {
+	subscriptions, err := service.Repository.FindDue(now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, subscription := range subscriptions {
+		claimed, err := service.Repository.Claim(subscription.ID, subscription.NextRunAt, nextRun(subscription.Frequency, now, service.Config.SendHour))
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		var lastError *string
+		if err := service.send(subscription, now); err != nil {
+			log.Printf("⚠️ Error sending report subscription %s: %v", subscription.UUID, err)
+			message := err.Error()
+			lastError = &message
+		} else {
+			sent++
+		}
+
+		if err := service.Repository.UpdateLastRun(subscription.ID, now, lastError); err != nil {
+			log.Printf("⚠️ Error saving the last run of report subscription %s: %v", subscription.UUID, err)
+		}
+	}
+
+	return sent, nil
+}
+
+// StartScheduler sends the due reports every IntervalMinutes until the context is done.
+// Each run is claimed before the report is generated, so that several instances never send it twice.
+func (service *reportSubscriptionService) StartScheduler(ctx context.Context) {
+	if service.Config.IntervalMinutes <= 0 {
+		log.Println("⚠️ Report subscriptions scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(service.Config.IntervalMinutes) * time.Minute)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sent, err := service.RunDue(time.Now())
+				if err != nil {
+					log.Printf("⚠️ Error sending scheduled reports: %v", err)
+					continue
+				}
+				if sent > 0 {
+					log.Printf("✅ Sent %d scheduled reports", sent)
+				}
+			}
+		}
+	}()
+}
+
+// send generates the report of the periods of the runs due at now and mails it to the subscriber.
+func (service *reportSubscriptionService) send(subscription model.ReportSubscription, now time.Time) error {
+	user, err := service.UserService.GetUserByUUID(subscription.UserUUID)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+
+	uuidToSearch := subscription.UserUUID
+	if subscription.Target == model.TargetTeam {
+		if subscription.TeamID == nil || subscription.TeamUUID == nil {
+			return errors.New(Config.ErrorMessages()["TEAM_NOT_FOUND"])
+		}
+
+		// the subscriber may have stopped managing the team since they subscribed
+		if !slices.Contains(user.Roles, "admin") {
+			isManager, err := service.TeamService.IsTeamManager(*subscription.TeamID, subscription.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to check team manager: %w", err)
+			}
+			if !isManager {
+				return errors.New(Config.ErrorMessages()["NOT_TEAM_MANAGER"])
+			}
+		}
+		uuidToSearch = *subscription.TeamUUID
+	}
+
+	start, end := reportRange(subscription.Frequency, subscription.NextRunAt.In(time.Local), now.In(time.Local), service.Config.SendHour)
+	// the KPIs are computed over whole days, the last one being included
+	startDate := start.Format("2006-01-02")
+	endDate := end.AddDate(0, 0, -1).Format("2006-01-02")
+
+	filename := fmt.Sprintf("kpi_%s_%s.%s", subscription.KPIType, start.Format("20060102"), subscription.Format)
+	file, err := service.ExportFileService.Store(subscription.UserUUID, subscription.KPIType, subscription.Format, filename, func(w io.Writer) error {
+		return service.KPIService.ExportKPIData(startDate, endDate, subscription.KPIType, uuidToSearch, subscription.Format, w, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	period := fmt.Sprintf("%s to %s", start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+	subject := fmt.Sprintf("Your %s %s report", subscription.Frequency, subscription.KPIType)
+	mail := MailModel.Mail{To: user.Email, Subject: subject}
+
+	if subscription.Delivery == model.DeliveryAttachment && file.SizeBytes <= int64(service.Config.MaxAttachmentBytes) {
+		reader, err := service.ExportFileService.Open(file)
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+
+		mail.Attachments = []MailModel.Attachment{{Name: file.Filename, Content: content}}
+		mail.Body = MailTemplate.BaseMailTemplate(
+			subject,
+			fmt.Sprintf("Hello %s,<br><br>Please find attached your <span style=\"font-weight:bold;\">%s</span> report from %s.<br><br>Best regards,<br>The TimeManager Team", user.FirstName, subscription.KPIType, period),
+			"",
+			"",
+		)
+	} else {
+		mail.Body = MailTemplate.BaseMailTemplate(
+			subject,
+			fmt.Sprintf("Hello %s,<br><br>Your <span style=\"font-weight:bold;\">%s</span> report from %s is ready to download.<br><br>Best regards,<br>The TimeManager Team", user.FirstName, subscription.KPIType, period),
+			"Download",
+			Config.LoadConfig().FrontendURL+service.ExportFileService.SignedURL(file),
+		)
+	}
+
+	return service.MailerService.Send(mail)
+}
+
+// nextRun returns the first send time strictly after the given time: every day, every monday
+// or every first day of the month at sendHour.
+func nextRun(frequency string, after time.Time, sendHour int) time.Time {
+	after = after.In(time.Local)
+	day := time.Date(after.Year(), after.Month(), after.Day(), sendHour, 0, 0, 0, time.Local)
+
+	switch frequency {
+	case model.FrequencyWeekly:
+		day = day.AddDate(0, 0, (8-int(day.Weekday()))%7)
+		if !day.After(after) {
+			day = day.AddDate(0, 0, 7)
+		}
+	case model.FrequencyMonthly:
+		day = time.Date(after.Year(), after.Month(), 1, sendHour, 0, 0, 0, time.Local)
+		if !day.After(after) {
+			day = day.AddDate(0, 1, 0)
+		}
+	default:
+		if !day.After(after) {
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+
+	return day
+}
+
+// reportRange returns the bounds, end excluded, of the periods of every run from the scheduled one up to now,
+// so that a report sent after a downtime also covers the runs missed since.
+func reportRange(frequency string, scheduledAt time.Time, now time.Time, sendHour int) (time.Time, time.Time) {
+	lastRun := scheduledAt
+	for next := nextRun(frequency, lastRun, sendHour); !next.After(now); next = nextRun(frequency, next, sendHour) {
+		lastRun = next
+	}
+
+	start, _ := reportPeriod(frequency, scheduledAt)
+	_, end := reportPeriod(frequency, lastRun)
+	return start, end
+}
+
+// reportPeriod returns the bounds, end excluded, of the day, week or month completed before the run.
+func reportPeriod(frequency string, runAt time.Time) (time.Time, time.Time) {
+	end := time.Date(runAt.Year(), runAt.Month(), runAt.Day(), 0, 0, 0, 0, runAt.Location())
+
+	switch frequency {
+	case model.FrequencyWeekly:
+		end = end.AddDate(0, 0, -(int(end.Weekday())+6)%7)
+		return end.AddDate(0, 0, -7), end
+	case model.FrequencyMonthly:
+		end = end.AddDate(0, 0, 1-end.Day())
+		return end.AddDate(0, -1, 0), end
+	default:
+		return end.AddDate(0, 0, -1), end
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"app/internal/app/report-subscription/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportRange(t *testing.T) {
+	at := func(day int, hour int) time.Time {
+		return time.Date(2026, time.January, day, hour, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name        string
+		frequency   string
+		scheduledAt time.Time
+		now         time.Time
+		start       time.Time
+		end         time.Time
+	}{
+		{name: "daily run on time", frequency: model.FrequencyDaily, scheduledAt: at(14, 7), now: at(14, 7), start: at(13, 0), end: at(14, 0)},
+		{name: "daily runs missed", frequency: model.FrequencyDaily, scheduledAt: at(14, 7), now: at(17, 9), start: at(13, 0), end: at(17, 0)},
+		{name: "daily run late the same day", frequency: model.FrequencyDaily, scheduledAt: at(14, 7), now: at(15, 6), start: at(13, 0), end: at(14, 0)},
+		// 2026-01-12 and 2026-01-19 are mondays
+		{name: "weekly run on time", frequency: model.FrequencyWeekly, scheduledAt: at(12, 7), now: at(12, 8), start: at(5, 0), end: at(12, 0)},
+		{name: "weekly runs missed", frequency: model.FrequencyWeekly, scheduledAt: at(12, 7), now: at(20, 8), start: at(5, 0), end: at(19, 0)},
+		{name: "monthly runs missed", frequency: model.FrequencyMonthly, scheduledAt: at(1, 7), now: time.Date(2026, time.March, 2, 7, 0, 0, 0, time.Local), start: time.Date(2025, time.December, 1, 0, 0, 0, 0, time.Local), end: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := reportRange(test.frequency, test.scheduledAt, test.now, 7)
+			assert.Equal(t, test.start, start)
+			assert.Equal(t, test.end, end)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Session is a login of a user, referenced by the "sid" claim of its access tokens.
+// Only the hash of its current refresh token is stored.
+type Session struct {
+	ID               string
+	UserUUID         string
+	RefreshTokenHash string
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+}
+
+type SessionConfig struct {
+	// the claims of an access token, such as the roles, are only reloaded when it is refreshed
+	AccessTokenMinutes int
+	// a session expires when it has not been refreshed for RefreshTokenDays
+	RefreshTokenDays int
+}
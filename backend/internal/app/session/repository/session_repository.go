@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"app/internal/app/session/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSessionNotFound is returned when the session does not exist, has expired or was revoked.
+var ErrSessionNotFound = errors.New("session not found")
+
+// rotateScript replaces the refresh token hash of the session only when the current one matches,
+// so that a refresh token can never be used twice, even by concurrent requests.
+var rotateScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'refresh_token_hash') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'refresh_token_hash', ARGV[2], 'expires_at', ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+redis.call('PEXPIRE', KEYS[2], ARGV[4])
+return 1
+`)
+
+// SessionRepository stores the sessions in Redis as hashes expiring with the session, along with the set of
+// the sessions of each user.
+type SessionRepository interface {
+	Create(session model.Session) error
+	Get(id string) (model.Session, error)
+	Exists(id string) (bool, error)
+	Rotate(id string, currentHash string, newHash string, expiresAt time.Time) (bool, error)
+	Delete(id string) error
+	DeleteByUser(userUUID string) error
+}
+
+type sessionRepository struct {
+	client *redis.Client
+}
+
+func NewSessionRepository(client *redis.Client) SessionRepository {
+	return &sessionRepository{client}
+}
+
+func sessionKey(id string) string {
+	return "session:" + id
+}
+
+func userSessionsKey(userUUID string) string {
+	return "session:user:" + userUUID
+}
+
+// Create stores the session. Every session living as long after its last refresh, the set of the user
+// expires with its latest session.
+func (repo *sessionRepository) Create(session model.Session) error {
+	ctx := context.Background()
+	ttl := time.Until(session.ExpiresAt)
+
+	_, err := repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID),
+			"user_uuid", session.UserUUID,
+			"refresh_token_hash", session.RefreshTokenHash,
+			"created_at", session.CreatedAt.Format(time.RFC3339Nano),
+			"expires_at", session.ExpiresAt.Format(time.RFC3339Nano),
+		)
+		pipe.PExpire(ctx, sessionKey(session.ID), ttl)
+		pipe.SAdd(ctx, userSessionsKey(session.UserUUID), session.ID)
+		pipe.PExpire(ctx, userSessionsKey(session.UserUUID), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *sessionRepository) Get(id string) (model.Session, error) {
+	fields, err := repo.client.HGetAll(context.Background(), sessionKey(id)).Result()
+	if err != nil {
+		return model.Session{}, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	if len(fields) == 0 {
+		return model.Session{}, ErrSessionNotFound
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, fields["created_at"])
+	if err != nil {
+		return model.Session{}, err
+	}
+	expiresAt, err := time.Parse(time.RFC3339Nano, fields["expires_at"])
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	return model.Session{
+		ID:               id,
+		UserUUID:         fields["user_uuid"],
+		RefreshTokenHash: fields["refresh_token_hash"],
+		CreatedAt:        createdAt,
+		ExpiresAt:        expiresAt,
+	}, nil
+}
+
+func (repo *sessionRepository) Exists(id string) (bool, error) {
+	count, err := repo.client.Exists(context.Background(), sessionKey(id)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// Rotate replaces the refresh token hash and extends the session when currentHash is still its refresh token hash.
+// False is returned otherwise, or when the session no longer exists.
+func (repo *sessionRepository) Rotate(id string, currentHash string, newHash string, expiresAt time.Time) (bool, error) {
+	ctx := context.Background()
+
+	userUUID, err := repo.client.HGet(ctx, sessionKey(id), "user_uuid").Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch session: %w", err)
+	}
+
+	rotated, err := rotateScript.Run(ctx, repo.client,
+		[]string{sessionKey(id), userSessionsKey(userUUID)},
+		currentHash, newHash, expiresAt.Format(time.RFC3339Nano), time.Until(expiresAt).Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	return rotated == 1, nil
+}
+
+func (repo *sessionRepository) Delete(id string) error {
+	ctx := context.Background()
+
+	userUUID, err := repo.client.HGet(ctx, sessionKey(id), "user_uuid").Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch session: %w", err)
+	}
+
+	_, err = repo.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(id))
+		pipe.SRem(ctx, userSessionsKey(userUUID), id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByUser deletes every session of the user.
+func (repo *sessionRepository) DeleteByUser(userUUID string) error {
+	ctx := context.Background()
+
+	ids, err := repo.client.SMembers(ctx, userSessionsKey(userUUID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	keys := []string{userSessionsKey(userUUID)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+
+	if err := repo.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"app/internal/app/session/model"
+	"app/internal/app/session/repository"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func newRedisClient(t *testing.T) *redis.Client {
+	ctx := context.Background()
+	const port = "6379/tcp"
+
+	redisC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{port},
+			WaitingFor:   wait.ForLog("Ready to accept connections").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("❌ Failed to start Redis: %v", err)
+	}
+	t.Cleanup(func() { _ = redisC.Terminate(ctx) })
+
+	host, _ := redisC.Host(ctx)
+	mappedPort, _ := redisC.MappedPort(ctx, port)
+
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, mappedPort.Port())})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+func TestSessionRepository(t *testing.T) {
+	client := newRedisClient(t)
+	repo := repository.NewSessionRepository(client)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, session := range []model.Session{
+		{ID: "session-1", UserUUID: "user-1", RefreshTokenHash: "hash-1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "session-2", UserUUID: "user-1", RefreshTokenHash: "hash-2", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "session-3", UserUUID: "user-2", RefreshTokenHash: "hash-3", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+	} {
+		assert.NoError(t, repo.Create(session))
+	}
+
+	session, err := repo.Get("session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", session.UserUUID)
+	assert.Equal(t, "hash-1", session.RefreshTokenHash)
+	assert.True(t, now.Equal(session.CreatedAt))
+
+	ttl, err := client.TTL(context.Background(), "session:session-1").Result()
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 60)
+
+	_, err = repo.Get("missing")
+	assert.True(t, errors.Is(err, repository.ErrSessionNotFound))
+
+	// a refresh token hash can only be rotated once
+	rotated, err := repo.Rotate("session-1", "hash-1", "hash-1b", now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+
+	rotated, err = repo.Rotate("session-1", "hash-1", "hash-1c", now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+
+	session, err = repo.Get("session-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash-1b", session.RefreshTokenHash)
+	assert.True(t, now.Add(2*time.Hour).Equal(session.ExpiresAt))
+
+	rotated, err = repo.Rotate("missing", "hash-1", "hash-1c", now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+
+	assert.NoError(t, repo.Delete("session-2"))
+	assert.NoError(t, repo.Delete("session-2"))
+
+	exists, err := repo.Exists("session-2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, repo.DeleteByUser("user-1"))
+
+	exists, err = repo.Exists("session-1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the sessions of the other users are kept
+	exists, err = repo.Exists("session-3")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
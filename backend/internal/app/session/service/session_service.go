@@ -0,0 +1,159 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"app/internal/app/session/model"
+	"app/internal/app/session/repository"
+	Config "app/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// SessionService keeps the sessions of the users server-side. A refresh token is "<session id>.<secret>" and can
+// be used once only: refreshing rotates it, and presenting an already used token revokes the whole session,
+// as it means the token was stolen.
+type SessionService interface {
+	Create(userUUID string) (model.Session, string, error)
+	Rotate(refreshToken string) (model.Session, string, error)
+	IsActive(sessionID string) (bool, error)
+	Revoke(sessionID string) error
+	RevokeRefreshToken(refreshToken string) error
+	RevokeUser(userUUID string) error
+}
+
+type sessionService struct {
+	Repository repository.SessionRepository
+	Config     model.SessionConfig
+}
+
+func NewSessionService(repo repository.SessionRepository, config model.SessionConfig) SessionService {
+	return &sessionService{
+		Repository: repo,
+		Config:     config,
+	}
+}
+
+// Create opens a session for the user and returns it along with its first refresh token.
+func (service *sessionService) Create(userUUID string) (model.Session, string, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return model.Session{}, "", err
+	}
+
+	now := time.Now()
+	session := model.Session{
+		ID:               uuid.New().String(),
+		UserUUID:         userUUID,
+		RefreshTokenHash: hashSecret(secret),
+		CreatedAt:        now,
+		ExpiresAt:        service.expiresAt(now),
+	}
+
+	if err := service.Repository.Create(session); err != nil {
+		return model.Session{}, "", err
+	}
+
+	return session, session.ID + "." + secret, nil
+}
+
+// Rotate exchanges the refresh token for a new one and extends the session.
+func (service *sessionService) Rotate(refreshToken string) (model.Session, string, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return model.Session{}, "", errors.New(Config.ErrorMessages()["INVALID_REFRESH_TOKEN"])
+	}
+
+	newSecret, err := newSecret()
+	if err != nil {
+		return model.Session{}, "", err
+	}
+
+	expiresAt := service.expiresAt(time.Now())
+	rotated, err := service.Repository.Rotate(sessionID, hashSecret(secret), hashSecret(newSecret), expiresAt)
+	if err != nil {
+		return model.Session{}, "", err
+	}
+
+	if !rotated {
+		// the session still exists, so the token is a previous one of the session
+		if active, err := service.IsActive(sessionID); err == nil && active {
+			log.Printf("⚠️ Refresh token of session %s reused, revoking the session", sessionID)
+			if err := service.Revoke(sessionID); err != nil {
+				return model.Session{}, "", err
+			}
+		}
+		return model.Session{}, "", errors.New(Config.ErrorMessages()["INVALID_REFRESH_TOKEN"])
+	}
+
+	session, err := service.Repository.Get(sessionID)
+	if err != nil {
+		return model.Session{}, "", err
+	}
+
+	return session, sessionID + "." + newSecret, nil
+}
+
+func (service *sessionService) IsActive(sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+
+	return service.Repository.Exists(sessionID)
+}
+
+func (service *sessionService) Revoke(sessionID string) error {
+	return service.Repository.Delete(sessionID)
+}
+
+// RevokeRefreshToken revokes the session of the refresh token, provided the token is its current one.
+func (service *sessionService) RevokeRefreshToken(refreshToken string) error {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return errors.New(Config.ErrorMessages()["INVALID_REFRESH_TOKEN"])
+	}
+
+	session, err := service.Repository.Get(sessionID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if session.RefreshTokenHash != hashSecret(secret) {
+		return errors.New(Config.ErrorMessages()["INVALID_REFRESH_TOKEN"])
+	}
+
+	return service.Repository.Delete(sessionID)
+}
+
+// RevokeUser revokes every session of the user, who has to log in again.
+func (service *sessionService) RevokeUser(userUUID string) error {
+	return service.Repository.DeleteByUser(userUUID)
+}
+
+func (service *sessionService) expiresAt(from time.Time) time.Time {
+	return from.Add(time.Duration(service.Config.RefreshTokenDays) * 24 * time.Hour)
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
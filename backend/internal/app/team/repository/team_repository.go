@@ -19,6 +19,8 @@ type TeamRepository interface {
 	AddMembersToTeam(teamID int, members []model.TeamMemberCreate) error
 	UpdateTeamByID(id int, updatedTeam model.TeamUpdate) error
 	UpdateTeamUserManagerStatus(teamID int, userID int, isManager bool) error
+	IsTeamManager(teamID int, userID int) (bool, error)
+	IsUserManagedBy(userID int, managerID int) (bool, error)
 }
 
 type teamRepository struct {
@@ -225,3 +227,25 @@ func (repo *teamRepository) FindUserIDsByTeamID(teamID int) ([]model.TeamMemberL
 	}
 	return users, nil
 }
+
+func (repo *teamRepository) IsTeamManager(teamID int, userID int) (bool, error) {
+	var isManager bool
+	err := repo.db.Raw("SELECT EXISTS (SELECT 1 FROM teams_members WHERE team_id = ? AND user_id = ? AND is_manager = TRUE)", teamID, userID).Scan(&isManager).Error
+	return isManager, err
+}
+
+// IsUserManagedBy checks if the user belongs to one of the teams managed by the given manager.
+func (repo *teamRepository) IsUserManagedBy(userID int, managerID int) (bool, error) {
+	var isManaged bool
+	err := repo.db.Raw(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM teams_members member
+			INNER JOIN teams_members manager ON manager.team_id = member.team_id
+			WHERE member.user_id = ?
+				AND manager.user_id = ?
+				AND manager.is_manager = TRUE
+		)
+	`, userID, managerID).Scan(&isManaged).Error
+	return isManaged, err
+}
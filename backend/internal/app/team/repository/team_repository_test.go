@@ -319,3 +319,77 @@ func TestUpdateTeamUserManagerStatus(t *testing.T) {
 	db.Raw("SELECT is_manager FROM teams_members WHERE team_id = ? AND user_id = ?", teamID, userID).Scan(&isManager)
 	assert.True(t, isManager)
 }
+
+//
+// IS TEAM MANAGER
+//
+
+func TestIsTeamManager(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewTeamRepository(db)
+
+	db.Exec("INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)", "manager-1", "managerone", "managerone@example.com", "hashedpwd", "active")
+	db.Exec("INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)", "member-1", "memberone", "memberone@example.com", "hashedpwd", "active")
+
+	var managerID, memberID int
+	db.Raw(selectIdUserUuid, "manager-1").Scan(&managerID)
+	db.Raw(selectIdUserUuid, "member-1").Scan(&memberID)
+
+	db.Exec(insertTeamQuery, "team-1", "Team A")
+	db.Exec(insertTeamQuery, "team-2", "Team B")
+	var teamID, otherTeamID int
+	db.Raw(selectIdTeamUuid, "team-1").Scan(&teamID)
+	db.Raw(selectIdTeamUuid, "team-2").Scan(&otherTeamID)
+
+	db.Exec(insertTeamMemberQuery, "tm-1", teamID, managerID, true)
+	db.Exec(insertTeamMemberQuery, "tm-2", teamID, memberID, false)
+
+	isManager, err := repo.IsTeamManager(teamID, managerID)
+	assert.NoError(t, err)
+	assert.True(t, isManager)
+
+	isManager, err = repo.IsTeamManager(teamID, memberID)
+	assert.NoError(t, err)
+	assert.False(t, isManager)
+
+	isManager, err = repo.IsTeamManager(otherTeamID, managerID)
+	assert.NoError(t, err)
+	assert.False(t, isManager)
+}
+
+func TestIsUserManagedBy(t *testing.T) {
+	db := test.ResetDB(t)
+	repo := repository.NewTeamRepository(db)
+
+	db.Exec("INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)", "manager-1", "managerone", "managerone@example.com", "hashedpwd", "active")
+	db.Exec("INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)", "member-1", "memberone", "memberone@example.com", "hashedpwd", "active")
+	db.Exec("INSERT INTO users (uuid, username, email, password_hash, status) VALUES (?, ?, ?, ?, ?)", "member-2", "membertwo", "membertwo@example.com", "hashedpwd", "active")
+
+	var managerID, memberID, otherMemberID int
+	db.Raw(selectIdUserUuid, "manager-1").Scan(&managerID)
+	db.Raw(selectIdUserUuid, "member-1").Scan(&memberID)
+	db.Raw(selectIdUserUuid, "member-2").Scan(&otherMemberID)
+
+	db.Exec(insertTeamQuery, "team-1", "Team A")
+	db.Exec(insertTeamQuery, "team-2", "Team B")
+	var teamID, otherTeamID int
+	db.Raw(selectIdTeamUuid, "team-1").Scan(&teamID)
+	db.Raw(selectIdTeamUuid, "team-2").Scan(&otherTeamID)
+
+	db.Exec(insertTeamMemberQuery, "tm-1", teamID, managerID, true)
+	db.Exec(insertTeamMemberQuery, "tm-2", teamID, memberID, false)
+	db.Exec(insertTeamMemberQuery, "tm-3", otherTeamID, otherMemberID, false)
+
+	isManaged, err := repo.IsUserManagedBy(memberID, managerID)
+	assert.NoError(t, err)
+	assert.True(t, isManaged)
+
+	isManaged, err = repo.IsUserManagedBy(otherMemberID, managerID)
+	assert.NoError(t, err)
+	assert.False(t, isManaged)
+
+	// a member does not manage the manager
+	isManaged, err = repo.IsUserManagedBy(managerID, memberID)
+	assert.NoError(t, err)
+	assert.False(t, isManaged)
+}
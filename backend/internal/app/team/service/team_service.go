@@ -1,6 +1,7 @@
 package service
 
 import (
+	CacheService "app/internal/app/cache/service"
 	"app/internal/app/team/model"
 	"app/internal/app/team/repository"
 	UserService "app/internal/app/user/service"
@@ -19,15 +20,26 @@ type TeamService interface {
 	AddUsersToTeam(teamID int, members []model.TeamMemberCreate) error
 	UpdateTeamByID(id int, updatedTeam model.TeamUpdate) error
 	UpdateTeamUserManagerStatus(teamUUID string, userUUID string, isManager bool) error
+	IsTeamManager(teamID int, userID int) (bool, error)
+	IsUserManagedBy(userID int, managerID int) (bool, error)
 }
 
 type teamService struct {
 	repo        repository.TeamRepository
 	UserService UserService.UserService
+	Cache       CacheService.CacheService
 }
 
-func NewTeamService(repo repository.TeamRepository, userService UserService.UserService) TeamService {
-	return &teamService{repo, userService}
+func NewTeamService(repo repository.TeamRepository, userService UserService.UserService, cache CacheService.CacheService) TeamService {
+	return &teamService{repo, userService, cache}
+}
+
+// invalidate drops every cached KPI once a team changes, since the teams also decide which users a manager is allowed to see.
+func (service *teamService) invalidate(err error) error {
+	if err == nil {
+		service.Cache.Invalidate(CacheService.KPINamespace)
+	}
+	return err
 }
 
 func (service *teamService) GetTeams() ([]model.TeamReadAll, error) {
@@ -48,11 +60,11 @@ func (service *teamService) GetTeamByUUID(uuid string) (model.TeamReadAll, error
 }
 
 func (service *teamService) DeleteTeamByID(id int) error {
-	return service.repo.DeleteByID(id)
+	return service.invalidate(service.repo.DeleteByID(id))
 }
 
 func (service *teamService) RemoveUserFromTeam(teamID int, userID int) error {
-	return service.repo.DeleteUserFromTeam(teamID, userID)
+	return service.invalidate(service.repo.DeleteUserFromTeam(teamID, userID))
 }
 
 func (service *teamService) CreateTeam(newTeam model.TeamCreate) error {
@@ -87,15 +99,15 @@ func (service *teamService) CreateTeam(newTeam model.TeamCreate) error {
 		}
 	}
 
-	return nil
+	return service.invalidate(nil)
 }
 
 func (service *teamService) AddUsersToTeam(teamID int, members []model.TeamMemberCreate) error {
-	return service.repo.AddMembersToTeam(teamID, members)
+	return service.invalidate(service.repo.AddMembersToTeam(teamID, members))
 }
 
 func (service *teamService) UpdateTeamByID(id int, updatedTeam model.TeamUpdate) error {
-	return service.repo.UpdateTeamByID(id, updatedTeam)
+	return service.invalidate(service.repo.UpdateTeamByID(id, updatedTeam))
 }
 
 func (service *teamService) UpdateTeamUserManagerStatus(teamUUID string, userUUID string, isManager bool) error {
@@ -109,9 +121,17 @@ func (service *teamService) UpdateTeamUserManagerStatus(teamUUID string, userUUI
 		return err
 	}
 
-	return service.repo.UpdateTeamUserManagerStatus(teamID, userID, isManager)
+	return service.invalidate(service.repo.UpdateTeamUserManagerStatus(teamID, userID, isManager))
 }
 
 func (service *teamService) GetUserIDsByTeamID(teamID int) ([]model.TeamMemberLight, error) {
 	return service.repo.FindUserIDsByTeamID(teamID)
 }
+
+func (service *teamService) IsTeamManager(teamID int, userID int) (bool, error) {
+	return service.repo.IsTeamManager(teamID, userID)
+}
+
+func (service *teamService) IsUserManagedBy(userID int, managerID int) (bool, error) {
+	return service.repo.IsUserManagedBy(userID, managerID)
+}
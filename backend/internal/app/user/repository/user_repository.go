@@ -79,11 +79,11 @@ func (repo *userRepository) FindIdByUuid(uuid string) (int, error) {
 func (repo *userRepository) FindByTypeAuth(typeOf string, data string) (*model.UserReadJWT, error) {
 	var user model.UserReadJWT
 
-	if typeOf != "email" && typeOf != "username" {
+	if typeOf != "email" && typeOf != "username" && typeOf != "uuid" {
 		return nil, fmt.Errorf("invalid type: %s", typeOf)
 	}
 
-	query := fmt.Sprintf("SELECT id, uuid, first_day_of_week, email, roles, first_name, last_name, username, phone_number, password_hash FROM users WHERE %s = ?", typeOf)
+	query := fmt.Sprintf("SELECT id, uuid, first_day_of_week, email, roles, first_name, last_name, username, phone_number, status, password_hash FROM users WHERE %s = ?", typeOf)
 
 	err := repo.db.Raw(query, data).Scan(&user).Error
 	if err != nil {
@@ -131,6 +131,14 @@ func TestFindByTypeAuth(t *testing.T) {
 	userByUsername, err := repo.FindByTypeAuth("username", "jane")
 	assert.NoError(t, err)
 	assert.Equal(t, uuid, userByUsername.UUID)
+
+	userByUUID, err := repo.FindByTypeAuth("uuid", uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, "jane", userByUUID.Username)
+	assert.NotNil(t, userByUUID.Status)
+
+	_, err = repo.FindByTypeAuth("password_hash", "x")
+	assert.Error(t, err)
 }
 
 func TestFindDashboardLayoutByUUID(t *testing.T) {
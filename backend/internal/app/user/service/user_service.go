@@ -6,6 +6,7 @@ import (
 	"app/internal/config"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 	"unicode"
@@ -14,11 +15,15 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	CacheService "app/internal/app/cache/service"
+
 	MailTemplate "app/internal/app/mailer/template"
 
 	MailModel "app/internal/app/mailer/model"
 	MailerService "app/internal/app/mailer/service"
 
+	SessionService "app/internal/app/session/service"
+
 	WeeklyRateService "app/internal/app/weekly-rate/service"
 
 	Config "app/internal/config"
@@ -39,16 +44,19 @@ type UserService interface {
 	UpdateUserDashboardLayout(userUUID string, layout model.UserDashboardLayoutUpdate) error
 	ChangeUserPassword(token string, newPassword string) error
 	ResetPassword(userEmail string, userUUID string) error
+	InvalidateCache(userUUID string)
 }
 
 type userService struct {
 	repo              repository.UserRepository
 	WeeklyRateService WeeklyRateService.WeeklyRateService
 	MailerService     MailerService.MailerService
+	SessionService    SessionService.SessionService
+	Cache             CacheService.CacheService
 }
 
-func NewUserService(repo repository.UserRepository, mailerService MailerService.MailerService) UserService {
-	return &userService{repo: repo, MailerService: mailerService}
+func NewUserService(repo repository.UserRepository, mailerService MailerService.MailerService, sessionService SessionService.SessionService, cache CacheService.CacheService) UserService {
+	return &userService{repo: repo, MailerService: mailerService, SessionService: sessionService, Cache: cache}
 }
 
 func (s *userService) SetWeeklyRateService(w WeeklyRateService.WeeklyRateService) {
@@ -99,6 +107,7 @@ func (service *userService) RegisterUser(user model.UserCreate) error {
 	if err != nil {
 		return err
 	}
+	service.Cache.Invalidate(CacheService.OrganizationTag)
 
 	// create a JWT token for account activation with user uuid as key inside and 15 min
 	secret := Config.LoadConfig().JWTSecret
@@ -141,11 +150,28 @@ func (service *userService) RegisterUser(user model.UserCreate) error {
 }
 
 func (service *userService) DeleteUser(userUUID string) error {
-	return service.repo.DeleteUser(userUUID)
+	// the teams of the user are looked up before they are gone
+	tags := service.cacheTags(userUUID)
+	if err := service.repo.DeleteUser(userUUID); err != nil {
+		return err
+	}
+	service.Cache.Invalidate(tags...)
+
+	return service.revokeSessions(userUUID)
 }
 
+// UpdateUserStatus logs the user out of every session unless the user is activated.
 func (service *userService) UpdateUserStatus(userUUID string, status string) error {
-	return service.repo.UpdateUserStatus(userUUID, status)
+	if err := service.repo.UpdateUserStatus(userUUID, status); err != nil {
+		return err
+	}
+	service.InvalidateCache(userUUID)
+
+	if status == "active" {
+		return nil
+	}
+
+	return service.revokeSessions(userUUID)
 }
 
 func (service *userService) UpdateUser(userID int, user model.UserUpdateEntry) error {
@@ -166,7 +192,52 @@ func (service *userService) UpdateUser(userID int, user model.UserUpdateEntry) e
 		user.WeeklyRateID = &weeklyRateID
 	}
 
-	return service.repo.UpdateUser(userID, user)
+	current, err := service.repo.FindByUUID(user.UUID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.repo.UpdateUser(userID, user); err != nil {
+		return err
+	}
+	service.InvalidateCache(user.UUID)
+
+	// the roles are part of the access tokens, so a user whose roles change or who is deactivated is logged out
+	rolesChanged := user.Roles != nil && !slices.Equal(*user.Roles, current.Roles)
+	deactivated := user.Status != nil && *user.Status != "active" && (current.Status == nil || *current.Status != *user.Status)
+	if rolesChanged || deactivated {
+		return service.revokeSessions(user.UUID)
+	}
+
+	return nil
+}
+
+func (service *userService) revokeSessions(userUUID string) error {
+	if err := service.SessionService.RevokeUser(userUUID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// InvalidateCache drops the cached results computed from the user, their teams and the organization right after a write.
+// The triggers notify the change asynchronously, so a read following the write could otherwise still get the stale result.
+func (service *userService) InvalidateCache(userUUID string) {
+	service.Cache.Invalidate(service.cacheTags(userUUID)...)
+}
+
+func (service *userService) cacheTags(userUUID string) []string {
+	tags := []string{CacheService.UserTag(userUUID), CacheService.OrganizationTag}
+
+	user, err := service.repo.FindByUUID(userUUID)
+	if err != nil {
+		log.Printf("⚠️ Failed to find the teams of user %s to invalidate their cache: %v", userUUID, err)
+		return tags
+	}
+	for _, team := range user.Teams {
+		tags = append(tags, CacheService.TeamTag(team.TeamUUID))
+	}
+	return tags
 }
 
 func (service *userService) GetUserByUUID(userUUID string) (*model.UserReadAll, error) {
@@ -245,7 +316,11 @@ func (service *userService) ChangeUserPassword(token string, newPassword string)
 		return err
 	}
 
-	return service.repo.UpdateUserPassword(userID, string(hashedPassword))
+	if err := service.repo.UpdateUserPassword(userID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	return service.revokeSessions(userUUID)
 }
 
 func (service *userService) ResetPassword(userEmail string, userUUID string) error {
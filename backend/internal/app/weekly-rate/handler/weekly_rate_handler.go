@@ -2,9 +2,11 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	"app/internal/app/weekly-rate/model"
 	WeeklyRateService "app/internal/app/weekly-rate/service"
+	Config "app/internal/config"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,7 +42,7 @@ func (handler *WeeklyRateHandler) GetAll(c *gin.Context) {
 // Create Weekly Rate
 //
 // @Summary      Create a new weekly rate
-// @Description  Create a new weekly rate with the provided details
+// @Description  Create a new weekly rate with the provided details. The optional **distribution** sets the expected hours for each weekday and must add up to the amount, when omitted the amount is spread evenly from monday to friday.
 // @Tags         WeeklyRates
 // @Accept       json
 // @Produce      json
@@ -56,7 +58,7 @@ func (handler *WeeklyRateHandler) Create(c *gin.Context) {
 
 	err := handler.service.Create(newWeeklyRate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(weeklyRateErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -66,7 +68,7 @@ func (handler *WeeklyRateHandler) Create(c *gin.Context) {
 // Update Weekly Rate
 //
 // @Summary      Update an existing weekly rate
-// @Description  Update the details of an existing weekly rate
+// @Description  Update the details of an existing weekly rate. When the amount changes without a new **distribution**, the current distribution is scaled to the new amount.
 // @Tags         WeeklyRates
 // @Accept       json
 // @Produce      json
@@ -84,7 +86,7 @@ func (handler *WeeklyRateHandler) Update(c *gin.Context) {
 
 	err := handler.service.Update(uuid, updatedWeeklyRate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(weeklyRateErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -144,3 +146,10 @@ func (handler *WeeklyRateHandler) AssignToUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Weekly rate assigned to user successfully"})
 }
+
+func weeklyRateErrorStatus(err error) int {
+	if strings.HasPrefix(err.Error(), Config.ErrorMessages()["INVALID_WEEKLY_RATE_DISTRIBUTION"]) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -1,20 +1,104 @@
 package model
 
+import "time"
+
+// swagger:model WeeklyRateDistribution
+type WeeklyRateDistribution struct {
+	MondayHours    float64 `json:"monday_hours" gorm:"column:monday_hours"`
+	TuesdayHours   float64 `json:"tuesday_hours" gorm:"column:tuesday_hours"`
+	WednesdayHours float64 `json:"wednesday_hours" gorm:"column:wednesday_hours"`
+	ThursdayHours  float64 `json:"thursday_hours" gorm:"column:thursday_hours"`
+	FridayHours    float64 `json:"friday_hours" gorm:"column:friday_hours"`
+	SaturdayHours  float64 `json:"saturday_hours" gorm:"column:saturday_hours"`
+	SundayHours    float64 `json:"sunday_hours" gorm:"column:sunday_hours"`
+}
+
 // swagger:model WeeklyRate
 type WeeklyRate struct {
-	UUID     *string `gorm:"not null" json:"uuid"`
-	RateName string  `gorm:"not null" json:"rate_name"`
-	Amount   float64 `gorm:"not null" json:"amount"`
+	UUID         *string                `gorm:"not null" json:"uuid"`
+	RateName     string                 `gorm:"not null" json:"rate_name"`
+	Amount       float64                `gorm:"not null" json:"amount"`
+	Distribution WeeklyRateDistribution `gorm:"embedded" json:"distribution"`
 }
 
 // swagger:model CreateWeeklyRate
 type CreateWeeklyRate struct {
 	RateName string  `gorm:"not null" json:"rate_name"`
 	Amount   float64 `gorm:"not null" json:"amount"`
+	// optional, spread evenly from monday to friday when omitted
+	Distribution *WeeklyRateDistribution `json:"distribution,omitempty"`
 }
 
 // swagger:model UpdateWeeklyRate
 type UpdateWeeklyRate struct {
-	RateName string  `gorm:"not null" json:"rate_name"`
-	Amount   float64 `gorm:"not null" json:"amount"`
+	RateName     string                  `gorm:"not null" json:"rate_name"`
+	Amount       float64                 `gorm:"not null" json:"amount"`
+	Distribution *WeeklyRateDistribution `json:"distribution,omitempty"`
+}
+
+// DefaultDistribution spreads the weekly amount evenly from monday to friday.
+func DefaultDistribution(amount float64) WeeklyRateDistribution {
+	daily := amount / 5
+	return WeeklyRateDistribution{
+		MondayHours:    daily,
+		TuesdayHours:   daily,
+		WednesdayHours: daily,
+		ThursdayHours:  daily,
+		FridayHours:    daily,
+	}
+}
+
+// HoursFor returns the expected working hours for the given weekday.
+func (d WeeklyRateDistribution) HoursFor(day time.Weekday) float64 {
+	switch day {
+	case time.Monday:
+		return d.MondayHours
+	case time.Tuesday:
+		return d.TuesdayHours
+	case time.Wednesday:
+		return d.WednesdayHours
+	case time.Thursday:
+		return d.ThursdayHours
+	case time.Friday:
+		return d.FridayHours
+	case time.Saturday:
+		return d.SaturdayHours
+	default:
+		return d.SundayHours
+	}
+}
+
+// Total returns the sum of the expected hours over the whole week.
+func (d WeeklyRateDistribution) Total() float64 {
+	return d.MondayHours + d.TuesdayHours + d.WednesdayHours + d.ThursdayHours + d.FridayHours + d.SaturdayHours + d.SundayHours
+}
+
+// WorkingDays returns the number of weekdays with expected hours.
+func (d WeeklyRateDistribution) WorkingDays() int {
+	count := 0
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if d.HoursFor(day) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Scale redistributes a new weekly amount over the same working days, keeping the current proportions.
+func (d WeeklyRateDistribution) Scale(amount float64) WeeklyRateDistribution {
+	total := d.Total()
+	if total <= 0 {
+		return DefaultDistribution(amount)
+	}
+
+	ratio := amount / total
+	return WeeklyRateDistribution{
+		MondayHours:    d.MondayHours * ratio,
+		TuesdayHours:   d.TuesdayHours * ratio,
+		WednesdayHours: d.WednesdayHours * ratio,
+		ThursdayHours:  d.ThursdayHours * ratio,
+		FridayHours:    d.FridayHours * ratio,
+		SaturdayHours:  d.SaturdayHours * ratio,
+		SundayHours:    d.SundayHours * ratio,
+	}
 }
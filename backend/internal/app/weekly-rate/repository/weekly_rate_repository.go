@@ -11,6 +11,7 @@ import (
 type WeeklyRateRepository interface {
 	GetAll() ([]WeeklyRateModel.WeeklyRate, error)
 	GetIDByUUID(uuid string) (int, error)
+	FindByID(id int) (WeeklyRateModel.WeeklyRate, error)
 	Create(input WeeklyRateModel.WeeklyRate) error
 	Update(id int, input WeeklyRateModel.UpdateWeeklyRate) error
 	Delete(uuid string) error
@@ -28,7 +29,17 @@ func NewWeeklyRateRepository(db *gorm.DB) WeeklyRateRepository {
 func (repo *weeklyRateRepository) GetAll() ([]WeeklyRateModel.WeeklyRate, error) {
 	var weeklyRates []WeeklyRateModel.WeeklyRate
 	err := repo.db.Raw(`
-		SELECT uuid, rate_name, amount
+		SELECT
+			uuid,
+			rate_name,
+			amount,
+			monday_hours,
+			tuesday_hours,
+			wednesday_hours,
+			thursday_hours,
+			friday_hours,
+			saturday_hours,
+			sunday_hours
 		FROM weekly_rate
 	`).Scan(&weeklyRates).Error
 	if err != nil {
@@ -50,11 +61,45 @@ func (repo *weeklyRateRepository) GetIDByUUID(uuid string) (int, error) {
 	return weeklyRateID, nil
 }
 
+func (repo *weeklyRateRepository) FindByID(id int) (WeeklyRateModel.WeeklyRate, error) {
+	var weeklyRate WeeklyRateModel.WeeklyRate
+	err := repo.db.Raw(`
+		SELECT
+			uuid,
+			rate_name,
+			amount,
+			monday_hours,
+			tuesday_hours,
+			wednesday_hours,
+			thursday_hours,
+			friday_hours,
+			saturday_hours,
+			sunday_hours
+		FROM weekly_rate
+		WHERE id = ?
+	`, id).Scan(&weeklyRate).Error
+	if err != nil {
+		return WeeklyRateModel.WeeklyRate{}, fmt.Errorf("failed to fetch weekly rate: %w", err)
+	}
+	if weeklyRate.UUID == nil {
+		return WeeklyRateModel.WeeklyRate{}, fmt.Errorf("weekly rate with id %d not found", id)
+	}
+	return weeklyRate, nil
+}
+
 func (repo *weeklyRateRepository) Create(input WeeklyRateModel.WeeklyRate) error {
 	result := repo.db.Exec(`
-		INSERT INTO weekly_rate (uuid, rate_name, amount)
-		VALUES (?, ?, ?)
-	`, input.UUID, input.RateName, input.Amount)
+		INSERT INTO weekly_rate (
+			uuid, rate_name, amount,
+			monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours, saturday_hours, sunday_hours
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		input.UUID, input.RateName, input.Amount,
+		input.Distribution.MondayHours, input.Distribution.TuesdayHours, input.Distribution.WednesdayHours,
+		input.Distribution.ThursdayHours, input.Distribution.FridayHours, input.Distribution.SaturdayHours,
+		input.Distribution.SundayHours,
+	)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create weekly rate: %w", result.Error)
 	}
@@ -70,6 +115,15 @@ func (repo *weeklyRateRepository) Update(id int, input WeeklyRateModel.UpdateWee
 	if input.Amount != 0 {
 		updateData["amount"] = input.Amount
 	}
+	if input.Distribution != nil {
+		updateData["monday_hours"] = input.Distribution.MondayHours
+		updateData["tuesday_hours"] = input.Distribution.TuesdayHours
+		updateData["wednesday_hours"] = input.Distribution.WednesdayHours
+		updateData["thursday_hours"] = input.Distribution.ThursdayHours
+		updateData["friday_hours"] = input.Distribution.FridayHours
+		updateData["saturday_hours"] = input.Distribution.SaturdayHours
+		updateData["sunday_hours"] = input.Distribution.SundayHours
+	}
 
 	result := repo.db.Model(&WeeklyRateModel.WeeklyRate{}).Table("weekly_rate").Where("id = ?", id).Updates(updateData)
 	if result.Error != nil {
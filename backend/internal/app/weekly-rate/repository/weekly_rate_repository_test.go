@@ -23,7 +23,14 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			uuid TEXT NOT NULL,
 			rate_name TEXT,
-			amount INTEGER
+			amount INTEGER,
+			monday_hours REAL NOT NULL DEFAULT 0,
+			tuesday_hours REAL NOT NULL DEFAULT 0,
+			wednesday_hours REAL NOT NULL DEFAULT 0,
+			thursday_hours REAL NOT NULL DEFAULT 0,
+			friday_hours REAL NOT NULL DEFAULT 0,
+			saturday_hours REAL NOT NULL DEFAULT 0,
+			sunday_hours REAL NOT NULL DEFAULT 0
 		);
 		CREATE TABLE users (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -57,6 +64,42 @@ func TestCreateWeeklyRate(t *testing.T) {
 	assert.Equal(t, int64(1), count)
 }
 
+func TestCreateWeeklyRateWithDistribution(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewWeeklyRateRepository(db)
+
+	uuid := "uuid-1"
+	newRate := model.WeeklyRate{
+		UUID:     &uuid,
+		RateName: "Four days",
+		Amount:   32,
+		Distribution: model.WeeklyRateDistribution{
+			MondayHours:    8,
+			TuesdayHours:   8,
+			WednesdayHours: 8,
+			ThursdayHours:  8,
+		},
+	}
+
+	err := repo.Create(newRate)
+	assert.NoError(t, err)
+
+	rate, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Four days", rate.RateName)
+	assert.Equal(t, 8.0, rate.Distribution.ThursdayHours)
+	assert.Equal(t, 0.0, rate.Distribution.FridayHours)
+	assert.Equal(t, 4, rate.Distribution.WorkingDays())
+}
+
+func TestFindWeeklyRateByIDNotFound(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewWeeklyRateRepository(db)
+
+	_, err := repo.FindByID(42)
+	assert.Error(t, err)
+}
+
 func TestGetAllWeeklyRates(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repository.NewWeeklyRateRepository(db)
@@ -113,6 +156,31 @@ func TestUpdateWeeklyRate(t *testing.T) {
 	assert.Equal(t, 200, result.Amount)
 }
 
+func TestUpdateWeeklyRateDistribution(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewWeeklyRateRepository(db)
+
+	db.Exec(`
+		INSERT INTO weekly_rate (uuid, rate_name, amount, monday_hours, tuesday_hours, wednesday_hours, thursday_hours, friday_hours)
+		VALUES ('uuid-4', 'Standard', 35, 7, 7, 7, 7, 7)
+	`)
+
+	distribution := model.WeeklyRateDistribution{
+		MondayHours:    9,
+		TuesdayHours:   9,
+		WednesdayHours: 9,
+		ThursdayHours:  8,
+	}
+
+	err := repo.Update(1, model.UpdateWeeklyRate{Distribution: &distribution})
+	assert.NoError(t, err)
+
+	rate, err := repo.FindByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 35.0, rate.Amount)
+	assert.Equal(t, distribution, rate.Distribution)
+}
+
 func TestDeleteWeeklyRate(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repository.NewWeeklyRateRepository(db)
@@ -2,7 +2,9 @@ package service
 
 import (
 	"fmt"
+	"math"
 
+	CacheService "app/internal/app/cache/service"
 	WeeklyRateModel "app/internal/app/weekly-rate/model"
 	WeeklyRateRepository "app/internal/app/weekly-rate/repository"
 
@@ -27,12 +29,14 @@ type WeeklyRateService interface {
 type weeklyRateService struct {
 	WeeklyRateRepo WeeklyRateRepository.WeeklyRateRepository
 	UserLookup     UserLookup
+	Cache          CacheService.CacheService
 }
 
-func NewWeeklyRateService(repo WeeklyRateRepository.WeeklyRateRepository, userLookup UserLookup) WeeklyRateService {
+func NewWeeklyRateService(repo WeeklyRateRepository.WeeklyRateRepository, userLookup UserLookup, cache CacheService.CacheService) WeeklyRateService {
 	return &weeklyRateService{
 		WeeklyRateRepo: repo,
 		UserLookup:     userLookup,
+		Cache:          cache,
 	}
 }
 
@@ -48,6 +52,14 @@ func (service *weeklyRateService) Create(input WeeklyRateModel.CreateWeeklyRate)
 	var newWeeklyRate WeeklyRateModel.WeeklyRate
 	newWeeklyRate.RateName = input.RateName
 	newWeeklyRate.Amount = input.Amount
+	newWeeklyRate.Distribution = WeeklyRateModel.DefaultDistribution(input.Amount)
+
+	if input.Distribution != nil {
+		if err := validateDistribution(*input.Distribution, input.Amount); err != nil {
+			return err
+		}
+		newWeeklyRate.Distribution = *input.Distribution
+	}
 
 	// Generate UUID for the new weekly rate
 	u := uuid.New().String()
@@ -66,10 +78,32 @@ func (service *weeklyRateService) Update(uuid string, input WeeklyRateModel.Upda
 		return fmt.Errorf(Config.ErrorMessages()["WEEKLY_RATE_NOT_FOUND"]+": %w", err)
 	}
 
+	current, err := service.WeeklyRateRepo.FindByID(weeklyRateID)
+	if err != nil {
+		return fmt.Errorf(Config.ErrorMessages()["WEEKLY_RATE_NOT_FOUND"]+": %w", err)
+	}
+
+	amount := current.Amount
+	if input.Amount != 0 {
+		amount = input.Amount
+	}
+
+	// keep the daily distribution consistent with the weekly amount
+	if input.Distribution != nil {
+		if err := validateDistribution(*input.Distribution, amount); err != nil {
+			return err
+		}
+	} else if amount != current.Amount {
+		scaled := current.Distribution.Scale(amount)
+		input.Distribution = &scaled
+	}
+
 	err = service.WeeklyRateRepo.Update(weeklyRateID, input)
 	if err != nil {
 		return fmt.Errorf("failed to update weekly rate: %w", err)
 	}
+	// the expected hours of every user of the weekly rate change
+	service.Cache.Invalidate(CacheService.KPINamespace)
 	return nil
 }
 
@@ -83,6 +117,7 @@ func (service *weeklyRateService) Delete(uuid string) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete weekly rate: %w", err)
 	}
+	service.Cache.Invalidate(CacheService.KPINamespace)
 	return nil
 }
 
@@ -101,9 +136,35 @@ func (service *weeklyRateService) AssignToUser(weeklyRateUUID string, userUUID s
 	if err != nil {
 		return fmt.Errorf("failed to assign weekly rate to user: %w", err)
 	}
+	service.Cache.Invalidate(CacheService.KPINamespace)
 	return nil
 }
 
 func (service *weeklyRateService) GetIdByUuid(id string) (int, error) {
 	return service.WeeklyRateRepo.GetIDByUUID(id)
 }
+
+// validateDistribution checks that each day is between 0 and 24 hours and that the days add up to the weekly amount.
+func validateDistribution(distribution WeeklyRateModel.WeeklyRateDistribution, amount float64) error {
+	days := []float64{
+		distribution.MondayHours,
+		distribution.TuesdayHours,
+		distribution.WednesdayHours,
+		distribution.ThursdayHours,
+		distribution.FridayHours,
+		distribution.SaturdayHours,
+		distribution.SundayHours,
+	}
+
+	for _, hours := range days {
+		if hours < 0 || hours > 24 {
+			return fmt.Errorf("%s: daily hours must be between 0 and 24", Config.ErrorMessages()["INVALID_WEEKLY_RATE_DISTRIBUTION"])
+		}
+	}
+
+	if math.Abs(distribution.Total()-amount) > 0.01 {
+		return fmt.Errorf("%s: daily hours add up to %.2f instead of %.2f", Config.ErrorMessages()["INVALID_WEEKLY_RATE_DISTRIBUTION"], distribution.Total(), amount)
+	}
+
+	return nil
+}
@@ -14,8 +14,10 @@ type WorkSessionRepository interface {
 	CreateWorkSession(uuid string, user_id int, status string) error
 	GetUserActiveWorkSession(user_id int, status []string) (workSession WorkSessionModel.WorkSessionRead, err error)
 	FindIdByUuid(uuid string) (workSessionId int, err error)
+	FindUserUuidByUuid(uuid string) (userUUID string, err error)
 	UpdateWorkSessionStatus(uuid string, status string) error
 	UpdateBreakDurationMinutes(uuid string, breakDuration int) error
+	UpdateBreaksCount(uuid string, breaksCount int) error
 	GetWorkSessionHistoryByUserId(userId int, startDate string, endDate string, limit int, offset int) (workSessions []WorkSessionModel.WorkSessionReadHistory, err error)
 }
 
@@ -35,6 +37,14 @@ func (repo *workSessionRepository) FindIdByUuid(uuid string) (workSessionId int,
 	return workSessionId, nil
 }
 
+func (repo *workSessionRepository) FindUserUuidByUuid(uuid string) (userUUID string, err error) {
+	err = repo.db.Raw("SELECT u.uuid FROM work_session_active AS w INNER JOIN users AS u ON u.id = w.user_id WHERE w.uuid = ?", uuid).Scan(&userUUID).Error
+	if err != nil {
+		return "", err
+	}
+	return userUUID, nil
+}
+
 func (repo *workSessionRepository) GetUserActiveWorkSession(userId int, status []string) (workSession WorkSessionModel.WorkSessionRead, err error) {
 	var workSessionFound WorkSessionModel.WorkSessionRead
 	err = repo.db.Raw(
@@ -98,6 +108,14 @@ func (repo *workSessionRepository) UpdateBreakDurationMinutes(uuid string, break
 	return err
 }
 
+func (repo *workSessionRepository) UpdateBreaksCount(uuid string, breaksCount int) error {
+	err := repo.db.Exec(
+		"UPDATE work_session_active SET breaks_count = ? WHERE uuid = ?",
+		breaksCount, uuid,
+	).Error
+	return err
+}
+
 func (repo *workSessionRepository) GetWorkSessionHistoryByUserId(userId int, startDate string, endDate string, limit int, offset int) (workSessions []WorkSessionModel.WorkSessionReadHistory, err error) {
 	err = repo.db.Raw(
 		`SELECT
@@ -35,7 +35,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			clock_out TEXT,
 			status TEXT,
 			duration_minutes INTEGER,
-			breaks_duration_minutes INTEGER
+			breaks_duration_minutes INTEGER,
+			breaks_count INTEGER
 		);
 
 		CREATE TABLE work_session_archived (
@@ -46,7 +47,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			clock_out TEXT,
 			status TEXT,
 			duration_minutes INTEGER,
-			breaks_duration_minutes INTEGER
+			breaks_duration_minutes INTEGER,
+			breaks_count INTEGER
 		);
 	`).Error
 	if err != nil {
@@ -117,6 +119,23 @@ func TestUpdateBreakDurationMinutes(t *testing.T) {
 	assert.Equal(t, 25, breaks)
 }
 
+func TestUpdateBreaksCount(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewWorkSessionRepository(db)
+
+	db.Exec(`
+		INSERT INTO work_session_active (uuid, user_id, clock_in, status)
+		VALUES ('ws-uuid-5', 1, CURRENT_TIMESTAMP, 'active')
+	`)
+
+	err := repo.UpdateBreaksCount("ws-uuid-5", 3)
+	assert.NoError(t, err)
+
+	var breaksCount int
+	db.Raw(`SELECT breaks_count FROM work_session_active WHERE uuid = 'ws-uuid-5'`).Scan(&breaksCount)
+	assert.Equal(t, 3, breaksCount)
+}
+
 func TestCompleteWorkSession(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repository.NewWorkSessionRepository(db)
@@ -2,14 +2,12 @@ package service
 
 import (
 	WorkSessionModel "app/internal/app/work-session/model"
-	"app/internal/db"
-	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"math"
 	"time"
 
+	CacheService "app/internal/app/cache/service"
 	UserService "app/internal/app/user/service"
 	WorkSessionRepository "app/internal/app/work-session/repository"
 
@@ -28,10 +26,11 @@ type workSessionService struct {
 	WorkSessionRepo WorkSessionRepository.WorkSessionRepository
 	UserService     UserService.UserService
 	BreakRepository BreakRepository.BreakRepository
+	Cache           CacheService.CacheService
 }
 
-func NewWorkSessionService(repo WorkSessionRepository.WorkSessionRepository, userService UserService.UserService, breakRepo BreakRepository.BreakRepository) WorkSessionService {
-	return &workSessionService{WorkSessionRepo: repo, UserService: userService, BreakRepository: breakRepo}
+func NewWorkSessionService(repo WorkSessionRepository.WorkSessionRepository, userService UserService.UserService, breakRepo BreakRepository.BreakRepository, cache CacheService.CacheService) WorkSessionService {
+	return &workSessionService{WorkSessionRepo: repo, UserService: userService, BreakRepository: breakRepo, Cache: cache}
 }
 
 func (service *workSessionService) UpdateWorkSessionClocking(data WorkSessionModel.WorkSessionUpdate) (WorkSessionModel.WorkSessionUpdateResponse, error) {
@@ -68,6 +67,7 @@ func (service *workSessionService) UpdateWorkSessionClocking(data WorkSessionMod
 			response.Success = false
 			return response, err
 		}
+		service.UserService.InvalidateCache(data.UserUUID)
 		return response, nil
 	}
 
@@ -79,7 +79,11 @@ func (service *workSessionService) UpdateWorkSessionClocking(data WorkSessionMod
 
 	// 6️⃣ Clock-out process
 	if workSessionFound.WorkSessionUUID != "" && !*data.IsClocked {
-		return service.completeWorkSessionProcess(workSessionFound, userID)
+		response, err := service.completeWorkSessionProcess(workSessionFound, userID)
+		if err == nil {
+			service.UserService.InvalidateCache(data.UserUUID)
+		}
+		return response, err
 	}
 
 	response.Success = true
@@ -115,35 +119,19 @@ func (service *workSessionService) GetWorkSessionStatus(userUUID string) (WorkSe
 	return response, nil
 }
 
+// GetWorkSessionHistory is cached until a session or a break of the user changes.
 func (service *workSessionService) GetWorkSessionHistory(userUUID string, startDate string, endDate string, limit int, offset int) ([]WorkSessionModel.WorkSessionReadHistory, error) {
-	ctx := context.Background()
-
-	cacheKey := fmt.Sprintf("worksession:history:%s:%s:%s:%d:%d", userUUID, startDate, endDate, limit, offset)
-
-	cached, err := db.RedisClient.Get(ctx, cacheKey).Result()
-	if err == nil && cached != "" {
-		var cachedHistory []WorkSessionModel.WorkSessionReadHistory
-		if jsonErr := json.Unmarshal([]byte(cached), &cachedHistory); jsonErr == nil {
-			return cachedHistory, nil
-		}
-	}
-
-	userID, userErr := service.UserService.GetIdByUuid(userUUID)
-	if userErr != nil {
-		return []WorkSessionModel.WorkSessionReadHistory{}, userErr
-	}
-
-	workSessions, err := service.WorkSessionRepo.GetWorkSessionHistoryByUserId(userID, startDate, endDate, limit, offset)
-	if err != nil {
-		return []WorkSessionModel.WorkSessionReadHistory{}, err
-	}
+	cacheKey := fmt.Sprintf("%s:%s:%s:%d:%d", userUUID, startDate, endDate, limit, offset)
 
-	data, _ := json.Marshal(workSessions)
-	if setErr := db.RedisClient.Set(ctx, cacheKey, data, 30*time.Second).Err(); setErr != nil {
-		log.Printf("⚠️ Error setting cache for %s : %v", cacheKey, setErr)
-	}
+	return CacheService.Remember(service.Cache, CacheService.HistoryNamespace, cacheKey, []string{CacheService.UserTag(userUUID)},
+		func() ([]WorkSessionModel.WorkSessionReadHistory, error) {
+			userID, userErr := service.UserService.GetIdByUuid(userUUID)
+			if userErr != nil {
+				return []WorkSessionModel.WorkSessionReadHistory{}, userErr
+			}
 
-	return workSessions, nil
+			return service.WorkSessionRepo.GetWorkSessionHistoryByUserId(userID, startDate, endDate, limit, offset)
+		})
 }
 
 func (service *workSessionService) completeWorkSessionProcess(workSessionFound WorkSessionModel.WorkSessionRead, userID int) (WorkSessionModel.WorkSessionUpdateResponse, error) {
@@ -192,6 +180,19 @@ func (service *workSessionService) completeWorkSessionProcess(workSessionFound W
 		return response, err
 	}
 
+	// Keep the number of breaks before deleting them
+	breaksCount, err := service.BreakRepository.CountBreaksByWorkSessionId(workSessionId)
+	if err != nil {
+		response.Success = false
+		return response, err
+	}
+
+	err = service.WorkSessionRepo.UpdateBreaksCount(workSessionFound.WorkSessionUUID, breaksCount)
+	if err != nil {
+		response.Success = false
+		return response, err
+	}
+
 	// Delete related breaks
 	err = service.BreakRepository.DeleteRelatedBreaksToWorkSession(workSessionId)
 	if err != nil {
@@ -4,8 +4,18 @@
 package config
 
 import (
+	AnomalyModel "app/internal/app/anomaly/model"
+	ExportFileModel "app/internal/app/export-file/model"
+	LoginAttemptModel "app/internal/app/login-attempt/model"
 	MailModel "app/internal/app/mailer/model"
+	MFAModel "app/internal/app/mfa/model"
+	ReportSubscriptionModel "app/internal/app/report-subscription/model"
+	SessionModel "app/internal/app/session/model"
+	"app/internal/storage"
+	"fmt"
+	"log"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -16,7 +26,6 @@ type Config struct {
 	DBName             string
 	DBPort             string
 	JWTSecret          string
-	JWTExpirationHours string
 	ProjectStatus      string
 	FrontendURL        string
 	RedisHost          string
@@ -24,35 +33,133 @@ type Config struct {
 	FixturesPassword   string
 	RootUsername       string
 	RootPassword       string
+	CacheTTLSeconds    int
+	MetricsToken       string
+	ExportWorkers      int
 	Mail               MailModel.MailConfig
+	Anomaly            AnomalyModel.AnomalyConfig
+	ExportFile         ExportFileModel.ExportFileConfig
+	ReportSubscription ReportSubscriptionModel.ReportSubscriptionConfig
+	Storage            storage.Config
+	Session            SessionModel.SessionConfig
+	MFA                MFAModel.MFAConfig
+	LoginThrottle      LoginAttemptModel.LoginThrottleConfig
 }
 
 func LoadConfig() *Config {
 	config := &Config{
-		Port:               getEnv("PORT", os.Getenv("DB_PORT")),
-		DBHost:             getEnv("DB_HOST", os.Getenv("DB_HOST")),
-		DBUser:             getEnv("DB_USER", os.Getenv("DB_USER")),
-		DBPassword:         getEnv("DB_PASSWORD", os.Getenv("DB_PASSWORD")),
-		DBName:             getEnv("DB_DATABASE", os.Getenv("DB_DATABASE")),
-		DBPort:             getEnv("DB_PORT", os.Getenv("DB_PORT")),
-		JWTSecret:          getEnv("JWT_SECRET", os.Getenv("JWT_SECRET")),
-		JWTExpirationHours: getEnv("JWT_EXPIRATION_HOURS", os.Getenv("JWT_EXPIRATION_HOURS")),
-		ProjectStatus:      getEnv("PROJECT_STATUS", os.Getenv("PROJECT_STATUS")),
-		FrontendURL:        getEnv("FRONTEND_URL", os.Getenv("FRONTEND_URL")),
-		RedisHost:          getEnv("REDIS_HOST", os.Getenv("REDIS_HOST")),
-		RedisPort:          getEnv("REDIS_PORT", os.Getenv("REDIS_PORT")),
-		FixturesPassword:   getEnv("FIXTURES_PASSWORD", os.Getenv("FIXTURES_PASSWORD")),
-		RootUsername:       getEnv("ROOT_USERNAME", os.Getenv("ROOT_USERNAME")),
-		RootPassword:       getEnv("ROOT_PASSWORD", os.Getenv("ROOT_PASSWORD")),
+		Port:             getEnv("PORT", os.Getenv("DB_PORT")),
+		DBHost:           getEnv("DB_HOST", os.Getenv("DB_HOST")),
+		DBUser:           getEnv("DB_USER", os.Getenv("DB_USER")),
+		DBPassword:       getEnv("DB_PASSWORD", os.Getenv("DB_PASSWORD")),
+		DBName:           getEnv("DB_DATABASE", os.Getenv("DB_DATABASE")),
+		DBPort:           getEnv("DB_PORT", os.Getenv("DB_PORT")),
+		JWTSecret:        getEnv("JWT_SECRET", os.Getenv("JWT_SECRET")),
+		ProjectStatus:    getEnv("PROJECT_STATUS", os.Getenv("PROJECT_STATUS")),
+		FrontendURL:      getEnv("FRONTEND_URL", os.Getenv("FRONTEND_URL")),
+		RedisHost:        getEnv("REDIS_HOST", os.Getenv("REDIS_HOST")),
+		RedisPort:        getEnv("REDIS_PORT", os.Getenv("REDIS_PORT")),
+		FixturesPassword: getEnv("FIXTURES_PASSWORD", os.Getenv("FIXTURES_PASSWORD")),
+		RootUsername:     getEnv("ROOT_USERNAME", os.Getenv("ROOT_USERNAME")),
+		RootPassword:     getEnv("ROOT_PASSWORD", os.Getenv("ROOT_PASSWORD")),
+		CacheTTLSeconds:  getEnvInt("CACHE_TTL_SECONDS", 600),
+		MetricsToken:     getEnv("METRICS_TOKEN", ""),
+		ExportWorkers:    getEnvInt("EXPORT_WORKERS", 2),
 		Mail: MailModel.MailConfig{
 			APIKey:  getEnv("MAIL_API_KEY", os.Getenv("MAIL_API_KEY")),
 			BaseURL: getEnv("MAIL_BASE_URL", os.Getenv("MAIL_BASE_URL")),
 		},
+		Anomaly: AnomalyModel.AnomalyConfig{
+			LongSessionMinutes:  getEnvInt("ANOMALY_LONG_SESSION_MINUTES", 720),
+			ShortSessionMinutes: getEnvInt("ANOMALY_SHORT_SESSION_MINUTES", 5),
+			NoBreakDays:         getEnvInt("ANOMALY_NO_BREAK_DAYS", 30),
+			NoBreakMinSessions:  getEnvInt("ANOMALY_NO_BREAK_MIN_SESSIONS", 10),
+			BaselineDays:        getEnvInt("ANOMALY_BASELINE_DAYS", 90),
+			MinSamples:          getEnvInt("ANOMALY_MIN_SAMPLES", 10),
+			ZScoreThreshold:     getEnvFloat("ANOMALY_Z_SCORE_THRESHOLD", 3),
+			ScanDays:            getEnvInt("ANOMALY_SCAN_DAYS", 7),
+			ScanIntervalMinutes: getEnvInt("ANOMALY_SCAN_INTERVAL_MINUTES", 60),
+		},
+		ExportFile: ExportFileModel.ExportFileConfig{
+			TTLHours:               getEnvInt("EXPORT_FILE_TTL_HOURS", 72),
+			SigningSecret:          getEnv("EXPORT_SIGNING_SECRET", ""),
+			CleanupIntervalMinutes: getEnvInt("EXPORT_CLEANUP_INTERVAL_MINUTES", 60),
+		},
+		ReportSubscription: ReportSubscriptionModel.ReportSubscriptionConfig{
+			IntervalMinutes:    getEnvInt("REPORT_SUBSCRIPTION_INTERVAL_MINUTES", 5),
+			SendHour:           getEnvInt("REPORT_SUBSCRIPTION_SEND_HOUR", 7),
+			MaxAttachmentBytes: getEnvInt("REPORT_SUBSCRIPTION_MAX_ATTACHMENT_BYTES", 5*1024*1024),
+		},
+		Storage: storage.Config{
+			Driver:           getEnv("STORAGE_DRIVER", storage.LocalDriver),
+			LocalDirectory:   getEnv("STORAGE_LOCAL_DIRECTORY", "/app/data"),
+			S3Endpoint:       getEnv("S3_ENDPOINT", ""),
+			S3Region:         getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:         getEnv("S3_BUCKET", ""),
+			S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+			S3ForcePathStyle: getEnvBool("S3_FORCE_PATH_STYLE", false),
+		},
+		Session: SessionModel.SessionConfig{
+			AccessTokenMinutes: getEnvInt("ACCESS_TOKEN_MINUTES", 15),
+			RefreshTokenDays:   getEnvInt("REFRESH_TOKEN_DAYS", 7),
+		},
+		MFA: MFAModel.MFAConfig{
+			Issuer:            getEnv("MFA_ISSUER", "TimeManager"),
+			EncryptionKey:     getEnv("MFA_ENCRYPTION_KEY", ""),
+			LoginTokenMinutes: getEnvInt("MFA_LOGIN_TOKEN_MINUTES", 5),
+		},
+		LoginThrottle: LoginAttemptModel.LoginThrottleConfig{
+			FailureWindowMinutes: getEnvInt("LOGIN_FAILURE_WINDOW_MINUTES", 15),
+			AccountFreeAttempts:  getEnvInt("LOGIN_ACCOUNT_FREE_ATTEMPTS", 3),
+			IPFreeAttempts:       getEnvInt("LOGIN_IP_FREE_ATTEMPTS", 20),
+			BaseDelaySeconds:     getEnvInt("LOGIN_BASE_DELAY_SECONDS", 1),
+			MaxDelaySeconds:      getEnvInt("LOGIN_MAX_DELAY_SECONDS", 60),
+			LockoutThreshold:     getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+			LockoutMinutes:       getEnvInt("LOGIN_LOCKOUT_MINUTES", 30),
+		},
 	}
 
 	return config
 }
 
+// ValidateSecrets returns an error when a secret the server cannot start without is empty or reuses JWT_SECRET:
+// each key has its own purpose, so that leaking one of them does not expose the others.
+func (config *Config) ValidateSecrets() error {
+	secrets := []struct {
+		name  string
+		value string
+	}{
+		{name: "MFA_ENCRYPTION_KEY", value: config.MFA.EncryptionKey},
+		{name: "EXPORT_SIGNING_SECRET", value: config.ExportFile.SigningSecret},
+	}
+
+	for _, secret := range secrets {
+		if secret.value == "" {
+			return fmt.Errorf("%s is required", secret.name)
+		}
+		if secret.value == config.JWTSecret {
+			return fmt.Errorf("%s must differ from JWT_SECRET", secret.name)
+		}
+	}
+
+	return nil
+}
+
+// deprecatedVariables are no longer read, mapped to the variables replacing them
+var deprecatedVariables = map[string]string{
+	"JWT_EXPIRATION_HOURS": "ACCESS_TOKEN_MINUTES and REFRESH_TOKEN_DAYS",
+}
+
+// WarnDeprecatedVariables logs the variables still set although they are no longer read.
+func WarnDeprecatedVariables() {
+	for name, replacement := range deprecatedVariables {
+		if _, exists := os.LookupEnv(name); exists {
+			log.Printf("⚠️ %s is deprecated and ignored, use %s instead", name, replacement)
+		}
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -60,10 +167,56 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvInt falls back to the default value when the variable is missing or is not an integer
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvFloat falls back to the default value when the variable is missing or is not a number
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvBool falls back to the default value when the variable is missing or is not a boolean
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func ErrorMessages() map[string]string {
 	return map[string]string{
-		"NO_CLAIMS":             "missing claims",
-		"INVALID_REQUEST":       "invalid request",
-		"WEEKLY_RATE_NOT_FOUND": "failed to find weekly rate",
+		"NO_CLAIMS":                        "missing claims",
+		"INVALID_REQUEST":                  "invalid request",
+		"WEEKLY_RATE_NOT_FOUND":            "failed to find weekly rate",
+		"INVALID_WEEKLY_RATE_DISTRIBUTION": "invalid weekly rate distribution",
+		"CONTRACT_NOT_FOUND":               "failed to find contract",
+		"INVALID_CONTRACT_DATES":           "invalid contract dates",
+		"CONTRACT_OVERLAP":                 "contract overlaps an existing contract",
+		"ANOMALY_NOT_FOUND":                "failed to find anomaly",
+		"TEAM_NOT_FOUND":                   "failed to find team",
+		"NOT_TEAM_MANAGER":                 "access restricted to the managers of the team",
+		"EXPORT_JOB_NOT_FOUND":             "failed to find export job",
+		"EXPORT_FILE_NOT_FOUND":            "failed to find export file",
+		"INVALID_DOWNLOAD_LINK":            "invalid or expired download link",
+		"REPORT_SUBSCRIPTION_NOT_FOUND":    "failed to find report subscription",
+		"INVALID_REFRESH_TOKEN":            "invalid or expired refresh token",
+		"API_TOKEN_NOT_FOUND":              "failed to find API token",
+		"INVALID_API_TOKEN":                "invalid, expired or revoked API token",
+		"MFA_NOT_ENABLED":                  "two-factor authentication is not enabled",
+		"MFA_ALREADY_ENABLED":              "two-factor authentication is already enabled",
+		"MFA_SETUP_NOT_FOUND":              "no two-factor authentication setup to confirm",
+		"MFA_REQUIRED":                     "two-factor authentication is required for your role",
+		"INVALID_MFA_CODE":                 "invalid two-factor authentication code",
+		"INVALID_MFA_TOKEN":                "invalid or expired two-factor authentication token",
+		"TOO_MANY_LOGIN_ATTEMPTS":          "too many failed login attempts",
+		"INVALID_UNLOCK_TOKEN":             "invalid or expired unlock link",
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -27,6 +28,8 @@ func ConnectPostgres() *gorm.DB {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Printf("❌ Failed to connect to Postgres: %v", err)
+	} else {
+		instrumentPostgres(db)
 	}
 
 	log.Println("✅ Connected to Postgres successfully")
@@ -52,3 +55,31 @@ func ConnectPostgresPool() *pgxpool.Pool {
 	log.Println("✅ Postgres pool connection established")
 	return pool
 }
+
+// ListenPostgres calls handle with the payload of every notification sent on the channel.
+// It holds its own connection and returns when the context is done or the connection is lost.
+func ListenPostgres(ctx context.Context, channel string, handle func(payload string)) error {
+	cfg := config.LoadConfig()
+	dsn := fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName,
+	)
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect listener: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
@@ -39,6 +39,7 @@ func ConnectRedis() *redis.Client {
 	}
 
 	log.Println("✅ Connection to Redis successful:", addr)
+	client.AddHook(redisMetricsHook{})
 	RedisClient = client
 	return client
 }
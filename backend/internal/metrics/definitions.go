@@ -0,0 +1,19 @@
+package metrics
+
+var (
+	HTTPRequests         = NewCounter("http_requests_total", "Number of HTTP requests handled, by method, route and status.", "method", "route", "status")
+	HTTPRequestDuration  = NewHistogram("http_request_duration_seconds", "Duration of the HTTP requests, by method, route and status.", DefaultBuckets, "method", "route", "status")
+	DBQueryDuration      = NewHistogram("db_query_duration_seconds", "Duration of the database calls, by operation.", DefaultBuckets, "operation")
+	DBQueryErrors        = NewCounter("db_query_errors_total", "Number of failed database calls, by operation.", "operation")
+	RedisCommandDuration = NewHistogram("redis_command_duration_seconds", "Duration of the Redis commands, by command.", DefaultBuckets, "command")
+	RedisCommandErrors   = NewCounter("redis_command_errors_total", "Number of failed Redis commands, by command.", "command")
+	CacheRequests        = NewCounter("cache_requests_total", "Number of reads of the cached results, by namespace and result (hit or miss).", "namespace", "result")
+	MailSendFailures     = NewCounter("mail_send_failures_total", "Number of mails the provider failed to send.")
+	LoginFailures        = NewCounter("login_failures_total", "Number of failed login attempts, by reason.", "reason")
+	AccountLockouts      = NewCounter("account_lockouts_total", "Number of accounts locked after too many failed logins.")
+
+	// refreshed from the database on every scrape
+	ActiveWorkSessions = NewGauge("work_sessions_active", "Number of work sessions currently clocked in.")
+	PausedWorkSessions = NewGauge("work_sessions_paused", "Number of work sessions currently on break.")
+	ClockInsPerMinute  = NewGauge("work_session_clock_ins_per_minute", "Number of clock-ins during the last minute.")
+)
@@ -0,0 +1,267 @@
+/**
+ * Metrics package exposing counters, gauges and histograms in the Prometheus text format
+ */
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the duration histograms, the Prometheus defaults.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+var (
+	registryMutex sync.Mutex
+	registry      []collector
+)
+
+func register(c collector) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry = append(registry, c)
+}
+
+// WriteText writes every metric in the Prometheus text exposition format, in the order they were created.
+func WriteText(w io.Writer) error {
+	registryMutex.Lock()
+	collectors := append([]collector{}, registry...)
+	registryMutex.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// series holds the values of a metric by combination of label values.
+type series struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	mutex  sync.Mutex
+	values map[string][]string
+}
+
+// init sets up the values of the metric, a metric without labels being exposed from zero.
+func (s *series) init(name, help, kind string, labels []string) {
+	s.name, s.help, s.kind, s.labels = name, help, kind, labels
+	s.values = map[string][]string{}
+	if len(labels) == 0 {
+		s.key(nil)
+	}
+}
+
+// key returns the key of the label values, registering them on first use. The mutex must be held.
+func (s *series) key(labelValues []string) string {
+	if len(labelValues) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", s.name, len(s.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	if _, exists := s.values[key]; !exists {
+		s.values[key] = append([]string{}, labelValues...)
+	}
+	return key
+}
+
+// sortedKeys returns the label keys in a stable order. The mutex must be held.
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *series) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, escapeHelp(s.help), s.name, s.kind)
+	return err
+}
+
+// labelPairs formats the labels as {name="value",...}, with extra pairs appended.
+func (s *series) labelPairs(labelValues []string, extra ...string) string {
+	pairs := make([]string, 0, len(labelValues)+len(extra)/2)
+	for i, value := range labelValues {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, s.labels[i], escapeLabel(value)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a value that only goes up, such as a number of requests.
+type Counter struct {
+	series
+	counts map[string]float64
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{counts: map[string]float64{}}
+	c.init(name, help, "counter", labels)
+	register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.counts[c.key(labelValues)] += value
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.header(w); err != nil {
+		return err
+	}
+	for _, key := range c.sortedKeys() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(c.values[key]), formatFloat(c.counts[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gauge is a value that goes up and down, such as a number of open sessions.
+type Gauge struct {
+	series
+	current map[string]float64
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{current: map[string]float64{}}
+	g.init(name, help, "gauge", labels)
+	register(g)
+	return g
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.current[g.key(labelValues)] = value
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if err := g.header(w); err != nil {
+		return err
+	}
+	for _, key := range g.sortedKeys() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(g.values[key]), formatFloat(g.current[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram counts observations, such as durations, in cumulative buckets.
+type Histogram struct {
+	series
+	buckets []float64
+	counts  map[string][]uint64
+	sums    map[string]float64
+	totals  map[string]uint64
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		buckets: buckets,
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+		totals:  map[string]uint64{},
+	}
+	h.init(name, help, "histogram", labels)
+	register(h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := h.key(labelValues)
+	if _, exists := h.counts[key]; !exists {
+		h.counts[key] = make([]uint64, len(h.buckets))
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[key][i]++
+		}
+	}
+	h.sums[key] += value
+	h.totals[key]++
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := h.header(w); err != nil {
+		return err
+	}
+	for _, key := range h.sortedKeys() {
+		labelValues := h.values[key]
+		counts := h.counts[key]
+		if counts == nil {
+			counts = make([]uint64, len(h.buckets))
+		}
+		for i, bound := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(labelValues, "le", formatFloat(bound)), counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(labelValues, "le", "+Inf"), h.totals[key]); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelPairs(labelValues), formatFloat(h.sums[key]),
+			h.name, h.labelPairs(labelValues), h.totals[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
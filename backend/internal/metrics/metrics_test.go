@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func written(t *testing.T, c collector) string {
+	var out bytes.Buffer
+	assert.NoError(t, c.write(&out))
+	return out.String()
+}
+
+func TestCounter(t *testing.T) {
+	counter := NewCounter("test_requests_total", "Requests handled,\nby method \\ status", "method", "status")
+
+	// the series of a counter with labels only appear once used
+	assert.Equal(t, "# HELP test_requests_total Requests handled,\\nby method \\\\ status\n# TYPE test_requests_total counter\n", written(t, counter))
+
+	counter.Inc("GET", "200")
+	counter.Inc("GET", "200")
+	counter.Add(2.5, "POST", "a \"quoted\" \\ value\non two lines")
+
+	assert.Equal(t, `# HELP test_requests_total Requests handled,\nby method \\ status
+# TYPE test_requests_total counter
+test_requests_total{method="GET",status="200"} 2
+test_requests_total{method="POST",status="a \"quoted\" \\ value\non two lines"} 2.5
+`, written(t, counter))
+}
+
+func TestGauge(t *testing.T) {
+	gauge := NewGauge("test_open_sessions", "Open sessions")
+
+	// a metric without labels is exposed from zero
+	assert.Equal(t, "# HELP test_open_sessions Open sessions\n# TYPE test_open_sessions gauge\ntest_open_sessions 0\n", written(t, gauge))
+
+	gauge.Set(3)
+	gauge.Set(2)
+	assert.Contains(t, written(t, gauge), "\ntest_open_sessions 2\n")
+}
+
+func TestHistogram(t *testing.T) {
+	histogram := NewHistogram("test_request_duration_seconds", "Request durations", []float64{0.1, 1}, "route")
+
+	histogram.Observe(0.05, "/kpi")
+	histogram.Observe(0.5, "/kpi")
+	histogram.Observe(2, "/kpi")
+	histogram.Observe(0.1, "/auth")
+
+	// the buckets are cumulative, up to +Inf counting every observation
+	assert.Equal(t, `# HELP test_request_duration_seconds Request durations
+# TYPE test_request_duration_seconds histogram
+test_request_duration_seconds_bucket{route="/auth",le="0.1"} 1
+test_request_duration_seconds_bucket{route="/auth",le="1"} 1
+test_request_duration_seconds_bucket{route="/auth",le="+Inf"} 1
+test_request_duration_seconds_sum{route="/auth"} 0.1
+test_request_duration_seconds_count{route="/auth"} 1
+test_request_duration_seconds_bucket{route="/kpi",le="0.1"} 1
+test_request_duration_seconds_bucket{route="/kpi",le="1"} 2
+test_request_duration_seconds_bucket{route="/kpi",le="+Inf"} 3
+test_request_duration_seconds_sum{route="/kpi"} 2.55
+test_request_duration_seconds_count{route="/kpi"} 3
+`, written(t, histogram))
+}
+
+func TestHistogramWithoutLabels(t *testing.T) {
+	histogram := NewHistogram("test_job_duration_seconds", "Job durations", []float64{1})
+
+	assert.Equal(t, `# HELP test_job_duration_seconds Job durations
+# TYPE test_job_duration_seconds histogram
+test_job_duration_seconds_bucket{le="1"} 0
+test_job_duration_seconds_bucket{le="+Inf"} 0
+test_job_duration_seconds_sum 0
+test_job_duration_seconds_count 0
+`, written(t, histogram))
+}
+
+func TestWrongLabelCountPanics(t *testing.T) {
+	counter := NewCounter("test_failures_total", "Failures", "reason")
+	histogram := NewHistogram("test_wait_seconds", "Waits", DefaultBuckets)
+
+	assert.PanicsWithValue(t, "metrics: test_failures_total expects 1 label values, got 0", func() { counter.Inc() })
+	assert.PanicsWithValue(t, "metrics: test_failures_total expects 1 label values, got 2", func() { counter.Inc("a", "b") })
+	assert.PanicsWithValue(t, "metrics: test_wait_seconds expects 0 label values, got 1", func() { histogram.Observe(1, "a") })
+
+	// the mutex is released by the panic
+	counter.Inc("invalid_password")
+	assert.Contains(t, written(t, counter), `test_failures_total{reason="invalid_password"} 1`)
+}
+
+func TestWriteText(t *testing.T) {
+	first := NewCounter("test_first_total", "First")
+	second := NewGauge("test_second", "Second")
+	first.Inc()
+	second.Set(1)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteText(&out))
+
+	// the metrics are written in the order they were created
+	text := out.String()
+	assert.Contains(t, text, "test_first_total 1\n")
+	assert.Contains(t, text, "test_second 1\n")
+	assert.Less(t, bytes.Index(out.Bytes(), []byte("test_first_total")), bytes.Index(out.Bytes(), []byte("test_second")))
+}
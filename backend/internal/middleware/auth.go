@@ -3,7 +3,9 @@ package middleware
 import (
 	"net/http"
 	"slices"
+	"strings"
 
+	APITokenModel "app/internal/app/api-token/model"
 	authService "app/internal/app/auth/service"
 
 	Config "app/internal/config"
@@ -11,18 +13,36 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// scopeGrantedKey marks the requests whose API token holds a scope of the route
+const scopeGrantedKey = "scopeGranted"
+
 type AuthHandler struct {
 	Service authService.AuthService
 }
 
+// AccessToken returns the bearer token of the Authorization header, or the token cookie of the browsers.
+func AccessToken(c *gin.Context) string {
+	if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+		return strings.TrimSpace(token)
+	}
+
+	token, _ := c.Cookie("token")
+	return token
+}
+
 func (handler *AuthHandler) AuthenticationMiddleware(c *gin.Context) {
-	tokenString, err := c.Cookie("token")
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid authentication cookie"})
+	tokenString := AccessToken(c)
+	if tokenString == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing authentication token or cookie"})
 		return
 	}
 
-	claims, err := handler.Service.ValidateJWT(tokenString)
+	validate := handler.Service.ValidateJWT
+	if strings.HasPrefix(tokenString, APITokenModel.TokenPrefix) {
+		validate = handler.Service.ValidateAPIToken
+	}
+
+	claims, err := validate(tokenString)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 		return
@@ -41,6 +61,12 @@ func (handler *AuthHandler) RequireRoles(roles ...string) gin.HandlerFunc {
 			return
 		}
 
+		// API tokens only reach the routes declaring one of their scopes
+		if claims.(*authService.Claims).IsAPIToken() && !c.GetBool(scopeGrantedKey) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied for API tokens"})
+			return
+		}
+
 		// check the roles in the claims
 		userRoles := claims.(*authService.Claims).Roles
 
@@ -55,3 +81,31 @@ func (handler *AuthHandler) RequireRoles(roles ...string) gin.HandlerFunc {
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 	}
 }
+
+// RequireScopes lets the API tokens holding at least one of the scopes through, to be used before RequireRoles.
+// The requests authenticated by a session are not restricted by scopes.
+func (handler *AuthHandler) RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("userClaims")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": Config.ErrorMessages()["NO_CLAIMS"]})
+			return
+		}
+
+		authClaims := claims.(*authService.Claims)
+		if !authClaims.IsAPIToken() {
+			c.Next()
+			return
+		}
+
+		for _, scope := range scopes {
+			if slices.Contains(authClaims.Scopes, scope) {
+				c.Set(scopeGrantedKey, true)
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing scope: " + strings.Join(scopes, " or ")})
+	}
+}
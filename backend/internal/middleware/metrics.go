@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"app/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the duration and the status of every request.
+// Requests are labelled with their route template so that path parameters do not multiply the series.
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(c.Writer.Status())
+
+	metrics.HTTPRequests.Inc(c.Request.Method, route, status)
+	metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+}
@@ -26,21 +26,76 @@ import (
 	WeeklyRatesR "app/internal/app/weekly-rate/repository"
 	WeeklyRatesS "app/internal/app/weekly-rate/service"
 
+	ContractH "app/internal/app/contract/handler"
+	ContractR "app/internal/app/contract/repository"
+	ContractS "app/internal/app/contract/service"
+
+	ExportFileH "app/internal/app/export-file/handler"
+	ExportFileR "app/internal/app/export-file/repository"
+	ExportFileS "app/internal/app/export-file/service"
+	ExportJobH "app/internal/app/export-job/handler"
+	ExportJobR "app/internal/app/export-job/repository"
+	ExportJobS "app/internal/app/export-job/service"
 	KPIH "app/internal/app/kpi/handler"
 	KPIR "app/internal/app/kpi/repository"
 	KPIService "app/internal/app/kpi/service"
 
+	AnomalyH "app/internal/app/anomaly/handler"
+	AnomalyR "app/internal/app/anomaly/repository"
+	AnomalyS "app/internal/app/anomaly/service"
+
+	CacheH "app/internal/app/cache/handler"
+	CacheS "app/internal/app/cache/service"
+
+	ReportSubscriptionH "app/internal/app/report-subscription/handler"
+	ReportSubscriptionR "app/internal/app/report-subscription/repository"
+	ReportSubscriptionS "app/internal/app/report-subscription/service"
+
+	MetricsH "app/internal/app/metrics/handler"
+	MetricsR "app/internal/app/metrics/repository"
+	MetricsS "app/internal/app/metrics/service"
+
+	APITokenH "app/internal/app/api-token/handler"
+	APITokenR "app/internal/app/api-token/repository"
+	APITokenS "app/internal/app/api-token/service"
+
+	LoginAttemptH "app/internal/app/login-attempt/handler"
+	LoginAttemptR "app/internal/app/login-attempt/repository"
+	LoginAttemptS "app/internal/app/login-attempt/service"
+
+	MFAH "app/internal/app/mfa/handler"
+	MFAR "app/internal/app/mfa/repository"
+	MFAS "app/internal/app/mfa/service"
+
+	SessionR "app/internal/app/session/repository"
+	SessionS "app/internal/app/session/service"
+
 	"app/internal/app/mailer"
+	"app/internal/config"
+	"app/internal/storage"
+	"context"
+	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter() *gin.Engine {
+func SetupRouter() (*gin.Engine, error) {
 	r := gin.Default()
+	r.Use(authM.Metrics)
+
+	if err := config.LoadConfig().ValidateSecrets(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
 	database := db.ConnectPostgres()
 	db.ConnectRedis()
 
+	fileStorage, err := storage.New(config.LoadConfig().Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the file storage: %w", err)
+	}
+
 	// 1) Repos
 	userRepo := repository.NewUserRepository(database)
 	workSessionRepo := workSessionR.NewWorkSessionRepository(database)
@@ -48,35 +103,83 @@ func SetupRouter() *gin.Engine {
 	kpiRepo := KPIR.NewKPIRepository(database)
 	teamRepo := TeamR.NewTeamRepository(database)
 	weeklyRateRepo := WeeklyRatesR.NewWeeklyRateRepository(database)
+	contractRepo := ContractR.NewContractRepository(database)
+	anomalyRepo := AnomalyR.NewAnomalyRepository(database)
+	metricsRepo := MetricsR.NewMetricsRepository(database)
+	exportFileRepo := ExportFileR.NewExportFileRepository(database)
+	exportJobRepo := ExportJobR.NewExportJobRepository(db.RedisClient)
+	reportSubscriptionRepo := ReportSubscriptionR.NewReportSubscriptionRepository(database)
+	sessionRepo := SessionR.NewSessionRepository(db.RedisClient)
+	apiTokenRepo := APITokenR.NewAPITokenRepository(database)
+	mfaRepo := MFAR.NewMFARepository(database)
+	loginAttemptRepo := LoginAttemptR.NewLoginAttemptRepository(database)
+	loginThrottleRepo := LoginAttemptR.NewLoginThrottleRepository(db.RedisClient)
 
 	// 2) Services
-	userService := service.NewUserService(userRepo, mailer.Service)
-	weeklyRateService := WeeklyRatesS.NewWeeklyRateService(weeklyRateRepo, userService)
+	sessionService := SessionS.NewSessionService(sessionRepo, config.LoadConfig().Session)
+	cacheService := CacheS.NewCacheService(db.RedisClient, time.Duration(config.LoadConfig().CacheTTLSeconds)*time.Second)
+	userService := service.NewUserService(userRepo, mailer.Service, sessionService, cacheService)
+	weeklyRateService := WeeklyRatesS.NewWeeklyRateService(weeklyRateRepo, userService, cacheService)
 
 	userService.SetWeeklyRateService(weeklyRateService)
 
-	workSessionService := workSessionS.NewWorkSessionService(workSessionRepo, userService, breakRepo)
-	breakService := BreakS.NewBreakService(breakRepo, workSessionRepo)
-	teamService := TeamS.NewTeamService(teamRepo, userService)
-	kpiService := KPIService.NewKPIService(breakService, teamService, userService, weeklyRateService, kpiRepo)
-	authService := authS.NewAuthService(userService)
+	workSessionService := workSessionS.NewWorkSessionService(workSessionRepo, userService, breakRepo, cacheService)
+	breakService := BreakS.NewBreakService(breakRepo, workSessionRepo, userService)
+	teamService := TeamS.NewTeamService(teamRepo, userService, cacheService)
+	contractService := ContractS.NewContractService(contractRepo, userService, weeklyRateService, cacheService)
+	kpiService := KPIService.NewCachedKPIService(KPIService.NewKPIService(breakService, teamService, userService, weeklyRateService, contractService, kpiRepo), cacheService)
+	anomalyService := AnomalyS.NewAnomalyService(anomalyRepo, teamService, userService, config.LoadConfig().Anomaly)
+	apiTokenService := APITokenS.NewAPITokenService(apiTokenRepo, userService)
+	loginAttemptService := LoginAttemptS.NewLoginAttemptService(loginAttemptRepo, loginThrottleRepo, userService, mailer.Service, config.LoadConfig().LoginThrottle)
+	mfaService := MFAS.NewMFAService(mfaRepo, userService, sessionService, loginAttemptService, config.LoadConfig().MFA)
+	authService := authS.NewAuthService(userService, sessionService, apiTokenService, mfaService, loginAttemptService)
+	metricsService := MetricsS.NewMetricsService(metricsRepo)
+	exportFileService := ExportFileS.NewExportFileService(exportFileRepo, userService, fileStorage, config.LoadConfig().ExportFile)
+	exportJobService := ExportJobS.NewExportJobService(exportJobRepo, kpiService, exportFileService, userService, mailer.Service, config.LoadConfig().ExportWorkers)
+	reportSubscriptionService := ReportSubscriptionS.NewReportSubscriptionService(reportSubscriptionRepo, kpiService, exportFileService, teamService, userService, mailer.Service, config.LoadConfig().ReportSubscription)
+
+	anomalyService.StartScheduler(context.Background())
+	cacheService.StartInvalidationListener(context.Background())
+	exportJobService.StartWorkers(context.Background())
+	exportFileService.StartCleanup(context.Background())
+	reportSubscriptionService.StartScheduler(context.Background())
 
 	// 3) Handlers
 	userHandler := handler.NewUserHandler(userService)
 	weeklyRateHandler := WeeklyRatesH.NewWeeklyRateHandler(weeklyRateService)
+	contractHandler := ContractH.NewContractHandler(contractService)
 	workSessionHandler := workSessionH.NewWorkSessionHandler(workSessionService)
 	breakHandler := BreakH.NewBreakHandler(breakService)
 	teamHandler := TeamH.NewTeamHandler(teamService, userService)
-	kpiHandler := KPIH.NewKPIHandler(kpiService)
+	kpiHandler := KPIH.NewKPIHandler(kpiService, exportJobService)
+	exportJobHandler := ExportJobH.NewExportJobHandler(exportJobService)
+	exportFileHandler := ExportFileH.NewExportFileHandler(exportFileService)
+	reportSubscriptionHandler := ReportSubscriptionH.NewReportSubscriptionHandler(reportSubscriptionService)
+	anomalyHandler := AnomalyH.NewAnomalyHandler(anomalyService)
+	cacheHandler := CacheH.NewCacheHandler(cacheService)
+	metricsHandler := MetricsH.NewMetricsHandler(metricsService, config.LoadConfig().MetricsToken)
+	apiTokenHandler := APITokenH.NewAPITokenHandler(apiTokenService)
+	mfaHandler := MFAH.NewMFAHandler(mfaService)
+	loginAttemptHandler := LoginAttemptH.NewLoginAttemptHandler(loginAttemptService)
 	authHandler := authH.NewAuthHandler(authService)
 	authMiddleware := &authM.AuthHandler{Service: authService}
 
 	/**
 	* Public Routes
 	 */
+	r.GET("/metrics", metricsHandler.GetMetrics)
 	r.POST("/api/auth/login", authHandler.LoginHandler)
+	r.POST("/api/auth/refresh", authHandler.RefreshHandler)
+	r.POST("/api/auth/token", authHandler.TokenHandler)
+	r.POST("/api/auth/token/refresh", authHandler.TokenRefreshHandler)
+	r.POST("/api/auth/mfa/setup", authHandler.MFASetupHandler)
+	r.POST("/api/auth/mfa/verify", authHandler.MFAVerifyHandler)
+	r.POST("/api/auth/token/mfa/verify", authHandler.TokenMFAVerifyHandler)
+	r.POST("/api/auth/unlock", loginAttemptHandler.UnlockWithToken)
+	r.POST("/api/auth/logout", authHandler.LogoutHandler)
 	r.POST("/api/users/reset-password", userHandler.ResetPassword)
 	r.POST("/api/users/update-password", userHandler.UpdateCurrentUserPassword)
+	r.GET("/api/kpi/downloads/:file_uuid", exportFileHandler.DownloadSignedFile)
 
 	/**
 	 * Protected Routes
@@ -84,71 +187,138 @@ func SetupRouter() *gin.Engine {
 	protected := r.Group("/api")
 	protected.Use(authMiddleware.AuthenticationMiddleware)
 	{
-		protected.POST("/auth/logout", authHandler.LogoutHandler)
-
 		protected.GET("/auth/me", authHandler.MeHandler)
 
 		/**
 		 * User Management Routes
 		 */
-		protected.POST("/users/register", authMiddleware.RequireRoles("admin"), userHandler.RegisterUser)
-		protected.POST("/users/weekly-rates/create", authMiddleware.RequireRoles("admin"), weeklyRateHandler.Create)
-		protected.POST("/users/weekly-rates/:weekly_rate_uuid/assign-to-user/:user_uuid", authMiddleware.RequireRoles("admin"), weeklyRateHandler.AssignToUser)
-
-		protected.PUT("/users/update-status", authMiddleware.RequireRoles("admin"), userHandler.UpdateUserStatus)
-		protected.PUT("/users", authMiddleware.RequireRoles("admin"), userHandler.UpdateUser)
-		protected.PUT("/users/weekly-rates/:uuid/update", authMiddleware.RequireRoles("admin"), weeklyRateHandler.Update)
+		protected.POST("/users/register", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), userHandler.RegisterUser)
+		protected.POST("/users/weekly-rates/create", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), weeklyRateHandler.Create)
+		protected.POST("/users/weekly-rates/:weekly_rate_uuid/assign-to-user/:user_uuid", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), weeklyRateHandler.AssignToUser)
+		protected.POST("/users/contracts/create", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), contractHandler.Create)
+		protected.POST("/users/unlock/:uuid", authMiddleware.RequireRoles("admin"), loginAttemptHandler.Unlock)
+
+		protected.PUT("/users/update-status", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), userHandler.UpdateUserStatus)
+		protected.PUT("/users", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), userHandler.UpdateUser)
+		protected.PUT("/users/weekly-rates/:uuid/update", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), weeklyRateHandler.Update)
+		protected.PUT("/users/contracts/:uuid/update", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), contractHandler.Update)
 		protected.PUT("/users/current-user-dashboard-layout/edit", authMiddleware.RequireRoles("all"), userHandler.UpdateCurrentUserDashboardLayout)
 
-		protected.GET("/users", authMiddleware.RequireRoles("admin","manager"), userHandler.GetUsers)
-		protected.GET("/users/:uuid", authMiddleware.RequireRoles("all"), userHandler.GetUserByUUID)
-		protected.GET("/users/weekly-rates", authMiddleware.RequireRoles("all"), weeklyRateHandler.GetAll)
+		protected.GET("/users", authMiddleware.RequireScopes("users:read"), authMiddleware.RequireRoles("admin","manager"), userHandler.GetUsers)
+		protected.GET("/users/:uuid", authMiddleware.RequireScopes("users:read"), authMiddleware.RequireRoles("all"), userHandler.GetUserByUUID)
+		protected.GET("/users/weekly-rates", authMiddleware.RequireScopes("users:read"), authMiddleware.RequireRoles("all"), weeklyRateHandler.GetAll)
+		protected.GET("/users/contracts/:user_uuid", authMiddleware.RequireScopes("users:read"), authMiddleware.RequireRoles("admin", "manager"), contractHandler.GetByUser)
 		protected.GET("/users/current-user-dashboard-layout", authMiddleware.RequireRoles("all"), userHandler.GetCurrentUserDashboardLayout)
+		protected.GET("/users/login-attempts/:uuid", authMiddleware.RequireRoles("admin"), loginAttemptHandler.GetHistory)
 
-		protected.DELETE("/users/weekly-rates/:uuid/delete", authMiddleware.RequireRoles("admin"), weeklyRateHandler.Delete)
-		protected.DELETE("/users/delete/:uuid", authMiddleware.RequireRoles("admin"), userHandler.DeleteUser)
+		protected.DELETE("/users/weekly-rates/:uuid/delete", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), weeklyRateHandler.Delete)
+		protected.DELETE("/users/contracts/:uuid/delete", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), contractHandler.Delete)
+		protected.DELETE("/users/delete/:uuid", authMiddleware.RequireScopes("users:write"), authMiddleware.RequireRoles("admin"), userHandler.DeleteUser)
 		protected.DELETE("/users/current-user-dashboard-layout/delete", authMiddleware.RequireRoles("all"), userHandler.DeleteCurrentUserDashboardLayout)
 
 		/**
 		 * Work Sessions & Breaks Routes
 		 */
-		protected.POST("/work-session/update-clocking", authMiddleware.RequireRoles("all"), workSessionHandler.UpdateWorkSessionClocking)
-		protected.POST("/work-session/update-breaking", authMiddleware.RequireRoles("all"), breakHandler.UpdateBreak)
+		protected.POST("/work-session/update-clocking", authMiddleware.RequireScopes("work-session:write"), authMiddleware.RequireRoles("all"), workSessionHandler.UpdateWorkSessionClocking)
+		protected.POST("/work-session/update-breaking", authMiddleware.RequireScopes("work-session:write"), authMiddleware.RequireRoles("all"), breakHandler.UpdateBreak)
 
-		protected.GET("/work-session/history", authMiddleware.RequireRoles("all"), workSessionHandler.GetWorkSessionHistory)
+		protected.GET("/work-session/history", authMiddleware.RequireScopes("work-session:read"), authMiddleware.RequireRoles("all"), workSessionHandler.GetWorkSessionHistory)
 
-		protected.GET("/work-session/status", authMiddleware.RequireRoles("all"), workSessionHandler.GetWorkSessionStatus)
+		protected.GET("/work-session/status", authMiddleware.RequireScopes("work-session:read"), authMiddleware.RequireRoles("all"), workSessionHandler.GetWorkSessionStatus)
 
 		/**
 		 * Teams Routes
 		 */
-		protected.GET("/teams", authMiddleware.RequireRoles("admin", "manager"), teamHandler.GetTeams)
-		protected.GET("/teams/:uuid", authMiddleware.RequireRoles("all"), teamHandler.GetTeamByUUID)
+		protected.GET("/teams", authMiddleware.RequireScopes("teams:read"), authMiddleware.RequireRoles("admin", "manager"), teamHandler.GetTeams)
+		protected.GET("/teams/:uuid", authMiddleware.RequireScopes("teams:read"), authMiddleware.RequireRoles("all"), teamHandler.GetTeamByUUID)
 
-		protected.DELETE("/teams/:uuid", authMiddleware.RequireRoles("admin"), teamHandler.DeleteTeamByUUID)
-		protected.DELETE("/teams/users/:team_uuid/:user_uuid", authMiddleware.RequireRoles("admin"), teamHandler.RemoveUserFromTeam)
+		protected.DELETE("/teams/:uuid", authMiddleware.RequireScopes("teams:write"), authMiddleware.RequireRoles("admin"), teamHandler.DeleteTeamByUUID)
+		protected.DELETE("/teams/users/:team_uuid/:user_uuid", authMiddleware.RequireScopes("teams:write"), authMiddleware.RequireRoles("admin"), teamHandler.RemoveUserFromTeam)
 
-		protected.POST("/teams", authMiddleware.RequireRoles("admin"), teamHandler.CreateTeam)
-		protected.POST("/teams/add-users", authMiddleware.RequireRoles("admin"), teamHandler.AddUsersToTeam)
+		protected.POST("/teams", authMiddleware.RequireScopes("teams:write"), authMiddleware.RequireRoles("admin"), teamHandler.CreateTeam)
+		protected.POST("/teams/add-users", authMiddleware.RequireScopes("teams:write"), authMiddleware.RequireRoles("admin"), teamHandler.AddUsersToTeam)
 
-		protected.PUT("/teams/edit/:uuid", authMiddleware.RequireRoles("admin"), teamHandler.UpdateTeamByUUID)
-		protected.PUT("/teams/:team_uuid/users/:user_uuid/edit-manager-status/:is_manager", authMiddleware.RequireRoles("admin"), teamHandler.UpdateTeamUserManagerStatus)
+		protected.PUT("/teams/edit/:uuid", authMiddleware.RequireScopes("teams:write"), authMiddleware.RequireRoles("admin"), teamHandler.UpdateTeamByUUID)
+		protected.PUT("/teams/:team_uuid/users/:user_uuid/edit-manager-status/:is_manager", authMiddleware.RequireScopes("teams:write"), authMiddleware.RequireRoles("admin"), teamHandler.UpdateTeamUserManagerStatus)
 
 		/**
 		 * KPI Routes
 		 */
 		// TODO: route to get all the weekly dates from a user where he worked for the frontend filter/dropdown
 
-		protected.GET("/kpi/work-session-user-weekly-total/:user_uuid/:start_date/:end_date", authMiddleware.RequireRoles("all"), kpiHandler.GetWorkSessionUserWeeklyTotal)
-		protected.GET("/kpi/work-session-team-weekly-total/:team_uuid/:start_date/:end_date", authMiddleware.RequireRoles("manager"), kpiHandler.GetWorkSessionTeamWeeklyTotal)
-		protected.GET("/kpi/presence-rate/:user_uuid/:start_date/:end_date", authMiddleware.RequireRoles("manager", "admin"), kpiHandler.GetPresenceRate)
-		protected.GET("/kpi/weekly-average-break-time/:user_uuid/:start_date/:end_date", authMiddleware.RequireRoles("all"), kpiHandler.GetAverageBreakTime)
+		protected.GET("/kpi/work-session-user-weekly-total/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("all"), kpiHandler.GetWorkSessionUserWeeklyTotal)
+		protected.GET("/kpi/work-session-team-weekly-total/:team_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.GetWorkSessionTeamWeeklyTotal)
+		protected.GET("/kpi/presence-rate/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.GetPresenceRate)
+		protected.GET("/kpi/weekly-average-break-time/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("all"), kpiHandler.GetAverageBreakTime)
+		protected.GET("/kpi/break-analytics/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("all"), kpiHandler.GetBreakAnalytics)
 		// moyenne par shift par individu
-		protected.GET("/kpi/average-time-per-shift/:user_uuid/:start_date/:end_date", authMiddleware.RequireRoles("manager", "admin"), kpiHandler.GetAverageTimePerShift)
+		protected.GET("/kpi/average-time-per-shift/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.GetAverageTimePerShift)
+		protected.GET("/kpi/daily-presence/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.GetDailyPresence)
+		protected.GET("/kpi/time-series/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.GetTimeSeries)
+		protected.GET("/kpi/team-presence-rate/:team_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.GetTeamPresenceRate)
+		protected.GET("/kpi/team-average-break-time/:team_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.GetTeamAverageBreakTime)
+		protected.GET("/kpi/team-average-time-per-shift/:team_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.GetTeamAverageTimePerShift)
+		protected.GET("/kpi/compare/user/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.CompareUser)
+		protected.GET("/kpi/compare/team/:team_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.CompareTeam)
+		protected.GET("/kpi/well-being/:user_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.GetWellBeing)
+		protected.GET("/kpi/team-well-being/:team_uuid/:start_date/:end_date", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager"), kpiHandler.GetTeamWellBeing)
+		protected.GET("/kpi/organization/overview", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("admin"), kpiHandler.GetOrganizationOverview)
+		protected.POST("/kpi/query", authMiddleware.RequireScopes("kpi:read"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.QueryKPIs)
+
+		protected.POST("/kpi/export", authMiddleware.RequireScopes("kpi:export"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.ExportKPIData)
+		protected.POST("/kpi/export/bundle", authMiddleware.RequireScopes("kpi:export"), authMiddleware.RequireRoles("manager", "admin"), kpiHandler.ExportKPIBundle)
+		protected.GET("/kpi/export/jobs/:job_id", authMiddleware.RequireScopes("kpi:export"), authMiddleware.RequireRoles("manager", "admin"), exportJobHandler.GetExportJob)
+		protected.GET("/kpi/files/:file_uuid", authMiddleware.RequireScopes("kpi:export"), authMiddleware.RequireRoles("all"), exportFileHandler.DownloadFile)
+
+		/**
+		 * Report Subscriptions Routes
+		 */
+		protected.POST("/report-subscriptions", authMiddleware.RequireRoles("all"), reportSubscriptionHandler.Create)
+
+		protected.GET("/report-subscriptions/me", authMiddleware.RequireRoles("all"), reportSubscriptionHandler.GetMine)
+
+		protected.DELETE("/report-subscriptions/:uuid", authMiddleware.RequireRoles("all"), reportSubscriptionHandler.Delete)
 
-		protected.POST("/kpi/export", authMiddleware.RequireRoles("manager, admin"), kpiHandler.ExportKPIData)
-		protected.GET("/kpi/files/:filename", authMiddleware.RequireRoles("all"), kpiHandler.DownloadKPIFile)
+		/**
+		 * API Tokens Routes
+		 */
+		protected.POST("/tokens", authMiddleware.RequireRoles("all"), apiTokenHandler.CreatePersonal)
+		protected.POST("/api-keys", authMiddleware.RequireRoles("admin"), apiTokenHandler.CreateAPIKey)
+
+		protected.GET("/tokens/me", authMiddleware.RequireRoles("all"), apiTokenHandler.GetMine)
+		protected.GET("/api-keys", authMiddleware.RequireRoles("admin"), apiTokenHandler.GetAPIKeys)
+
+		protected.DELETE("/tokens/:uuid", authMiddleware.RequireRoles("all"), apiTokenHandler.Revoke)
+
+		/**
+		 * Two-Factor Authentication Routes
+		 */
+		protected.POST("/mfa/setup", authMiddleware.RequireRoles("all"), mfaHandler.Setup)
+		protected.POST("/mfa/confirm", authMiddleware.RequireRoles("all"), mfaHandler.Confirm)
+		protected.POST("/mfa/disable", authMiddleware.RequireRoles("all"), mfaHandler.Disable)
+		protected.POST("/mfa/recovery-codes", authMiddleware.RequireRoles("all"), mfaHandler.RegenerateRecoveryCodes)
+
+		protected.GET("/mfa/status", authMiddleware.RequireRoles("all"), mfaHandler.GetStatus)
+		protected.GET("/mfa/policy", authMiddleware.RequireRoles("admin"), mfaHandler.GetPolicy)
+
+		protected.PUT("/mfa/policy", authMiddleware.RequireRoles("admin"), mfaHandler.UpdatePolicy)
+
+		protected.DELETE("/mfa/users/:uuid", authMiddleware.RequireRoles("admin"), mfaHandler.Reset)
+
+		/**
+		 * Anomalies Routes
+		 */
+		protected.POST("/anomalies/scan", authMiddleware.RequireScopes("anomalies:write"), authMiddleware.RequireRoles("admin"), anomalyHandler.Scan)
+
+		protected.GET("/anomalies/team/:team_uuid", authMiddleware.RequireScopes("anomalies:read"), authMiddleware.RequireRoles("manager"), anomalyHandler.GetByTeam)
+
+		protected.PUT("/anomalies/:uuid/status", authMiddleware.RequireScopes("anomalies:write"), authMiddleware.RequireRoles("manager"), anomalyHandler.UpdateStatus)
+
+		/**
+		 * Cache Routes
+		 */
+		protected.GET("/cache/stats", authMiddleware.RequireRoles("admin"), cacheHandler.GetStats)
 	}
 
-	return r
+	return r, nil
 }
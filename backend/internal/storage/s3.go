@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Storage stores the files in a bucket through the S3 REST API, signing its requests with AWS Signature Version 4.
+type s3Storage struct {
+	config Config
+	client *http.Client
+}
+
+func NewS3Storage(config Config) Storage {
+	if config.S3Region == "" {
+		config.S3Region = "us-east-1"
+	}
+	return &s3Storage{config: config, client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+func (storage *s3Storage) Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error {
+	resp, err := storage.do(ctx, http.MethodPut, key, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(http.MethodPut, key, resp)
+	}
+	return nil
+}
+
+func (storage *s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := storage.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, responseError(http.MethodGet, key, resp)
+	}
+}
+
+func (storage *s3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := storage.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(http.MethodDelete, key, resp)
+	}
+	return nil
+}
+
+// do sends a signed request on the object of the key, or on the bucket itself when the key is empty.
+func (storage *s3Storage) do(ctx context.Context, method string, key string, body io.ReadSeeker, contentType string) (*http.Response, error) {
+	endpoint, err := url.Parse(storage.config.S3Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint: %w", err)
+	}
+
+	target := *endpoint
+	path := "/" + key
+	if storage.config.S3ForcePathStyle {
+		path = "/" + storage.config.S3Bucket + path
+	} else {
+		target.Host = storage.config.S3Bucket + "." + endpoint.Host
+	}
+	target.Path = strings.TrimSuffix(endpoint.Path, "/") + path
+	target.RawPath = strings.TrimSuffix(endpoint.Path, "/") + encodePath(path)
+
+	payloadHash := emptyPayloadHash
+	size := int64(0)
+	if body != nil {
+		payloadHash, size, err = hashBody(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && size > 0 {
+		req.Body = io.NopCloser(body)
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	storage.sign(req, payloadHash, time.Now().UTC())
+
+	return storage.client.Do(req)
+}
+
+// sign adds the Authorization header of AWS Signature Version 4, covering the host, the payload hash and the date.
+func (storage *s3Storage) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" + "x-amz-content-sha256:" + payloadHash + "\n" + "x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + storage.config.S3Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+storage.config.S3SecretKey), day)
+	key = hmacSHA256(key, storage.config.S3Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		storage.config.S3AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// hashBody returns the hex SHA-256 and the size of the body, rewound to its start.
+func hashBody(body io.ReadSeeker) (string, int64, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// encodePath escapes every byte of the path but the unreserved characters and the slashes, as S3 expects.
+func encodePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func responseError(method string, key string, resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("storage: s3 %s %s failed: %s %s", method, key, resp.Status, strings.TrimSpace(string(message)))
+}
@@ -0,0 +1,57 @@
+/**
+ * Storage package keeping the generated files on the local filesystem or in an S3-compatible bucket shared by the replicas
+ */
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	LocalDriver = "local"
+	S3Driver    = "s3"
+)
+
+// ErrNotFound is returned when no file is stored under the key.
+var ErrNotFound = errors.New("storage: file not found")
+
+// Storage keeps files under keys such as "kpi/<uuid>.csv". A file is only visible once it is fully written.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds when no file is stored under the key
+	Delete(ctx context.Context, key string) error
+}
+
+type Config struct {
+	// driver is either "local" or "s3"
+	Driver string
+	// root directory of the local driver
+	LocalDirectory string
+	// endpoint of the S3 API, such as https://s3.eu-west-3.amazonaws.com or http://minio:9000
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// address the bucket in the path rather than in the host name, as required by MinIO
+	S3ForcePathStyle bool
+}
+
+// New returns the storage of the configured driver.
+func New(config Config) (Storage, error) {
+	switch config.Driver {
+	case LocalDriver, "":
+		return NewLocalStorage(config.LocalDirectory), nil
+	case S3Driver:
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return nil, errors.New("storage: the s3 driver requires an endpoint and a bucket")
+		}
+		return NewS3Storage(config), nil
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", config.Driver)
+	}
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// assertStorage runs the same scenario against every driver.
+func assertStorage(t *testing.T, storage Storage) {
+	ctx := context.Background()
+
+	_, err := storage.Get(ctx, "kpi/missing.csv")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	content := "date,worked_minutes\n2026-03-02,480\n"
+	assert.NoError(t, storage.Put(ctx, "kpi/report été.csv", strings.NewReader(content), "text/csv"))
+
+	reader, err := storage.Get(ctx, "kpi/report été.csv")
+	if assert.NoError(t, err) {
+		stored, err := io.ReadAll(reader)
+		reader.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(stored))
+	}
+
+	// an empty file is still a file
+	assert.NoError(t, storage.Put(ctx, "kpi/empty.csv", strings.NewReader(""), "text/csv"))
+	reader, err = storage.Get(ctx, "kpi/empty.csv")
+	if assert.NoError(t, err) {
+		reader.Close()
+	}
+
+	assert.NoError(t, storage.Delete(ctx, "kpi/report été.csv"))
+	assert.NoError(t, storage.Delete(ctx, "kpi/report été.csv"))
+
+	_, err = storage.Get(ctx, "kpi/report été.csv")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestLocalStorage(t *testing.T) {
+	storage := NewLocalStorage(t.TempDir())
+
+	assertStorage(t, storage)
+
+	assert.Error(t, storage.Put(context.Background(), "../outside.csv", strings.NewReader("x"), "text/csv"))
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	const port = "9000/tcp"
+
+	minioC, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "minio/minio:latest",
+			Cmd:          []string{"server", "/data"},
+			Env:          map[string]string{"MINIO_ROOT_USER": "minioadmin", "MINIO_ROOT_PASSWORD": "minioadmin"},
+			ExposedPorts: []string{port},
+			WaitingFor:   wait.ForHTTP("/minio/health/live").WithPort(port).WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		t.Fatalf("❌ Failed to start MinIO: %v", err)
+	}
+	t.Cleanup(func() { _ = minioC.Terminate(ctx) })
+
+	host, _ := minioC.Host(ctx)
+	mappedPort, _ := minioC.MappedPort(ctx, port)
+
+	storage := NewS3Storage(Config{
+		S3Endpoint:       fmt.Sprintf("http://%s:%s", host, mappedPort.Port()),
+		S3Bucket:         "exports",
+		S3AccessKey:      "minioadmin",
+		S3SecretKey:      "minioadmin",
+		S3ForcePathStyle: true,
+	})
+
+	// the bucket itself is created with a signed request on an empty key
+	resp, err := storage.(*s3Storage).do(ctx, http.MethodPut, "", nil, "")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	assertStorage(t, storage)
+
+	wrongKey := NewS3Storage(Config{
+		S3Endpoint:       fmt.Sprintf("http://%s:%s", host, mappedPort.Port()),
+		S3Bucket:         "exports",
+		S3AccessKey:      "minioadmin",
+		S3SecretKey:      "wrong",
+		S3ForcePathStyle: true,
+	})
+	assert.Error(t, wrongKey.Put(ctx, "kpi/denied.csv", strings.NewReader("x"), "text/csv"))
+}
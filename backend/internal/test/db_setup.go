@@ -104,6 +104,13 @@ var (
 		uuid VARCHAR(36) NOT NULL UNIQUE,
 		rate_name VARCHAR(255) NOT NULL,
 		amount SMALLINT NOT NULL,
+		monday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+		tuesday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+		wednesday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+		thursday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+		friday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+		saturday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+		sunday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -132,14 +139,236 @@ var (
 	ALTER TABLE work_session_active
 	ADD COLUMN breaks_duration_minutes INTEGER DEFAULT 0;
 
+	ALTER TABLE work_session_active
+	ADD COLUMN breaks_count INTEGER DEFAULT 0;
+
 	ALTER TABLE work_session_archived
 	ADD COLUMN breaks_duration_minutes INTEGER DEFAULT 0;
 
+	ALTER TABLE work_session_archived
+	ADD COLUMN breaks_count INTEGER DEFAULT 0;
+
 	ALTER TABLE work_session_history
 	ADD COLUMN breaks_duration_minutes INTEGER DEFAULT 0;
 
+	ALTER TABLE work_session_history
+	ADD COLUMN breaks_count INTEGER DEFAULT 0;
+
 	CREATE INDEX idx_users_weekly_rate_id ON users (weekly_rate_id);
 
+	CREATE TYPE contract_type AS ENUM('permanent', 'fixed_term', 'intern', 'interim');
+
+	CREATE TABLE contracts (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		uuid VARCHAR(36) NOT NULL UNIQUE,
+		user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		contract_type contract_type NOT NULL,
+		start_date DATE NOT NULL,
+		end_date DATE,
+		weekly_rate_id INT REFERENCES weekly_rate (id) ON DELETE SET NULL,
+		hourly_cost NUMERIC(10, 2),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		CONSTRAINT chk_contracts_dates CHECK (end_date IS NULL OR end_date >= start_date)
+	);
+
+	CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+	ALTER TABLE contracts
+	ADD CONSTRAINT excl_contracts_overlap EXCLUDE USING gist (user_id WITH =, daterange(start_date, end_date, '[]') WITH &&);
+
+	CREATE TYPE anomaly_status AS ENUM('open', 'acknowledged', 'resolved');
+
+	CREATE TABLE anomalies (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		uuid VARCHAR(36) NOT NULL UNIQUE,
+		user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		work_session_uuid VARCHAR(36),
+		anomaly_type VARCHAR(50) NOT NULL,
+		day DATE NOT NULL,
+		details TEXT NOT NULL,
+		score DOUBLE PRECISION,
+		fingerprint VARCHAR(255) NOT NULL UNIQUE,
+		status anomaly_status NOT NULL DEFAULT 'open',
+		status_updated_by INT REFERENCES users (id) ON DELETE SET NULL,
+		status_updated_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE work_session_daily_totals (
+		user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		day DATE NOT NULL,
+		worked_minutes INT NOT NULL DEFAULT 0,
+		break_minutes INT NOT NULL DEFAULT 0,
+		sessions_count INT NOT NULL DEFAULT 0,
+		first_clock_in TIMESTAMP NOT NULL,
+		last_clock_out TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, day)
+	);
+
+	CREATE INDEX idx_work_session_daily_totals_day ON work_session_daily_totals (day);
+
+	CREATE FUNCTION refresh_work_session_daily_total(p_user_id INT, p_day DATE) RETURNS VOID AS $$
+	BEGIN
+		PERFORM pg_advisory_xact_lock(p_user_id, p_day - DATE '2000-01-01');
+
+		DELETE FROM work_session_daily_totals WHERE user_id = p_user_id AND day = p_day;
+
+		INSERT INTO work_session_daily_totals (user_id, day, worked_minutes, break_minutes, sessions_count, first_clock_in, last_clock_out)
+		SELECT
+			p_user_id,
+			p_day,
+			COALESCE(SUM(duration_minutes), 0),
+			COALESCE(SUM(breaks_duration_minutes), 0),
+			COUNT(*),
+			MIN(clock_in),
+			MAX(clock_out)
+		FROM (
+			SELECT clock_in, clock_out, duration_minutes, breaks_duration_minutes
+			FROM work_session_active
+			WHERE user_id = p_user_id AND clock_in >= p_day AND clock_in < p_day + 1
+			UNION ALL
+			SELECT clock_in, clock_out, duration_minutes, breaks_duration_minutes
+			FROM work_session_archived
+			WHERE user_id = p_user_id AND clock_in >= p_day AND clock_in < p_day + 1
+		) AS day_sessions
+		HAVING COUNT(*) > 0;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE FUNCTION work_session_daily_totals_trigger() RETURNS TRIGGER AS $$
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			PERFORM refresh_work_session_daily_total(OLD.user_id, OLD.clock_in::date);
+		END IF;
+
+		IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND (NEW.user_id <> OLD.user_id OR NEW.clock_in::date <> OLD.clock_in::date)) THEN
+			PERFORM refresh_work_session_daily_total(NEW.user_id, NEW.clock_in::date);
+		END IF;
+
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER trg_work_session_active_daily_totals
+	AFTER INSERT OR DELETE OR UPDATE OF user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes
+	ON work_session_active
+	FOR EACH ROW EXECUTE FUNCTION work_session_daily_totals_trigger();
+
+	CREATE TRIGGER trg_work_session_archived_daily_totals
+	AFTER INSERT OR DELETE OR UPDATE OF user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes
+	ON work_session_archived
+	FOR EACH ROW EXECUTE FUNCTION work_session_daily_totals_trigger();
+
+	CREATE FUNCTION rebuild_work_session_daily_totals(p_start DATE, p_end DATE) RETURNS INT AS $$
+	DECLARE
+		written INT;
+	BEGIN
+		LOCK TABLE work_session_daily_totals IN EXCLUSIVE MODE;
+
+		DELETE FROM work_session_daily_totals
+		WHERE (p_start IS NULL OR day >= p_start) AND (p_end IS NULL OR day <= p_end);
+
+		INSERT INTO work_session_daily_totals (user_id, day, worked_minutes, break_minutes, sessions_count, first_clock_in, last_clock_out)
+		SELECT
+			user_id,
+			clock_in::date,
+			COALESCE(SUM(duration_minutes), 0),
+			COALESCE(SUM(breaks_duration_minutes), 0),
+			COUNT(*),
+			MIN(clock_in),
+			MAX(clock_out)
+		FROM (
+			SELECT user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes FROM work_session_active
+			UNION ALL
+			SELECT user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes FROM work_session_archived
+		) AS all_sessions
+		WHERE (p_start IS NULL OR clock_in >= p_start) AND (p_end IS NULL OR clock_in < p_end + 1)
+		GROUP BY user_id, clock_in::date;
+
+		GET DIAGNOSTICS written = ROW_COUNT;
+		RETURN written;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TABLE export_files (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		uuid VARCHAR(36) NOT NULL UNIQUE,
+		owner_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		kpi_type VARCHAR(50) NOT NULL,
+		format VARCHAR(10) NOT NULL,
+		filename VARCHAR(255) NOT NULL,
+		path VARCHAR(255) NOT NULL,
+		size_bytes BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE report_subscriptions (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		uuid VARCHAR(36) NOT NULL UNIQUE,
+		user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		team_id INT REFERENCES teams (id) ON DELETE CASCADE,
+		kpi_type VARCHAR(50) NOT NULL,
+		target VARCHAR(10) NOT NULL,
+		frequency VARCHAR(10) NOT NULL,
+		format VARCHAR(10) NOT NULL,
+		delivery VARCHAR(10) NOT NULL,
+		next_run_at TIMESTAMP NOT NULL,
+		last_run_at TIMESTAMP,
+		last_error TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE api_tokens (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		uuid VARCHAR(36) NOT NULL UNIQUE,
+		user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		created_by INT REFERENCES users (id) ON DELETE SET NULL,
+		kind VARCHAR(20) NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		token_prefix VARCHAR(20) NOT NULL,
+		scopes TEXT[] NOT NULL,
+		expires_at TIMESTAMP,
+		last_used_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE user_mfa (
+		user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+		secret TEXT NOT NULL,
+		enabled_at TIMESTAMP,
+		last_used_step BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE mfa_recovery_codes (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		code_hash VARCHAR(64) NOT NULL,
+		used_at TIMESTAMP
+	);
+
+	CREATE TABLE mfa_policy (
+		id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+		required_roles TEXT[] NOT NULL DEFAULT '{}',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE login_attempts (
+		id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		user_id INT REFERENCES users (id) ON DELETE CASCADE,
+		login VARCHAR(255) NOT NULL,
+		ip_address VARCHAR(64) NOT NULL,
+		reason VARCHAR(32) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
 	INSERT INTO weekly_rate (uuid, rate_name, amount) VALUES (gen_random_uuid()::varchar, 'Temps pleins', 35);
 	INSERT INTO weekly_rate (uuid, rate_name, amount) VALUES (gen_random_uuid()::varchar, 'Temps pleins + RTT', 39);
 	INSERT INTO weekly_rate (uuid, rate_name, amount) VALUES (gen_random_uuid()::varchar, 'Temps partiel', 20);
@@ -218,7 +447,7 @@ func ResetDB(t *testing.T) *gorm.DB {
 	}
 
 	tables := []string{
-		"teams_members", "teams", "users", "weekly_rate",
+		"login_attempts", "mfa_policy", "mfa_recovery_codes", "user_mfa", "api_tokens", "report_subscriptions", "export_files", "anomalies", "work_session_daily_totals", "teams_members", "teams", "contracts", "users", "weekly_rate",
 		"work_session_active", "work_session_archived", "work_session_history",
 	}
 	for _, tbl := range tables {
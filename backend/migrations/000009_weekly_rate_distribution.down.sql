@@ -0,0 +1,8 @@
+ALTER TABLE weekly_rate
+DROP COLUMN IF EXISTS monday_hours,
+DROP COLUMN IF EXISTS tuesday_hours,
+DROP COLUMN IF EXISTS wednesday_hours,
+DROP COLUMN IF EXISTS thursday_hours,
+DROP COLUMN IF EXISTS friday_hours,
+DROP COLUMN IF EXISTS saturday_hours,
+DROP COLUMN IF EXISTS sunday_hours;
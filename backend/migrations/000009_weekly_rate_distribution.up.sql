@@ -0,0 +1,18 @@
+-- Expected working hours for each weekday, the sum of the seven columns must match the weekly amount
+ALTER TABLE weekly_rate
+ADD COLUMN monday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN tuesday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN wednesday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN thursday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN friday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN saturday_hours DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN sunday_hours DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Existing rates are spread evenly from monday to friday
+UPDATE weekly_rate
+SET
+    monday_hours = amount / 5.0,
+    tuesday_hours = amount / 5.0,
+    wednesday_hours = amount / 5.0,
+    thursday_hours = amount / 5.0,
+    friday_hours = amount / 5.0;
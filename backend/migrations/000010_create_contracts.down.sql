@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_contracts_dates;
+
+DROP INDEX IF EXISTS idx_contracts_user_id;
+
+DROP TABLE IF EXISTS contracts;
+
+DROP TYPE IF EXISTS contract_type;
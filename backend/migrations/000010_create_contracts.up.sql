@@ -0,0 +1,29 @@
+CREATE TYPE contract_type AS ENUM(
+    'permanent',
+    'fixed_term',
+    'intern',
+    'interim'
+);
+
+-- A user can have several contracts over time but they can not overlap
+-- A NULL end_date means the contract is still running
+CREATE TABLE contracts (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    contract_type contract_type NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    weekly_rate_id INT REFERENCES weekly_rate (id) ON DELETE SET NULL,
+    hourly_cost NUMERIC(10, 2),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_contracts_dates CHECK (
+        end_date IS NULL
+        OR end_date >= start_date
+    )
+);
+
+CREATE INDEX idx_contracts_user_id ON contracts (user_id);
+
+CREATE INDEX idx_contracts_dates ON contracts (start_date, end_date);
@@ -0,0 +1,5 @@
+ALTER TABLE work_session_history DROP COLUMN breaks_count;
+
+ALTER TABLE work_session_archived DROP COLUMN breaks_count;
+
+ALTER TABLE work_session_active DROP COLUMN breaks_count;
@@ -0,0 +1,13 @@
+-- Number of breaks taken during a work session, set at clock-out once the breaks are deleted
+-- Sessions closed before this migration keep a NULL count since their breaks are already gone
+ALTER TABLE work_session_active ADD COLUMN breaks_count INTEGER;
+
+ALTER TABLE work_session_active ALTER COLUMN breaks_count SET DEFAULT 0;
+
+ALTER TABLE work_session_archived ADD COLUMN breaks_count INTEGER;
+
+ALTER TABLE work_session_archived ALTER COLUMN breaks_count SET DEFAULT 0;
+
+ALTER TABLE work_session_history ADD COLUMN breaks_count INTEGER;
+
+ALTER TABLE work_session_history ALTER COLUMN breaks_count SET DEFAULT 0;
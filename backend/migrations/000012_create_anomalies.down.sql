@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_anomalies_status;
+
+DROP INDEX IF EXISTS idx_anomalies_user_id_day;
+
+DROP TABLE IF EXISTS anomalies;
+
+DROP TYPE IF EXISTS anomaly_status;
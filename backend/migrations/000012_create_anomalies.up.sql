@@ -0,0 +1,29 @@
+CREATE TYPE anomaly_status AS ENUM(
+    'open',
+    'acknowledged',
+    'resolved'
+);
+
+-- Anomalies flagged by the detector on the clocking data
+-- The fingerprint identifies what was flagged so that a scan never flags it twice,
+-- an acknowledged or resolved anomaly is never reopened by a later scan
+CREATE TABLE anomalies (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    work_session_uuid VARCHAR(36),
+    anomaly_type VARCHAR(50) NOT NULL,
+    day DATE NOT NULL,
+    details TEXT NOT NULL,
+    score DOUBLE PRECISION,
+    fingerprint VARCHAR(255) NOT NULL UNIQUE,
+    status anomaly_status NOT NULL DEFAULT 'open',
+    status_updated_by INT REFERENCES users (id) ON DELETE SET NULL,
+    status_updated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_anomalies_user_id_day ON anomalies (user_id, day);
+
+CREATE INDEX idx_anomalies_status ON anomalies (status);
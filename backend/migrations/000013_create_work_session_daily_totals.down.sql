@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS trg_work_session_archived_daily_totals ON work_session_archived;
+
+DROP TRIGGER IF EXISTS trg_work_session_active_daily_totals ON work_session_active;
+
+DROP FUNCTION IF EXISTS rebuild_work_session_daily_totals(DATE, DATE);
+
+DROP FUNCTION IF EXISTS work_session_daily_totals_trigger();
+
+DROP FUNCTION IF EXISTS refresh_work_session_daily_total(INT, DATE);
+
+DROP INDEX IF EXISTS idx_work_session_daily_totals_day;
+
+DROP TABLE IF EXISTS work_session_daily_totals;
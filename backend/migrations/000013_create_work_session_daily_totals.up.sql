@@ -0,0 +1,105 @@
+-- Worked time of each user per day, summed from the active and archived work sessions
+-- Sessions are counted on the day they were clocked in, running sessions are counted without duration
+-- Kept up to date by triggers on both work session tables, so clock-outs, corrections and archiving are all reflected
+CREATE TABLE work_session_daily_totals (
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    worked_minutes INT NOT NULL DEFAULT 0,
+    break_minutes INT NOT NULL DEFAULT 0,
+    sessions_count INT NOT NULL DEFAULT 0,
+    first_clock_in TIMESTAMP NOT NULL,
+    last_clock_out TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX idx_work_session_daily_totals_day ON work_session_daily_totals (day);
+
+-- Recompute the totals of a user on a day, the row is removed once the day has no session left
+-- The advisory lock serializes the refreshes of a same day so that concurrent writes never leave stale totals
+CREATE FUNCTION refresh_work_session_daily_total(p_user_id INT, p_day DATE) RETURNS VOID AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(p_user_id, p_day - DATE '2000-01-01');
+
+    DELETE FROM work_session_daily_totals WHERE user_id = p_user_id AND day = p_day;
+
+    INSERT INTO work_session_daily_totals (user_id, day, worked_minutes, break_minutes, sessions_count, first_clock_in, last_clock_out)
+    SELECT
+        p_user_id,
+        p_day,
+        COALESCE(SUM(duration_minutes), 0),
+        COALESCE(SUM(breaks_duration_minutes), 0),
+        COUNT(*),
+        MIN(clock_in),
+        MAX(clock_out)
+    FROM (
+        SELECT clock_in, clock_out, duration_minutes, breaks_duration_minutes
+        FROM work_session_active
+        WHERE user_id = p_user_id AND clock_in >= p_day AND clock_in < p_day + 1
+        UNION ALL
+        SELECT clock_in, clock_out, duration_minutes, breaks_duration_minutes
+        FROM work_session_archived
+        WHERE user_id = p_user_id AND clock_in >= p_day AND clock_in < p_day + 1
+    ) AS day_sessions
+    HAVING COUNT(*) > 0;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION work_session_daily_totals_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM refresh_work_session_daily_total(OLD.user_id, OLD.clock_in::date);
+    END IF;
+
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND (NEW.user_id <> OLD.user_id OR NEW.clock_in::date <> OLD.clock_in::date)) THEN
+        PERFORM refresh_work_session_daily_total(NEW.user_id, NEW.clock_in::date);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_work_session_active_daily_totals
+AFTER INSERT OR DELETE OR UPDATE OF user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes
+ON work_session_active
+FOR EACH ROW EXECUTE FUNCTION work_session_daily_totals_trigger();
+
+CREATE TRIGGER trg_work_session_archived_daily_totals
+AFTER INSERT OR DELETE OR UPDATE OF user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes
+ON work_session_archived
+FOR EACH ROW EXECUTE FUNCTION work_session_daily_totals_trigger();
+
+-- Rebuild the totals of the days between the two dates, both included, NULL bounds meaning no limit
+-- Returns the number of rows written
+CREATE FUNCTION rebuild_work_session_daily_totals(p_start DATE, p_end DATE) RETURNS INT AS $$
+DECLARE
+    written INT;
+BEGIN
+    LOCK TABLE work_session_daily_totals IN EXCLUSIVE MODE;
+
+    DELETE FROM work_session_daily_totals
+    WHERE (p_start IS NULL OR day >= p_start) AND (p_end IS NULL OR day <= p_end);
+
+    INSERT INTO work_session_daily_totals (user_id, day, worked_minutes, break_minutes, sessions_count, first_clock_in, last_clock_out)
+    SELECT
+        user_id,
+        clock_in::date,
+        COALESCE(SUM(duration_minutes), 0),
+        COALESCE(SUM(breaks_duration_minutes), 0),
+        COUNT(*),
+        MIN(clock_in),
+        MAX(clock_out)
+    FROM (
+        SELECT user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes FROM work_session_active
+        UNION ALL
+        SELECT user_id, clock_in, clock_out, duration_minutes, breaks_duration_minutes FROM work_session_archived
+    ) AS all_sessions
+    WHERE (p_start IS NULL OR clock_in >= p_start) AND (p_end IS NULL OR clock_in < p_end + 1)
+    GROUP BY user_id, clock_in::date;
+
+    GET DIAGNOSTICS written = ROW_COUNT;
+    RETURN written;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT rebuild_work_session_daily_totals(NULL, NULL);
@@ -0,0 +1,29 @@
+DROP TRIGGER IF EXISTS trg_teams_cache_invalidation ON teams;
+
+DROP TRIGGER IF EXISTS trg_teams_members_cache_invalidation ON teams_members;
+
+DROP TRIGGER IF EXISTS trg_weekly_rate_cache_invalidation ON weekly_rate;
+
+DROP TRIGGER IF EXISTS trg_users_weekly_rate_cache_invalidation ON users;
+
+DROP TRIGGER IF EXISTS trg_breaks_cache_invalidation ON breaks;
+
+DROP TRIGGER IF EXISTS trg_contracts_cache_invalidation ON contracts;
+
+DROP TRIGGER IF EXISTS trg_work_session_archived_cache_invalidation ON work_session_archived;
+
+DROP TRIGGER IF EXISTS trg_work_session_active_cache_invalidation ON work_session_active;
+
+DROP FUNCTION IF EXISTS teams_cache_invalidation_trigger();
+
+DROP FUNCTION IF EXISTS teams_members_cache_invalidation_trigger();
+
+DROP FUNCTION IF EXISTS weekly_rate_cache_invalidation_trigger();
+
+DROP FUNCTION IF EXISTS user_weekly_rate_cache_invalidation_trigger();
+
+DROP FUNCTION IF EXISTS break_cache_invalidation_trigger();
+
+DROP FUNCTION IF EXISTS user_rows_cache_invalidation_trigger();
+
+DROP FUNCTION IF EXISTS notify_user_cache_invalidation(INT);
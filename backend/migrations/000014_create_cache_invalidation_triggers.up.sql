@@ -0,0 +1,121 @@
+-- Notify the backend of the changes that make its cached KPIs and histories stale
+-- The payload is the cache tag to invalidate: user:<uuid>, team:<uuid>, or kpi for every KPI
+-- Notifications are sent on commit, a same tag being notified once per transaction
+
+-- Invalidate a user and every team they belong to
+CREATE FUNCTION notify_user_cache_invalidation(p_user_id INT) RETURNS VOID AS $$
+DECLARE
+    tag TEXT;
+BEGIN
+    FOR tag IN
+        SELECT 'user:' || u.uuid FROM users u WHERE u.id = p_user_id
+        UNION
+        SELECT 'team:' || t.uuid
+        FROM teams_members tm
+        INNER JOIN teams t ON t.id = tm.team_id
+        WHERE tm.user_id = p_user_id
+    LOOP
+        PERFORM pg_notify('cache_invalidation', tag);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Work sessions and contracts both belong to a user
+CREATE FUNCTION user_rows_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM notify_user_cache_invalidation(OLD.user_id);
+    END IF;
+
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.user_id <> OLD.user_id) THEN
+        PERFORM notify_user_cache_invalidation(NEW.user_id);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_work_session_active_cache_invalidation
+AFTER INSERT OR UPDATE OR DELETE ON work_session_active
+FOR EACH ROW EXECUTE FUNCTION user_rows_cache_invalidation_trigger();
+
+CREATE TRIGGER trg_work_session_archived_cache_invalidation
+AFTER INSERT OR UPDATE OR DELETE ON work_session_archived
+FOR EACH ROW EXECUTE FUNCTION user_rows_cache_invalidation_trigger();
+
+CREATE TRIGGER trg_contracts_cache_invalidation
+AFTER INSERT OR UPDATE OR DELETE ON contracts
+FOR EACH ROW EXECUTE FUNCTION user_rows_cache_invalidation_trigger();
+
+-- Breaks belong to the user of their work session
+CREATE FUNCTION break_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM notify_user_cache_invalidation(ws.user_id)
+    FROM work_session_active ws
+    WHERE ws.id = CASE WHEN TG_OP = 'DELETE' THEN OLD.work_session_active_id ELSE NEW.work_session_active_id END;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_breaks_cache_invalidation
+AFTER INSERT OR UPDATE OR DELETE ON breaks
+FOR EACH ROW EXECUTE FUNCTION break_cache_invalidation_trigger();
+
+-- The weekly rate of a user drives their expected hours
+CREATE FUNCTION user_weekly_rate_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM notify_user_cache_invalidation(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_users_weekly_rate_cache_invalidation
+AFTER UPDATE OF weekly_rate_id ON users
+FOR EACH ROW EXECUTE FUNCTION user_weekly_rate_cache_invalidation_trigger();
+
+-- A weekly rate may be shared by any number of users and contracts
+CREATE FUNCTION weekly_rate_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('cache_invalidation', 'kpi');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_weekly_rate_cache_invalidation
+AFTER UPDATE OR DELETE ON weekly_rate
+FOR EACH STATEMENT EXECUTE FUNCTION weekly_rate_cache_invalidation_trigger();
+
+-- Membership changes the members of the team, and the teams the user belongs to or manages
+CREATE FUNCTION teams_members_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+DECLARE
+    changed teams_members%ROWTYPE;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    PERFORM pg_notify('cache_invalidation', 'team:' || t.uuid) FROM teams t WHERE t.id = changed.team_id;
+    PERFORM notify_user_cache_invalidation(changed.user_id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_teams_members_cache_invalidation
+AFTER INSERT OR UPDATE OR DELETE ON teams_members
+FOR EACH ROW EXECUTE FUNCTION teams_members_cache_invalidation_trigger();
+
+-- The name of the team is part of the cached results
+CREATE FUNCTION teams_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('cache_invalidation', 'team:' || OLD.uuid);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_teams_cache_invalidation
+AFTER UPDATE OR DELETE ON teams
+FOR EACH ROW EXECUTE FUNCTION teams_cache_invalidation_trigger();
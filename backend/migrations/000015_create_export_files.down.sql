@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_export_files_expires_at;
+
+DROP INDEX IF EXISTS idx_export_files_owner_id;
+
+DROP TABLE IF EXISTS export_files;
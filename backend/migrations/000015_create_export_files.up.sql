@@ -0,0 +1,18 @@
+-- Files generated by the KPI exports, stored under their uuid and purged once expired
+-- Only their owner and the admins can download them, or anyone holding a signed link until expires_at
+CREATE TABLE export_files (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL UNIQUE,
+    owner_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kpi_type VARCHAR(50) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_export_files_owner_id ON export_files (owner_id);
+
+CREATE INDEX idx_export_files_expires_at ON export_files (expires_at);
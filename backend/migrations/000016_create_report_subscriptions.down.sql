@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_report_subscriptions_next_run_at;
+
+DROP INDEX IF EXISTS idx_report_subscriptions_user_id;
+
+DROP TABLE IF EXISTS report_subscriptions;
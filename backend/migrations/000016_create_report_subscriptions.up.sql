@@ -0,0 +1,22 @@
+-- Recurring KPI reports mailed to their subscriber, either about themselves or about a team they manage
+-- next_run_at is moved forward before a report is sent, so that a report is never sent twice
+CREATE TABLE report_subscriptions (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    team_id INT REFERENCES teams (id) ON DELETE CASCADE,
+    kpi_type VARCHAR(50) NOT NULL,
+    target VARCHAR(10) NOT NULL,
+    frequency VARCHAR(10) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    delivery VARCHAR(10) NOT NULL,
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_report_subscriptions_user_id ON report_subscriptions (user_id);
+
+CREATE INDEX idx_report_subscriptions_next_run_at ON report_subscriptions (next_run_at);
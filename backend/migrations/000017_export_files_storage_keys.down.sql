@@ -0,0 +1 @@
+UPDATE export_files SET path = '/app/data/' || path WHERE path NOT LIKE '/%';
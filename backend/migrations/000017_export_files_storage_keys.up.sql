@@ -0,0 +1,2 @@
+-- The export files are now addressed by their key in the storage, relative to its root directory
+UPDATE export_files SET path = regexp_replace(path, '^/app/data/', '') WHERE path LIKE '/app/data/%';
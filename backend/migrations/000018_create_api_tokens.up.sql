@@ -0,0 +1,19 @@
+-- Personal access tokens issued by the users for themselves, and API keys issued by the admins for an account
+-- Only the SHA-256 hash of a token is stored, its prefix being kept to recognize it in the lists
+CREATE TABLE api_tokens (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    uuid VARCHAR(36) NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_by INT REFERENCES users (id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    token_prefix VARCHAR(20) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens (user_id);
@@ -0,0 +1,29 @@
+-- TOTP second factor of the users, the secret being encrypted with MFA_ENCRYPTION_KEY
+-- enabled_at stays null until the enrollment is confirmed with a first code
+-- last_used_step is the time step of the last accepted code, so that a code cannot be replayed
+CREATE TABLE user_mfa (
+    user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One-time recovery codes, only their SHA-256 hash is stored
+CREATE TABLE mfa_recovery_codes (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
+
+-- Single row policy of the roles required to use a second factor
+CREATE TABLE mfa_policy (
+    id INT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    required_roles TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO mfa_policy (id) VALUES (1);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_login_attempts_user_id;
+
+DROP TABLE IF EXISTS login_attempts;
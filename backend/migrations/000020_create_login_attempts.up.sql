@@ -0,0 +1,12 @@
+-- Failed login attempts, user_id being null when the login matches no user
+-- reason is one of unknown_user, disabled, invalid_password, invalid_mfa_code, throttled or locked
+CREATE TABLE login_attempts (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INT REFERENCES users (id) ON DELETE CASCADE,
+    login VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(64) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_login_attempts_user_id ON login_attempts (user_id, created_at);
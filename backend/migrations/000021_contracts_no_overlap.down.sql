@@ -0,0 +1 @@
+ALTER TABLE contracts DROP CONSTRAINT IF EXISTS excl_contracts_overlap;
@@ -0,0 +1,9 @@
+-- The overlap of the contracts of a user is enforced by the database, so that two concurrent writes can not both pass
+-- the check of the service. A NULL end_date is open-ended and both bounds are included.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE contracts
+ADD CONSTRAINT excl_contracts_overlap EXCLUDE USING gist (
+    user_id WITH =,
+    daterange(start_date, end_date, '[]') WITH &&
+);
@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS trg_teams_cache_invalidation ON teams;
+
+CREATE OR REPLACE FUNCTION teams_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('cache_invalidation', 'team:' || OLD.uuid);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_teams_cache_invalidation
+AFTER UPDATE OR DELETE ON teams
+FOR EACH ROW EXECUTE FUNCTION teams_cache_invalidation_trigger();
+
+DROP TRIGGER IF EXISTS trg_users_cache_invalidation ON users;
+
+DROP FUNCTION IF EXISTS users_cache_invalidation_trigger();
@@ -0,0 +1,40 @@
+-- The users and the teams themselves are part of the cached results: names, statuses, roles and the first day of the week
+-- The backend invalidates its cache right after its own writes, these notifications covering the changes made outside of it
+
+-- A new user only changes the organization results, which every notification invalidates
+CREATE FUNCTION users_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        PERFORM notify_user_cache_invalidation(NEW.id);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('cache_invalidation', 'user:' || OLD.uuid);
+    ELSE
+        PERFORM pg_notify('cache_invalidation', 'user:' || NEW.uuid);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_users_cache_invalidation
+AFTER INSERT OR DELETE OR UPDATE OF username, email, status, first_name, last_name, roles, first_day_of_week ON users
+FOR EACH ROW EXECUTE FUNCTION users_cache_invalidation_trigger();
+
+-- A created team changes the organization results as well
+CREATE OR REPLACE FUNCTION teams_cache_invalidation_trigger() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM pg_notify('cache_invalidation', 'team:' || NEW.uuid);
+    ELSE
+        PERFORM pg_notify('cache_invalidation', 'team:' || OLD.uuid);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_teams_cache_invalidation ON teams;
+
+CREATE TRIGGER trg_teams_cache_invalidation
+AFTER INSERT OR UPDATE OR DELETE ON teams
+FOR EACH ROW EXECUTE FUNCTION teams_cache_invalidation_trigger();
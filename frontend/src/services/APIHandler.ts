@@ -19,16 +19,34 @@ const APIHandler = axios.create({
      */
 // });
 
+// Requests answering 401 for a reason a refreshed access token cannot fix
+const noRefreshURLs = ['auth/login', 'auth/refresh', 'auth/logout', 'auth/mfa/verify'];
+
+// Shared by the requests failing at the same time, so that the refresh token is used only once
+let refreshing: Promise<unknown> | null = null;
+
 APIHandler.interceptors.response.use(
     (response: any) => {
         return response
     },
-    (error: any) => {
-        if (error.response?.status === 401 || error.response?.data?.message === "Invalid or expired token") {
+    async (error: any) => {
+        const request = error.config
+        if (error.response?.status === 401 && request && !request._retried && !noRefreshURLs.includes(request.url)) {
             /**
-             * If the user is not authenticated, redirect to the login page
-             * Log him out and clear the local storage
+             * The access token is short-lived: renew it with the refresh token cookie, then retry the request once.
+             * If the session cannot be refreshed, the original error is returned and the user has to log in again.
              */
+            request._retried = true
+            refreshing ??= APIHandler.post('auth/refresh').finally(() => {
+                refreshing = null
+            })
+
+            try {
+                await refreshing
+            } catch {
+                return Promise.reject(error)
+            }
+            return APIHandler(request)
         }
 
         return Promise.reject(error)
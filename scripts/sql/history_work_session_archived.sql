@@ -11,6 +11,8 @@ INSERT INTO
         clock_in,
         clock_out,
         duration_minutes,
+        breaks_duration_minutes,
+        breaks_count,
         status,
         updated_at,
         created_at,
@@ -21,6 +23,8 @@ SELECT
     clock_in,
     clock_out,
     duration_minutes,
+    breaks_duration_minutes,
+    breaks_count,
     status,
     updated_at,
     created_at,